@@ -0,0 +1,188 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KrystianMarek/golang-202/internal/bench"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns"
+)
+
+// registerBenchmarks registers the strategy families compared by the
+// "bench" command.
+func registerBenchmarks(s *bench.Suite) {
+	s.Register(bench.NewFamily("sort",
+		"patterns.SortStrategy implementations on random integers",
+		[]int{10, 100, 1000},
+		randomInts,
+		bench.Impl[[]int]{Name: "bubble", Run: sortWith(patterns.BubbleSort[int]{})},
+		bench.Impl[[]int]{Name: "quick", Run: sortWith(patterns.QuickSort[int]{})},
+		bench.Impl[[]int]{Name: "merge", Run: sortWith(patterns.MergeSort[int]{})},
+		bench.Impl[[]int]{Name: "heap", Run: sortWith(patterns.HeapSort[int]{})},
+		bench.Impl[[]int]{Name: "intro", Run: sortWith(patterns.IntroSort[int]{})},
+		bench.Impl[[]int]{Name: "radix", Run: sortWith(patterns.NewRadixSort(func(n int) int64 { return int64(n) }))},
+		bench.Impl[[]int]{Name: "parallel-merge", Run: sortWith(patterns.ParallelMergeSort[int]{})},
+	))
+
+	s.Register(bench.NewFamily("compression",
+		"patterns.CompressionStrategy implementations on English-like text",
+		[]int{64, 1024, 16384},
+		sampleText,
+		bench.Impl[string]{Name: "gzip", Run: compressWith(&patterns.GzipCompression{})},
+		bench.Impl[string]{Name: "zlib", Run: compressWith(&patterns.ZlibCompression{})},
+		bench.Impl[string]{Name: "flate", Run: compressWith(&patterns.FlateCompression{})},
+		bench.Impl[string]{Name: "lzw", Run: compressWith(&patterns.LZWCompression{})},
+		bench.Impl[string]{Name: "zip", Run: compressWith(&patterns.ZipCompression{})},
+		bench.Impl[string]{Name: "zstd", Run: compressWith(patterns.NewCodecStrategy(patterns.ZstdCodec))},
+		bench.Impl[string]{Name: "snappy", Run: compressWith(patterns.NewCodecStrategy(patterns.SnappyCodec))},
+	))
+}
+
+// sortWith sorts a copy of the input, since strategies sort in place
+// and every iteration must see the same unsorted data. The copy goes
+// into a buffer reused across iterations, so the allocations reported
+// are the strategy's own; the copy itself costs the same for every
+// implementation. Benchmarks run one at a time, so sharing buf is safe.
+func sortWith(s patterns.SortStrategy[int]) func([]int) {
+	var buf []int
+	return func(data []int) {
+		if cap(buf) < len(data) {
+			buf = make([]int, len(data))
+		}
+		buf = buf[:len(data)]
+		copy(buf, data)
+		s.Sort(buf, cmp.Compare[int])
+	}
+}
+
+func compressWith(c patterns.CompressionStrategy) func(string) {
+	return func(data string) {
+		if err := c.Compress(strings.NewReader(data), io.Discard); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// randomInts returns n pseudo-random integers. The fixed seed keeps runs
+// comparable.
+func randomInts(n int) []int {
+	rng := rand.New(rand.NewPCG(1, uint64(n)))
+	data := make([]int, n)
+	for i := range data {
+		data[i] = rng.IntN(n * 10)
+	}
+	return data
+}
+
+// sampleText returns n bytes of repetitive, compressible text.
+func sampleText(n int) string {
+	words := []string{"the", "quick", "brown", "fox", "jumps", "over", "lazy", "dog", "Go", "strategy"}
+	rng := rand.New(rand.NewPCG(2, uint64(n)))
+
+	var b strings.Builder
+	for b.Len() < n {
+		b.WriteString(words[rng.IntN(len(words))])
+		b.WriteByte(' ')
+	}
+	return b.String()[:n]
+}
+
+// benchOptions holds the flags of the "bench" command.
+type benchOptions struct {
+	sizes     sizeList
+	benchTime string
+	csvPath   string
+}
+
+// sizeList parses comma-separated --sizes values.
+type sizeList []int
+
+func (s *sizeList) String() string {
+	parts := make([]string, 0, len(*s))
+	for _, n := range *s {
+		parts = append(parts, strconv.Itoa(n))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (s *sizeList) Set(value string) error {
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid size %q", part)
+		}
+		*s = append(*s, n)
+	}
+	return nil
+}
+
+// runBench benchmarks the families matching patterns (all if empty),
+// prints a comparison table and optionally exports CSV.
+func runBench(patterns []string, opts benchOptions, stdout, stderr io.Writer) int {
+	suite := bench.NewSuite()
+	registerBenchmarks(suite)
+
+	if len(patterns) == 0 {
+		patterns = []string{""}
+	}
+	var families []bench.Family
+	for _, pattern := range patterns {
+		for _, f := range suite.Families(pattern) {
+			if !slices.ContainsFunc(families, func(seen bench.Family) bool { return seen.Name == f.Name }) {
+				families = append(families, f)
+			}
+		}
+	}
+	if len(families) == 0 {
+		fmt.Fprintf(stderr, "no benchmark families match %s\n", strings.Join(patterns, " "))
+		return 1
+	}
+
+	results, err := bench.Run(families, bench.Options{
+		Sizes:     opts.sizes,
+		BenchTime: opts.benchTime,
+		Progress:  stderr,
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	fmt.Fprintln(stdout)
+	if err := bench.WriteTable(stdout, results); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if opts.csvPath != "" {
+		if err := writeCSVFile(opts.csvPath, stdout, results); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func writeCSVFile(path string, stdout io.Writer, results []bench.Measurement) (err error) {
+	if path == "-" {
+		fmt.Fprintln(stdout)
+		return bench.WriteCSV(stdout, results)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return bench.WriteCSV(f, results)
+}
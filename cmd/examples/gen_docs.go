@@ -0,0 +1,94 @@
+//go:build ignore
+
+// gen_docs extracts the package comment of every package under pkg/ into
+// package_docs.go, so the interactive browser can show it without access
+// to the source tree.
+//
+// Run it with:
+//
+//	go generate ./cmd/examples
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+const root = "../../pkg"
+
+func main() {
+	docs, err := packageDocs(root)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen_docs.go; DO NOT EDIT.\n\n")
+	b.WriteString("package main\n\n")
+	b.WriteString("// packageDocs holds the package comment of each package under pkg/,\n")
+	b.WriteString("// keyed by its path relative to pkg/.\n")
+	b.WriteString("var packageDocs = map[string]string{\n")
+
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s: %s,\n", strconv.Quote(k), strconv.Quote(docs[k]))
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("package_docs.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// packageDocs returns the package comment of each package below dir.
+// doc.go wins when present; otherwise the first file with a package
+// comment is used.
+func packageDocs(dir string) (map[string]string, error) {
+	docs := make(map[string]string)
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".go" {
+			return err
+		}
+		if matched, _ := filepath.Match("*_test.go", d.Name()); matched {
+			return nil
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		if f.Doc == nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if _, seen := docs[key]; !seen || d.Name() == "doc.go" {
+			docs[key] = f.Doc.Text()
+		}
+		return nil
+	})
+	return docs, err
+}
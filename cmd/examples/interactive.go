@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KrystianMarek/golang-202/internal/runner"
+)
+
+// errQuit ends the interactive session.
+var errQuit = errors.New("quit")
+
+// browser is a line-oriented menu for exploring examples. It only needs
+// an input and an output stream, so it works in any terminal and can be
+// driven by a script in tests.
+type browser struct {
+	ctx    context.Context
+	runner *runner.Runner
+	in     *bufio.Scanner
+	out    io.Writer
+}
+
+// runInteractive starts the browser on in/out and returns an exit code.
+func runInteractive(ctx context.Context, r *runner.Runner, in io.Reader, out io.Writer) int {
+	r.SetOutput(out)
+	r.SetExampleOutput(out)
+
+	b := &browser{ctx: ctx, runner: r, in: bufio.NewScanner(in), out: out}
+	if err := b.packages(); err != nil && !errors.Is(err, errQuit) {
+		fmt.Fprintln(out, err)
+		return 1
+	}
+	return 0
+}
+
+// packages shows the package menu until the user quits.
+func (b *browser) packages() error {
+	categories := b.categories()
+	for {
+		header(b.out, "GoLang-202 Interactive Browser")
+		for i, category := range categories {
+			count := len(b.runner.Select(runner.Filter{Category: category}))
+			fmt.Fprintf(b.out, "%2d) %-12s %s (%d examples)\n", i+1, category, categoryTitle(category), count)
+		}
+
+		choice, err := b.prompt("\nSelect a package [1-%d], q to quit: ", len(categories))
+		if err != nil {
+			return err
+		}
+		if choice == "" {
+			continue
+		}
+		i, ok := parseChoice(choice, len(categories))
+		if !ok {
+			fmt.Fprintf(b.out, "Unknown choice %q\n\n", choice)
+			continue
+		}
+		if err := b.category(categories[i]); err != nil {
+			return err
+		}
+	}
+}
+
+// category shows a package's documentation and its examples.
+func (b *browser) category(category string) error {
+	examples := b.runner.Select(runner.Filter{Category: category})
+	for {
+		separator(b.out)
+		header(b.out, categoryTitle(category))
+		if doc, ok := packageDocs[categoryPackages[category]]; ok {
+			fmt.Fprintln(b.out, strings.TrimSpace(doc))
+			fmt.Fprintln(b.out)
+		}
+		if err := runner.WriteList(b.out, examples, runner.FormatText); err != nil {
+			return err
+		}
+
+		choice, err := b.prompt("\nSelect an example [1-%d], b to go back, q to quit: ", len(examples))
+		if err != nil {
+			return err
+		}
+		if choice == "b" {
+			separator(b.out)
+			return nil
+		}
+		i, ok := parseChoice(choice, len(examples))
+		if !ok {
+			fmt.Fprintf(b.out, "Unknown choice %q\n", choice)
+			continue
+		}
+		if err := b.example(examples[i]); err != nil {
+			return err
+		}
+	}
+}
+
+// example runs ex and offers to run it again.
+func (b *browser) example(ex runner.Example) error {
+	run := true
+	for {
+		if run {
+			separator(b.out)
+			b.runner.Run(b.ctx, ex.ID())
+		}
+
+		choice, err := b.prompt("\n[r] run again  [b] back  [q] quit: ")
+		if err != nil {
+			return err
+		}
+		switch choice {
+		case "r", "":
+			run = true
+		case "b":
+			return nil
+		default:
+			fmt.Fprintf(b.out, "Unknown choice %q\n", choice)
+			run = false
+		}
+	}
+}
+
+// prompt prints a prompt and reads one trimmed line. It returns errQuit
+// on "q" and at the end of the input.
+func (b *browser) prompt(format string, args ...any) (string, error) {
+	fmt.Fprintf(b.out, format, args...)
+	if !b.in.Scan() {
+		fmt.Fprintln(b.out)
+		if err := b.in.Err(); err != nil {
+			return "", err
+		}
+		return "", errQuit
+	}
+	line := strings.ToLower(strings.TrimSpace(b.in.Text()))
+	if line == "q" || line == "quit" {
+		return "", errQuit
+	}
+	return line, nil
+}
+
+// categories returns the registered categories in alphabetical order.
+func (b *browser) categories() []string {
+	var categories []string
+	for _, ex := range b.runner.Examples() {
+		if !slices.Contains(categories, ex.Category) {
+			categories = append(categories, ex.Category)
+		}
+	}
+	slices.Sort(categories)
+	return categories
+}
+
+func categoryTitle(category string) string {
+	if title, ok := categoryTitles[category]; ok {
+		return title
+	}
+	return category
+}
+
+// parseChoice converts a 1-based menu choice into an index.
+func parseChoice(s string, n int) (int, bool) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i - 1, true
+}
@@ -1,134 +1,279 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
-	"github.com/KrystianMarek/golang-202/pkg/functional"
-	"github.com/KrystianMarek/golang-202/pkg/go124"
-	"github.com/KrystianMarek/golang-202/pkg/idioms"
-	"github.com/KrystianMarek/golang-202/pkg/oop"
-	"github.com/KrystianMarek/golang-202/pkg/oop/patterns"
+	"github.com/KrystianMarek/golang-202/internal/runner"
 )
 
-func main() {
-	if len(os.Args) > 1 {
-		runExample(os.Args[1])
-		return
-	}
+const usageText = `Usage: examples [flags] [command]
 
-	fmt.Println("==========================================================")
-	fmt.Println("    GoLang-202: Advanced Go Patterns & Features")
-	fmt.Println("==========================================================")
+Commands:
+  list               List registered examples
+  interactive        Browse packages and run examples from a menu
+  bench [family ...] Compare strategy implementations (sort, compression)
+  run [glob ...]     Run examples whose ID matches a glob (default: all)
+  <glob ...>         Shorthand for "run <glob ...>"
 
-	runAllExamples()
-}
+Examples are addressed as <category>/<name>, e.g. patterns/decorator.
+Globs without a slash also match a bare category or name.
 
-func runExample(name string) {
-	examples := map[string]func(){
-		"go124":      runGo124Examples,
-		"oop":        runOOPExamples,
-		"functional": runFunctionalExamples,
-		"idioms":     runIdiomsExamples,
-		"patterns":   runPatternExamples,
-	}
-
-	if fn, ok := examples[name]; ok {
-		fn()
-	} else {
-		fmt.Printf("Unknown example: %s\n", name)
-		fmt.Println("\nAvailable examples:")
-		fmt.Println("  go124      - Go 1.24 features")
-		fmt.Println("  oop        - OOP patterns")
-		fmt.Println("  functional - Functional programming")
-		fmt.Println("  idioms     - Go idioms")
-		fmt.Println("  patterns   - Design patterns")
-	}
-}
+With --golden, each example's output is compared with
+<golden-dir>/<category>/<name>.golden; add --update to rewrite the files.
 
-func runAllExamples() {
-	runGo124Examples()
-	separator()
+Flags:
+`
 
-	runOOPExamples()
-	separator()
+// tagList collects repeated or comma-separated --tag values.
+type tagList []string
 
-	runFunctionalExamples()
-	separator()
+func (t *tagList) String() string { return strings.Join(*t, ",") }
 
-	runIdiomsExamples()
-	separator()
+func (t *tagList) Set(value string) error {
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*t = append(*t, tag)
+		}
+	}
+	return nil
+}
 
-	runPatternExamples()
+// options holds the parsed command line.
+type options struct {
+	command  string
+	filter   runner.Filter
+	format   runner.Format
+	golden   *runner.Golden
+	timeout  time.Duration
+	parallel int
+	bench    benchOptions
 }
 
-func runGo124Examples() {
-	header("Go 1.24 Features")
-	go124.ExampleIterators()
+func main() {
+	// Parallel runs re-execute this binary once per example.
+	if newRunner().ServeChild() {
+		return
+	}
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	go124.ExampleUnique()
+func newRunner() *runner.Runner {
+	r := runner.NewRunner()
+	registerExamples(r)
+	return r
+}
 
-	go124.ExampleCleanup()
+func run(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseOptions(args, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
 
-	go124.ExampleGenericAliases()
+	if opts.command == "bench" {
+		return runBench(opts.filter.Patterns, opts.bench, stdout, stderr)
+	}
 
-	go124.ExampleGenerics()
-}
+	r := newRunner()
+	selected := r.Select(opts.filter)
+	if len(selected) == 0 {
+		fmt.Fprintf(stderr, "no examples match %s\n", describeFilter(opts.filter))
+		fmt.Fprintln(stderr, `run "examples list" to see what is available`)
+		return 1
+	}
 
-func runOOPExamples() {
-	header("Object-Oriented Programming")
-	oop.ExampleComposition()
-}
+	if opts.command == "list" {
+		if err := runner.WriteList(stdout, selected, opts.format); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	}
 
-func runFunctionalExamples() {
-	header("Functional Programming")
-	functional.ExampleHigherOrder()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	functional.ExampleImmutability()
+	if opts.command == "interactive" {
+		return runInteractive(ctx, r, os.Stdin, stdout)
+	}
 
-	functional.ExamplePipelines()
+	r.SetGolden(opts.golden)
+	r.SetTimeout(opts.timeout)
+	parallel := runner.WithParallelism(opts.parallel)
+	if opts.format == runner.FormatJSON {
+		return runJSON(ctx, r, opts.filter, stdout, stderr, parallel)
+	}
+	if opts.golden != nil {
+		return runGolden(ctx, r, opts.filter, stdout, parallel)
+	}
+	return runText(ctx, r, opts.filter, stdout, parallel)
 }
 
-func runIdiomsExamples() {
-	header("Go Idioms")
-	idioms.ExampleInterfaces()
-
-	idioms.ExampleErrors()
+// parseOptions accepts flags before and after the command and globs,
+// so both "examples --format=json run x" and "examples run x --format=json"
+// work.
+func parseOptions(args []string, stderr io.Writer) (options, error) {
+	var (
+		opts      options
+		tags      tagList
+		format    string
+		golden    bool
+		update    bool
+		goldenDir string
+	)
+
+	fs := flag.NewFlagSet("examples", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.filter.Category, "category", "", "only include examples in this category")
+	fs.Var(&tags, "tag", "only include examples with this tag (repeatable, comma-separated)")
+	fs.StringVar(&format, "format", string(runner.FormatText), "output format: text or json")
+	fs.BoolVar(&golden, "golden", false, "compare example output with golden files")
+	fs.BoolVar(&update, "update", false, "rewrite golden files with the current output (implies --golden)")
+	fs.StringVar(&goldenDir, "golden-dir", "testdata", "directory holding golden files")
+	fs.DurationVar(&opts.timeout, "timeout", runner.DefaultTimeout, "per-example timeout (0 disables)")
+	fs.IntVar(&opts.parallel, "parallel", 1, "number of examples to run at once, each in its own process")
+	fs.Var(&opts.bench.sizes, "sizes", "bench: comma-separated input sizes (default: per family)")
+	fs.StringVar(&opts.bench.benchTime, "benchtime", "", "bench: time or iterations per measurement, e.g. 200ms or 100x (default 1s)")
+	fs.StringVar(&opts.bench.csvPath, "csv", "", "bench: also write results as CSV to this file (- for stdout)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usageText)
+		fs.PrintDefaults()
+	}
 
-	idioms.ExampleConcurrency()
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
 
-	idioms.ExampleChannels()
+	f, err := runner.ParseFormat(format)
+	if err != nil {
+		return opts, err
+	}
+	opts.format = f
+	opts.filter.Tags = tags
+	if golden || update {
+		opts.golden = &runner.Golden{Dir: goldenDir, Update: update}
+	}
 
-	idioms.ExampleZeroValues()
+	opts.command = "run"
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "list", "run", "interactive", "bench":
+			opts.command = positional[0]
+			positional = positional[1:]
+		case "help":
+			fs.Usage()
+			return opts, flag.ErrHelp
+		}
+	}
+	opts.filter.Patterns = positional
+	return opts, nil
 }
 
-func runPatternExamples() {
-	header("Design Patterns")
-	patterns.ExampleSingleton()
+// runText runs the selection with a header per category, matching the
+// classic output of this command.
+func runText(ctx context.Context, r *runner.Runner, f runner.Filter, stdout io.Writer, opts ...runner.RunOption) int {
+	r.SetOutput(stdout)
 
-	patterns.ExampleFactory()
+	if len(f.Patterns) == 0 && f.Category == "" && len(f.Tags) == 0 {
+		fmt.Fprintln(stdout, "==========================================================")
+		fmt.Fprintln(stdout, "    GoLang-202: Advanced Go Patterns & Features")
+		fmt.Fprintln(stdout, "==========================================================")
+	}
 
-	patterns.ExampleBuilder()
+	category := ""
+	headers := runner.WithBeforeEach(func(ex runner.Example) {
+		if ex.Category == category {
+			return
+		}
+		if category != "" {
+			separator(stdout)
+		}
+		category = ex.Category
+		header(stdout, categoryTitle(category))
+	})
+
+	rep := r.RunMatching(ctx, f, append(opts, headers)...)
+
+	separator(stdout)
+	if err := runner.WriteReport(stdout, rep, runner.FormatText); err != nil {
+		return 1
+	}
+	return exitCode(rep)
+}
 
-	patterns.ExampleObserver()
+// runGolden checks (or updates) golden files quietly: only the result
+// table, including diffs for mismatches, is printed.
+func runGolden(ctx context.Context, r *runner.Runner, f runner.Filter, stdout io.Writer, opts ...runner.RunOption) int {
+	r.SetOutput(io.Discard)
+	r.SetExampleOutput(io.Discard)
 
-	patterns.ExampleGenericObserver()
+	rep := r.RunMatching(ctx, f, opts...)
+	if err := runner.WriteReport(stdout, rep, runner.FormatText); err != nil {
+		return 1
+	}
+	return exitCode(rep)
+}
 
-	patterns.ExampleAdapter()
+// runJSON keeps stdout machine-readable: example output and progress
+// messages go to stderr, and only the JSON results are written to stdout.
+func runJSON(ctx context.Context, r *runner.Runner, f runner.Filter, stdout, stderr io.Writer, opts ...runner.RunOption) int {
+	r.SetOutput(stderr)
+	r.SetExampleOutput(stderr)
 
-	patterns.ExampleDecorator()
+	rep := r.RunMatching(ctx, f, opts...)
+	if err := runner.WriteReport(stdout, rep, runner.FormatJSON); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return exitCode(rep)
+}
 
-	patterns.ExampleStrategy()
+func exitCode(rep runner.Report) int {
+	if rep.OK() {
+		return 0
+	}
+	return 1
 }
 
-func header(title string) {
+func describeFilter(f runner.Filter) string {
+	var parts []string
+	if len(f.Patterns) > 0 {
+		parts = append(parts, strings.Join(f.Patterns, " "))
+	}
+	if f.Category != "" {
+		parts = append(parts, "--category="+f.Category)
+	}
+	for _, tag := range f.Tags {
+		parts = append(parts, "--tag="+tag)
+	}
+	return strings.Join(parts, " ")
+}
 
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	fmt.Printf("  %s\n", title)
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+func header(w io.Writer, title string) {
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintf(w, "  %s\n", title)
+	fmt.Fprintln(w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 }
 
-func separator() {
-	fmt.Println("\n" + strings.Repeat("─", 60) + "")
+func separator(w io.Writer) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("─", 60))
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestMain(m *testing.M) {
+	// Parallel runs re-execute the test binary for each example.
+	if newRunner().ServeChild() {
+		return
+	}
+	flag.Parse()
+	os.Exit(m.Run())
+}
+
+// TestGolden runs every registered example and compares its output with
+// testdata/<category>/<name>.golden. Refresh the files with:
+//
+//	go test ./cmd/examples -run TestGolden -update
+func TestGolden(t *testing.T) {
+	args := []string{"--golden"}
+	if *update {
+		args = append(args, "--update")
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("golden check failed (exit %d):\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunUnknownExample(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"run", "does-not-exist"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected stdout: %q", stdout.String())
+	}
+}
+
+func TestGoldenParallel(t *testing.T) {
+	if *update {
+		t.Skip("golden files are rewritten by TestGolden")
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"--golden", "--parallel=8"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("parallel golden check failed (exit %d):\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestInteractiveSession(t *testing.T) {
+	// Open "patterns", run example 7 (decorator), re-run it, then leave.
+	input := strings.NewReader("6\n7\nr\nb\nb\nq\n")
+	var out bytes.Buffer
+
+	if code := runInteractive(context.Background(), newRunner(), input, &out); code != 0 {
+		t.Fatalf("exit code %d\n%s", code, out.String())
+	}
+
+	got := out.String()
+	if !strings.Contains(got, "Package patterns implements Gang of Four") {
+		t.Error("package documentation not shown")
+	}
+	if n := strings.Count(got, "=== Decorator Pattern ==="); n != 2 {
+		t.Errorf("decorator ran %d times, want 2", n)
+	}
+}
+
+func TestEveryCategoryHasDocs(t *testing.T) {
+	for category, pkg := range categoryPackages {
+		if packageDocs[pkg] == "" {
+			t.Errorf("no package docs for %s (pkg/%s); run go generate ./cmd/examples", category, pkg)
+		}
+	}
+}
+
+func TestBenchCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"bench", "sort", "--sizes=10", "--benchtime=1x", "--csv=-"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr.String())
+	}
+	for _, want := range []string{"bubble", "quick", "sort,quick,10,1,"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Code generated by gen_docs.go; DO NOT EDIT.
+
+package main
+
+// packageDocs holds the package comment of each package under pkg/,
+// keyed by its path relative to pkg/.
+var packageDocs = map[string]string{
+	"examples":             "Package examples provides integrated examples combining multiple patterns.\n",
+	"functional":           "Package functional demonstrates functional programming patterns in Go.\n\nThis package covers functional programming concepts adapted to Go:\n  - Higher-order functions (map, filter, reduce)\n  - Function composition, currying and memoization\n  - Time-based debounce, throttle and coalesce\n  - Immutable data structures, including persistent vectors and maps\n  - Lenses and other optics for nested updates (package optics)\n  - Option and Result with Map, FlatMap, Zip and Collect (package monad)\n  - Lazy evaluation through iterators (Go 1.24+)\n  - Pipeline-based data processing, including parallel stages\n\nGo supports functional programming through:\n  - First-class functions\n  - Closures for state encapsulation\n  - Generic types for type-safe operations\n  - Iterators for lazy evaluation (Go 1.24+)\n\nTrade-offs:\n  - Immutability increases memory usage but improves safety\n  - Lazy evaluation reduces memory but adds complexity\n  - Functional style can be more declarative but less performant\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/functional\"\n\n\tfunc main() {\n\t\tnumbers := []int{1, 2, 3, 4, 5}\n\t\tevens := functional.Filter(numbers, func(n int) bool { return n%2 == 0 })\n\t\tdoubled := functional.Map(evens, func(n int) int { return n * 2 })\n\n\t\t// Or use pipelines\n\t\tresult := functional.NewPipeline(numbers).\n\t\t\tFilter(func(n int) bool { return n%2 == 0 }).\n\t\t\tMap(func(n int) int { return n * 2 }).\n\t\t\tCollect()\n\t}\n",
+	"functional/monad":     "Package monad provides Option and Result, the repository's canonical\ntypes for a value that may be absent and a value that may have failed.\n\nThis package covers:\n  - Option[T]: Some, None, OptionOf, Get, OrElse, OrElseGet, Filter\n  - Result[T]: Ok, Err, ResultOf, Unwrap, OrElse, OrElseGet, Filter\n  - Generic combinators: MapOption, FlatMapOption, ZipOption,\n    MapResult, FlatMapResult, ZipResult\n  - Collect over slices and iterators of results\n  - Iterator support: All on both types, Values and Results adapters\n  - JSON (None is null) and database/sql (None is NULL) support\n\nidioms.Result, idioms.Optional, go124.Result, go124.GenericResult and\ngo124.Optional are aliases of these types. Their old IsPresent, IsValid\nand GenericResult.Map methods remain as deprecated shims; Option.Get\nand the old exported Result fields could not be kept (see\ndeprecated.go).\n\nWhy MapOption and MapResult rather than Map? Go has neither overloading\nnor generic methods, so one package cannot export a Map for both types;\nthe suffix names the type the combinator works on.\n\nWhy one package? Each of those used to be its own struct with its own\nsubset of methods, so a value could not move between packages without\nbeing rebuilt field by field.\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/functional/monad\"\n\n\tfunc main() {\n\t\tport := monad.ResultOf(strconv.Atoi(os.Getenv(\"PORT\")))\n\t\tfmt.Println(port.OrElse(8080))\n\n\t\tnick := monad.MapOption(user.Nickname, strings.ToUpper)\n\t\tfmt.Println(nick.OrElse(\"anonymous\"))\n\t}\n",
+	"functional/optics":    "Package optics provides lenses, prisms and traversals: composable\ngetters and setters for updating deeply nested immutable values.\n\nThis package covers:\n  - Lens[S, A]: a field that is always present (Get, Set, Modify)\n  - Prism[S, A]: a part that may be absent, such as a map key or a\n    pointer (Preview, Set, Modify)\n  - Traversal[S, A]: any number of parts, such as every list element\n  - Composition of each kind, and conversion from stronger optics to\n    weaker ones (a Lens is a Prism that always matches)\n  - Ready-made lenses for functional.Point and functional.User\n\nWhy? Updating one field three levels down an immutable value means\nreading each level, calling WithX on the innermost one, and writing\nevery level back by hand. A lens packages the read and the write of\none level, and composed lenses do the whole round trip in one call.\n\nExample usage:\n\n\timport (\n\t\t\"github.com/KrystianMarek/golang-202/pkg/functional\"\n\t\t\"github.com/KrystianMarek/golang-202/pkg/functional/optics\"\n\t)\n\n\tfunc main() {\n\t\temail := optics.Compose(ownerLens, optics.UserEmail)\n\t\taccount = email.Set(account, \"new@example.com\")\n\n\t\tolder := optics.ComposeTraversal(optics.Each[functional.User](), optics.UserAge.AsTraversal())\n\t\tteam = older.Modify(team, func(age int) int { return age + 1 })\n\t}\n",
+	"go124":                "Package go124 provides examples and demonstrations of features\nintroduced in Go 1.24 (released February 2025).\n\nThis package covers:\n  - Iterator functions for custom iteration patterns (iter.Seq)\n  - Value canonicalization with unique.Handle\n  - Resource cleanup with runtime.AddCleanup\n  - Parameterized type aliases for generic types\n  - Comprehensive generic programming (type parameters, constraints)\n  - A sharded generic cache keyed with maphash.Comparable\n  - Enhanced testing benchmarks with testing.B.Loop\n\nEach file contains focused examples with godoc comments explaining\nthe \"why\" behind each feature and demonstrating idiomatic usage.\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/go124\"\n\n\tfunc main() {\n\t\t// Iterator functions\n\t\tgo124.ExampleIterators()\n\n\t\t// Value interning\n\t\tgo124.ExampleUnique()\n\n\t\t// Resource cleanup\n\t\tgo124.ExampleCleanup()\n\n\t\t// Generic type aliases\n\t\tgo124.ExampleGenericAliases()\n\n\t\t// Generic data structures\n\t\tgo124.ExampleGenerics()\n\n\t\t// Bounded, concurrency-safe caching\n\t\tgo124.ExampleCache()\n\t}\n",
+	"idioms":               "Package idioms demonstrates Go-specific patterns and best practices.\n\nThis package covers idiomatic Go patterns that differentiate Go\nfrom other languages:\n  - Duck typing through implicit interface satisfaction\n  - Explicit error handling with errors.Is and errors.As\n  - Zero value semantics for usable defaults\n  - Goroutines and channels for concurrency\n  - Go 1.24 enhanced channel patterns (safe for-range, context integration)\n  - Generic pipeline stages (Source, Stage, FanOut, FanIn, Tee, Batch, Throttle)\n  - Context propagation for cancellation and timeouts\n  - Defer for resource cleanup\n\nKey Go idioms:\n  - Accept interfaces, return structs\n  - Error handling at each call site\n  - Leverage zero values for initialization\n  - Use defer for cleanup (LIFO ordering)\n  - Context for cancellation propagation\n  - Channels for goroutine communication\n  - Go 1.24: Guaranteed channel termination with for-range\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/idioms\"\n\n\tfunc main() {\n\t\t// Interface-based dependency injection\n\t\tvar processor idioms.Processor = idioms.UpperCaseProcessor{}\n\t\tresult := processor.Process(\"hello\")\n\n\t\t// Error handling with errors.Is\n\t\tif errors.Is(err, idioms.ErrNotFound) {\n\t\t\t// Handle not found\n\t\t}\n\n\t\t// Concurrency with channels (Go 1.24)\n\t\tctx := context.Background()\n\t\tnumbers := idioms.GenerateNumbers(ctx, 1, 10)\n\t\tsquares := idioms.Square(ctx, numbers)\n\n\t\t// Generic stages with guaranteed termination\n\t\tlabels := idioms.Stage(ctx, squares, strconv.Itoa)\n\t\tfor label := range idioms.Batch(ctx, labels, 10, time.Second) {\n\t\t\tfmt.Println(label)\n\t\t}\n\t}\n",
+	"oop":                  "Package oop demonstrates object-oriented programming patterns in Go\nusing composition, interfaces, and struct embedding.\n\nGo doesn't have traditional class-based inheritance, but provides\npowerful alternatives through:\n  - Struct embedding for composition\n  - Interfaces for polymorphism\n  - Methods for behavior\n  - Dependency injection via interfaces\n\nThis package covers:\n  - Composition over inheritance\n  - Interface-based polymorphism\n  - Component-based design\n  - Dependency injection\n  - Gang of Four design patterns (see patterns subpackage)\n\nExample usage:\n\n\timport (\n\t\t\"github.com/KrystianMarek/golang-202/pkg/oop\"\n\t\t\"github.com/KrystianMarek/golang-202/pkg/oop/patterns\"\n\t)\n\n\tfunc main() {\n\t\toop.ExampleComposition()\n\t\tpatterns.ExampleSingleton()\n\t}\n",
+	"oop/patterns":         "Package patterns implements Gang of Four (GoF) design patterns\nadapted to Go's interfaces, structs, and idioms.\n\nThis package demonstrates how classical OOP design patterns can be\nimplemented idiomatically in Go using:\n  - Interfaces for polymorphism\n  - Struct embedding for composition\n  - Channels for event-driven patterns\n  - sync.Once for thread-safe singletons\n  - Function types for strategy patterns\n\nPatterns included:\n\nCreational:\n  - Singleton: Thread-safe single instances using sync.Once\n  - Factory: Factory functions returning interfaces\n  - Builder: Fluent interfaces for complex object construction\n\nStructural:\n  - Adapter: Making incompatible interfaces work together, including a\n    legacy payment SDK adapted to payment.Gateway\n  - Decorator: Adding behavior dynamically through composition, including\n    stackable encryption and compression layers over an io-based DataSource\n\nBehavioral:\n  - Observer: Event-driven patterns using channels and interfaces\n  - Strategy: Swappable algorithms via interfaces, including real\n    compression strategies chosen automatically from a registry and\n    generic sorting strategies that report whether they are stable,\n    payment strategies running on package payment, and a ShoppingCart\n    priced by a discount rule engine, taxed per region and itemized\n    on text or JSON receipts\n\nEach pattern includes:\n  - Clear godoc comments explaining the \"why\"\n  - Multiple examples showing different use cases\n  - Runnable example functions\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/oop/patterns\"\n\n\tfunc main() {\n\t\tpatterns.ExampleSingleton()\n\t\tpatterns.ExampleFactory()\n\t\tpatterns.ExampleBuilder()\n\t}\n",
+	"oop/patterns/payment": "Package payment is the payment-processing domain behind the Strategy\nand Adapter examples in package patterns.\n\nThis package covers:\n  - Money: exact amounts in integer minor units with a currency,\n    percentages with an explicit Rounding, and Allocate for splitting\n    an amount without losing a cent\n  - Currency conversion through a pluggable RateSource\n  - Payment methods: cards with Luhn and expiry checks, PayPal\n    accounts and crypto wallets\n  - Gateway: the port a payment provider is adapted to\n  - Processor: the authorize, capture, refund and void lifecycle,\n    with idempotency keys so retries never charge twice\n  - FakeGateway: an in-memory provider for tests\n\nWhy integer minor units? 0.1 + 0.2 is not 0.3 in float64, and a\ncheckout that drifts by a cent per thousand orders will not reconcile\nwith the bank. Every amount here is a count of cents (or yen, or\nsatoshis), and every rounding step is explicit.\n\nExample usage:\n\n\timport \"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment\"\n\n\tfunc main() {\n\t\tp := payment.NewProcessor(payment.NewFakeGateway())\n\t\tcard := payment.Card{Number: \"4242 4242 4242 4242\", ExpMonth: 12, ExpYear: 2030, CVV: \"123\"}\n\t\tauth, err := p.Authorize(ctx, \"order-1-auth\", card, payment.MustParse(\"19.99\", payment.USD))\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n\t\t_, err = p.Capture(ctx, \"order-1-capture\", auth.ID, auth.Authorized)\n\t}\n",
+}
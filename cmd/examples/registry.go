@@ -0,0 +1,147 @@
+package main
+
+import (
+	"github.com/KrystianMarek/golang-202/internal/runner"
+	"github.com/KrystianMarek/golang-202/pkg/examples"
+	"github.com/KrystianMarek/golang-202/pkg/functional"
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
+	"github.com/KrystianMarek/golang-202/pkg/functional/optics"
+	"github.com/KrystianMarek/golang-202/pkg/go124"
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+//go:generate go run gen_docs.go
+
+// categoryPackages maps each category to its package path below pkg/,
+// used to look up the package documentation in packageDocs.
+var categoryPackages = map[string]string{
+	"go124":      "go124",
+	"oop":        "oop",
+	"functional": "functional",
+	"idioms":     "idioms",
+	"patterns":   "oop/patterns",
+	"examples":   "examples",
+}
+
+// categoryTitles maps each category to the header printed before its
+// examples in text mode.
+var categoryTitles = map[string]string{
+	"go124":      "Go 1.24 Features",
+	"oop":        "Object-Oriented Programming",
+	"functional": "Functional Programming",
+	"idioms":     "Go Idioms",
+	"patterns":   "Design Patterns",
+	"examples":   "Integrated Examples",
+}
+
+// registerExamples registers every Example* function with r.
+// Registration order is the order "run" executes them in.
+func registerExamples(r *runner.Runner) {
+	for _, ex := range []runner.Example{
+		{Category: "go124", Name: "iterators", Run: runner.Legacy(go124.ExampleIterators),
+			Description: "Range-over-func iterators and lazy sequences",
+			Tags:        []string{"iterators", "generics"}},
+		{Category: "go124", Name: "unique", Run: runner.Legacy(go124.ExampleUnique),
+			Description: "Value canonicalization with unique.Handle",
+			Tags:        []string{"memory"}},
+		{Category: "go124", Name: "cleanup", Run: runner.Legacy(go124.ExampleCleanup),
+			Description:      "Resource cleanup with runtime.AddCleanup",
+			Tags:             []string{"memory", "runtime"},
+			Nondeterministic: true},
+		{Category: "go124", Name: "generic-aliases", Run: runner.Legacy(go124.ExampleGenericAliases),
+			Description: "Parameterized type aliases",
+			Tags:        []string{"generics"}},
+		{Category: "go124", Name: "generics", Run: runner.Legacy(go124.ExampleGenerics),
+			Description: "Generic types, constraints and functions",
+			Tags:        []string{"generics"}},
+
+		{Category: "go124", Name: "cache", Run: runner.Legacy(go124.ExampleCache),
+			Description: "Sharded generic cache with LRU/LFU/ARC, TTL and single-flight loading",
+			Tags:        []string{"generics", "concurrency"}},
+
+		{Category: "oop", Name: "composition", Run: runner.Legacy(oop.ExampleComposition),
+			Description: "Struct embedding and interface composition",
+			Tags:        []string{"oop"}},
+
+		{Category: "functional", Name: "higher-order", Run: runner.Legacy(functional.ExampleHigherOrder),
+			Description: "Map, filter, reduce, composition and currying",
+			Tags:        []string{"generics"}},
+		{Category: "functional", Name: "immutability", Run: runner.Legacy(functional.ExampleImmutability),
+			Description: "Immutable values and persistent collections",
+			Tags:        []string{"immutability"}},
+		{Category: "functional", Name: "pipelines", Run: runner.Legacy(functional.ExamplePipelines),
+			Description: "Lazy iterator-based pipelines",
+			Tags:        []string{"iterators", "generics"}},
+		{Category: "functional", Name: "monad", Run: runner.Legacy(monad.ExampleMonad),
+			Description: "Option and Result with Map, FlatMap, Zip and Collect",
+			Tags:        []string{"errors", "generics"}},
+		{Category: "functional", Name: "optics", Run: runner.Legacy(optics.ExampleOptics),
+			Description: "Lenses, prisms and traversals for nested immutable updates",
+			Tags:        []string{"immutability", "generics"}},
+		{Category: "functional", Name: "timing", Run: runner.Legacy(functional.ExampleTiming),
+			Description: "Time-based debounce, throttle and coalesce",
+			Tags:        []string{"concurrency", "generics"}},
+		{Category: "functional", Name: "try-pipelines", Run: runner.Legacy(functional.ExampleTryPipelines),
+			Description: "Pipelines that carry per-item errors",
+			Tags:        []string{"iterators", "errors"}},
+
+		{Category: "idioms", Name: "interfaces", Run: runner.Legacy(idioms.ExampleInterfaces),
+			Description: "Small interfaces and implicit satisfaction",
+			Tags:        []string{"interfaces"}},
+		{Category: "idioms", Name: "errors", Run: runner.Legacy(idioms.ExampleErrors),
+			Description: "Error wrapping with errors.Is and errors.As",
+			Tags:        []string{"errors"}},
+		{Category: "idioms", Name: "concurrency", Run: runner.Legacy(idioms.ExampleConcurrency),
+			Description: "Worker pools, rate limiting and sync primitives",
+			Tags:        []string{"concurrency", "slow"}},
+		{Category: "idioms", Name: "rate-limiting", Run: runner.Legacy(idioms.ExampleRateLimiting),
+			Description: "Rate-limiting algorithms behind one interface, with HTTP middleware",
+			Tags:        []string{"concurrency", "http"}},
+		{Category: "idioms", Name: "channels", Run: runner.Legacy(idioms.ExampleChannels),
+			Description:      "Channel pipelines, fan-out/fan-in and select",
+			Tags:             []string{"concurrency", "generics", "slow"},
+			Nondeterministic: true},
+		{Category: "idioms", Name: "zero-values", Run: runner.Legacy(idioms.ExampleZeroValues),
+			Description: "Useful zero values and lazy initialization",
+			Tags:        []string{"basics"}},
+
+		{Category: "patterns", Name: "singleton", Run: runner.Legacy(patterns.ExampleSingleton),
+			Description: "Thread-safe singletons with sync.Once",
+			Tags:        []string{"creational"}},
+		{Category: "patterns", Name: "factory", Run: runner.Legacy(patterns.ExampleFactory),
+			Description: "Factory functions returning interfaces",
+			Tags:        []string{"creational"}},
+		{Category: "patterns", Name: "builder", Run: runner.Legacy(patterns.ExampleBuilder),
+			Description: "Fluent builders and functional options",
+			Tags:        []string{"creational"}},
+		{Category: "patterns", Name: "observer", Run: runner.Legacy(patterns.ExampleObserver),
+			Description: "Event notification via interfaces and channels",
+			Tags:        []string{"behavioral"},
+			Unordered:   true},
+		{Category: "patterns", Name: "generic-observer", Run: runner.Legacy(patterns.ExampleGenericObserver),
+			Description: "Type-safe observers with generics",
+			Tags:        []string{"behavioral", "generics"},
+			Unordered:   true},
+		{Category: "patterns", Name: "adapter", Run: runner.Legacy(patterns.ExampleAdapter),
+			Description: "Adapting incompatible interfaces",
+			Tags:        []string{"structural"}},
+		{Category: "patterns", Name: "decorator", Run: runner.Legacy(patterns.ExampleDecorator),
+			Description: "Layering behavior through composition",
+			Tags:        []string{"structural"}},
+		{Category: "patterns", Name: "strategy", Run: runner.Legacy(patterns.ExampleStrategy),
+			Description: "Swappable algorithms: checkout pricing, tax and payment, compression, sorting",
+			Tags:        []string{"behavioral"}},
+		{Category: "patterns", Name: "payment", Run: runner.Legacy(payment.ExamplePayment),
+			Description: "Exact money, card validation and an idempotent payment lifecycle",
+			Tags:        []string{"behavioral", "errors"}},
+
+		{Category: "examples", Name: "game-engine", Run: runner.Legacy(examples.ExampleGameEngine),
+			Description: "Game engine combining OOP, observer and components",
+			Tags:        []string{"integration"}},
+	} {
+		r.Register(ex)
+	}
+}
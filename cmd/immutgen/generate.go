@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const annotation = "//immutable:"
+
+type fieldKind int
+
+const (
+	kindPlain fieldKind = iota
+	kindString
+	kindSlice
+	kindMap
+)
+
+// field is one field of a record.
+type field struct {
+	name   string // as declared, e.g. "id"
+	method string // exported form, e.g. "ID"
+	typ    string // source form of the type
+	kind   fieldKind
+	shape  *shape
+}
+
+// shape is a type as far as copying and comparing it goes: its kind,
+// judged by the underlying type, and for slices and maps the shape of
+// the elements.
+type shape struct {
+	kind fieldKind
+	typ  string // source form, e.g. "Tags" or "[]int"
+	elem *shape // slice or map element
+}
+
+// deep reports whether values of the shape share storage when assigned.
+func (s *shape) deep() bool {
+	return s.kind == kindSlice || s.kind == kindMap
+}
+
+// record is one //immutable: struct and the methods declared for it by
+// hand, which are not generated.
+type record struct {
+	name     string
+	fields   []field
+	declared map[string]bool
+}
+
+// generate returns the source of the generated file for the package in
+// dir, or nil if it has no records. The file named output is ignored so
+// regenerating starts from the hand-written code alone.
+func generate(dir, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, dir, output)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	methods := declaredMethods(files)
+	local := localTypes(files)
+	imports := make(map[string]string) // import path by package name
+	var records []record
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if !annotated(doc) {
+					continue
+				}
+				rec, err := newRecord(fset, f, ts, local, imports)
+				if err != nil {
+					return nil, err
+				}
+				rec.declared = methods[rec.name]
+				records = append(records, rec)
+			}
+		}
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return render(files[0].Name.Name, records, imports)
+}
+
+// parseDir parses the non-test Go files in dir, except output.
+func parseDir(fset *token.FileSet, dir, output string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || filepath.Ext(name) != ".go" || strings.HasSuffix(name, "_test.go") || name == output {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			return nil, fmt.Errorf("%s: package %s, want %s", name, f.Name.Name, files[0].Name.Name)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// declaredMethods returns the method names declared for each type.
+func declaredMethods(files []*ast.File) map[string]map[string]bool {
+	methods := make(map[string]map[string]bool)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || len(fd.Recv.List) == 0 {
+				continue
+			}
+			recv := fd.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if id, ok := recv.(*ast.Ident); ok {
+				if methods[id.Name] == nil {
+					methods[id.Name] = make(map[string]bool)
+				}
+				methods[id.Name][fd.Name.Name] = true
+			}
+		}
+	}
+	return methods
+}
+
+// localTypes returns the underlying type expression of every
+// non-generic type declared in the package, by name.
+func localTypes(files []*ast.File) map[string]ast.Expr {
+	types := make(map[string]ast.Expr)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.TypeParams == nil {
+					types[ts.Name.Name] = ts.Type
+				}
+			}
+		}
+	}
+	return types
+}
+
+// shapeOf classifies typ by its underlying type, following the package's
+// own named types such as "type Tags []string". Types from other
+// packages cannot be resolved without type-checking them and count as
+// plain values, as does a named type met again inside itself.
+func shapeOf(typ ast.Expr, local map[string]ast.Expr, resolving map[string]bool) *shape {
+	s := &shape{kind: kindPlain, typ: types.ExprString(typ)}
+	under := typ
+	for {
+		paren, ok := under.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		under = paren.X
+	}
+	switch t := under.(type) {
+	case *ast.ArrayType:
+		if t.Len == nil {
+			s.kind = kindSlice
+			s.elem = shapeOf(t.Elt, local, resolving)
+		}
+	case *ast.MapType:
+		s.kind = kindMap
+		s.elem = shapeOf(t.Value, local, resolving)
+	case *ast.Ident:
+		if t.Name == "string" {
+			s.kind = kindString
+		} else if def, ok := local[t.Name]; ok && !resolving[t.Name] {
+			resolving[t.Name] = true
+			u := shapeOf(def, local, resolving)
+			delete(resolving, t.Name)
+			s.kind, s.elem = u.kind, u.elem
+		}
+	}
+	return s
+}
+
+// annotated reports whether doc has a line starting with //immutable:.
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	return slices.ContainsFunc(doc.List, func(c *ast.Comment) bool {
+		return strings.HasPrefix(c.Text, annotation)
+	})
+}
+
+func newRecord(fset *token.FileSet, f *ast.File, ts *ast.TypeSpec, local map[string]ast.Expr, imports map[string]string) (record, error) {
+	rec := record{name: ts.Name.Name}
+	pos := fset.Position(ts.Pos())
+
+	st, ok := ts.Type.(*ast.StructType)
+	if !ok {
+		return rec, fmt.Errorf("%s: %s is marked %s but is not a struct", pos, rec.name, annotation)
+	}
+	if ts.TypeParams != nil {
+		return rec, fmt.Errorf("%s: generic record %s is not supported", pos, rec.name)
+	}
+
+	for _, fl := range st.Fields.List {
+		if len(fl.Names) == 0 {
+			return rec, fmt.Errorf("%s: embedded field in %s is not supported", fset.Position(fl.Pos()), rec.name)
+		}
+		var typ bytes.Buffer
+		if err := format.Node(&typ, fset, fl.Type); err != nil {
+			return rec, err
+		}
+		if err := addImports(f, fl.Type, imports); err != nil {
+			return rec, fmt.Errorf("%s: %w", fset.Position(fl.Pos()), err)
+		}
+
+		shape := shapeOf(fl.Type, local, make(map[string]bool))
+
+		for _, name := range fl.Names {
+			if name.IsExported() {
+				return rec, fmt.Errorf("%s: field %s of %s must be unexported", fset.Position(name.Pos()), name.Name, rec.name)
+			}
+			rec.fields = append(rec.fields, field{
+				name:   name.Name,
+				method: exportedName(name.Name),
+				typ:    typ.String(),
+				kind:   shape.kind,
+				shape:  shape,
+			})
+		}
+	}
+	return rec, nil
+}
+
+// addImports records the imports, by package name, that the type
+// expression typ needs.
+func addImports(f *ast.File, typ ast.Expr, imports map[string]string) error {
+	var err error
+	ast.Inspect(typ, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for _, spec := range f.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := lastElem(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name == pkg.Name {
+				imports[name] = path
+				return false
+			}
+		}
+		err = fmt.Errorf("no import for package %s", pkg.Name)
+		return false
+	})
+	return err
+}
+
+func lastElem(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// initialisms are written in upper case when they start a field name,
+// so id becomes ID() and urlPath becomes URLPath().
+var initialisms = map[string]bool{
+	"api": true, "dns": true, "html": true, "http": true, "https": true,
+	"id": true, "ip": true, "json": true, "sql": true, "ttl": true,
+	"uid": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+func exportedName(name string) string {
+	end := strings.IndexFunc(name, func(r rune) bool { return !unicode.IsLower(r) })
+	if end < 0 {
+		end = len(name)
+	}
+	if initialisms[name[:end]] {
+		return strings.ToUpper(name[:end]) + name[end:]
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// render writes the generated file.
+func render(pkg string, records []record, imports map[string]string) ([]byte, error) {
+	var body bytes.Buffer
+	uses := make(map[string]bool)
+	for _, rec := range records {
+		renderRecord(&body, rec, uses)
+	}
+
+	stdlib := map[string]string{"fmt": "fmt", "json": "encoding/json", "maps": "maps", "slices": "slices"}
+	for name := range uses {
+		imports[name] = stdlib[name]
+	}
+	specs := make([]string, 0, len(imports))
+	for name, path := range imports {
+		spec := strconv.Quote(path)
+		if name != lastElem(path) {
+			spec = name + " " + spec
+		}
+		specs = append(specs, spec)
+	}
+	// Sort by path, ignoring any alias.
+	slices.SortFunc(specs, func(a, b string) int {
+		return strings.Compare(a[strings.Index(a, `"`):], b[strings.Index(b, `"`):])
+	})
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by immutgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if len(specs) > 0 {
+		fmt.Fprintf(&b, "import (\n\t%s\n)\n", strings.Join(specs, "\n\t"))
+	}
+	b.Write(body.Bytes())
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, b.Bytes())
+	}
+	return src, nil
+}
+
+func renderRecord(b *bytes.Buffer, rec record, uses map[string]bool) {
+	recv := strings.ToLower(rec.name[:1])
+	want := func(method string) bool { return !rec.declared[method] }
+
+	// clone returns the expression copying v, a value of field f's type,
+	// so that slices and maps are never shared with callers. Containers
+	// of containers are copied by a helper function, emitted once.
+	var helpers []string
+	clone := func(f field, v string) string {
+		expr := cloneExpr(f.shape, v, uses)
+		if !strings.HasPrefix(expr, "func(") {
+			return expr
+		}
+		name := "clone" + rec.name + f.method
+		if !slices.ContainsFunc(helpers, func(h string) bool { return strings.HasPrefix(h, "\n// "+name+" ") }) {
+			literal := cloneExpr(f.shape, "", uses)
+			helpers = append(helpers, fmt.Sprintf("\n// %s deep-copies values of the %s field.\nfunc %s%s\n",
+				name, f.name, name, strings.TrimSuffix(literal[len("func"):], "()")))
+		}
+		return name + "(" + v + ")"
+	}
+
+	for _, f := range rec.fields {
+		if want(f.method) {
+			copyNote := ""
+			if f.shape.deep() {
+				copyNote = "a copy of "
+			}
+			fmt.Fprintf(b, "\n// %s returns %sthe %s field.\n", f.method, copyNote, f.name)
+			fmt.Fprintf(b, "func (%s %s) %s() %s {\n\treturn %s\n}\n",
+				recv, rec.name, f.method, f.typ, clone(f, recv+"."+f.name))
+		}
+
+		if with := "With" + f.method; want(with) {
+			param := f.name
+			if param == recv {
+				param = "v"
+			}
+			fmt.Fprintf(b, "\n// %s returns a copy of %s with %s replaced.\n", with, recv, f.name)
+			fmt.Fprintf(b, "func (%s %s) %s(%s %s) %s {\n\t%s.%s = %s\n\treturn %s\n}\n",
+				recv, rec.name, with, param, f.typ, rec.name, recv, f.name, clone(f, param), recv)
+		}
+	}
+
+	for _, h := range helpers {
+		b.WriteString(h)
+	}
+
+	if want("Equal") {
+		var terms []string
+		for _, f := range rec.fields {
+			terms = append(terms, equalExpr(f.shape, recv+"."+f.name, "other."+f.name, uses))
+		}
+		if len(terms) == 0 {
+			terms = []string{"true"}
+		}
+		fmt.Fprintf(b, "\n// Equal reports whether %s and other hold equal fields.\n", recv)
+		fmt.Fprintf(b, "func (%s %s) Equal(other %s) bool {\n\treturn %s\n}\n",
+			recv, rec.name, rec.name, strings.Join(terms, " &&\n\t\t"))
+	}
+
+	if want("String") {
+		verbs := make([]string, len(rec.fields))
+		args := make([]string, len(rec.fields))
+		for i, f := range rec.fields {
+			verb := "%v"
+			if f.kind == kindString {
+				verb = "%q"
+			}
+			verbs[i] = f.name + ": " + verb
+			args[i] = recv + "." + f.name
+		}
+		layout := strconv.Quote(rec.name + "{" + strings.Join(verbs, ", ") + "}")
+		fmt.Fprintf(b, "\n// String formats %s for debugging.\n", recv)
+		if len(args) == 0 {
+			fmt.Fprintf(b, "func (%s %s) String() string {\n\treturn %s\n}\n", recv, rec.name, layout)
+		} else {
+			uses["fmt"] = true
+			fmt.Fprintf(b, "func (%s %s) String() string {\n\treturn fmt.Sprintf(%s, %s)\n}\n",
+				recv, rec.name, layout, strings.Join(args, ", "))
+		}
+	}
+
+	marshal, unmarshal := want("MarshalJSON"), want("UnmarshalJSON")
+	if !marshal && !unmarshal {
+		return
+	}
+	uses["json"] = true
+	jsonType := strings.ToLower(rec.name[:1]) + rec.name[1:] + "JSON"
+	fmt.Fprintf(b, "\n// %s is the JSON form of %s.\ntype %s struct {\n", jsonType, rec.name, jsonType)
+	for _, f := range rec.fields {
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", f.method, f.typ, f.name)
+	}
+	b.WriteString("}\n")
+
+	if marshal {
+		var inits []string
+		for _, f := range rec.fields {
+			inits = append(inits, fmt.Sprintf("%s: %s.%s", f.method, recv, f.name))
+		}
+		fmt.Fprintf(b, "\n// MarshalJSON encodes %s as an object keyed by field name.\n", recv)
+		fmt.Fprintf(b, "func (%s %s) MarshalJSON() ([]byte, error) {\n\treturn json.Marshal(%s{%s})\n}\n",
+			recv, rec.name, jsonType, strings.Join(inits, ", "))
+	}
+	if unmarshal {
+		var inits []string
+		for _, f := range rec.fields {
+			inits = append(inits, fmt.Sprintf("%s: v.%s", f.name, f.method))
+		}
+		fmt.Fprintf(b, "\n// UnmarshalJSON decodes an object written by MarshalJSON.\n")
+		fmt.Fprintf(b, "func (%s *%s) UnmarshalJSON(data []byte) error {\n", recv, rec.name)
+		fmt.Fprintf(b, "\tvar v %s\n\tif err := json.Unmarshal(data, &v); err != nil {\n\t\treturn err\n\t}\n", jsonType)
+		fmt.Fprintf(b, "\t*%s = %s{%s}\n\treturn nil\n}\n", recv, rec.name, strings.Join(inits, ", "))
+	}
+}
+
+// cloneExpr returns an expression copying v, a value of shape s, so that
+// no slice or map, however deeply nested, is shared with the original.
+// Slices and maps of plain values are cloned in one call; containers of
+// containers are copied element by element by a function literal.
+func cloneExpr(s *shape, v string, uses map[string]bool) string {
+	switch {
+	case s.kind == kindSlice && !s.elem.deep():
+		uses["slices"] = true
+		return "slices.Clone(" + v + ")"
+	case s.kind == kindMap && !s.elem.deep():
+		uses["maps"] = true
+		return "maps.Clone(" + v + ")"
+	case s.kind == kindSlice:
+		return fmt.Sprintf("func(s %[1]s) %[1]s {\nif s == nil {\nreturn nil\n}\nc := make(%[1]s, len(s))\n"+
+			"for i, e := range s {\nc[i] = %[2]s\n}\nreturn c\n}(%[3]s)", s.typ, cloneExpr(s.elem, "e", uses), v)
+	case s.kind == kindMap:
+		return fmt.Sprintf("func(m %[1]s) %[1]s {\nif m == nil {\nreturn nil\n}\nc := make(%[1]s, len(m))\n"+
+			"for k, e := range m {\nc[k] = %[2]s\n}\nreturn c\n}(%[3]s)", s.typ, cloneExpr(s.elem, "e", uses), v)
+	}
+	return v
+}
+
+// equalExpr returns an expression reporting whether a and b, values of
+// shape s, are equal. Slices and maps are compared element by element,
+// recursing into nested containers, since only their plain elements can
+// be compared with ==.
+func equalExpr(s *shape, a, b string, uses map[string]bool) string {
+	switch {
+	case s.kind == kindSlice && !s.elem.deep():
+		uses["slices"] = true
+		return fmt.Sprintf("slices.Equal(%s, %s)", a, b)
+	case s.kind == kindMap && !s.elem.deep():
+		uses["maps"] = true
+		return fmt.Sprintf("maps.Equal(%s, %s)", a, b)
+	case s.kind == kindSlice:
+		uses["slices"] = true
+		return fmt.Sprintf("slices.EqualFunc(%s, %s, func(x, y %s) bool {\nreturn %s\n})", a, b, s.elem.typ, equalExpr(s.elem, "x", "y", uses))
+	case s.kind == kindMap:
+		uses["maps"] = true
+		return fmt.Sprintf("maps.EqualFunc(%s, %s, func(x, y %s) bool {\nreturn %s\n})", a, b, s.elem.typ, equalExpr(s.elem, "x", "y", uses))
+	}
+	return fmt.Sprintf("%s == %s", a, b)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestGenerateGolden compares the code generated for testdata/records
+// with testdata/records.golden. Regenerate it with:
+//
+//	go test ./cmd/immutgen -update
+func TestGenerateGolden(t *testing.T) {
+	got, err := generate("testdata/records", "immutable_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "records.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from %s; rerun with -update and review the diff:\n%s", golden, got)
+	}
+}
+
+// TestGeneratedCodeBuilds compiles the code generated for
+// testdata/records, which a golden file alone cannot show, and runs the
+// package's own test against it.
+func TestGeneratedCodeBuilds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	src := filepath.Join("testdata", "records")
+	dir := t.TempDir()
+	gen, err := generate(src, "immutable_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"go.mod":           []byte("module records\n\ngo 1.24\n"),
+		"immutable_gen.go": gen,
+	}
+	for _, name := range []string{"records.go", "records_test.go"} {
+		if files[name], err = os.ReadFile(filepath.Join(src, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(gotool, "test", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test on the generated code: %v\n%s", err, out)
+	}
+}
+
+// TestGeneratedFilesUpToDate fails when a record in the repository was
+// edited without rerunning go generate.
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	dir := filepath.Join("..", "..", "pkg", "functional")
+	got, err := generate(dir, "immutable_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "immutable_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("pkg/functional/immutable_gen.go is stale; run go generate ./pkg/functional")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"not a struct", "//immutable:\ntype T int", "is not a struct"},
+		{"exported field", "//immutable:\ntype T struct{ Name string }", "must be unexported"},
+		{"embedded field", "type E struct{}\n\n//immutable:\ntype T struct{ E }", "embedded field"},
+		{"generic", "//immutable:\ntype T[V any] struct{ v V }", "generic record"},
+		{"unknown package", "//immutable:\ntype T struct{ at time.Time }", "no import for package time"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := "package p\n\n" + tt.src + "\n"
+			if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := generate(dir, "immutable_gen.go")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"x":       "X",
+		"id":      "ID",
+		"idToken": "IDToken",
+		"urlPath": "URLPath",
+		"ident":   "Ident",
+		"userID":  "UserID",
+	}
+	for in, want := range tests {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
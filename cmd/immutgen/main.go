@@ -0,0 +1,75 @@
+// Command immutgen generates the boilerplate of immutable record types.
+//
+// A record is a struct with unexported fields whose doc comment contains
+// a line starting with //immutable:, conventionally //immutable:record
+// (gofmt only keeps a directive comment unspaced when text follows the
+// colon):
+//
+//	// Team is an immutable team record.
+//	//
+//	//immutable:record
+//	type Team struct {
+//		name    string
+//		members []string
+//	}
+//
+// For each record immutgen writes getters (Name, Members), copying With*
+// methods (WithName, WithMembers), Equal, String, and MarshalJSON and
+// UnmarshalJSON using the field names as keys. Slice and map fields,
+// including nested ones such as [][]int and the package's own named
+// types such as "type Tags []string", are deep-copied on the way in and
+// out, so callers can never reach the record's own storage. Equal
+// compares slices and maps element by element, recursing into nested
+// containers, and every other field with ==. Named types from other
+// packages are not resolved and count as plain values. Methods the
+// package already declares are not generated, so a record can replace
+// any of them by hand.
+//
+// Why generate? Hand-written With* methods must list every field again,
+// and the first field added without updating them is silently dropped
+// from every copy.
+//
+// Run it from a go:generate directive in the package:
+//
+//	//go:generate go run ../../cmd/immutgen
+//
+// Usage:
+//
+//	immutgen [-output file] [dir]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("immutgen: ")
+
+	output := flag.String("output", "immutable_gen.go", "name of the generated file, written into dir")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: immutgen [-output file] [dir]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if src == nil {
+		log.Fatalf("no //immutable: structs in %s", dir)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package records
+
+import (
+	"fmt"
+	stdtime "time"
+)
+
+// Event exercises every kind of field immutgen handles.
+//
+//immutable:record
+type Event struct {
+	id      int
+	urlPath string
+	tags    []string
+	counts  map[string]int
+	at      stdtime.Time
+	size    [2]int
+}
+
+// Tags and Matrix are named containers; immutgen must treat them by
+// their underlying types.
+type (
+	Tags   []string
+	Matrix [][]int
+	Label  string
+)
+
+// Nested exercises nested and named container fields, whose elements
+// are not comparable with ==.
+//
+//immutable:record
+type Nested struct {
+	grid    [][]int
+	index   map[string][]string
+	deep    []map[string][]int
+	labels  Tags
+	matrix  Matrix
+	byLabel map[Label]Tags
+	label   Label
+}
+
+// String is written by hand, so immutgen must not generate it.
+func (e Event) String() string {
+	return fmt.Sprintf("event %d", e.id)
+}
+
+// Empty has no fields.
+//
+//immutable:record
+type Empty struct{}
+
+// plain is not annotated and must be left alone.
+type plain struct {
+	x int
+}
@@ -0,0 +1,40 @@
+package records
+
+import "testing"
+
+// This test runs against the code generated for this package, in a
+// scratch module set up by TestGeneratedCodeBuilds.
+
+func TestNestedCopiesAreDeep(t *testing.T) {
+	grid := [][]int{{1, 2}, {3}}
+	index := map[string][]string{"a": {"x"}}
+	deep := []map[string][]int{{"k": {1}}}
+	matrix := Matrix{{4}}
+	byLabel := map[Label]Tags{"l": {"t"}}
+	n := Nested{}.WithGrid(grid).WithIndex(index).WithDeep(deep).WithMatrix(matrix).WithByLabel(byLabel)
+	same := n.WithLabels(nil)
+
+	grid[0][0] = 9
+	index["a"][0] = "changed"
+	deep[0]["k"][0] = 9
+	matrix[0][0] = 9
+	byLabel["l"][0] = "changed"
+	n.Grid()[1][0] = 9
+	n.Index()["a"][0] = "changed"
+	n.Deep()[0]["k"][0] = 9
+	n.Matrix()[0][0] = 9
+	n.ByLabel()["l"][0] = "changed"
+
+	want := Nested{}.
+		WithGrid([][]int{{1, 2}, {3}}).
+		WithIndex(map[string][]string{"a": {"x"}}).
+		WithDeep([]map[string][]int{{"k": {1}}}).
+		WithMatrix(Matrix{{4}}).
+		WithByLabel(map[Label]Tags{"l": {"t"}})
+	if !n.Equal(want) || !n.Equal(same) {
+		t.Errorf("record changed through a shared slice or map: %v", n)
+	}
+	if n.Equal(want.WithDeep([]map[string][]int{{"k": {2}}})) {
+		t.Error("Equal ignored a nested difference")
+	}
+}
@@ -0,0 +1,225 @@
+// Package bench compares interchangeable implementations, such as the
+// strategies in pkg/oop/patterns, across growing input sizes.
+//
+// Measurements come from testing.Benchmark, the same machinery as
+// "go test -bench", so ns/op, allocs/op and B/op are directly comparable
+// with benchmark output from the test suite.
+package bench
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"testing"
+	"text/tabwriter"
+)
+
+// Family is a group of implementations benchmarked on the same inputs.
+type Family struct {
+	Name        string
+	Description string
+	Sizes       []int
+	impls       []impl
+}
+
+// impl is a family member with its input generator bound in.
+type impl struct {
+	name  string
+	bench func(size int) func(b *testing.B)
+}
+
+// Impl is one implementation of a family operating on inputs of type In.
+type Impl[In any] struct {
+	Name string
+	Run  func(In)
+}
+
+// NewFamily builds a family whose implementations all receive input
+// generated by gen for each size. Input generation is not timed.
+func NewFamily[In any](name, description string, sizes []int, gen func(size int) In, impls ...Impl[In]) Family {
+	f := Family{Name: name, Description: description, Sizes: sizes}
+	for _, im := range impls {
+		f.impls = append(f.impls, impl{
+			name: im.Name,
+			bench: func(size int) func(b *testing.B) {
+				return func(b *testing.B) {
+					input := gen(size)
+					b.ReportAllocs()
+					b.ResetTimer()
+					for range b.N {
+						im.Run(input)
+					}
+				}
+			},
+		})
+	}
+	return f
+}
+
+// Impls returns the names of the family's implementations.
+func (f Family) Impls() []string {
+	names := make([]string, 0, len(f.impls))
+	for _, im := range f.impls {
+		names = append(names, im.name)
+	}
+	return names
+}
+
+// Measurement is the benchmark result of one implementation at one size.
+type Measurement struct {
+	Family      string
+	Impl        string
+	Size        int
+	N           int
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// Suite holds the registered families.
+type Suite struct {
+	families []Family
+}
+
+// NewSuite creates an empty suite.
+func NewSuite() *Suite {
+	return &Suite{}
+}
+
+// Register adds a family to the suite.
+func (s *Suite) Register(f Family) {
+	s.families = append(s.families, f)
+}
+
+// Families returns the registered families whose name matches the
+// path.Match glob pattern; an empty pattern matches all.
+func (s *Suite) Families(pattern string) []Family {
+	var matched []Family
+	for _, f := range s.families {
+		if ok, _ := path.Match(pattern, f.Name); pattern == "" || ok {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+// Options tune a benchmark run.
+type Options struct {
+	// Sizes overrides each family's default input sizes.
+	Sizes []int
+	// BenchTime is the -test.benchtime value: a duration such as "200ms"
+	// or an iteration count such as "100x". Empty means the default, 1s,
+	// whatever an earlier Run or the command line set.
+	BenchTime string
+	// Progress, if set, receives a line per measurement as it completes.
+	Progress io.Writer
+}
+
+// Run benchmarks every implementation of every family at every size.
+func Run(families []Family, opts Options) ([]Measurement, error) {
+	restore, err := setBenchTime(opts.BenchTime)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+
+	var results []Measurement
+	for _, f := range families {
+		sizes := f.Sizes
+		if len(opts.Sizes) > 0 {
+			sizes = opts.Sizes
+		}
+		for _, size := range sizes {
+			for _, im := range f.impls {
+				r := testing.Benchmark(im.bench(size))
+				m := Measurement{
+					Family:      f.Name,
+					Impl:        im.name,
+					Size:        size,
+					N:           r.N,
+					NsPerOp:     r.NsPerOp(),
+					AllocsPerOp: r.AllocsPerOp(),
+					BytesPerOp:  r.AllocedBytesPerOp(),
+				}
+				if opts.Progress != nil {
+					fmt.Fprintf(opts.Progress, "%s/%s/%d\t%d\t%d ns/op\n", m.Family, m.Impl, m.Size, m.N, m.NsPerOp)
+				}
+				results = append(results, m)
+			}
+		}
+	}
+	return results, nil
+}
+
+// setBenchTime configures testing.Benchmark through the testing flags,
+// which is the only knob it exposes. The flag is process-wide, so the
+// returned func puts back the previous value once the run is over.
+func setBenchTime(value string) (restore func(), err error) {
+	if value == "" {
+		value = "1s"
+	}
+	testing.Init()
+	previous := flag.Lookup("test.benchtime").Value.String()
+	if err := flag.Set("test.benchtime", value); err != nil {
+		return nil, fmt.Errorf("invalid bench time %q: %w", value, err)
+	}
+	return func() { _ = flag.Set("test.benchtime", previous) }, nil
+}
+
+// WriteTable renders measurements as a comparison table. Within each
+// family and size, "vs best" is the ratio to the fastest implementation.
+func WriteTable(w io.Writer, results []Measurement) error {
+	fastest := make(map[string]int64)
+	for _, m := range results {
+		key := fmt.Sprintf("%s/%d", m.Family, m.Size)
+		if best, ok := fastest[key]; !ok || m.NsPerOp < best {
+			fastest[key] = m.NsPerOp
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tIMPL\tSIZE\tns/op\tallocs/op\tB/op\tvs best\t")
+	previous := ""
+	for _, m := range results {
+		key := fmt.Sprintf("%s/%d", m.Family, m.Size)
+		if previous != "" && key != previous {
+			fmt.Fprintln(tw, "\t\t\t\t\t\t\t")
+		}
+		previous = key
+
+		ratio := 1.0
+		if best := fastest[key]; best > 0 {
+			ratio = float64(m.NsPerOp) / float64(best)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%.2fx\t\n",
+			m.Family, m.Impl, m.Size, m.NsPerOp, m.AllocsPerOp, m.BytesPerOp, ratio)
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes measurements as CSV with a header row.
+func WriteCSV(w io.Writer, results []Measurement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"family", "impl", "size", "n", "ns_per_op", "allocs_per_op", "bytes_per_op"}); err != nil {
+		return err
+	}
+	for _, m := range results {
+		record := []string{
+			m.Family,
+			m.Impl,
+			strconv.Itoa(m.Size),
+			strconv.Itoa(m.N),
+			strconv.FormatInt(m.NsPerOp, 10),
+			strconv.FormatInt(m.AllocsPerOp, 10),
+			strconv.FormatInt(m.BytesPerOp, 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
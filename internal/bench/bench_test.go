@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunMeasuresEveryImplAndSize(t *testing.T) {
+	family := NewFamily("copy", "slice copies", []int{8, 64},
+		func(n int) []byte { return make([]byte, n) },
+		Impl[[]byte]{Name: "clone", Run: func(b []byte) { _ = slices.Clone(b) }},
+		Impl[[]byte]{Name: "noop", Run: func([]byte) {}},
+	)
+
+	results, err := Run([]Family{family}, Options{BenchTime: "10x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %d measurements, want 4", len(results))
+	}
+	for _, m := range results {
+		if m.N != 10 {
+			t.Errorf("%s/%d: N = %d, want 10", m.Impl, m.Size, m.N)
+		}
+	}
+	// The race detector pads small allocations, so an 8-byte clone may
+	// be reported as up to 16 bytes.
+	if c := results[0]; c.Impl != "clone" || c.AllocsPerOp != 1 || c.BytesPerOp < 8 || c.BytesPerOp > 16 {
+		t.Errorf("unexpected clone measurement: %+v", results[0])
+	}
+	if results[1].Impl != "noop" || results[1].AllocsPerOp != 0 {
+		t.Errorf("unexpected noop measurement: %+v", results[1])
+	}
+
+	var table bytes.Buffer
+	if err := WriteTable(&table, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "vs best") {
+		t.Errorf("table missing header:\n%s", table.String())
+	}
+
+	var out bytes.Buffer
+	if err := WriteCSV(&out, results); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || records[0][0] != "family" || records[1][1] != "clone" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+}
+
+func TestRunRestoresBenchTime(t *testing.T) {
+	benchtime := flag.Lookup("test.benchtime").Value
+	before := benchtime.String()
+	family := NewFamily("noop", "", []int{1}, func(int) int { return 0 }, Impl[int]{Name: "noop", Run: func(int) {}})
+
+	if _, err := Run([]Family{family}, Options{BenchTime: "3x"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := benchtime.String(); got != before {
+		t.Errorf("test.benchtime = %q after Run, want %q", got, before)
+	}
+	if _, err := Run([]Family{family}, Options{BenchTime: "bogus"}); err == nil {
+		t.Error("Run accepted an invalid bench time")
+	}
+	if got := benchtime.String(); got != before {
+		t.Errorf("test.benchtime = %q after a failed Run, want %q", got, before)
+	}
+}
+
+func TestSuiteFamilies(t *testing.T) {
+	s := NewSuite()
+	s.Register(Family{Name: "sort"})
+	s.Register(Family{Name: "compression"})
+
+	if got := s.Families(""); len(got) != 2 {
+		t.Errorf("empty pattern matched %d families", len(got))
+	}
+	if got := s.Families("s*"); len(got) != 1 || got[0].Name != "sort" {
+		t.Errorf("s* matched %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// stdoutCapture routes everything written to os.Stdout through a pipe
+// and forwards it to a switchable sink.
+//
+// Why? The examples print with fmt.Println, which always targets the
+// os.Stdout variable, so swapping that variable is the only way to collect
+// their output without changing every example's signature. The variable
+// is swapped exactly once: goroutines an example leaves behind (cleanups,
+// listeners) may still read it, and reassigning it per example would be a
+// data race. Between examples the sink is the original stdout.
+type stdoutCapture struct {
+	mu      sync.Mutex // serializes capture calls
+	sinkMu  sync.Mutex // guards sink
+	sink    io.Writer
+	stdout  *os.File // the original os.Stdout
+	pipe    *os.File
+	flushed chan struct{}
+}
+
+// flushMarker is written to the pipe to find out when everything written
+// before it has reached the sink.
+var flushMarker = []byte("\x00runner-flush\x00\n")
+
+var (
+	captureOnce   sync.Once
+	sharedCapture *stdoutCapture
+	captureErr    error
+)
+
+// installCapture replaces os.Stdout with the capture pipe on first use.
+func installCapture() (*stdoutCapture, error) {
+	captureOnce.Do(func() {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			captureErr = err
+			return
+		}
+		c := &stdoutCapture{
+			sink:    os.Stdout,
+			stdout:  os.Stdout,
+			pipe:    pw,
+			flushed: make(chan struct{}),
+		}
+		os.Stdout = pw
+		go c.forward(pr)
+		sharedCapture = c
+	})
+	return sharedCapture, captureErr
+}
+
+// forward copies pipe contents to the current sink line by line,
+// acknowledging flush markers as they arrive.
+func (c *stdoutCapture) forward(pr io.Reader) {
+	br := bufio.NewReader(pr)
+	for {
+		line, err := br.ReadBytes('\n')
+		marked := bytes.HasSuffix(line, flushMarker)
+		if marked {
+			line = line[:len(line)-len(flushMarker)]
+		}
+		if len(line) > 0 {
+			c.sinkMu.Lock()
+			_, _ = c.sink.Write(line)
+			c.sinkMu.Unlock()
+		}
+		if marked {
+			c.flushed <- struct{}{}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// flush blocks until everything written to the pipe so far has been
+// forwarded to the sink.
+func (c *stdoutCapture) flush() error {
+	if _, err := c.pipe.Write(flushMarker); err != nil {
+		return err
+	}
+	<-c.flushed
+	return nil
+}
+
+func (c *stdoutCapture) setSink(w io.Writer) {
+	c.sinkMu.Lock()
+	c.sink = w
+	c.sinkMu.Unlock()
+}
+
+// originalStdout returns the process's real standard output, even after
+// os.Stdout has been replaced by the capture pipe.
+func originalStdout() io.Writer {
+	if c, err := installCapture(); err == nil {
+		return c.stdout
+	}
+	return os.Stdout
+}
+
+// captureStdout runs fn and copies everything it prints to os.Stdout
+// into w as it is produced.
+func captureStdout(w io.Writer, fn func()) error {
+	c, err := installCapture()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Output printed between examples belongs to whatever was there before.
+	idle := c.sink
+	if err := c.flush(); err != nil {
+		return err
+	}
+	c.setSink(w)
+	defer c.setSink(idle)
+
+	fn()
+	return c.flush()
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// childEnv names the environment variable that tells a re-executed binary
+// which example to run on behalf of a parallel parent.
+const childEnv = "GOLANG202_RUNNER_EXAMPLE"
+
+// childMarker separates a child's example output from its report.
+var childMarker = []byte("\x00runner-report\x00")
+
+// childReport is what a child process sends back to its parent.
+type childReport struct {
+	Duration time.Duration `json:"duration_ns"`
+	Err      string        `json:"error,omitempty"`
+	Panic    string        `json:"panic,omitempty"`
+	Stack    string        `json:"stack,omitempty"`
+}
+
+// ServeChild runs a single example when the process was started by a
+// parallel run, writes the result for the parent, and returns true.
+// Otherwise it does nothing and returns false.
+//
+// Programs that use WithParallelism must call it once all examples are
+// registered and before doing anything else:
+//
+//	r := runner.NewRunner()
+//	registerExamples(r)
+//	if r.ServeChild() {
+//		return
+//	}
+func (r *Runner) ServeChild() bool {
+	id, ok := os.LookupEnv(childEnv)
+	if !ok {
+		return false
+	}
+
+	stdout := originalStdout()
+	report := childReport{}
+
+	i := slices.IndexFunc(r.examples, func(ex Example) bool { return ex.ID() == id })
+	if i < 0 {
+		report.Err = fmt.Sprintf("example %q is not registered in the child process", id)
+	} else {
+		// The parent enforces the timeout by killing this process.
+		result, err := r.execInProcess(context.Background(), r.examples[i], stdout)
+		report.Duration = result.duration
+		var panicErr *PanicError
+		switch {
+		case err != nil:
+			report.Err = err.Error()
+		case errors.As(result.err, &panicErr):
+			report.Panic = fmt.Sprint(panicErr.Value)
+			report.Stack = string(panicErr.Stack)
+		case result.err != nil:
+			report.Err = result.err.Error()
+		}
+	}
+
+	data, _ := json.Marshal(report)
+	_, _ = stdout.Write(childMarker)
+	_, _ = stdout.Write(data)
+	return true
+}
+
+// execInChild runs ex in a fresh copy of the current executable.
+// ctx cancellation (including the per-example timeout) kills the child.
+func (r *Runner) execInChild(ctx context.Context, ex Example, exampleOut io.Writer) (execution, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return execution{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe)
+	cmd.Env = append(os.Environ(), childEnv+"="+ex.ID())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	runErr := cmd.Run()
+	elapsed := time.Since(start)
+
+	output, reportData, found := bytes.Cut(stdout.Bytes(), childMarker)
+	if _, err := exampleOut.Write(output); err != nil {
+		return execution{}, err
+	}
+	result := execution{duration: elapsed, output: output}
+	if cmd.ProcessState != nil {
+		result.cpu = cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+	}
+
+	if ctx.Err() != nil {
+		result.err = ctx.Err()
+		return result, nil
+	}
+	if !found {
+		return result, fmt.Errorf("example process exited without a report: %v%s", runErr, stderrTail(stderr.String()))
+	}
+
+	// Goroutines the example left behind may print after the report,
+	// so decode only the first JSON value.
+	var report childReport
+	if err := json.NewDecoder(bytes.NewReader(reportData)).Decode(&report); err != nil {
+		return result, fmt.Errorf("decoding child report: %w", err)
+	}
+	result.duration = report.Duration
+	switch {
+	case report.Panic != "":
+		result.err = &PanicError{Value: report.Panic, Stack: []byte(report.Stack)}
+	case report.Err != "":
+		result.err = errors.New(report.Err)
+	}
+	return result, nil
+}
+
+func stderrTail(s string) string {
+	const maxLines = 10
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	lines := strings.Split(s, "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package runner
+
+import "time"
+
+// processCPU is not measured on this platform; in-process examples
+// report no CPU time.
+func processCPU() time.Duration { return 0 }
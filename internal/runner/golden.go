@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Golden configures golden-file comparison of example output.
+//
+// Each example's captured output is compared with Dir/<id>.golden,
+// e.g. testdata/patterns/decorator.golden. With Update set, the files
+// are rewritten instead of compared.
+//
+// Why? Examples are documentation; golden files turn every one of them
+// into a regression test without writing assertions by hand.
+type Golden struct {
+	Dir    string
+	Update bool
+}
+
+// GoldenStatus reports the outcome of a golden-file comparison.
+type GoldenStatus string
+
+// Golden comparison outcomes.
+const (
+	GoldenMatch    GoldenStatus = "match"
+	GoldenMismatch GoldenStatus = "mismatch"
+	GoldenMissing  GoldenStatus = "missing"
+	GoldenUpdated  GoldenStatus = "updated"
+	GoldenSkipped  GoldenStatus = "skipped"
+)
+
+// Path returns the golden file for an example.
+func (g Golden) Path(ex Example) string {
+	return filepath.Join(g.Dir, filepath.FromSlash(ex.ID())+".golden")
+}
+
+// Check compares output with the example's golden file, or rewrites the
+// file when g.Update is set. The returned diff is empty unless the
+// status is GoldenMismatch.
+func (g Golden) Check(ex Example, output []byte) (GoldenStatus, string, error) {
+	path := g.Path(ex)
+
+	if g.Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", "", err
+		}
+		if err := os.WriteFile(path, output, 0o644); err != nil {
+			return "", "", err
+		}
+		return GoldenUpdated, "", nil
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return GoldenMissing, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	wantLines := splitLines(string(want))
+	gotLines := splitLines(string(output))
+	if ex.Unordered {
+		slices.Sort(wantLines)
+		slices.Sort(gotLines)
+	}
+	if slices.Equal(wantLines, gotLines) {
+		return GoldenMatch, "", nil
+	}
+	return GoldenMismatch, diffLines(wantLines, gotLines), nil
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines renders a compact line diff: the first differing line with a
+// little context, and the line counts of both sides.
+func diffLines(want, got []string) string {
+	const context = 2
+
+	first := 0
+	for first < len(want) && first < len(got) && want[first] == got[first] {
+		first++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "first difference at line %d (want %d lines, got %d)\n",
+		first+1, len(want), len(got))
+	for i := max(0, first-context); i < first; i++ {
+		fmt.Fprintf(&b, "  %s\n", want[i])
+	}
+	for i := first; i < min(len(want), first+context+1); i++ {
+		fmt.Fprintf(&b, "- %s\n", want[i])
+	}
+	for i := first; i < min(len(got), first+context+1); i++ {
+		fmt.Fprintf(&b, "+ %s\n", got[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// Status is the outcome of running an example.
+type Status string
+
+// Example outcomes.
+const (
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusTimeout Status = "timeout"
+)
+
+// PanicError reports a panic recovered from an example.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Legacy adapts a plain func() example, such as patterns.ExampleStrategy,
+// to the context-aware Run signature. The function cannot observe
+// cancellation, so a timeout abandons it rather than stopping it.
+func Legacy(fn func()) func(context.Context) error {
+	return func(context.Context) error {
+		fn()
+		return nil
+	}
+}
+
+// runIsolated runs fn on its own goroutine so that a panic is turned into
+// a *PanicError and a hung example is abandoned once ctx is done.
+//
+// Why? One misbehaving example must not take down or stall the whole
+// run. Go cannot kill a goroutine, so an abandoned example keeps running
+// in the background; the runner simply stops waiting for it.
+func runIsolated(ctx context.Context, fn func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- &PanicError{Value: v, Stack: debug.Stack()}
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// Prefer a result that raced with the deadline.
+		select {
+		case err := <-done:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
+
+// statusOf classifies the error returned by runIsolated.
+func statusOf(err error) Status {
+	switch {
+	case err == nil:
+		return StatusPass
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusTimeout
+	default:
+		return StatusFail
+	}
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// RunOption configures a single RunAll or RunMatching call.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	parallelism int
+	beforeEach  func(Example)
+}
+
+// WithParallelism runs up to n examples at the same time. Values below 2
+// run examples one after another in the current process.
+//
+// Parallel examples each run in a child process (see ServeChild), since
+// the examples print straight to os.Stdout and their output could not be
+// told apart otherwise. Output is buffered per example and written in
+// registration order, so a parallel run prints exactly what a sequential
+// one would.
+func WithParallelism(n int) RunOption {
+	return func(c *runConfig) {
+		c.parallelism = n
+	}
+}
+
+// WithBeforeEach registers fn to be called, in registration order, just
+// before each example's output is written. Callers use it to print
+// section headers that line up with the output even in parallel runs.
+func WithBeforeEach(fn func(Example)) RunOption {
+	return func(c *runConfig) {
+		c.beforeEach = fn
+	}
+}
+
+// runParallel runs examples on a bounded pool of child processes and
+// replays their buffered output in registration order.
+func (r *Runner) runParallel(ctx context.Context, examples []Example, cfg runConfig) []Result {
+	exampleOut := r.exampleOutput()
+
+	results := make([]Result, len(examples))
+	buffers := make([]*replayBuffer, len(examples))
+	done := make([]chan struct{}, len(examples))
+	for i := range examples {
+		buffers[i] = &replayBuffer{}
+		done[i] = make(chan struct{})
+	}
+
+	// Start examples in registration order so the earliest ones, whose
+	// output is needed first, also finish first.
+	go func() {
+		sem := make(chan struct{}, cfg.parallelism)
+		for i, ex := range examples {
+			sem <- struct{}{}
+			go func() {
+				defer func() { <-sem }()
+				defer close(done[i])
+
+				worker := *r
+				worker.out = buffers[i].writer(r.out)
+				worker.exampleOut = buffers[i].writer(exampleOut)
+				results[i] = worker.runExample(ctx, ex, worker.execInChild)
+			}()
+		}
+	}()
+
+	for i, ex := range examples {
+		<-done[i]
+		if cfg.beforeEach != nil {
+			cfg.beforeEach(ex)
+		}
+		_ = buffers[i].replay()
+	}
+	return results
+}
+
+// replayBuffer records writes aimed at several writers and replays them
+// later in their original order.
+type replayBuffer struct {
+	mu     sync.Mutex
+	chunks []chunk
+}
+
+type chunk struct {
+	w    io.Writer
+	data []byte
+}
+
+// writer returns an io.Writer whose writes are recorded for w.
+func (b *replayBuffer) writer(w io.Writer) io.Writer {
+	return replayWriter{buf: b, target: w}
+}
+
+// replay writes every recorded chunk to its target.
+func (b *replayBuffer) replay() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, c := range b.chunks {
+		if _, err := c.w.Write(c.data); err != nil {
+			return err
+		}
+	}
+	b.chunks = nil
+	return nil
+}
+
+type replayWriter struct {
+	buf    *replayBuffer
+	target io.Writer
+}
+
+func (w replayWriter) Write(p []byte) (int, error) {
+	w.buf.mu.Lock()
+	defer w.buf.mu.Unlock()
+
+	w.buf.chunks = append(w.buf.chunks, chunk{w: w.target, data: append([]byte(nil), p...)})
+	return len(p), nil
+}
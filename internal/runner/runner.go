@@ -2,73 +2,532 @@
 package runner
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
 	"time"
 )
 
 // Example represents a runnable example.
+//
+// Examples are addressed by their ID, "<category>/<name>", which lets
+// callers select them with shell-style globs such as "patterns/*".
 type Example struct {
 	Name        string
+	Category    string
 	Description string
-	Run         func()
+	Tags        []string
+	Run         func(ctx context.Context) error
+
+	// Timeout overrides the runner's default per-example timeout.
+	Timeout time.Duration
+
+	// Unordered marks examples whose output lines are printed in a
+	// nondeterministic order (map iteration, goroutine scheduling).
+	// Golden comparison then ignores line order.
+	Unordered bool
+
+	// Nondeterministic marks examples whose output content depends on
+	// scheduling or the garbage collector. They are not golden-checked.
+	Nondeterministic bool
+}
+
+// ID returns the fully qualified example name, e.g. "patterns/decorator".
+func (e Example) ID() string {
+	if e.Category == "" {
+		return e.Name
+	}
+	return e.Category + "/" + e.Name
+}
+
+// HasTag reports whether the example is tagged with tag.
+func (e Example) HasTag(tag string) bool {
+	return slices.Contains(e.Tags, tag)
+}
+
+// Filter selects a subset of registered examples.
+// Empty fields match everything; all non-empty fields must match.
+type Filter struct {
+	// Patterns are path.Match globs matched against the example ID.
+	// A pattern without a slash is also tried against the category and
+	// the bare name, so "patterns" and "decorator" both work.
+	Patterns []string
+	Category string
+	Tags     []string
+}
+
+// Match reports whether the example satisfies the filter.
+func (f Filter) Match(ex Example) bool {
+	if f.Category != "" && ex.Category != f.Category {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !ex.HasTag(tag) {
+			return false
+		}
+	}
+	if len(f.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range f.Patterns {
+		if matchPattern(pattern, ex) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern string, ex Example) bool {
+	candidates := []string{ex.ID()}
+	if !strings.Contains(pattern, "/") {
+		candidates = append(candidates, ex.Category, ex.Name)
+	}
+	for _, c := range candidates {
+		if ok, err := path.Match(pattern, c); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Result describes the outcome of running a single example.
+type Result struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Tags     []string      `json:"tags,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	// CPU is the user plus system CPU time the example used. Examples
+	// run in this process are measured on the whole process, so the
+	// figure includes the runtime's own work and any goroutines an
+	// earlier example left behind.
+	CPU    time.Duration `json:"cpu_ns"`
+	Status Status        `json:"status"`
+
+	// Output is everything the example printed to stdout.
+	Output []byte `json:"-"`
+
+	// Golden and Diff are set when golden-file comparison is enabled.
+	Golden GoldenStatus `json:"golden,omitempty"`
+	Diff   string       `json:"diff,omitempty"`
+
+	// Err describes why the example failed or timed out, or a failure
+	// to capture or compare its output.
+	Err string `json:"error,omitempty"`
+}
+
+// OK reports whether the example passed and matched its golden file,
+// if one was checked.
+func (res Result) OK() bool {
+	return res.Status == StatusPass && res.Err == "" &&
+		res.Golden != GoldenMismatch && res.Golden != GoldenMissing
+}
+
+// Format selects how listings and results are rendered.
+type Format string
+
+// Supported output formats.
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates a user-supplied format name.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want text or json)", s)
+	}
 }
 
 // Runner manages and executes examples.
 type Runner struct {
-	examples []Example
+	examples   []Example
+	out        io.Writer
+	exampleOut io.Writer
+	golden     *Golden
+	timeout    time.Duration
 }
 
+// DefaultTimeout bounds how long a single example may run unless the
+// runner or the example overrides it.
+const DefaultTimeout = 30 * time.Second
+
 // NewRunner creates a new runner.
 func NewRunner() *Runner {
 	return &Runner{
 		examples: make([]Example, 0),
+		out:      os.Stdout,
+		timeout:  DefaultTimeout,
 	}
 }
 
+// SetOutput sets where the runner writes its progress messages.
+// Output produced by the examples themselves is not affected.
+func (r *Runner) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// SetExampleOutput sets where captured example output is forwarded;
+// by default it goes to the real standard output. Use io.Discard to
+// silence examples while still recording their output in each Result.
+func (r *Runner) SetExampleOutput(w io.Writer) {
+	r.exampleOut = w
+}
+
+// SetTimeout sets the default per-example timeout; zero disables it.
+func (r *Runner) SetTimeout(d time.Duration) {
+	r.timeout = d
+}
+
+// SetGolden enables golden-file comparison; nil disables it.
+func (r *Runner) SetGolden(g *Golden) {
+	r.golden = g
+}
+
 // Register registers an example.
 func (r *Runner) Register(example Example) {
 	r.examples = append(r.examples, example)
 }
 
-// Run runs a specific example by name.
-func (r *Runner) Run(name string) bool {
+// Examples returns all registered examples in registration order.
+func (r *Runner) Examples() []Example {
+	return slices.Clone(r.examples)
+}
+
+// Select returns the registered examples matching the filter,
+// in registration order.
+func (r *Runner) Select(f Filter) []Example {
+	var selected []Example
 	for _, ex := range r.examples {
-		if ex.Name == name {
-			r.runExample(ex)
-			return true
+		if f.Match(ex) {
+			selected = append(selected, ex)
 		}
 	}
-	return false
+	return selected
 }
 
-// RunAll runs all registered examples.
-func (r *Runner) RunAll() {
+// Run runs a specific example by ID or name.
+func (r *Runner) Run(ctx context.Context, name string) (Result, bool) {
 	for _, ex := range r.examples {
-		r.runExample(ex)
+		if ex.ID() == name || ex.Name == name {
+			return r.runExample(ctx, ex, r.execInProcess), true
+		}
 	}
+	return Result{}, false
 }
 
-func (r *Runner) runExample(ex Example) {
-	fmt.Printf("Running: %s\n", ex.Name)
-	if ex.Description != "" {
-		fmt.Printf("Description: %s\n", ex.Description)
+// Report is the outcome of a RunAll or RunMatching call.
+type Report struct {
+	Results     []Result
+	Wall        time.Duration
+	Parallelism int
+}
+
+// Summed returns the total of the individual example wall-clock
+// durations: roughly the time a sequential run would have taken.
+func (rep Report) Summed() time.Duration {
+	var total time.Duration
+	for _, res := range rep.Results {
+		total += res.Duration
+	}
+	return total
+}
+
+// CPU returns the total CPU time the examples used.
+func (rep Report) CPU() time.Duration {
+	var total time.Duration
+	for _, res := range rep.Results {
+		total += res.CPU
+	}
+	return total
+}
+
+// OK reports whether every example passed.
+func (rep Report) OK() bool {
+	for _, res := range rep.Results {
+		if !res.OK() {
+			return false
+		}
 	}
+	return true
+}
+
+// RunAll runs all registered examples and ends with a summary table.
+func (r *Runner) RunAll(opts ...RunOption) Report {
+	rep := r.RunMatching(context.Background(), Filter{}, opts...)
+	_ = WriteReport(r.out, rep, FormatText)
+	return rep
+}
 
+// RunMatching runs every example selected by f.
+// A failing, panicking or timed-out example is recorded in its Result and
+// does not stop the remaining examples.
+func (r *Runner) RunMatching(ctx context.Context, f Filter, opts ...RunOption) Report {
+	cfg := runConfig{parallelism: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	examples := r.Select(f)
+	rep := Report{Parallelism: max(cfg.parallelism, 1)}
 	start := time.Now()
-	ex.Run()
-	duration := time.Since(start)
 
-	fmt.Printf("\nCompleted in: %v\n", duration)
+	if cfg.parallelism > 1 {
+		rep.Results = r.runParallel(ctx, examples, cfg)
+	} else {
+		for _, ex := range examples {
+			if cfg.beforeEach != nil {
+				cfg.beforeEach(ex)
+			}
+			rep.Results = append(rep.Results, r.runExample(ctx, ex, r.execInProcess))
+		}
+	}
+
+	rep.Wall = time.Since(start)
+	return rep
+}
+
+// execution is the raw outcome of running one example.
+type execution struct {
+	duration time.Duration
+	cpu      time.Duration
+	output   []byte
+	err      error // returned by the example: nil, *PanicError, ctx.Err(), ...
+}
+
+// executor runs an example, forwarding its output to exampleOut as it is
+// produced. A non-nil error means the example could not be run or
+// observed at all.
+type executor func(ctx context.Context, ex Example, exampleOut io.Writer) (execution, error)
+
+func (r *Runner) exampleOutput() io.Writer {
+	if r.exampleOut == nil {
+		return originalStdout()
+	}
+	return r.exampleOut
+}
+
+// execInProcess runs ex on a goroutine of this process, capturing stdout.
+func (r *Runner) execInProcess(ctx context.Context, ex Example, exampleOut io.Writer) (execution, error) {
+	var output bytes.Buffer
+	var result execution
+
+	start, cpuStart := time.Now(), processCPU()
+	err := captureStdout(io.MultiWriter(&output, exampleOut), func() {
+		result.err = runIsolated(ctx, ex.Run)
+	})
+	result.duration = time.Since(start)
+	result.cpu = processCPU() - cpuStart
+	result.output = output.Bytes()
+	return result, err
+}
+
+func (r *Runner) runExample(ctx context.Context, ex Example, run executor) Result {
+	fmt.Fprintf(r.out, "Running: %s\n", ex.ID())
+	if ex.Description != "" {
+		fmt.Fprintf(r.out, "Description: %s\n", ex.Description)
+	}
+
+	timeout := r.timeout
+	if ex.Timeout > 0 {
+		timeout = ex.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	exec, err := run(ctx, ex, r.exampleOutput())
+
+	res := Result{
+		ID:       ex.ID(),
+		Name:     ex.Name,
+		Category: ex.Category,
+		Tags:     ex.Tags,
+		Duration: exec.duration,
+		CPU:      exec.cpu,
+		Status:   statusOf(exec.err),
+		Output:   exec.output,
+	}
+
+	switch res.Status {
+	case StatusPass:
+		fmt.Fprintf(r.out, "\nCompleted in: %v\n", exec.duration)
+	case StatusTimeout:
+		res.Err = fmt.Sprintf("timed out after %v", timeout)
+		fmt.Fprintf(r.out, "\nTimed out after: %v\n", timeout)
+	default:
+		res.Err = exec.err.Error()
+		fmt.Fprintf(r.out, "\nFailed after %v: %v\n", exec.duration, exec.err)
+		var panicErr *PanicError
+		if errors.As(exec.err, &panicErr) {
+			fmt.Fprintf(r.out, "%s\n", panicErr.Stack)
+		}
+	}
+
+	if err != nil {
+		res.Status = StatusFail
+		res.Err = err.Error()
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+		return res
+	}
+	if res.Status != StatusPass {
+		return res
+	}
+
+	if r.golden != nil && ex.Nondeterministic {
+		res.Golden = GoldenSkipped
+	} else if r.golden != nil {
+		status, diff, err := r.golden.Check(ex, res.Output)
+		if err != nil {
+			res.Err = fmt.Sprintf("golden file: %v", err)
+		}
+		res.Golden, res.Diff = status, diff
+	}
+	return res
 }
 
 // List lists all registered examples.
 func (r *Runner) List() {
-	fmt.Println("Available examples:")
-	for i, ex := range r.examples {
-		fmt.Printf("%d. %s", i+1, ex.Name)
+	_ = WriteList(r.out, r.examples, FormatText)
+}
+
+// listEntry is the JSON shape of a listed example.
+type listEntry struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Category    string   `json:"category"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// WriteList renders examples to w in the given format.
+func WriteList(w io.Writer, examples []Example, format Format) error {
+	if format == FormatJSON {
+		entries := make([]listEntry, 0, len(examples))
+		for _, ex := range examples {
+			entries = append(entries, listEntry{
+				ID:          ex.ID(),
+				Name:        ex.Name,
+				Category:    ex.Category,
+				Description: ex.Description,
+				Tags:        ex.Tags,
+			})
+		}
+		return writeJSON(w, entries)
+	}
+
+	fmt.Fprintln(w, "Available examples:")
+	for i, ex := range examples {
+		fmt.Fprintf(w, "%d. %s", i+1, ex.ID())
 		if ex.Description != "" {
-			fmt.Printf(" - %s", ex.Description)
+			fmt.Fprintf(w, " - %s", ex.Description)
 		}
+		if len(ex.Tags) > 0 {
+			fmt.Fprintf(w, " %v", ex.Tags)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// reportJSON is the JSON shape of a Report.
+type reportJSON struct {
+	Results     []Result      `json:"results"`
+	Wall        time.Duration `json:"wall_ns"`
+	Summed      time.Duration `json:"summed_ns"`
+	CPU         time.Duration `json:"cpu_ns"`
+	Parallelism int           `json:"parallelism"`
+}
+
+// WriteReport renders a run report to w in the given format. The text
+// format is a summary table followed by errors and golden diffs.
+func WriteReport(w io.Writer, rep Report, format Format) error {
+	if format == FormatJSON {
+		results := rep.Results
+		if results == nil {
+			results = []Result{}
+		}
+		return writeJSON(w, reportJSON{
+			Results:     results,
+			Wall:        rep.Wall,
+			Summed:      rep.Summed(),
+			CPU:         rep.CPU(),
+			Parallelism: rep.Parallelism,
+		})
+	}
 
+	counts := make(map[Status]int)
+	showGolden := slices.ContainsFunc(rep.Results, func(res Result) bool { return res.Golden != "" })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "STATUS\tEXAMPLE\tDURATION")
+	if showGolden {
+		fmt.Fprint(tw, "\tGOLDEN")
 	}
+	fmt.Fprintln(tw)
+	for _, res := range rep.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%v", res.Status, res.ID, res.Duration.Round(time.Microsecond))
+		if showGolden {
+			fmt.Fprintf(tw, "\t%s", res.Golden)
+		}
+		fmt.Fprintln(tw)
+		counts[res.Status]++
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, res := range rep.Results {
+		if res.Err == "" && res.Diff == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", res.ID)
+		if res.Err != "" {
+			fmt.Fprintf(w, "  error: %s\n", res.Err)
+		}
+		if res.Diff != "" {
+			fmt.Fprint(w, indent(res.Diff, "  "))
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d example(s): %d passed, %d failed, %d timed out\n",
+		len(rep.Results), counts[StatusPass], counts[StatusFail], counts[StatusTimeout])
+
+	summed := rep.Summed()
+	fmt.Fprintf(w, "Wall time %v, summed example time %v, CPU time %v",
+		rep.Wall.Round(time.Microsecond), summed.Round(time.Microsecond), rep.CPU().Round(time.Microsecond))
+	if rep.Parallelism > 1 && rep.Wall > 0 {
+		fmt.Fprintf(w, " (%d workers, %.1fx speedup)", rep.Parallelism, float64(summed)/float64(rep.Wall))
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
 }
@@ -0,0 +1,264 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// Parallel runs re-execute the test binary for each example.
+	if newParallelRunner().ServeChild() {
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func newTestRunner() *Runner {
+	r := NewRunner()
+	r.SetOutput(&bytes.Buffer{})
+	r.Register(Example{Category: "patterns", Name: "decorator", Tags: []string{"structural"}, Run: Legacy(func() {})})
+	r.Register(Example{Category: "patterns", Name: "strategy", Tags: []string{"behavioral"}, Run: Legacy(func() {})})
+	r.Register(Example{Category: "idioms", Name: "channels", Tags: []string{"concurrency", "slow"}, Run: Legacy(func() {})})
+	return r
+}
+
+func ids(examples []Example) []string {
+	var out []string
+	for _, ex := range examples {
+		out = append(out, ex.ID())
+	}
+	return out
+}
+
+func TestFilterSelect(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"empty filter", Filter{}, []string{"patterns/decorator", "patterns/strategy", "idioms/channels"}},
+		{"exact id", Filter{Patterns: []string{"patterns/decorator"}}, []string{"patterns/decorator"}},
+		{"glob", Filter{Patterns: []string{"patterns/*"}}, []string{"patterns/decorator", "patterns/strategy"}},
+		{"bare category", Filter{Patterns: []string{"idioms"}}, []string{"idioms/channels"}},
+		{"bare name", Filter{Patterns: []string{"strategy"}}, []string{"patterns/strategy"}},
+		{"category flag", Filter{Category: "patterns"}, []string{"patterns/decorator", "patterns/strategy"}},
+		{"tag", Filter{Tags: []string{"slow"}}, []string{"idioms/channels"}},
+		{"tag and glob", Filter{Patterns: []string{"*/*"}, Tags: []string{"structural"}}, []string{"patterns/decorator"}},
+		{"no match", Filter{Patterns: []string{"nope"}}, nil},
+	}
+
+	r := newTestRunner()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(r.Select(tt.filter))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteListJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteList(&buf, newTestRunner().Examples(), FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(entries) != 3 || entries[0].ID != "patterns/decorator" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
+
+func TestListEndsLinesWithNewline(t *testing.T) {
+	var buf bytes.Buffer
+	r := newTestRunner()
+	r.SetOutput(&buf)
+	r.List()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Errorf("expected header plus 3 lines, got %q", buf.String())
+	}
+}
+
+func TestRunCapturesOutput(t *testing.T) {
+	var shown bytes.Buffer
+	r := NewRunner()
+	r.SetOutput(&bytes.Buffer{})
+	r.SetExampleOutput(&shown)
+	r.Register(Example{Category: "demo", Name: "hello", Run: Legacy(func() { fmt.Println("hello") })})
+
+	results := r.RunMatching(context.Background(), Filter{}).Results
+	if len(results) != 1 || string(results[0].Output) != "hello\n" {
+		t.Fatalf("captured %q", results[0].Output)
+	}
+	if shown.String() != "hello\n" {
+		t.Errorf("forwarded %q", shown.String())
+	}
+}
+
+func TestGoldenCheck(t *testing.T) {
+	dir := t.TempDir()
+	ex := Example{Category: "demo", Name: "lines"}
+	unordered := Example{Category: "demo", Name: "lines", Unordered: true}
+
+	update := Golden{Dir: dir, Update: true}
+	check := Golden{Dir: dir}
+
+	if status, _, err := check.Check(ex, []byte("a\nb\n")); err != nil || status != GoldenMissing {
+		t.Fatalf("before update: status=%q err=%v", status, err)
+	}
+	if status, _, err := update.Check(ex, []byte("a\nb\n")); err != nil || status != GoldenUpdated {
+		t.Fatalf("update: status=%q err=%v", status, err)
+	}
+
+	tests := []struct {
+		name   string
+		ex     Example
+		output string
+		want   GoldenStatus
+	}{
+		{"identical", ex, "a\nb\n", GoldenMatch},
+		{"changed", ex, "a\nc\n", GoldenMismatch},
+		{"reordered", ex, "b\na\n", GoldenMismatch},
+		{"reordered but unordered", unordered, "b\na\n", GoldenMatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, diff, err := check.Check(tt.ex, []byte(tt.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.want {
+				t.Errorf("status = %q, want %q", status, tt.want)
+			}
+			if (status == GoldenMismatch) != (diff != "") {
+				t.Errorf("unexpected diff %q for status %q", diff, status)
+			}
+		})
+	}
+}
+
+func TestRunIsolatesFailures(t *testing.T) {
+	r := NewRunner()
+	r.SetOutput(&bytes.Buffer{})
+	r.SetExampleOutput(&bytes.Buffer{})
+	r.SetTimeout(50 * time.Millisecond)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	r.Register(Example{Category: "demo", Name: "panics", Run: Legacy(func() { panic("boom") })})
+	r.Register(Example{Category: "demo", Name: "hangs", Run: Legacy(func() { <-block })})
+	r.Register(Example{Category: "demo", Name: "errors", Run: func(context.Context) error { return errors.New("bad input") }})
+	r.Register(Example{Category: "demo", Name: "honours-ctx", Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+	r.Register(Example{Category: "demo", Name: "passes", Run: Legacy(func() {})})
+
+	want := map[string]Status{
+		"demo/panics":      StatusFail,
+		"demo/hangs":       StatusTimeout,
+		"demo/errors":      StatusFail,
+		"demo/honours-ctx": StatusTimeout,
+		"demo/passes":      StatusPass,
+	}
+
+	results := r.RunMatching(context.Background(), Filter{}).Results
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for _, res := range results {
+		if res.Status != want[res.ID] {
+			t.Errorf("%s: status = %q, want %q (err %q)", res.ID, res.Status, want[res.ID], res.Err)
+		}
+	}
+	if !strings.Contains(results[0].Err, "boom") {
+		t.Errorf("panic value not reported: %q", results[0].Err)
+	}
+
+	var summary bytes.Buffer
+	if err := WriteReport(&summary, Report{Results: results}, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "1 passed, 2 failed, 2 timed out") {
+		t.Errorf("unexpected summary:\n%s", summary.String())
+	}
+}
+
+// newParallelRunner registers examples whose durations are in reverse
+// registration order, so a parallel run finishes them out of order.
+func newParallelRunner() *Runner {
+	r := NewRunner()
+	for i, delay := range []time.Duration{150, 100, 50} {
+		r.Register(Example{
+			Category: "parallel",
+			Name:     fmt.Sprint(i),
+			Run: Legacy(func() {
+				fmt.Printf("example %d start\n", i)
+				time.Sleep(delay * time.Millisecond)
+				fmt.Printf("example %d end\n", i)
+			}),
+		})
+	}
+	r.Register(Example{Category: "parallel", Name: "panics", Run: Legacy(func() { panic("boom") })})
+	return r
+}
+
+func TestRunMatchingParallel(t *testing.T) {
+	var out bytes.Buffer
+	r := newParallelRunner()
+	r.SetOutput(io.Discard)
+	r.SetExampleOutput(&out)
+
+	var order []string
+	rep := r.RunMatching(context.Background(), Filter{},
+		WithParallelism(4),
+		WithBeforeEach(func(ex Example) { order = append(order, ex.Name) }))
+
+	want := "example 0 start\nexample 0 end\nexample 1 start\nexample 1 end\nexample 2 start\nexample 2 end\n"
+	if out.String() != want {
+		t.Errorf("output not in registration order:\n%s", out.String())
+	}
+	if strings.Join(order, ",") != "0,1,2,panics" {
+		t.Errorf("beforeEach order = %v", order)
+	}
+	if rep.Summed() < 300*time.Millisecond || rep.Parallelism != 4 {
+		t.Errorf("summed time %v, parallelism %d", rep.Summed(), rep.Parallelism)
+	}
+	// Each child's CPU time is measured by the OS; sleeping examples
+	// use far less CPU than wall time.
+	for _, res := range rep.Results {
+		if res.CPU <= 0 || res.CPU >= res.Duration+time.Second {
+			t.Errorf("%s: CPU time %v for a %v run", res.ID, res.CPU, res.Duration)
+		}
+	}
+
+	statuses := make([]Status, 0, len(rep.Results))
+	for _, res := range rep.Results {
+		statuses = append(statuses, res.Status)
+	}
+	if !slices.Equal(statuses, []Status{StatusPass, StatusPass, StatusPass, StatusFail}) {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if !strings.Contains(rep.Results[3].Err, "boom") {
+		t.Errorf("child panic not reported: %q", rep.Results[3].Err)
+	}
+}
@@ -0,0 +1,131 @@
+// Package scrypt implements the scrypt key derivation function of
+// RFC 7914.
+//
+// Why not golang.org/x/crypto/scrypt? The module depends on the standard
+// library only, and since Go 1.24 the standard library has PBKDF2, which
+// is the only primitive scrypt needs besides the Salsa20/8 core below.
+package scrypt
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// Key derives a key of keyLen bytes from password and salt. N is the
+// CPU/memory cost and must be a power of two greater than 1; r is the
+// block size and p the parallelization. Memory use is 128*N*r bytes.
+//
+// RFC 7914 recommends N=32768, r=8, p=1 for interactive logins (2016).
+func Key(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	if N <= 1 || N&(N-1) != 0 {
+		return nil, errors.New("scrypt: N must be a power of two greater than 1")
+	}
+	if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 || r > (1<<31-1)/128/p || r > (1<<31-1)/256 || N > (1<<31-1)/128/r {
+		return nil, errors.New("scrypt: parameters are too large")
+	}
+
+	b, err := pbkdf2.Key(sha256.New, string(password), salt, 1, p*128*r)
+	if err != nil {
+		return nil, err
+	}
+
+	x := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*N)
+	tmp := make([]uint32, 32*r)
+	for i := range p {
+		block := b[i*128*r : (i+1)*128*r]
+		for j := range x {
+			x[j] = binary.LittleEndian.Uint32(block[j*4:])
+		}
+		roMix(x, v, tmp, r, N)
+		for j, w := range x {
+			binary.LittleEndian.PutUint32(block[j*4:], w)
+		}
+	}
+
+	return pbkdf2.Key(sha256.New, string(password), b, 1, keyLen)
+}
+
+// roMix is the sequential memory-hard mixing function scryptROMix,
+// operating in place on x (32*r words) with v as scratch space for the
+// N previous states.
+func roMix(x, v, tmp []uint32, r, N int) {
+	n := 32 * r
+	for i := range N {
+		copy(v[i*n:], x)
+		blockMix(x, tmp, r)
+	}
+	for range N {
+		j := int(x[n-16] & uint32(N-1))
+		for k := range x {
+			x[k] ^= v[j*n+k]
+		}
+		blockMix(x, tmp, r)
+	}
+}
+
+// blockMix is scryptBlockMix: it runs Salsa20/8 over the 2r 64-byte
+// sub-blocks of b, writing even outputs to the first half and odd ones
+// to the second.
+func blockMix(b, tmp []uint32, r int) {
+	var x [16]uint32
+	copy(x[:], b[(2*r-1)*16:])
+	for i := range 2 * r {
+		for j := range x {
+			x[j] ^= b[i*16+j]
+		}
+		salsa208(&x)
+		// Y_i goes to position i/2 for even i and r+i/2 for odd i.
+		dst := (i/2 + (i%2)*r) * 16
+		copy(tmp[dst:], x[:])
+	}
+	copy(b, tmp)
+}
+
+// salsa208 applies the Salsa20/8 core to x in place.
+func salsa208(x *[16]uint32) {
+	w := *x
+	for range 4 {
+		// Column round.
+		w[4] ^= bits.RotateLeft32(w[0]+w[12], 7)
+		w[8] ^= bits.RotateLeft32(w[4]+w[0], 9)
+		w[12] ^= bits.RotateLeft32(w[8]+w[4], 13)
+		w[0] ^= bits.RotateLeft32(w[12]+w[8], 18)
+		w[9] ^= bits.RotateLeft32(w[5]+w[1], 7)
+		w[13] ^= bits.RotateLeft32(w[9]+w[5], 9)
+		w[1] ^= bits.RotateLeft32(w[13]+w[9], 13)
+		w[5] ^= bits.RotateLeft32(w[1]+w[13], 18)
+		w[14] ^= bits.RotateLeft32(w[10]+w[6], 7)
+		w[2] ^= bits.RotateLeft32(w[14]+w[10], 9)
+		w[6] ^= bits.RotateLeft32(w[2]+w[14], 13)
+		w[10] ^= bits.RotateLeft32(w[6]+w[2], 18)
+		w[3] ^= bits.RotateLeft32(w[15]+w[11], 7)
+		w[7] ^= bits.RotateLeft32(w[3]+w[15], 9)
+		w[11] ^= bits.RotateLeft32(w[7]+w[3], 13)
+		w[15] ^= bits.RotateLeft32(w[11]+w[7], 18)
+
+		// Row round.
+		w[1] ^= bits.RotateLeft32(w[0]+w[3], 7)
+		w[2] ^= bits.RotateLeft32(w[1]+w[0], 9)
+		w[3] ^= bits.RotateLeft32(w[2]+w[1], 13)
+		w[0] ^= bits.RotateLeft32(w[3]+w[2], 18)
+		w[6] ^= bits.RotateLeft32(w[5]+w[4], 7)
+		w[7] ^= bits.RotateLeft32(w[6]+w[5], 9)
+		w[4] ^= bits.RotateLeft32(w[7]+w[6], 13)
+		w[5] ^= bits.RotateLeft32(w[4]+w[7], 18)
+		w[11] ^= bits.RotateLeft32(w[10]+w[9], 7)
+		w[8] ^= bits.RotateLeft32(w[11]+w[10], 9)
+		w[9] ^= bits.RotateLeft32(w[8]+w[11], 13)
+		w[10] ^= bits.RotateLeft32(w[9]+w[8], 18)
+		w[12] ^= bits.RotateLeft32(w[15]+w[14], 7)
+		w[13] ^= bits.RotateLeft32(w[12]+w[15], 9)
+		w[14] ^= bits.RotateLeft32(w[13]+w[12], 13)
+		w[15] ^= bits.RotateLeft32(w[14]+w[13], 18)
+	}
+	for i := range x {
+		x[i] += w[i]
+	}
+}
@@ -0,0 +1,60 @@
+package scrypt
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		password, salt string
+		N, r, p        int
+		want           string
+	}{
+		// RFC 7914, section 12.
+		{"rfc empty", "", "", 16, 1, 1,
+			"77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"rfc password", "password", "NaCl", 1024, 8, 16,
+			"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+		{"rfc pleaseletmein", "pleaseletmein", "SodiumChloride", 16384, 8, 1,
+			"7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+		// Cross-checked with Python's hashlib.scrypt.
+		{"short key", "correct horse", "0123456789abcdef", 32, 2, 3,
+			"a5fc15582279fbd7a4c7ea1092e150d3b6368741d96de786bbe47b13c1035b24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if testing.Short() && tt.N > 1024 {
+				t.Skip("slow vector")
+			}
+			got, err := Key([]byte(tt.password), []byte(tt.salt), tt.N, tt.r, tt.p, len(tt.want)/2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("Key = %x\nwant  %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRejectsBadParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		N, r, p int
+	}{
+		{"N not a power of two", 1000, 8, 1},
+		{"N is one", 1, 8, 1},
+		{"zero r", 16, 0, 1},
+		{"zero p", 16, 8, 0},
+		{"r*p too large", 16, 1 << 15, 1 << 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Key([]byte("pw"), []byte("salt"), tt.N, tt.r, tt.p, 32); err == nil {
+				t.Error("Key accepted invalid parameters")
+			}
+		})
+	}
+}
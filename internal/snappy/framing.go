@@ -0,0 +1,201 @@
+package snappy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Chunk types of the framing format.
+const (
+	chunkCompressed   = 0x00
+	chunkUncompressed = 0x01
+	chunkPadding      = 0xfe
+	chunkStreamID     = 0xff
+)
+
+// streamID is the stream identifier chunk every stream starts with.
+var streamID = []byte("\xff\x06\x00\x00sNaPpY")
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// maskedCRC returns the CRC-32C of b, masked as the framing format
+// requires so that CRCs of data containing CRCs stay well distributed.
+func maskedCRC(b []byte) uint32 {
+	c := crc32.Checksum(b, crcTable)
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// Writer compresses everything written to it into a Snappy framed
+// stream. Data is buffered into chunks of up to 64 KiB; call Flush to
+// emit a partial chunk and Close to finish the stream.
+type Writer struct {
+	w       io.Writer
+	buf     []byte
+	out     []byte
+	wroteID bool
+	err     error
+	closed  bool
+}
+
+// NewWriter returns a Writer that writes a framed stream to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, buf: make([]byte, 0, maxBlockSize)}
+}
+
+// Write buffers p, emitting a chunk each time 64 KiB have accumulated.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("snappy: write to closed Writer")
+	}
+	written := 0
+	for len(p) > 0 && w.err == nil {
+		n := copy(w.buf[len(w.buf):cap(w.buf)], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+		if len(w.buf) == maxBlockSize {
+			w.err = w.writeChunk()
+		}
+	}
+	return written, w.err
+}
+
+// Flush emits any buffered data as a chunk.
+func (w *Writer) Flush() error {
+	if w.err == nil && len(w.buf) > 0 {
+		w.err = w.writeChunk()
+	}
+	return w.err
+}
+
+// Close flushes buffered data and, for an empty stream, writes the
+// stream identifier so the output is still a valid stream. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.Flush() == nil && !w.wroteID {
+		_, w.err = w.w.Write(streamID)
+	}
+	return w.err
+}
+
+// writeChunk writes w.buf as one chunk, compressed unless compression
+// saves less than an eighth, and empties the buffer.
+func (w *Writer) writeChunk() error {
+	out := w.out[:0]
+	if !w.wroteID {
+		out = append(out, streamID...)
+		w.wroteID = true
+	}
+	headerAt := len(out)
+	out = append(out, chunkCompressed, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(out[headerAt+4:], maskedCRC(w.buf))
+	out = Encode(out, w.buf)
+	if len(out)-headerAt-8 >= len(w.buf)-len(w.buf)/8 {
+		out = append(out[:headerAt+8], w.buf...)
+		out[headerAt] = chunkUncompressed
+	}
+	size := len(out) - headerAt - 4
+	out[headerAt+1], out[headerAt+2], out[headerAt+3] = byte(size), byte(size>>8), byte(size>>16)
+
+	w.out = out
+	w.buf = w.buf[:0]
+	_, err := w.w.Write(out)
+	return err
+}
+
+// Reader decompresses a Snappy framed stream.
+type Reader struct {
+	r       io.Reader
+	chunk   []byte
+	decoded []byte
+	pending []byte
+	seenID  bool
+	err     error
+}
+
+// NewReader returns a Reader that decompresses the framed stream in r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Read decompresses into p. It returns ErrCorrupt, wrapped with detail,
+// for malformed chunks and checksum mismatches.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.nextChunk()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// nextChunk reads one chunk and leaves any data it carries in r.pending.
+func (r *Reader) nextChunk() error {
+	var header [4]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: truncated chunk header", ErrCorrupt)
+		}
+		return err // io.EOF at a chunk boundary ends the stream
+	}
+	typ := header[0]
+	size := int(header[1]) | int(header[2])<<8 | int(header[3])<<16
+
+	if typ >= 0x02 && typ <= 0x7f {
+		return fmt.Errorf("%w: unskippable chunk type %#x", ErrCorrupt, typ)
+	}
+	if !r.seenID && typ != chunkStreamID {
+		return fmt.Errorf("%w: missing stream identifier", ErrCorrupt)
+	}
+	if (typ == chunkCompressed || typ == chunkUncompressed) && (size < 4 || size > 4+MaxEncodedLen(maxBlockSize)) {
+		return fmt.Errorf("%w: chunk size %d", ErrCorrupt, size)
+	}
+
+	if cap(r.chunk) < size {
+		r.chunk = make([]byte, size)
+	}
+	chunk := r.chunk[:size]
+	if _, err := io.ReadFull(r.r, chunk); err != nil {
+		return fmt.Errorf("%w: truncated chunk", ErrCorrupt)
+	}
+
+	switch typ {
+	case chunkStreamID:
+		if string(chunk) != string(streamID[4:]) {
+			return fmt.Errorf("%w: bad stream identifier", ErrCorrupt)
+		}
+		r.seenID = true
+		return nil
+	case chunkCompressed, chunkUncompressed:
+		data := chunk[4:]
+		if typ == chunkCompressed {
+			if n, err := DecodedLen(data); err != nil || n > maxBlockSize {
+				return fmt.Errorf("%w: chunk decodes to more than 64 KiB", ErrCorrupt)
+			}
+			decoded, err := Decode(r.decoded[:0], data)
+			if err != nil {
+				return err
+			}
+			r.decoded, data = decoded, decoded
+		} else if len(data) > maxBlockSize {
+			return fmt.Errorf("%w: chunk holds more than 64 KiB", ErrCorrupt)
+		}
+		if maskedCRC(data) != binary.LittleEndian.Uint32(chunk) {
+			return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+		}
+		r.pending = data
+		return nil
+	default: // padding and reserved skippable chunks
+		return nil
+	}
+}
@@ -0,0 +1,204 @@
+// Package snappy implements the Snappy block format and its framing
+// format (https://github.com/google/snappy/blob/main/framing_format.txt).
+//
+// Snappy trades compression ratio for speed: there is no entropy coding,
+// only literals and back-references, so both directions run close to
+// memory bandwidth. Streams written here are readable by any Snappy
+// framing implementation, and vice versa.
+package snappy
+
+import (
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// ErrCorrupt reports that the input is not valid Snappy data.
+var ErrCorrupt = errors.New("snappy: corrupt input")
+
+// Element tags, in the low two bits of each element's first byte.
+const (
+	tagLiteral = 0x00
+	tagCopy1   = 0x01
+	tagCopy2   = 0x02
+	tagCopy4   = 0x03
+)
+
+// maxBlockSize is the largest input Encode compresses as one unit; the
+// framing format never produces chunks larger than this.
+const maxBlockSize = 65536
+
+// MaxEncodedLen returns the largest possible size of Encode's output for
+// srcLen bytes of input.
+func MaxEncodedLen(srcLen int) int {
+	// A varint header, then at worst a literal tag of up to 5 bytes for
+	// every 60 bytes of input, rounded up generously as in the reference.
+	return 32 + srcLen + srcLen/6
+}
+
+// Encode appends the block-format encoding of src to dst.
+func Encode(dst, src []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), maxBlockSize)
+		dst = encodeBlock(dst, src[:n])
+		src = src[n:]
+	}
+	return dst
+}
+
+// encodeBlock appends the elements for src, at most maxBlockSize bytes,
+// using a greedy search over a hash table of 4-byte sequences.
+func encodeBlock(dst, src []byte) []byte {
+	const tableBits = 14
+	if len(src) < 8 {
+		return emitLiteral(dst, src)
+	}
+	var table [1 << tableBits]uint16
+	hash := func(u uint32) uint32 { return (u * 0x1e35a7bd) >> (32 - tableBits) }
+
+	lit := 0
+	for i := 0; i+4 <= len(src); {
+		cur := binary.LittleEndian.Uint32(src[i:])
+		h := hash(cur)
+		cand := int(table[h])
+		table[h] = uint16(i)
+		if cand >= i || binary.LittleEndian.Uint32(src[cand:]) != cur {
+			i++
+			continue
+		}
+		end := i + 4
+		for end < len(src) && src[end] == src[end-i+cand] {
+			end++
+		}
+		dst = emitLiteral(dst, src[lit:i])
+		dst = emitCopy(dst, i-cand, end-i)
+		i, lit = end, end
+	}
+	return emitLiteral(dst, src[lit:])
+}
+
+// emitLiteral appends a literal element for lit, if it is not empty.
+func emitLiteral(dst, lit []byte) []byte {
+	if len(lit) == 0 {
+		return dst
+	}
+	n := uint32(len(lit) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|tagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|tagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|tagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|tagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|tagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, lit...)
+}
+
+// emitCopy appends copy elements for a back-reference of length bytes
+// at offset, which is below 65536 within a block.
+func emitCopy(dst []byte, offset, length int) []byte {
+	// A 2-byte-offset copy holds at most 64 bytes. Splitting at 60 when
+	// 65 to 67 bytes remain keeps the last piece at 4 or more, the
+	// minimum a 1-byte-offset copy can express.
+	for length >= 68 {
+		dst = append(dst, 63<<2|tagCopy2, byte(offset), byte(offset>>8))
+		length -= 64
+	}
+	if length > 64 {
+		dst = append(dst, 59<<2|tagCopy2, byte(offset), byte(offset>>8))
+		length -= 60
+	}
+	if length >= 12 || offset >= 2048 {
+		return append(dst, byte(length-1)<<2|tagCopy2, byte(offset), byte(offset>>8))
+	}
+	return append(dst, byte(offset>>8)<<5|byte(length-4)<<2|tagCopy1, byte(offset))
+}
+
+// DecodedLen returns the length of the data src decodes to.
+func DecodedLen(src []byte) (int, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > 1<<32-1 {
+		return 0, ErrCorrupt
+	}
+	return int(n), nil
+}
+
+// Decode appends the decoding of the block-format src to dst.
+func Decode(dst, src []byte) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > 1<<32-1 {
+		return nil, ErrCorrupt
+	}
+	src = src[k:]
+	base := len(dst)
+	limit := base + int(n)
+	// n comes from the input, so do not trust it for more than one block.
+	dst = slices.Grow(dst, min(int(n), maxBlockSize))
+
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 0x03 {
+		case tagLiteral:
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				extra := length - 59
+				if len(src) < extra {
+					return nil, ErrCorrupt
+				}
+				length = 0
+				for i := extra - 1; i >= 0; i-- {
+					length = length<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			length++
+			if length > len(src) || length > limit-len(dst) {
+				return nil, ErrCorrupt
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case tagCopy1:
+			if len(src) < 2 {
+				return nil, ErrCorrupt
+			}
+			length = 4 + int(tag>>2&0x07)
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case tagCopy2:
+			if len(src) < 3 {
+				return nil, ErrCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case tagCopy4:
+			if len(src) < 5 {
+				return nil, ErrCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst)-base || length > limit-len(dst) {
+			return nil, ErrCorrupt
+		}
+		// Byte by byte: the source and destination overlap whenever
+		// offset < length, which is how runs are encoded.
+		from := len(dst) - offset
+		for i := range length {
+			dst = append(dst, dst[from+i])
+		}
+	}
+	if len(dst) != limit {
+		return nil, ErrCorrupt
+	}
+	return dst, nil
+}
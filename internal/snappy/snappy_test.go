@@ -0,0 +1,192 @@
+package snappy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// testInputs covers the shapes compressors get wrong: empty input, input
+// shorter than the hash window, long runs, incompressible bytes and
+// sizes straddling the 64 KiB block limit.
+func testInputs() map[string][]byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := make([]byte, 200_000)
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	text := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 4000))
+	return map[string][]byte{
+		"empty":       {},
+		"one byte":    {'a'},
+		"short":       []byte("abcabc"),
+		"run":         bytes.Repeat([]byte{'z'}, 100_000),
+		"text":        text,
+		"random":      random,
+		"block limit": text[:maxBlockSize],
+		"block+1":     text[:maxBlockSize+1],
+		"mixed":       append(append(random[:5000:5000], text[:70_000]...), random[5000:9000]...),
+	}
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	for name, in := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			enc := Encode(nil, in)
+			if len(enc) > MaxEncodedLen(len(in)) {
+				t.Errorf("encoded %d bytes into %d, above MaxEncodedLen %d", len(in), len(enc), MaxEncodedLen(len(in)))
+			}
+			dec, err := Decode(nil, enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(dec, in) {
+				t.Fatalf("round trip mismatch: %d bytes in, %d out", len(in), len(dec))
+			}
+		})
+	}
+}
+
+func TestDecodeHandEncoded(t *testing.T) {
+	tests := []struct {
+		name, hex, want string
+	}{
+		{"empty", "00", ""},
+		{"literal", "0100" + "61", "a"},
+		// "abc" as a literal, then a 1-byte-offset copy of 9 bytes at
+		// offset 3 that overlaps its own output.
+		{"overlapping copy", "0c08616263" + "1503", "abcabcabcabc"},
+		// The same copy with a 2-byte offset.
+		{"copy2", "0c08616263" + "220300", "abcabcabcabc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, _ := hex.DecodeString(tt.hex)
+			got, err := Decode(nil, src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Decode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsCorruptInput(t *testing.T) {
+	tests := []struct{ name, hex string }{
+		{"no length", ""},
+		{"literal past end", "0508616263"},
+		{"longer than header", "0208616263"},
+		{"shorter than header", "0408616263"},
+		{"offset zero", "0c086162631500"},
+		{"offset before start", "0c086162631504"},
+		{"truncated copy", "0c0861626315"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, _ := hex.DecodeString(tt.hex)
+			if _, err := Decode(nil, src); !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Decode error = %v, want ErrCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestFramedRoundTrip(t *testing.T) {
+	for name, in := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+			// Uneven writes exercise the chunk buffering.
+			for rest := in; len(rest) > 0; {
+				n := min(len(rest), 7919)
+				if _, err := w.Write(rest[:n]); err != nil {
+					t.Fatal(err)
+				}
+				rest = rest[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(buf.Bytes(), streamID) {
+				t.Fatalf("stream starts with %x, want the stream identifier", buf.Bytes()[:min(10, buf.Len())])
+			}
+
+			got, err := io.ReadAll(NewReader(&buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, in) {
+				t.Fatalf("round trip mismatch: %d bytes in, %d out", len(in), len(got))
+			}
+		})
+	}
+}
+
+func TestFramedReader(t *testing.T) {
+	chunk := func(typ byte, body []byte) []byte {
+		n := len(body)
+		return append([]byte{typ, byte(n), byte(n >> 8), byte(n >> 16)}, body...)
+	}
+	data := func(typ byte, payload []byte) []byte {
+		crc := maskedCRC(payload)
+		if typ == chunkCompressed {
+			payload = Encode(nil, payload)
+		}
+		return chunk(typ, append([]byte{byte(crc), byte(crc >> 8), byte(crc >> 16), byte(crc >> 24)}, payload...))
+	}
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	hello := []byte("hello, hello, hello")
+
+	tests := []struct {
+		name    string
+		stream  []byte
+		want    string
+		corrupt bool
+	}{
+		{"empty input", nil, "", false},
+		{"identifier only", streamID, "", false},
+		{"compressed", join(streamID, data(chunkCompressed, hello)), string(hello), false},
+		{"uncompressed", join(streamID, data(chunkUncompressed, hello)), string(hello), false},
+		{"padding and skippable chunks", join(streamID, chunk(chunkPadding, make([]byte, 5)),
+			data(chunkUncompressed, hello), chunk(0x80, []byte("skip"))), string(hello), false},
+		{"concatenated streams", join(streamID, data(chunkUncompressed, hello), streamID,
+			data(chunkCompressed, hello)), string(hello) + string(hello), false},
+		{"missing identifier", data(chunkUncompressed, hello), "", true},
+		{"reserved unskippable", join(streamID, chunk(0x02, nil)), "", true},
+		{"bad checksum", join(streamID, chunk(chunkUncompressed, append([]byte{1, 2, 3, 4}, hello...))), "", true},
+		{"truncated", join(streamID, data(chunkCompressed, hello))[:20], "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewReader(bytes.NewReader(tt.stream)))
+			if tt.corrupt {
+				if !errors.Is(err, ErrCorrupt) {
+					t.Errorf("error = %v, want ErrCorrupt", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyStreamIsValid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), streamID) {
+		t.Errorf("empty stream = %x, want just the stream identifier", buf.Bytes())
+	}
+}
@@ -0,0 +1,566 @@
+package zstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrCorrupt reports that the input is not a valid zstd stream.
+var ErrCorrupt = errors.New("zstd: corrupt input")
+
+func errCorrupt(detail string) error {
+	return fmt.Errorf("%w: %s", ErrCorrupt, detail)
+}
+
+func errUnsupported(feature string) error {
+	return fmt.Errorf("zstd: %s: %w", feature, errors.ErrUnsupported)
+}
+
+// maxWindowSize bounds the memory a frame may ask the decoder for; it
+// matches the default limit of the reference decoder (window log 27).
+const maxWindowSize = 1 << 27
+
+// Skippable frames carry application data a decoder ignores.
+const (
+	skippableMagicMin = 0x184D2A50
+	skippableMagicMax = 0x184D2A5F
+)
+
+// Reader decompresses a stream of zstd frames, as written by the Writer
+// or by any other encoder, including the reference one at every level.
+// Frames that need a dictionary or a window larger than 128 MiB are
+// reported with an error wrapping errors.ErrUnsupported.
+type Reader struct {
+	r   *bufio.Reader
+	err error
+
+	// Frame state.
+	inFrame     bool
+	checksum    bool
+	contentSize int64 // -1 when the frame does not declare it
+	produced    int64
+	windowSize  int
+	blockMax    int
+	hash        *xxh64
+	rep         [3]uint32
+	tables      [3]*fseTable // previous literal length, offset, match length tables
+	huffman     *huffmanTable
+	last        bool
+
+	// hist holds the window followed by the block being read out.
+	hist    []byte
+	pending []byte
+
+	block []byte
+	lits  []byte
+}
+
+// NewReader returns a Reader that decompresses the zstd stream in r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), hash: newXXH64()}
+}
+
+// Read decompresses into p.
+func (r *Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.step()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// step decodes the next frame header, block or checksum.
+func (r *Reader) step() error {
+	if !r.inFrame {
+		return r.readFrameHeader()
+	}
+	if r.last {
+		return r.finishFrame()
+	}
+	return r.readBlock()
+}
+
+func (r *Reader) readFrameHeader() error {
+	var magic [4]byte
+	if _, err := io.ReadFull(r.r, magic[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return errCorrupt("truncated frame header")
+		}
+		return err // io.EOF between frames ends the stream
+	}
+	m := binary.LittleEndian.Uint32(magic[:])
+	if m >= skippableMagicMin && m <= skippableMagicMax {
+		var size [4]byte
+		if _, err := io.ReadFull(r.r, size[:]); err != nil {
+			return errCorrupt("truncated skippable frame")
+		}
+		if _, err := r.r.Discard(int(binary.LittleEndian.Uint32(size[:]))); err != nil {
+			return errCorrupt("truncated skippable frame")
+		}
+		return nil
+	}
+	if m != frameMagic {
+		return errCorrupt("bad magic number")
+	}
+
+	fhd, err := r.r.ReadByte()
+	if err != nil {
+		return errCorrupt("truncated frame header")
+	}
+	fcsFlag := fhd >> 6
+	singleSegment := fhd&0x20 != 0
+	if fhd&0x08 != 0 {
+		return errCorrupt("reserved frame header bit set")
+	}
+	r.checksum = fhd&0x04 != 0
+	dictIDSize := [4]int{0, 1, 2, 4}[fhd&0x03]
+	fcsSize := [4]int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && singleSegment {
+		fcsSize = 1
+	}
+
+	var windowDesc byte
+	if !singleSegment {
+		if windowDesc, err = r.r.ReadByte(); err != nil {
+			return errCorrupt("truncated frame header")
+		}
+	}
+	var field [8]byte
+	if _, err := io.ReadFull(r.r, field[:dictIDSize]); err != nil {
+		return errCorrupt("truncated frame header")
+	}
+	for _, b := range field[:dictIDSize] {
+		if b != 0 {
+			return errUnsupported("dictionaries")
+		}
+	}
+	clear(field[:])
+	if _, err := io.ReadFull(r.r, field[:fcsSize]); err != nil {
+		return errCorrupt("truncated frame header")
+	}
+	r.contentSize = -1
+	if fcsSize > 0 {
+		r.contentSize = int64(binary.LittleEndian.Uint64(field[:]))
+		if fcsSize == 2 {
+			r.contentSize += 256
+		}
+	}
+
+	var window uint64
+	if singleSegment {
+		window = uint64(r.contentSize)
+	} else {
+		log := 10 + uint(windowDesc>>3)
+		base := uint64(1) << log
+		window = base + base/8*uint64(windowDesc&0x07)
+	}
+	if window > maxWindowSize {
+		return errUnsupported(fmt.Sprintf("window of %d bytes", window))
+	}
+
+	r.inFrame, r.last = true, false
+	r.windowSize = int(window)
+	r.blockMax = min(r.windowSize, maxBlockSize)
+	r.produced = 0
+	r.hash.reset()
+	r.rep = [3]uint32{1, 4, 8}
+	r.tables = [3]*fseTable{}
+	r.huffman = nil
+	r.hist = r.hist[:0]
+	return nil
+}
+
+func (r *Reader) finishFrame() error {
+	r.inFrame = false
+	if r.contentSize >= 0 && r.produced != r.contentSize {
+		return errCorrupt("frame content size mismatch")
+	}
+	if !r.checksum {
+		return nil
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(r.r, sum[:]); err != nil {
+		return errCorrupt("truncated checksum")
+	}
+	if binary.LittleEndian.Uint32(sum[:]) != uint32(r.hash.sum64()) {
+		return errCorrupt("checksum mismatch")
+	}
+	return nil
+}
+
+func (r *Reader) readBlock() error {
+	var h [3]byte
+	if _, err := io.ReadFull(r.r, h[:]); err != nil {
+		return errCorrupt("truncated block header")
+	}
+	header := uint32(h[0]) | uint32(h[1])<<8 | uint32(h[2])<<16
+	r.last = header&1 != 0
+	typ := header >> 1 & 0x03
+	size := int(header >> 3)
+
+	// Keep the last window of output and make room for this block.
+	if keep := r.windowSize; len(r.hist) > keep+maxBlockSize {
+		r.hist = r.hist[:copy(r.hist, r.hist[len(r.hist)-keep:])]
+	}
+	start := len(r.hist)
+
+	switch typ {
+	case blockRaw, blockRLE:
+		if size > r.blockMax {
+			return errCorrupt("block larger than the maximum")
+		}
+		if typ == blockRaw {
+			r.hist = append(r.hist, make([]byte, size)...)
+			if _, err := io.ReadFull(r.r, r.hist[start:]); err != nil {
+				return errCorrupt("truncated block")
+			}
+		} else {
+			b, err := r.r.ReadByte()
+			if err != nil {
+				return errCorrupt("truncated block")
+			}
+			for range size {
+				r.hist = append(r.hist, b)
+			}
+		}
+	case blockCompressed:
+		if size > r.blockMax {
+			return errCorrupt("block larger than the maximum")
+		}
+		if cap(r.block) < size {
+			r.block = make([]byte, size)
+		}
+		block := r.block[:size]
+		if _, err := io.ReadFull(r.r, block); err != nil {
+			return errCorrupt("truncated block")
+		}
+		if err := r.decompress(block, start); err != nil {
+			return err
+		}
+		if len(r.hist)-start > r.blockMax {
+			return errCorrupt("block decodes past the maximum size")
+		}
+	default:
+		return errCorrupt("reserved block type")
+	}
+
+	out := r.hist[start:]
+	r.produced += int64(len(out))
+	if r.contentSize >= 0 && r.produced > r.contentSize {
+		return errCorrupt("frame longer than its declared content size")
+	}
+	r.hash.write(out)
+	r.pending = out
+	return nil
+}
+
+// decompress decodes a compressed block, appending its output to r.hist.
+// start is where the block's own output begins.
+func (r *Reader) decompress(block []byte, start int) error {
+	lits, rest, err := r.readLiterals(block)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) == 0 {
+		return errCorrupt("missing sequences section")
+	}
+	n := int(rest[0])
+	switch {
+	case n < 0x80:
+		rest = rest[1:]
+	case n < 0xff:
+		if len(rest) < 2 {
+			return errCorrupt("truncated sequences header")
+		}
+		n = (n-0x80)<<8 | int(rest[1])
+		rest = rest[2:]
+	default:
+		if len(rest) < 3 {
+			return errCorrupt("truncated sequences header")
+		}
+		n = int(rest[1]) | int(rest[2])<<8 + 0x7f00
+		rest = rest[3:]
+	}
+	if n == 0 {
+		if len(rest) != 0 {
+			return errCorrupt("trailing bytes after literals")
+		}
+		r.hist = append(r.hist, lits...)
+		return nil
+	}
+
+	if len(rest) == 0 {
+		return errCorrupt("missing compression modes")
+	}
+	modes := rest[0]
+	rest = rest[1:]
+	if modes&0x03 != 0 {
+		return errCorrupt("reserved compression mode bits set")
+	}
+	predefined := [3]*fseTable{literalLengthTable, offsetTable, matchLengthTable}
+	maxSymbol := [3]int{len(literalLengthBase) - 1, 31, len(matchLengthBase) - 1}
+	maxLog := [3]uint8{9, 8, 9}
+	var tables [3]*fseTable
+	for i, shift := range [3]uint{6, 4, 2} {
+		switch modes >> shift & 0x03 {
+		case 0:
+			tables[i] = predefined[i]
+		case 1:
+			if len(rest) == 0 {
+				return errCorrupt("truncated RLE table")
+			}
+			if int(rest[0]) > maxSymbol[i] {
+				return errCorrupt("RLE symbol out of range")
+			}
+			tables[i] = rleTable(rest[0])
+			rest = rest[1:]
+		case 2:
+			t, n, err := readFSETable(rest, maxSymbol[i], maxLog[i])
+			if err != nil {
+				return err
+			}
+			tables[i] = t
+			rest = rest[n:]
+		case 3:
+			if r.tables[i] == nil {
+				return errCorrupt("repeat mode without a previous table")
+			}
+			tables[i] = r.tables[i]
+		}
+	}
+	r.tables = tables
+	ll, of, ml := tables[0], tables[1], tables[2]
+
+	var br bitReader
+	if err := br.init(rest); err != nil {
+		return err
+	}
+	var llState, ofState, mlState uint64
+	for _, s := range []struct {
+		state *uint64
+		t     *fseTable
+	}{{&llState, ll}, {&ofState, of}, {&mlState, ml}} {
+		if *s.state, err = br.read(uint(s.t.accuracyLog)); err != nil {
+			return err
+		}
+	}
+
+	for i := range n {
+		llSym := ll.states[llState].symbol
+		ofSym := of.states[ofState].symbol
+		mlSym := ml.states[mlState].symbol
+
+		ofBits, err := br.read(uint(ofSym))
+		if err != nil {
+			return err
+		}
+		ofValue := uint32(1)<<ofSym + uint32(ofBits)
+		mlBits, err := br.read(uint(matchLengthExtra[mlSym]))
+		if err != nil {
+			return err
+		}
+		matchLen := matchLengthBase[mlSym] + uint32(mlBits)
+		llBits, err := br.read(uint(literalLengthExtra[llSym]))
+		if err != nil {
+			return err
+		}
+		litLen := literalLengthBase[llSym] + uint32(llBits)
+
+		offset, err := r.resolveOffset(ofValue, litLen)
+		if err != nil {
+			return err
+		}
+
+		if int(litLen) > len(lits) {
+			return errCorrupt("literal length past the literals")
+		}
+		r.hist = append(r.hist, lits[:litLen]...)
+		lits = lits[litLen:]
+		if int(offset) > len(r.hist) || int(offset) > r.windowSize {
+			return errCorrupt("match offset before the window")
+		}
+		if len(r.hist)-start+int(matchLen) > r.blockMax {
+			return errCorrupt("block decodes past the maximum size")
+		}
+		from := len(r.hist) - int(offset)
+		for j := range int(matchLen) {
+			r.hist = append(r.hist, r.hist[from+j])
+		}
+
+		if i == n-1 {
+			break
+		}
+		for _, s := range []struct {
+			state *uint64
+			t     *fseTable
+		}{{&llState, ll}, {&mlState, ml}, {&ofState, of}} {
+			st := s.t.states[*s.state]
+			next, err := br.read(uint(st.nbBits))
+			if err != nil {
+				return err
+			}
+			*s.state = uint64(st.baseline) + next
+		}
+	}
+	if br.pos != 0 {
+		return errCorrupt("bits left over after the last sequence")
+	}
+	r.hist = append(r.hist, lits...)
+	return nil
+}
+
+// resolveOffset turns an offset value into a distance, maintaining the
+// three repeat offsets of RFC 8878, section 3.1.1.5.
+func (r *Reader) resolveOffset(ofValue, litLen uint32) (uint32, error) {
+	if ofValue > 3 {
+		offset := ofValue - 3
+		r.rep = [3]uint32{offset, r.rep[0], r.rep[1]}
+		return offset, nil
+	}
+	idx := ofValue - 1
+	if litLen == 0 {
+		idx++
+	}
+	var offset uint32
+	switch idx {
+	case 0:
+		return r.rep[0], nil
+	case 1:
+		offset = r.rep[1]
+		r.rep[1] = r.rep[0]
+	case 2:
+		offset = r.rep[2]
+		r.rep[2], r.rep[1] = r.rep[1], r.rep[0]
+	case 3:
+		offset = r.rep[0] - 1
+		if offset == 0 {
+			return 0, errCorrupt("repeat offset of zero")
+		}
+		r.rep[2], r.rep[1] = r.rep[1], r.rep[0]
+	}
+	r.rep[0] = offset
+	return offset, nil
+}
+
+// Literals section types.
+const (
+	literalsRaw        = 0
+	literalsRLE        = 1
+	literalsCompressed = 2 // Huffman-coded, with a new tree description
+	literalsTreeless   = 3 // Huffman-coded with the previous block's tree
+)
+
+// readLiterals parses the literals section at the start of block and
+// returns the literals and the rest of the block.
+func (r *Reader) readLiterals(block []byte) (lits, rest []byte, err error) {
+	if len(block) == 0 {
+		return nil, nil, errCorrupt("missing literals section")
+	}
+	typ := block[0] & 0x03
+	if typ == literalsCompressed || typ == literalsTreeless {
+		return r.readHuffmanLiterals(block)
+	}
+	var size, headerLen int
+	switch block[0] >> 2 & 0x03 {
+	case 0, 2:
+		size, headerLen = int(block[0]>>3), 1
+	case 1:
+		if len(block) < 2 {
+			return nil, nil, errCorrupt("truncated literals header")
+		}
+		size, headerLen = int(block[0]>>4)|int(block[1])<<4, 2
+	case 3:
+		if len(block) < 3 {
+			return nil, nil, errCorrupt("truncated literals header")
+		}
+		size, headerLen = int(block[0]>>4)|int(block[1])<<4|int(block[2])<<12, 3
+	}
+	if size > r.blockMax {
+		return nil, nil, errCorrupt("literals larger than a block")
+	}
+	block = block[headerLen:]
+
+	if typ == literalsRaw {
+		if len(block) < size {
+			return nil, nil, errCorrupt("truncated literals")
+		}
+		return block[:size], block[size:], nil
+	}
+	if len(block) < 1 {
+		return nil, nil, errCorrupt("truncated RLE literals")
+	}
+	r.lits = r.lits[:0]
+	for range size {
+		r.lits = append(r.lits, block[0])
+	}
+	return r.lits, block[1:], nil
+}
+
+// readHuffmanLiterals parses a Huffman-coded literals section: a header
+// with the decoded and compressed sizes, the tree description unless the
+// section reuses the previous one, and one or four bitstreams.
+func (r *Reader) readHuffmanLiterals(block []byte) (lits, rest []byte, err error) {
+	typ := block[0] & 0x03
+	var size, compressed, headerLen int
+	streams := 4
+	switch format := block[0] >> 2 & 0x03; format {
+	case 0, 1:
+		if format == 0 {
+			streams = 1
+		}
+		if len(block) < 3 {
+			return nil, nil, errCorrupt("truncated literals header")
+		}
+		h := int(block[0]) | int(block[1])<<8 | int(block[2])<<16
+		size, compressed, headerLen = h>>4&0x3ff, h>>14&0x3ff, 3
+	case 2:
+		if len(block) < 4 {
+			return nil, nil, errCorrupt("truncated literals header")
+		}
+		h := int(binary.LittleEndian.Uint32(block))
+		size, compressed, headerLen = h>>4&0x3fff, h>>18&0x3fff, 4
+	case 3:
+		if len(block) < 5 {
+			return nil, nil, errCorrupt("truncated literals header")
+		}
+		h := int(binary.LittleEndian.Uint32(block)) | int(block[4])<<32
+		size, compressed, headerLen = h>>4&0x3ffff, h>>22&0x3ffff, 5
+	}
+	if size > r.blockMax {
+		return nil, nil, errCorrupt("literals larger than a block")
+	}
+	block = block[headerLen:]
+	if len(block) < compressed {
+		return nil, nil, errCorrupt("truncated literals")
+	}
+	src, rest := block[:compressed], block[compressed:]
+
+	if typ == literalsCompressed {
+		t, n, err := readHuffmanTable(src)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.huffman = t
+		src = src[n:]
+	} else if r.huffman == nil {
+		return nil, nil, errCorrupt("treeless literals without a previous Huffman table")
+	}
+
+	if streams == 1 {
+		r.lits, err = r.huffman.decode(r.lits[:0], src, size)
+	} else {
+		r.lits, err = r.huffman.decodeStreams(r.lits[:0], src, size)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.lits, rest, nil
+}
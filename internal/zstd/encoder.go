@@ -0,0 +1,276 @@
+// Package zstd writes and reads Zstandard frames (RFC 8878).
+//
+// The Writer produces standard frames that any zstd decoder reads: each
+// block is stored raw, as a run, or compressed with back-references
+// whose literals are stored raw and whose sequence codes use the
+// format's predefined FSE distributions. Leaving out Huffman literals
+// and custom FSE tables costs ratio, not compatibility, and keeps the
+// package small enough to read in one sitting.
+//
+// The Reader decodes the full format as other encoders write it,
+// including Huffman-coded literals and the FSE tables a block describes
+// itself, so it reads the reference encoder's output at every level;
+// see Reader for the two things it does not support.
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+const (
+	frameMagic = 0xFD2FB528
+
+	// maxBlockSize is the largest block the format allows, and the unit
+	// the Writer compresses.
+	maxBlockSize = 128 << 10
+
+	// writerWindowLog sizes the window the Writer declares. Matches never
+	// cross blocks, so one block's worth is enough.
+	writerWindowLog = 17
+
+	minMatch = 4
+)
+
+// Block types.
+const (
+	blockRaw        = 0
+	blockRLE        = 1
+	blockCompressed = 2
+)
+
+// Writer compresses everything written to it into one zstd frame with a
+// content checksum. Call Close to finish the frame.
+type Writer struct {
+	w       io.Writer
+	buf     []byte
+	out     []byte
+	hash    *xxh64
+	enc     blockEncoder
+	started bool
+	closed  bool
+	err     error
+}
+
+// NewWriter returns a Writer that writes a zstd frame to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, hash: newXXH64()}
+}
+
+// Write buffers p and compresses it a block at a time. The final block
+// is held back until Close, which has to mark it as the last one.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("zstd: write to closed Writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.hash.write(p)
+	w.buf = append(w.buf, p...)
+	for len(w.buf) > maxBlockSize && w.err == nil {
+		w.err = w.writeBlock(w.buf[:maxBlockSize], false)
+		w.buf = w.buf[:copy(w.buf, w.buf[maxBlockSize:])]
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// Close writes the last block and the checksum. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err == nil {
+		w.err = w.writeBlock(w.buf, true)
+	}
+	if w.err == nil {
+		var sum [4]byte
+		binary.LittleEndian.PutUint32(sum[:], uint32(w.hash.sum64()))
+		_, w.err = w.w.Write(sum[:])
+	}
+	return w.err
+}
+
+// writeBlock writes src as one block, preceded by the frame header if
+// this is the first one.
+func (w *Writer) writeBlock(src []byte, last bool) error {
+	out := w.out[:0]
+	if !w.started {
+		w.started = true
+		out = binary.LittleEndian.AppendUint32(out, frameMagic)
+		// Frame header descriptor: content checksum, no content size,
+		// no dictionary, not single-segment, so a window descriptor
+		// follows: exponent writerWindowLog-10, mantissa 0.
+		out = append(out, 0x04, (writerWindowLog-10)<<3)
+	}
+
+	headerAt := len(out)
+	out = append(out, 0, 0, 0)
+	typ, size := blockRaw, len(src)
+	switch {
+	case len(src) > 1 && isRun(src):
+		typ = blockRLE
+		out = append(out, src[0])
+	default:
+		out = w.enc.compress(out, src)
+		if n := len(out) - headerAt - 3; n < len(src) {
+			typ, size = blockCompressed, n
+		} else {
+			out = append(out[:headerAt+3], src...)
+		}
+	}
+	header := uint32(size)<<3 | uint32(typ)<<1
+	if last {
+		header |= 1
+	}
+	out[headerAt], out[headerAt+1], out[headerAt+2] = byte(header), byte(header>>8), byte(header>>16)
+
+	w.out = out
+	_, err := w.w.Write(out)
+	return err
+}
+
+// isRun reports whether every byte of src is the same.
+func isRun(src []byte) bool {
+	for _, b := range src[1:] {
+		if b != src[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// sequence is one literal run followed by one match. offsetValue is
+// the coded offset: 1 to 3 select a repeat offset, and larger values
+// are the distance plus 3.
+type sequence struct {
+	litLen, matchLen, offsetValue uint32
+}
+
+// blockEncoder finds matches with a hash table of 4-byte prefixes and
+// codes them with raw literals and the predefined FSE distributions.
+type blockEncoder struct {
+	table [1 << 16]int32
+	seqs  []sequence
+	lits  []byte
+}
+
+// compress appends the compressed block content for src to dst. The
+// caller falls back to a raw block if it is not smaller than src.
+func (e *blockEncoder) compress(dst, src []byte) []byte {
+	e.seqs, e.lits = e.seqs[:0], e.lits[:0]
+	for i := range e.table {
+		e.table[i] = -1
+	}
+
+	lit := 0
+	for i := 0; i+minMatch <= len(src); {
+		cur := binary.LittleEndian.Uint32(src[i:])
+		h := (cur * 2654435761) >> 16
+		cand := int(e.table[h])
+		e.table[h] = int32(i)
+		if cand < 0 || binary.LittleEndian.Uint32(src[cand:]) != cur {
+			i++
+			continue
+		}
+		end := i + minMatch
+		for end < len(src) && src[end] == src[end-i+cand] {
+			end++
+		}
+		e.lits = append(e.lits, src[lit:i]...)
+		e.seqs = append(e.seqs, sequence{
+			litLen:   uint32(i - lit),
+			matchLen: uint32(end - i),
+			// Every match is coded as a literal distance, never as a
+			// repeat offset.
+			offsetValue: uint32(i-cand) + 3,
+		})
+		i, lit = end, end
+	}
+	e.lits = append(e.lits, src[lit:]...)
+
+	dst = appendLiterals(dst, e.lits)
+	return appendSequences(dst, e.seqs)
+}
+
+// appendLiterals appends a Raw_Literals_Block section.
+func appendLiterals(dst, lits []byte) []byte {
+	n := len(lits)
+	switch {
+	case n < 1<<5:
+		dst = append(dst, byte(n<<3))
+	case n < 1<<12:
+		dst = append(dst, byte(n<<4)|0x04, byte(n>>4))
+	default:
+		dst = append(dst, byte(n<<4)|0x0c, byte(n>>4), byte(n>>12))
+	}
+	return append(dst, lits...)
+}
+
+// appendSequences appends the sequences section, coding every symbol
+// stream with its predefined distribution.
+func appendSequences(dst []byte, seqs []sequence) []byte {
+	n := len(seqs)
+	switch {
+	case n < 0x80:
+		dst = append(dst, byte(n))
+	case n < 0x7f00:
+		dst = append(dst, byte(n>>8)+0x80, byte(n))
+	default:
+		dst = append(dst, 0xff, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	if n == 0 {
+		return dst
+	}
+	dst = append(dst, 0) // Predefined_Mode for all three streams
+
+	type coded struct {
+		llCode, mlCode, ofCode uint8
+		llExtra, mlExtra       uint32
+		ofExtra                uint32
+	}
+	codes := make([]coded, n)
+	for i, s := range seqs {
+		ofValue := s.offsetValue
+		ofCode := uint8(bits.Len32(ofValue) - 1)
+		llCode := lengthCode(literalLengthBase[:], s.litLen)
+		mlCode := lengthCode(matchLengthBase[:], s.matchLen)
+		codes[i] = coded{
+			llCode: llCode, mlCode: mlCode, ofCode: ofCode,
+			llExtra: s.litLen - literalLengthBase[llCode],
+			mlExtra: s.matchLen - matchLengthBase[mlCode],
+			ofExtra: ofValue - 1<<ofCode,
+		}
+	}
+
+	w := bitWriter{out: dst}
+	extras := func(c coded) {
+		w.addBits(uint64(c.llExtra), uint(literalLengthExtra[c.llCode]))
+		w.addBits(uint64(c.mlExtra), uint(matchLengthExtra[c.mlCode]))
+		w.addBits(uint64(c.ofExtra), uint(c.ofCode))
+	}
+
+	last := codes[n-1]
+	ll := literalLengthTable.initState(last.llCode)
+	ml := matchLengthTable.initState(last.mlCode)
+	of := offsetTable.initState(last.ofCode)
+	extras(last)
+	for i := n - 2; i >= 0; i-- {
+		c := codes[i]
+		of = offsetTable.encodeSymbol(&w, of, c.ofCode)
+		ml = matchLengthTable.encodeSymbol(&w, ml, c.mlCode)
+		ll = literalLengthTable.encodeSymbol(&w, ll, c.llCode)
+		extras(c)
+	}
+	w.addBits(uint64(ml), uint(matchLengthTable.accuracyLog))
+	w.addBits(uint64(of), uint(offsetTable.accuracyLog))
+	w.addBits(uint64(ll), uint(literalLengthTable.accuracyLog))
+	return w.close()
+}
@@ -0,0 +1,351 @@
+package zstd
+
+import "math/bits"
+
+// Finite State Entropy (FSE) tables for the three sequence symbol
+// streams (literal lengths, match lengths and offsets) and for the
+// weights of Huffman literal tables.
+//
+// The Writer only uses the format's predefined distributions: a block
+// coded with them carries no table description at all. The Reader also
+// parses the distributions other encoders describe in the block
+// (readFSETable).
+
+// fseState is one entry of a decoding table: the symbol the state
+// decodes to, and how the next state is read from the bitstream.
+type fseState struct {
+	symbol   uint8
+	nbBits   uint8
+	baseline uint16
+}
+
+// fseTable is a decoding table, together with the matching encoder view
+// for the predefined tables.
+type fseTable struct {
+	accuracyLog uint8
+	states      []fseState
+	// encode[symbol][next] is the state that decodes to symbol and whose
+	// transition reaches next; see encodeSymbol. Only tables built with
+	// newFSEEncodeTable have it.
+	encode [][]uint16
+}
+
+// newFSETable builds the decoding table for a normalized distribution,
+// following the spreading procedure of RFC 8878, section 4.1.1. A count
+// of -1 marks a symbol with probability below 1/size.
+func newFSETable(counts []int16, accuracyLog uint8) *fseTable {
+	size := 1 << accuracyLog
+	states := make([]fseState, size)
+	next := make([]int, len(counts))
+
+	high := size - 1
+	for s, c := range counts {
+		if c == -1 {
+			states[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		} else {
+			next[s] = int(c)
+		}
+	}
+
+	step := size>>1 + size>>3 + 3
+	pos := 0
+	for s, c := range counts {
+		for range max(c, 0) {
+			states[pos].symbol = uint8(s)
+			pos = (pos + step) & (size - 1)
+			for pos > high {
+				pos = (pos + step) & (size - 1)
+			}
+		}
+	}
+
+	for i := range states {
+		st := &states[i]
+		n := next[st.symbol]
+		next[st.symbol]++
+		st.nbBits = accuracyLog - uint8(bits.Len(uint(n))-1)
+		st.baseline = uint16(n<<st.nbBits - size)
+	}
+	return &fseTable{accuracyLog: accuracyLog, states: states}
+}
+
+// newFSEEncodeTable is newFSETable with the encoder view filled in.
+func newFSEEncodeTable(counts []int16, accuracyLog uint8) *fseTable {
+	t := newFSETable(counts, accuracyLog)
+	t.encode = make([][]uint16, len(counts))
+	for s := range t.encode {
+		t.encode[s] = make([]uint16, len(t.states))
+	}
+	for i, st := range t.states {
+		for next := int(st.baseline); next < int(st.baseline)+1<<st.nbBits; next++ {
+			t.encode[st.symbol][next] = uint16(i)
+		}
+	}
+	return t
+}
+
+// readFSETable parses the table description at the start of src (RFC
+// 8878, section 4.1.1) and returns the table and the number of bytes the
+// description used. The description is a forward bitstream of
+// variable-width counts: each count is written in just enough bits for
+// the probability still unassigned, and runs of zero counts are written
+// as 2-bit repeat flags.
+func readFSETable(src []byte, maxSymbol int, maxLog uint8) (*fseTable, int, error) {
+	var br forwardBitReader
+	br.init(src)
+	accuracyLog := uint8(br.read(4)) + 5
+	if accuracyLog > maxLog {
+		return nil, 0, errCorrupt("FSE accuracy log too large")
+	}
+
+	remaining := 1<<accuracyLog + 1
+	threshold := 1 << accuracyLog
+	nbBits := uint(accuracyLog) + 1
+	var counts []int16
+	previousZero := false
+	for remaining > 1 && len(counts) <= maxSymbol {
+		if previousZero {
+			// Each flag adds up to three more zero counts; 3 means
+			// another flag follows.
+			for {
+				flag := br.read(2)
+				for range flag {
+					counts = append(counts, 0)
+				}
+				if flag != 3 {
+					break
+				}
+			}
+			if len(counts) > maxSymbol {
+				break
+			}
+		}
+
+		limit := 2*threshold - 1 - remaining
+		var value int
+		if v := int(br.peek(nbBits)); v&(threshold-1) < limit {
+			value = v & (threshold - 1)
+			br.skip(nbBits - 1)
+		} else {
+			value = v & (2*threshold - 1)
+			if value >= threshold {
+				value -= limit
+			}
+			br.skip(nbBits)
+		}
+		count := value - 1 // -1 marks a probability below 1/size
+		remaining -= max(count, -count)
+		counts = append(counts, int16(count))
+		previousZero = count == 0
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || len(counts) > maxSymbol+1 || br.overread() {
+		return nil, 0, errCorrupt("invalid FSE table description")
+	}
+	return newFSETable(counts, accuracyLog), br.bytesUsed(), nil
+}
+
+// rleTable returns the single-state table of RLE_Mode, in which every
+// sequence uses symbol and no bits are read.
+func rleTable(symbol uint8) *fseTable {
+	return &fseTable{states: []fseState{{symbol: symbol}}}
+}
+
+// initState returns a starting state for the encoder that decodes to
+// symbol. Any such state works: the decoder reads it in full.
+func (t *fseTable) initState(symbol uint8) uint16 {
+	return t.encode[symbol][0]
+}
+
+// encodeSymbol writes the transition into state, the state chosen for
+// the following symbol, and returns the state that decodes to symbol.
+// The encoder runs backwards over the sequences so that the decoder,
+// reading the bitstream backwards, sees them in order.
+func (t *fseTable) encodeSymbol(w *bitWriter, state uint16, symbol uint8) uint16 {
+	prev := t.encode[symbol][state]
+	st := t.states[prev]
+	w.addBits(uint64(state-st.baseline), uint(st.nbBits))
+	return prev
+}
+
+// Predefined distributions from RFC 8878, section 3.1.1.3.2.2.
+var (
+	literalLengthTable = newFSEEncodeTable([]int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}, 6)
+	matchLengthTable = newFSEEncodeTable([]int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}, 6)
+	offsetTable = newFSEEncodeTable([]int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}, 5)
+)
+
+// Literal length and match length codes: the value of code c is
+// base[c] plus extra[c] bits read from the stream.
+var (
+	literalLengthBase = [36]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536,
+	}
+	literalLengthExtra = [36]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16,
+	}
+	matchLengthBase = [53]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539,
+	}
+	matchLengthExtra = [53]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16,
+	}
+)
+
+// lengthCode returns the code for value: the last code whose base does
+// not exceed it.
+func lengthCode(base []uint32, value uint32) uint8 {
+	lo, hi := 0, len(base)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if base[mid] <= value {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return uint8(lo)
+}
+
+// bitWriter appends bits least significant first, the order zstd's
+// backward bitstreams are written in.
+type bitWriter struct {
+	out   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) addBits(v uint64, n uint) {
+	w.acc |= (v & (1<<n - 1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// close writes the end-of-stream marker bit and pads to a byte.
+func (w *bitWriter) close() []byte {
+	w.addBits(1, 1)
+	if w.nbits > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
+
+// forwardBitReader reads a little-endian bitstream from the start of its
+// data, as FSE table descriptions are written. Reads past the end return
+// zero bits; overread reports whether that happened.
+type forwardBitReader struct {
+	data []byte
+	pos  uint // bits read so far
+}
+
+func (r *forwardBitReader) init(data []byte) {
+	r.data, r.pos = data, 0
+}
+
+// peek returns the next n bits, n <= 32, without consuming them.
+func (r *forwardBitReader) peek(n uint) uint64 {
+	var v uint64
+	for i := range (n + r.pos%8 + 7) / 8 {
+		if b := r.pos/8 + i; b < uint(len(r.data)) {
+			v |= uint64(r.data[b]) << (8 * i)
+		}
+	}
+	return v >> (r.pos % 8) & (1<<n - 1)
+}
+
+func (r *forwardBitReader) skip(n uint) { r.pos += n }
+
+func (r *forwardBitReader) read(n uint) uint64 {
+	v := r.peek(n)
+	r.skip(n)
+	return v
+}
+
+func (r *forwardBitReader) overread() bool { return r.pos > uint(len(r.data))*8 }
+
+// bytesUsed returns the number of bytes read, counting a partly read
+// byte as used.
+func (r *forwardBitReader) bytesUsed() int { return int((r.pos + 7) / 8) }
+
+// bitReader reads a backward bitstream: the last field written is the
+// first one read.
+type bitReader struct {
+	data []byte
+	pos  uint // bits left to read, counted from the start of data
+}
+
+// init positions r just below the end-of-stream marker of data.
+func (r *bitReader) init(data []byte) error {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return errCorrupt("bitstream without end marker")
+	}
+	r.data = data
+	r.pos = uint(len(data)-1)*8 + uint(bits.Len8(data[len(data)-1])) - 1
+	return nil
+}
+
+func (r *bitReader) read(n uint) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	if n > r.pos {
+		return 0, errCorrupt("bitstream overread")
+	}
+	r.pos -= n
+	first, last := r.pos/8, (r.pos+n-1)/8
+	var v uint64
+	for i := last; ; i-- {
+		v = v<<8 | uint64(r.data[i])
+		if i == first {
+			break
+		}
+	}
+	return v >> (r.pos % 8) & (1<<n - 1), nil
+}
+
+// peekPadded returns the next n bits without consuming them. Past the
+// start of the stream it reads zero bits, so a prefix code shorter than
+// n can be looked up at the very end of a stream.
+func (r *bitReader) peekPadded(n uint) uint64 {
+	if n <= r.pos {
+		saved := r.pos
+		v, _ := r.read(n)
+		r.pos = saved
+		return v
+	}
+	saved := r.pos
+	v, _ := r.read(r.pos)
+	r.pos = saved
+	return v << (n - saved)
+}
@@ -0,0 +1,212 @@
+package zstd
+
+import "math/bits"
+
+// Huffman-coded literals (RFC 8878, section 4.2).
+//
+// Why does only the Reader know Huffman? The reference encoder codes the
+// literals of almost every compressible block this way, so a Reader
+// without it could not read ordinary .zst files. The Writer stores
+// literals raw instead, which costs ratio but not compatibility.
+
+// maxHuffmanBits is the longest prefix code the format allows.
+const maxHuffmanBits = 11
+
+// huffmanEntry is one entry of a decoding table: the symbol whose code
+// is a prefix of the entry's index, and the code's length.
+type huffmanEntry struct {
+	symbol uint8
+	nbBits uint8
+}
+
+// huffmanTable decodes prefix codes of up to tableLog bits by looking up
+// the next tableLog bits of the stream.
+type huffmanTable struct {
+	tableLog uint8
+	entries  []huffmanEntry
+}
+
+// readHuffmanTable parses the Huffman tree description at the start of
+// src and returns the table and the number of bytes the description
+// used. The description lists a weight per symbol, either as 4-bit
+// values or compressed with FSE; the last symbol's weight is implied.
+func readHuffmanTable(src []byte) (*huffmanTable, int, error) {
+	if len(src) == 0 {
+		return nil, 0, errCorrupt("missing Huffman tree description")
+	}
+	header := int(src[0])
+	var weights []uint8
+	var used int
+	if header >= 128 {
+		// Direct representation: two weights per byte, high nibble first.
+		n := header - 127
+		used = 1 + (n+1)/2
+		if len(src) < used {
+			return nil, 0, errCorrupt("truncated Huffman weights")
+		}
+		for i := range n {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				b >>= 4
+			}
+			weights = append(weights, b&0x0f)
+		}
+	} else {
+		used = 1 + header
+		if len(src) < used {
+			return nil, 0, errCorrupt("truncated Huffman weights")
+		}
+		var err error
+		if weights, err = readFSEWeights(src[1:used]); err != nil {
+			return nil, 0, err
+		}
+	}
+	t, err := newHuffmanTable(weights)
+	return t, used, err
+}
+
+// readFSEWeights decodes FSE-compressed Huffman weights. Two states
+// take turns decoding from one backward bitstream; the stream ends when
+// updating a state would need more bits than are left, and the other
+// state's symbol is the last weight.
+func readFSEWeights(src []byte) ([]uint8, error) {
+	table, n, err := readFSETable(src, 255, 6)
+	if err != nil {
+		return nil, err
+	}
+	var br bitReader
+	if err := br.init(src[n:]); err != nil {
+		return nil, err
+	}
+	var state [2]uint64
+	for i := range state {
+		if state[i], err = br.read(uint(table.accuracyLog)); err != nil {
+			return nil, err
+		}
+	}
+
+	var weights []uint8
+	for i := 0; ; i ^= 1 {
+		st := table.states[state[i]]
+		weights = append(weights, st.symbol)
+		if uint(st.nbBits) > br.pos {
+			weights = append(weights, table.states[state[i^1]].symbol)
+			break
+		}
+		next, _ := br.read(uint(st.nbBits))
+		state[i] = uint64(st.baseline) + next
+		if len(weights) > 255 {
+			return nil, errCorrupt("too many Huffman weights")
+		}
+	}
+	return weights, nil
+}
+
+// newHuffmanTable builds the decoding table for the given weights, the
+// last symbol's weight being implied: the code lengths must fill the
+// code space exactly.
+func newHuffmanTable(weights []uint8) (*huffmanTable, error) {
+	if len(weights) > 255 {
+		return nil, errCorrupt("too many Huffman weights")
+	}
+	total := 0
+	for _, w := range weights {
+		if w > maxHuffmanBits {
+			return nil, errCorrupt("Huffman weight too large")
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, errCorrupt("Huffman weights are all zero")
+	}
+	tableLog := bits.Len(uint(total))
+	if tableLog > maxHuffmanBits {
+		return nil, errCorrupt("Huffman codes too long")
+	}
+	rest := 1<<tableLog - total
+	if rest&(rest-1) != 0 {
+		return nil, errCorrupt("Huffman weights do not fill the code space")
+	}
+	weights = append(weights, uint8(bits.Len(uint(rest))))
+
+	// Codes are assigned by increasing weight, then by symbol, so the
+	// entries of each weight start after those of all lower weights.
+	var start [maxHuffmanBits + 2]int
+	for _, w := range weights {
+		if w > 0 {
+			start[w] += 1 << (w - 1)
+		}
+	}
+	next := 0
+	for w := 1; w <= tableLog; w++ {
+		next, start[w] = next+start[w], next
+	}
+
+	t := &huffmanTable{tableLog: uint8(tableLog), entries: make([]huffmanEntry, 1<<tableLog)}
+	for sym, w := range weights {
+		if w == 0 {
+			continue
+		}
+		e := huffmanEntry{symbol: uint8(sym), nbBits: uint8(tableLog + 1 - int(w))}
+		n := 1 << (w - 1)
+		for i := range n {
+			t.entries[start[w]+i] = e
+		}
+		start[w] += n
+	}
+	return t, nil
+}
+
+// decode appends the n symbols of the backward bitstream src to dst. The
+// stream must end exactly after the last symbol.
+func (t *huffmanTable) decode(dst, src []byte, n int) ([]byte, error) {
+	var br bitReader
+	if err := br.init(src); err != nil {
+		return nil, err
+	}
+	for range n {
+		e := t.entries[br.peekPadded(uint(t.tableLog))]
+		if uint(e.nbBits) > br.pos {
+			return nil, errCorrupt("Huffman stream overread")
+		}
+		br.pos -= uint(e.nbBits)
+		dst = append(dst, e.symbol)
+	}
+	if br.pos != 0 {
+		return nil, errCorrupt("bits left over after the Huffman stream")
+	}
+	return dst, nil
+}
+
+// decodeStreams decodes literals split into four streams, preceded by a
+// jump table with the sizes of the first three.
+func (t *huffmanTable) decodeStreams(dst, src []byte, n int) ([]byte, error) {
+	if len(src) < 6 {
+		return nil, errCorrupt("truncated Huffman jump table")
+	}
+	sizes := [4]int{
+		int(src[0]) | int(src[1])<<8,
+		int(src[2]) | int(src[3])<<8,
+		int(src[4]) | int(src[5])<<8,
+	}
+	src = src[6:]
+	sizes[3] = len(src) - sizes[0] - sizes[1] - sizes[2]
+	segment := (n + 3) / 4
+	if sizes[3] < 0 || n < 3*segment {
+		return nil, errCorrupt("invalid Huffman stream sizes")
+	}
+	for i, size := range sizes {
+		count := segment
+		if i == 3 {
+			count = n - 3*segment
+		}
+		var err error
+		if dst, err = t.decode(dst, src[:size], count); err != nil {
+			return nil, err
+		}
+		src = src[size:]
+	}
+	return dst, nil
+}
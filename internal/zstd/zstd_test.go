@@ -0,0 +1,310 @@
+package zstd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXXH64(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"Nobody inspects the spammish repetition", 0xfbcea83c8a378bf1},
+	}
+	for _, tt := range tests {
+		// Feeding one byte at a time must match a single write.
+		whole, split := newXXH64(), newXXH64()
+		whole.write([]byte(tt.in))
+		for i := range len(tt.in) {
+			split.write([]byte{tt.in[i]})
+		}
+		if got := whole.sum64(); got != tt.want {
+			t.Errorf("xxh64(%q) = %#x, want %#x", tt.in, got, tt.want)
+		}
+		if got := split.sum64(); got != tt.want {
+			t.Errorf("xxh64(%q) byte by byte = %#x, want %#x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFSETablesPartitionStates(t *testing.T) {
+	// Every symbol's states must cover every possible next state exactly
+	// once, or encodeSymbol would pick a state that cannot reach it.
+	for name, table := range map[string]*fseTable{
+		"literal length": literalLengthTable,
+		"match length":   matchLengthTable,
+		"offset":         offsetTable,
+	} {
+		size := len(table.states)
+		covered := make(map[uint8]int)
+		for _, st := range table.states {
+			covered[st.symbol] += 1 << st.nbBits
+		}
+		for sym, n := range covered {
+			if n != size {
+				t.Errorf("%s symbol %d: states cover %d next states, want %d", name, sym, n, size)
+			}
+		}
+	}
+}
+
+// decodeHex decodes a whole stream given as hex.
+func decodeHex(t *testing.T, s string) ([]byte, error) {
+	t.Helper()
+	src, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return io.ReadAll(NewReader(bytes.NewReader(src)))
+}
+
+func TestDecodeReferenceFrames(t *testing.T) {
+	// Frames written by the zstd command-line tool: single-segment, with
+	// a one-byte content size and a content checksum.
+	tests := []struct{ name, hex, want string }{
+		{"empty", "28b52ffd" + "24" + "00" + "010000" + "99e9d851", ""},
+		{"one byte", "28b52ffd" + "24" + "01" + "090000" + "61" + "5b6e8ca9", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeHex(t, tt.hex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decoded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeReferenceFiles(t *testing.T) {
+	// testdata holds two inputs compressed by the zstd 1.5.6 command-line
+	// tool. Level 1 codes literals with Huffman tables, level 19 also
+	// describes its own FSE tables. The blocks file is cut into 1 KiB
+	// blocks, which reuse the previous block's tables, and the stdin file
+	// was written from a pipe, without content size or checksum:
+	//
+	//	zstd -1 corpus.txt -o corpus.txt.1.zst
+	//	zstd -3 --target-compressed-block-size=1024 corpus.txt -o corpus.txt.blocks.zst
+	//	zstd -3 --no-check < corpus.txt > corpus.txt.stdin.zst
+	files, err := filepath.Glob("testdata/*.zst")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no reference files: %v", err)
+	}
+	for _, name := range files {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			src, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// corpus.txt.19.zst decodes to corpus.txt.
+			orig := strings.TrimSuffix(name, ".zst")
+			want, err := os.ReadFile(orig[:strings.LastIndexByte(orig, '.')])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(NewReader(bytes.NewReader(src)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("decoded %d bytes that differ from the %d original bytes", len(got), len(want))
+			}
+		})
+	}
+}
+
+// frame wraps block contents (each with its 3-byte header already
+// prepended) in a frame without checksum or content size.
+func frame(blocks ...[]byte) []byte {
+	out := binary.LittleEndian.AppendUint32(nil, frameMagic)
+	out = append(out, 0x00, (writerWindowLog-10)<<3)
+	for _, b := range blocks {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// block returns a block header and content.
+func block(typ int, last bool, size int, content []byte) []byte {
+	h := uint32(size)<<3 | uint32(typ)<<1
+	if last {
+		h |= 1
+	}
+	return append([]byte{byte(h), byte(h >> 8), byte(h >> 16)}, content...)
+}
+
+func compressedBlock(last bool, lits string, seqs []sequence) []byte {
+	content := appendSequences(appendLiterals(nil, []byte(lits)), seqs)
+	return block(blockCompressed, last, len(content), content)
+}
+
+func TestDecodeHandBuiltBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream []byte
+		want   string
+	}{
+		{
+			// Offset values 1 to 3 pick one of three recent offsets, with
+			// the choice shifted by one when the literal length is zero.
+			name: "repeat offsets",
+			stream: frame(compressedBlock(true, "abcdeZ!", []sequence{
+				{litLen: 5, matchLen: 5, offsetValue: 5 + 3}, // offset 5; recent 5,1,4
+				{litLen: 0, matchLen: 4, offsetValue: 3},     // recent[0]-1 = 4; recent 4,5,1
+				{litLen: 1, matchLen: 4, offsetValue: 2},     // recent[1] = 5; recent 5,4,1
+				{litLen: 0, matchLen: 4, offsetValue: 2},     // recent[2] = 1; recent 1,5,4
+			})),
+			want: "abcdeabcde" + "bcde" + "Z" + "bcde" + "eeee" + "!",
+		},
+		{
+			// Repeat offsets survive into the next block.
+			name: "repeat offset across blocks",
+			stream: frame(
+				compressedBlock(false, "wxyz", []sequence{{litLen: 4, matchLen: 4, offsetValue: 4 + 3}}),
+				compressedBlock(true, "-", []sequence{{litLen: 1, matchLen: 4, offsetValue: 1}}),
+			),
+			want: "wxyzwxyz" + "-" + "xyz-",
+		},
+		{
+			name:   "RLE literals",
+			stream: frame(block(blockCompressed, true, 3, []byte{10<<3 | 0x01, 'q', 0})),
+			want:   "qqqqqqqqqq",
+		},
+		{
+			name:   "RLE and raw blocks",
+			stream: frame(block(blockRLE, false, 3, []byte{'r'}), block(blockRaw, true, 2, []byte("aw"))),
+			want:   "rrraw",
+		},
+		{
+			name: "skippable and concatenated frames",
+			stream: bytes.Join([][]byte{
+				frame(block(blockRaw, true, 3, []byte("one"))),
+				{0x50, 0x2a, 0x4d, 0x18, 2, 0, 0, 0, 0xde, 0xad},
+				frame(block(blockRaw, true, 3, []byte("two"))),
+			}, nil),
+			want: "onetwo",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewReader(bytes.NewReader(tt.stream)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decoded %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	valid := func() []byte {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.Write([]byte(strings.Repeat("hello zstd ", 50)))
+		w.Close()
+		return buf.Bytes()
+	}
+	flip := func(i int) []byte {
+		b := valid()
+		if i < 0 {
+			i += len(b)
+		}
+		b[i] ^= 0x01
+		return b
+	}
+
+	tests := []struct {
+		name        string
+		stream      []byte
+		unsupported bool
+	}{
+		{"bad magic", flip(0), false},
+		{"bad checksum", flip(-1), false},
+		{"truncated", valid()[:20], false},
+		{"reserved block type", frame(block(3, true, 0, nil)), false},
+		{"offset before start", frame(compressedBlock(true, "ab", []sequence{{litLen: 2, matchLen: 4, offsetValue: 9 + 3}})), false},
+		{"literals overrun", frame(compressedBlock(true, "ab", []sequence{{litLen: 3, matchLen: 4, offsetValue: 1 + 3}})), false},
+		{"huffman literals without table", frame(block(blockCompressed, true, 3, []byte{0x02, 0, 0})), false},
+		{"treeless literals without table", frame(block(blockCompressed, true, 4, []byte{0x03, 0x10, 0, 0x01})), false},
+		{"invalid fse table", frame(block(blockCompressed, true, 5, []byte{0, 1, 0x80, 0xff, 0xff})), false},
+		{"dictionary", append(binary.LittleEndian.AppendUint32(nil, frameMagic), 0x21, 7, 0x01, 0, 0), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := io.ReadAll(NewReader(bytes.NewReader(tt.stream)))
+			switch {
+			case tt.unsupported && !errors.Is(err, errors.ErrUnsupported):
+				t.Errorf("error = %v, want errors.ErrUnsupported", err)
+			case !tt.unsupported && !errors.Is(err, ErrCorrupt):
+				t.Errorf("error = %v, want ErrCorrupt", err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	random := make([]byte, 300_000)
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	text := []byte(strings.Repeat("It was the best of times, it was the worst of times. ", 6000))
+	// Long literal runs and long matches exercise the largest length codes.
+	long := append(append(random[:70_000:70_000], bytes.Repeat([]byte("0123456789"), 9000)...), random[:100]...)
+
+	inputs := map[string][]byte{
+		"empty":         {},
+		"one byte":      {'x'},
+		"tiny":          []byte("abcdabcd"),
+		"run":           bytes.Repeat([]byte{0}, 300_000),
+		"text":          text,
+		"random":        random,
+		"long codes":    long,
+		"block limit":   text[:maxBlockSize],
+		"block limit+1": text[:maxBlockSize+1],
+	}
+	for name, in := range inputs {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+			for rest := in; len(rest) > 0; {
+				n := min(len(rest), 40_000)
+				if _, err := w.Write(rest[:n]); err != nil {
+					t.Fatal(err)
+				}
+				rest = rest[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			compressed := buf.Len()
+
+			got, err := io.ReadAll(NewReader(&buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, in) {
+				t.Fatalf("round trip mismatch: %d bytes in, %d out", len(in), len(got))
+			}
+			if name == "text" && compressed > len(in)/10 {
+				t.Errorf("repetitive text compressed to %d of %d bytes", compressed, len(in))
+			}
+		})
+	}
+}
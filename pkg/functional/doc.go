@@ -2,10 +2,13 @@
 //
 // This package covers functional programming concepts adapted to Go:
 //   - Higher-order functions (map, filter, reduce)
-//   - Function composition and currying
-//   - Immutable data structures with copy-on-write
+//   - Function composition, currying and memoization
+//   - Time-based debounce, throttle and coalesce
+//   - Immutable data structures, including persistent vectors and maps
+//   - Lenses and other optics for nested updates (package optics)
+//   - Option and Result with Map, FlatMap, Zip and Collect (package monad)
 //   - Lazy evaluation through iterators (Go 1.24+)
-//   - Pipeline-based data processing
+//   - Pipeline-based data processing, including parallel stages
 //
 // Go supports functional programming through:
 //   - First-class functions
@@ -1,7 +1,10 @@
 // Package functional demonstrates functional programming patterns in Go.
 package functional
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Higher-order functions demonstrate functions as first-class citizens.
 //
@@ -105,7 +108,9 @@ func Pipe[T any](initial T, functions ...func(T) T) T {
 	return result
 }
 
-// Memoize caches function results.
+// Memoize caches function results. It is not safe for concurrent use
+// and never forgets a result; see MemoizeWith for a bounded,
+// concurrency-safe version.
 func Memoize[K comparable, V any](fn func(K) V) func(K) V {
 	cache := make(map[K]V)
 	return func(k K) V {
@@ -133,18 +138,6 @@ func Once[T any](fn func() T) func() T {
 	}
 }
 
-// Debounce returns a debounced version of the function.
-func Debounce[T any](fn func(T), threshold int) func(T) {
-	count := 0
-	return func(t T) {
-		count++
-		if count >= threshold {
-			fn(t)
-			count = 0
-		}
-	}
-}
-
 // ExampleHigherOrder demonstrates higher-order functions.
 func ExampleHigherOrder() {
 	fmt.Println("=== Higher-Order Functions ===")
@@ -207,4 +200,23 @@ func ExampleHigherOrder() {
 	memoFib := Memoize(fibonacci)
 	fmt.Printf("Memoized fib(10) = %d\n", memoFib(10))
 	fmt.Printf("Memoized fib(10) = %d (cached)\n", memoFib(10))
+
+	// Bounded memoization that does not cache failures
+	lookups := 0
+	lookup := func(id int) (string, error) {
+		lookups++
+		if id < 0 {
+			return "", fmt.Errorf("invalid id %d", id)
+		}
+		return fmt.Sprintf("user-%d", id), nil
+	}
+	users := MemoizeWith(lookup, WithMaxSize(2), WithTTL(time.Minute))
+	for _, id := range []int{1, 1, -1, -1, 2, 3, 1} {
+		if name, err := users.Get(id); err != nil {
+			fmt.Printf("Lookup %d: error %v\n", id, err)
+		} else {
+			fmt.Printf("Lookup %d: %s\n", id, name)
+		}
+	}
+	fmt.Printf("Underlying lookups: %d (errors retried, 1 evicted by size)\n", lookups)
 }
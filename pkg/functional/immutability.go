@@ -1,13 +1,26 @@
 package functional
 
-import "fmt"
-
-// Immutability demonstrates immutable data structures through copy-on-write.
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// Immutability demonstrates immutable data structures: small values
+// updated by copying, and collections that share structure between
+// versions (see PersistentVector and PersistentMap).
 //
 // Why? Immutable data structures prevent unintended side effects and make
 // concurrent code safer, though they trade memory for safety.
 
-// Point represents an immutable 2D point.
+//go:generate go run ../../cmd/immutgen
+
+// Point represents an immutable 2D point. Its getters, With* methods,
+// Equal, String and JSON methods are generated by cmd/immutgen.
+//
+//immutable:record
 type Point struct {
 	x, y float64
 }
@@ -17,100 +30,89 @@ func NewPoint(x, y float64) Point {
 	return Point{x: x, y: y}
 }
 
-// X returns the x coordinate.
-func (p Point) X() float64 {
-	return p.x
-}
-
-// Y returns the y coordinate.
-func (p Point) Y() float64 {
-	return p.y
-}
-
-// WithX returns a new point with updated x.
-func (p Point) WithX(x float64) Point {
-	return Point{x: x, y: p.y}
-}
-
-// WithY returns a new point with updated y.
-func (p Point) WithY(y float64) Point {
-	return Point{x: p.x, y: y}
-}
-
 // Move returns a new point moved by dx, dy.
 func (p Point) Move(dx, dy float64) Point {
 	return Point{x: p.x + dx, y: p.y + dy}
 }
 
-// ImmutableList represents an immutable list.
+// ImmutableList represents an immutable list. It is backed by a
+// PersistentVector, so Add and Get are O(log32 n) and versions share
+// storage; the zero value is an empty list.
 type ImmutableList[T any] struct {
-	items []T
+	items PersistentVector[T]
 }
 
 // NewImmutableList creates a new immutable list.
 func NewImmutableList[T any](items ...T) ImmutableList[T] {
-	copied := make([]T, len(items))
-	copy(copied, items)
-	return ImmutableList[T]{items: copied}
+	return ImmutableList[T]{items: VectorOf(items...)}
 }
 
 // Get returns the item at index.
 func (l ImmutableList[T]) Get(index int) T {
-	return l.items[index]
+	return l.items.Get(index)
 }
 
 // Size returns the list size.
 func (l ImmutableList[T]) Size() int {
-	return len(l.items)
+	return l.items.Len()
 }
 
 // Add returns a new list with the item added.
 func (l ImmutableList[T]) Add(item T) ImmutableList[T] {
-	newItems := make([]T, len(l.items)+1)
-	copy(newItems, l.items)
-	newItems[len(l.items)] = item
-	return ImmutableList[T]{items: newItems}
+	return ImmutableList[T]{items: l.items.Append(item)}
+}
+
+// Set returns a new list with the item at index replaced.
+func (l ImmutableList[T]) Set(index int, item T) ImmutableList[T] {
+	return ImmutableList[T]{items: l.items.Set(index, item)}
 }
 
 // Remove returns a new list with the item at index removed.
 func (l ImmutableList[T]) Remove(index int) ImmutableList[T] {
-	if index < 0 || index >= len(l.items) {
+	if index < 0 || index >= l.items.Len() {
 		return l
 	}
-	newItems := make([]T, 0, len(l.items)-1)
-	newItems = append(newItems, l.items[:index]...)
-	newItems = append(newItems, l.items[index+1:]...)
-	return ImmutableList[T]{items: newItems}
+	return ImmutableList[T]{items: l.items.Delete(index)}
 }
 
 // Map returns a new list with mapper applied to each element.
 func (l ImmutableList[T]) Map(mapper func(T) T) ImmutableList[T] {
-	newItems := make([]T, len(l.items))
-	for i, item := range l.items {
-		newItems[i] = mapper(item)
+	var out PersistentVector[T]
+	t := out.Transient()
+	for _, item := range l.items.All() {
+		t.Append(mapper(item))
 	}
-	return ImmutableList[T]{items: newItems}
+	return ImmutableList[T]{items: t.Persistent()}
 }
 
 // Filter returns a new list with only elements satisfying the predicate.
 func (l ImmutableList[T]) Filter(predicate func(T) bool) ImmutableList[T] {
-	newItems := make([]T, 0)
-	for _, item := range l.items {
+	var out PersistentVector[T]
+	t := out.Transient()
+	for _, item := range l.items.All() {
 		if predicate(item) {
-			newItems = append(newItems, item)
+			t.Append(item)
 		}
 	}
-	return ImmutableList[T]{items: newItems}
+	return ImmutableList[T]{items: t.Persistent()}
+}
+
+// All returns an iterator over indexes and items.
+func (l ImmutableList[T]) All() iter.Seq2[int, T] {
+	return l.items.All()
 }
 
-// ToSlice returns a copy of the internal slice.
+// ToSlice returns the items as a new slice.
 func (l ImmutableList[T]) ToSlice() []T {
-	result := make([]T, len(l.items))
-	copy(result, l.items)
-	return result
+	return l.items.ToSlice()
 }
 
-// User represents an immutable user record.
+// User represents an immutable user record. Its getters, With* methods,
+// Equal, String and JSON methods are generated by cmd/immutgen. User has
+// only comparable fields, so users can also be compared with == and used
+// as map keys.
+//
+//immutable:record
 type User struct {
 	id       int
 	username string
@@ -118,103 +120,73 @@ type User struct {
 	age      int
 }
 
-// NewUser creates a new user.
-func NewUser(id int, username, email string, age int) User {
-	return User{
-		id:       id,
-		username: username,
-		email:    email,
-		age:      age,
-	}
-}
-
-// ID returns the user ID.
-func (u User) ID() int {
-	return u.id
-}
-
-// Username returns the username.
-func (u User) Username() string {
-	return u.username
-}
-
-// Email returns the email.
-func (u User) Email() string {
-	return u.email
+// Team is an immutable record with a slice field. Its generated methods
+// copy members on the way in and out, so a caller cannot change a Team
+// through a slice it passed or received. A slice field makes Team
+// non-comparable: use Equal instead of ==.
+//
+//immutable:record
+type Team struct {
+	name    string
+	members []string
 }
 
-// Age returns the age.
-func (u User) Age() int {
-	return u.age
+// NewTeam creates a team with a copy of members.
+func NewTeam(name string, members []string) Team {
+	return Team{name: name, members: slices.Clone(members)}
 }
 
-// WithEmail returns a new user with updated email.
-func (u User) WithEmail(email string) User {
+// NewUser creates a new user.
+func NewUser(id int, username, email string, age int) User {
 	return User{
-		id:       u.id,
-		username: u.username,
+		id:       id,
+		username: username,
 		email:    email,
-		age:      u.age,
-	}
-}
-
-// WithAge returns a new user with updated age.
-func (u User) WithAge(age int) User {
-	return User{
-		id:       u.id,
-		username: u.username,
-		email:    u.email,
 		age:      age,
 	}
 }
 
-// Config represents immutable configuration.
+// Config represents immutable configuration. It is backed by a
+// PersistentMap, so WithSetting and WithoutSetting do not copy the
+// other settings.
 type Config struct {
-	settings map[string]string
+	settings PersistentMap[string, string]
 }
 
 // NewConfig creates a new config.
 func NewConfig(settings map[string]string) Config {
-	copied := make(map[string]string, len(settings))
-	for k, v := range settings {
-		copied[k] = v
-	}
-	return Config{settings: copied}
+	return Config{settings: MapFrom(settings)}
 }
 
 // Get retrieves a setting.
 func (c Config) Get(key string) string {
-	return c.settings[key]
+	v, _ := c.settings.Get(key)
+	return v
 }
 
 // WithSetting returns a new config with the setting added/updated.
 func (c Config) WithSetting(key, value string) Config {
-	newSettings := make(map[string]string, len(c.settings)+1)
-	for k, v := range c.settings {
-		newSettings[k] = v
-	}
-	newSettings[key] = value
-	return Config{settings: newSettings}
+	return Config{settings: c.settings.Set(key, value)}
 }
 
 // WithoutSetting returns a new config with the setting removed.
 func (c Config) WithoutSetting(key string) Config {
-	newSettings := make(map[string]string, len(c.settings))
-	for k, v := range c.settings {
-		if k != key {
-			newSettings[k] = v
-		}
+	return Config{settings: c.settings.Delete(key)}
+}
+
+// WithSettings returns a new config with all of updates applied in one
+// batch.
+func (c Config) WithSettings(updates map[string]string) Config {
+	t := c.settings.Transient()
+	for k, v := range updates {
+		t.Set(k, v)
 	}
-	return Config{settings: newSettings}
+	return Config{settings: t.Persistent()}
 }
 
 // GetAll returns a copy of all settings.
 func (c Config) GetAll() map[string]string {
-	result := make(map[string]string, len(c.settings))
-	for k, v := range c.settings {
-		result[k] = v
-	}
-	return result
+	return maps.Collect(c.settings.All())
 }
 
 // ExampleImmutability demonstrates immutable data structures.
@@ -255,9 +227,23 @@ func ExampleImmutability() {
 		user1.Username(), user1.Email(), user1.Age())
 	fmt.Printf("user2: %s (%s), age %d\n",
 		user2.Username(), user2.Email(), user2.Age())
-	fmt.Printf("user3: %s (%s), age %d\n\n",
+	fmt.Printf("user3: %s (%s), age %d\n",
 		user3.Username(), user3.Email(), user3.Age())
 
+	// Generated methods: slices are copied, Equal compares fields, and
+	// records round-trip through JSON despite their unexported fields
+	members := []string{"alice", "bob"}
+	team := NewTeam("core", members)
+	members[0] = "mallory"
+	fmt.Printf("team: %v\n", team)
+
+	data, _ := json.Marshal(team)
+	var decoded Team
+	_ = json.Unmarshal(data, &decoded)
+	grown := team.WithMembers(append(team.Members(), "carol"))
+	fmt.Printf("JSON: %s\n", data)
+	fmt.Printf("decoded.Equal(team): %v, grown.Equal(team): %v\n\n", decoded.Equal(team), grown.Equal(team))
+
 	// Immutable config
 	config1 := NewConfig(map[string]string{
 		"host": "localhost",
@@ -269,4 +255,16 @@ func ExampleImmutability() {
 	fmt.Printf("config1: %v\n", config1.GetAll())
 	fmt.Printf("config2: %v\n", config2.GetAll())
 	fmt.Printf("config3: %v\n", config3.GetAll())
+	fmt.Printf("config4: %v\n\n", config3.WithSettings(map[string]string{"host": "example.com", "tls": "on"}).GetAll())
+
+	// Persistent collections share structure, so large versions are cheap
+	big := VectorOf(Map(make([]int, 100_000), func(int) int { return 1 })...)
+	changed := big.Set(50_000, 42).Append(7)
+	fmt.Printf("big: len=%d [50000]=%d; changed: len=%d [50000]=%d\n",
+		big.Len(), big.Get(50_000), changed.Len(), changed.Get(50_000))
+
+	users := PersistentMap[int, string]{}.Set(1, "alice").Set(2, "bob")
+	fewer := users.Delete(1)
+	name, ok := fewer.Get(1)
+	fmt.Printf("users: %d entries; fewer: %d entries, Get(1) = %q, %v\n", users.Len(), fewer.Len(), name, ok)
 }
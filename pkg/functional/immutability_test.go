@@ -0,0 +1,80 @@
+package functional
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestTeamMembersAreCopied(t *testing.T) {
+	members := []string{"alice", "bob"}
+	team := NewTeam("core", members)
+
+	members[0] = "changed"
+	got := team.Members()
+	got[1] = "changed"
+
+	if want := []string{"alice", "bob"}; !slices.Equal(team.Members(), want) {
+		t.Errorf("Members() = %v, want %v", team.Members(), want)
+	}
+}
+
+func TestUserIsComparable(t *testing.T) {
+	a := NewUser(1, "alice", "alice@example.com", 30)
+	seen := map[User]bool{a: true}
+	if !seen[NewUser(1, "alice", "alice@example.com", 30)] || a == a.WithAge(31) {
+		t.Error("User should compare by value with ==")
+	}
+}
+
+func TestRecordEqualAndJSON(t *testing.T) {
+	base := NewTeam("core", []string{"alice"})
+
+	tests := []struct {
+		name  string
+		other Team
+		equal bool
+	}{
+		{"same fields", NewTeam("core", []string{"alice"}), true},
+		{"different scalar", base.WithName("infra"), false},
+		{"different members", base.WithMembers([]string{"bob"}), false},
+		{"no members", base.WithMembers(nil), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.Equal(tt.other); got != tt.equal {
+				t.Errorf("Equal = %v, want %v", got, tt.equal)
+			}
+
+			data, err := json.Marshal(tt.other)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded Team
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !decoded.Equal(tt.other) {
+				t.Errorf("JSON round trip: got %v, want %v", decoded, tt.other)
+			}
+		})
+	}
+}
+
+func TestPointGeneratedMethods(t *testing.T) {
+	p := NewPoint(1, 2).WithX(5).Move(1, 1)
+	if !p.Equal(NewPoint(6, 3)) {
+		t.Errorf("got %v, want Point{x: 6, y: 3}", p)
+	}
+	if got, want := p.String(), "Point{x: 6, y: 3}"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	var decoded Point
+	if err := json.Unmarshal([]byte(`{"x":1.5,"y":-2}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.X() != 1.5 || decoded.Y() != -2 {
+		t.Errorf("decoded %v", decoded)
+	}
+}
@@ -0,0 +1,197 @@
+// Code generated by immutgen; DO NOT EDIT.
+
+package functional
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// X returns the x field.
+func (p Point) X() float64 {
+	return p.x
+}
+
+// WithX returns a copy of p with x replaced.
+func (p Point) WithX(x float64) Point {
+	p.x = x
+	return p
+}
+
+// Y returns the y field.
+func (p Point) Y() float64 {
+	return p.y
+}
+
+// WithY returns a copy of p with y replaced.
+func (p Point) WithY(y float64) Point {
+	p.y = y
+	return p
+}
+
+// Equal reports whether p and other hold equal fields.
+func (p Point) Equal(other Point) bool {
+	return p.x == other.x &&
+		p.y == other.y
+}
+
+// String formats p for debugging.
+func (p Point) String() string {
+	return fmt.Sprintf("Point{x: %v, y: %v}", p.x, p.y)
+}
+
+// pointJSON is the JSON form of Point.
+type pointJSON struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// MarshalJSON encodes p as an object keyed by field name.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pointJSON{X: p.x, Y: p.y})
+}
+
+// UnmarshalJSON decodes an object written by MarshalJSON.
+func (p *Point) UnmarshalJSON(data []byte) error {
+	var v pointJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = Point{x: v.X, y: v.Y}
+	return nil
+}
+
+// ID returns the id field.
+func (u User) ID() int {
+	return u.id
+}
+
+// WithID returns a copy of u with id replaced.
+func (u User) WithID(id int) User {
+	u.id = id
+	return u
+}
+
+// Username returns the username field.
+func (u User) Username() string {
+	return u.username
+}
+
+// WithUsername returns a copy of u with username replaced.
+func (u User) WithUsername(username string) User {
+	u.username = username
+	return u
+}
+
+// Email returns the email field.
+func (u User) Email() string {
+	return u.email
+}
+
+// WithEmail returns a copy of u with email replaced.
+func (u User) WithEmail(email string) User {
+	u.email = email
+	return u
+}
+
+// Age returns the age field.
+func (u User) Age() int {
+	return u.age
+}
+
+// WithAge returns a copy of u with age replaced.
+func (u User) WithAge(age int) User {
+	u.age = age
+	return u
+}
+
+// Equal reports whether u and other hold equal fields.
+func (u User) Equal(other User) bool {
+	return u.id == other.id &&
+		u.username == other.username &&
+		u.email == other.email &&
+		u.age == other.age
+}
+
+// String formats u for debugging.
+func (u User) String() string {
+	return fmt.Sprintf("User{id: %v, username: %q, email: %q, age: %v}", u.id, u.username, u.email, u.age)
+}
+
+// userJSON is the JSON form of User.
+type userJSON struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Age      int    `json:"age"`
+}
+
+// MarshalJSON encodes u as an object keyed by field name.
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(userJSON{ID: u.id, Username: u.username, Email: u.email, Age: u.age})
+}
+
+// UnmarshalJSON decodes an object written by MarshalJSON.
+func (u *User) UnmarshalJSON(data []byte) error {
+	var v userJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = User{id: v.ID, username: v.Username, email: v.Email, age: v.Age}
+	return nil
+}
+
+// Name returns the name field.
+func (t Team) Name() string {
+	return t.name
+}
+
+// WithName returns a copy of t with name replaced.
+func (t Team) WithName(name string) Team {
+	t.name = name
+	return t
+}
+
+// Members returns a copy of the members field.
+func (t Team) Members() []string {
+	return slices.Clone(t.members)
+}
+
+// WithMembers returns a copy of t with members replaced.
+func (t Team) WithMembers(members []string) Team {
+	t.members = slices.Clone(members)
+	return t
+}
+
+// Equal reports whether t and other hold equal fields.
+func (t Team) Equal(other Team) bool {
+	return t.name == other.name &&
+		slices.Equal(t.members, other.members)
+}
+
+// String formats t for debugging.
+func (t Team) String() string {
+	return fmt.Sprintf("Team{name: %q, members: %v}", t.name, t.members)
+}
+
+// teamJSON is the JSON form of Team.
+type teamJSON struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// MarshalJSON encodes t as an object keyed by field name.
+func (t Team) MarshalJSON() ([]byte, error) {
+	return json.Marshal(teamJSON{Name: t.name, Members: t.members})
+}
+
+// UnmarshalJSON decodes an object written by MarshalJSON.
+func (t *Team) UnmarshalJSON(data []byte) error {
+	var v teamJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*t = Team{name: v.Name, members: v.Members}
+	return nil
+}
@@ -0,0 +1,102 @@
+package functional
+
+import (
+	"context"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/go124"
+)
+
+// MemoizeWith is Memoize for real workloads.
+//
+// Why not just Memoize? Memoize keeps every result forever in a map that
+// is unsafe for concurrent use, and it would happily cache a failure.
+// MemoizeWith stores results in a go124.Cache, which bounds its size,
+// expires old results, and deduplicates concurrent calls for the same
+// key so an expensive lookup runs once however many handlers ask for it
+// at the same moment. Errors are returned to every caller waiting on
+// that call but are never cached, so the next call retries.
+
+// MemoOption configures MemoizeWith.
+type MemoOption func(*memoConfig)
+
+type memoConfig struct {
+	maxSize int
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// WithMaxSize bounds the number of cached results, evicting the least
+// recently used. The default is unbounded.
+func WithMaxSize(n int) MemoOption {
+	return func(c *memoConfig) { c.maxSize = n }
+}
+
+// WithTTL makes cached results expire after ttl.
+func WithTTL(ttl time.Duration) MemoOption {
+	return func(c *memoConfig) { c.ttl = ttl }
+}
+
+// WithNow replaces time.Now for expiry, so tests can control time.
+func WithNow(now func() time.Time) MemoOption {
+	return func(c *memoConfig) { c.now = now }
+}
+
+// Memoized is a memoized function with handles for managing its cache.
+type Memoized[K comparable, V any] struct {
+	fn    func(K) (V, error)
+	cache *go124.Cache[K, V]
+}
+
+// MemoizeWith returns a concurrency-safe memoized version of fn.
+func MemoizeWith[K comparable, V any](fn func(K) (V, error), opts ...MemoOption) *Memoized[K, V] {
+	var cfg memoConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	// A size bound promises the least recently used result goes first,
+	// which only holds across the whole cache with a single shard.
+	shards := 0
+	if cfg.maxSize > 0 {
+		shards = 1
+	}
+	return &Memoized[K, V]{
+		fn: fn,
+		cache: go124.NewCacheWith(go124.CacheOptions[K, V]{
+			MaxEntries: cfg.maxSize,
+			Shards:     shards,
+			Policy:     go124.LRU,
+			TTL:        cfg.ttl,
+			Now:        cfg.now,
+		}),
+	}
+}
+
+// Get returns fn(key), from the cache when possible.
+func (m *Memoized[K, V]) Get(key K) (V, error) {
+	return m.cache.GetOrLoad(context.Background(), key, func(_ context.Context, k K) (V, error) {
+		return m.fn(k)
+	})
+}
+
+// Func returns Get as a plain function, for callers that expect one.
+func (m *Memoized[K, V]) Func() func(K) (V, error) {
+	return m.Get
+}
+
+// Invalidate forgets the cached result for key. A call for key already
+// in flight still answers its callers but does not cache its result,
+// since it may have read the data Invalidate was called for.
+func (m *Memoized[K, V]) Invalidate(key K) {
+	m.cache.Delete(key)
+}
+
+// Purge forgets every cached result, including those of calls in flight.
+func (m *Memoized[K, V]) Purge() {
+	m.cache.Clear()
+}
+
+// Stats reports cache hits, misses and calls to fn.
+func (m *Memoized[K, V]) Stats() go124.CacheStats {
+	return m.cache.Stats()
+}
@@ -0,0 +1,169 @@
+package functional
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSquare returns a squaring function that counts its calls and
+// fails for negative inputs.
+func countingSquare(calls *atomic.Int64) func(int) (int, error) {
+	return func(n int) (int, error) {
+		calls.Add(1)
+		if n < 0 {
+			return 0, errors.New("negative")
+		}
+		return n * n, nil
+	}
+}
+
+func TestMemoizeWithCalls(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []MemoOption
+		keys      []int
+		wantCalls int64
+	}{
+		{"repeats are cached", nil, []int{1, 2, 1, 2, 1}, 2},
+		{"errors are retried", nil, []int{-1, -1, 3, -1}, 4},
+		{"size bound evicts least recently used", []MemoOption{WithMaxSize(2)}, []int{1, 2, 1, 3, 1, 2}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			m := MemoizeWith(countingSquare(&calls), tt.opts...)
+			for _, k := range tt.keys {
+				got, err := m.Get(k)
+				if k < 0 {
+					if err == nil {
+						t.Errorf("Get(%d) returned no error", k)
+					}
+					continue
+				}
+				if err != nil || got != k*k {
+					t.Errorf("Get(%d) = %d, %v", k, got, err)
+				}
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMemoizeWithTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	var calls atomic.Int64
+	m := MemoizeWith(countingSquare(&calls), WithTTL(time.Minute), WithNow(func() time.Time { return now }))
+
+	m.Get(2)
+	now = now.Add(30 * time.Second)
+	m.Get(2)
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("fn called %d times before expiry, want 1", got)
+	}
+
+	now = now.Add(time.Minute)
+	m.Get(2)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("fn called %d times after expiry, want 2", got)
+	}
+}
+
+func TestMemoizeWithInvalidate(t *testing.T) {
+	var calls atomic.Int64
+	m := MemoizeWith(countingSquare(&calls))
+	get := m.Func()
+
+	get(1)
+	get(2)
+	m.Invalidate(1)
+	get(1)
+	get(2)
+	if got := calls.Load(); got != 3 {
+		t.Errorf("after Invalidate: fn called %d times, want 3", got)
+	}
+
+	m.Purge()
+	get(1)
+	get(2)
+	if got := calls.Load(); got != 5 {
+		t.Errorf("after Purge: fn called %d times, want 5", got)
+	}
+	if s := m.Stats(); s.Hits != 1 {
+		t.Errorf("Stats().Hits = %d, want 1", s.Hits)
+	}
+}
+
+func TestMemoizeInvalidateDuringCall(t *testing.T) {
+	// The source changes while a call is in flight. Invalidate must not
+	// be undone by that call caching the value it read before the change.
+	var version, calls atomic.Int64
+	started, release := make(chan struct{}), make(chan struct{})
+	m := MemoizeWith(func(int) (int64, error) {
+		v := version.Load()
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return v, nil
+	})
+
+	done := make(chan int64)
+	go func() {
+		v, _ := m.Get(1)
+		done <- v
+	}()
+	<-started
+	version.Store(1)
+	m.Invalidate(1)
+	close(release)
+
+	if v := <-done; v != 0 {
+		t.Errorf("in-flight call returned version %d, want the 0 it read", v)
+	}
+	if v, _ := m.Get(1); v != 1 || calls.Load() != 2 {
+		t.Errorf("Get after Invalidate = version %d after %d calls, want version 1 after 2", v, calls.Load())
+	}
+}
+
+func TestMemoizeWithSingleFlight(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	m := MemoizeWith(func(n int) (int, error) {
+		calls.Add(1)
+		<-release
+		return n * 10, nil
+	})
+
+	const callers = 8
+	var started, wg sync.WaitGroup
+	started.Add(callers)
+	results := make([]int, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			results[i], _ = m.Get(7)
+		}()
+	}
+	started.Wait()
+	// Give the callers time to queue behind the first load.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn called %d times for concurrent callers, want 1", got)
+	}
+	for i, r := range results {
+		if r != 70 {
+			t.Errorf("caller %d got %d, want 70", i, r)
+		}
+	}
+}
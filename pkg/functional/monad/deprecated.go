@@ -0,0 +1,30 @@
+package monad
+
+// The methods in this file keep code written against the types that
+// Option and Result replaced compiling. Parts of the old APIs could not
+// be kept: Option.Get now returns (T, bool) instead of T, and the exported
+// Value, Err and Error fields of idioms.Result and go124.Result are now
+// the Unwrap, Err and MustGet methods.
+
+// IsPresent reports whether o holds a value.
+//
+// Deprecated: Use IsSome. IsPresent was go124.Optional's name for it.
+func (o Option[T]) IsPresent() bool {
+	return o.IsSome()
+}
+
+// IsValid reports whether o holds a value.
+//
+// Deprecated: Use IsSome. IsValid was idioms.Optional's name for it.
+func (o Option[T]) IsValid() bool {
+	return o.IsSome()
+}
+
+// Map applies fn to the value of an Ok result and returns r unchanged
+// otherwise.
+//
+// Deprecated: Use MapResult, which can also change the value's type.
+// Map was go124.GenericResult's method.
+func (r Result[T]) Map(fn func(T) T) Result[T] {
+	return MapResult(r, fn)
+}
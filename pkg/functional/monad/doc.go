@@ -0,0 +1,38 @@
+// Package monad provides Option and Result, the repository's canonical
+// types for a value that may be absent and a value that may have failed.
+//
+// This package covers:
+//   - Option[T]: Some, None, OptionOf, Get, OrElse, OrElseGet, Filter
+//   - Result[T]: Ok, Err, ResultOf, Unwrap, OrElse, OrElseGet, Filter
+//   - Generic combinators: MapOption, FlatMapOption, ZipOption,
+//     MapResult, FlatMapResult, ZipResult
+//   - Collect over slices and iterators of results
+//   - Iterator support: All on both types, Values and Results adapters
+//   - JSON (None is null) and database/sql (None is NULL) support
+//
+// idioms.Result, idioms.Optional, go124.Result, go124.GenericResult and
+// go124.Optional are aliases of these types. Their old IsPresent, IsValid
+// and GenericResult.Map methods remain as deprecated shims; Option.Get
+// and the old exported Result fields could not be kept (see
+// deprecated.go).
+//
+// Why MapOption and MapResult rather than Map? Go has neither overloading
+// nor generic methods, so one package cannot export a Map for both types;
+// the suffix names the type the combinator works on.
+//
+// Why one package? Each of those used to be its own struct with its own
+// subset of methods, so a value could not move between packages without
+// being rebuilt field by field.
+//
+// Example usage:
+//
+//	import "github.com/KrystianMarek/golang-202/pkg/functional/monad"
+//
+//	func main() {
+//		port := monad.ResultOf(strconv.Atoi(os.Getenv("PORT")))
+//		fmt.Println(port.OrElse(8080))
+//
+//		nick := monad.MapOption(user.Nickname, strings.ToUpper)
+//		fmt.Println(nick.OrElse("anonymous"))
+//	}
+package monad
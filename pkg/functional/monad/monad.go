@@ -0,0 +1,199 @@
+package monad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+)
+
+// Combinators are package functions rather than methods because Go
+// methods cannot introduce type parameters: Option[T].Map could only
+// return another Option[T], never an Option[U].
+
+// Pair holds two values of possibly different types.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Swap returns a new pair with the values exchanged.
+func (p Pair[A, B]) Swap() Pair[B, A] {
+	return Pair[B, A]{First: p.Second, Second: p.First}
+}
+
+// MapOption applies fn to the value of o, if any.
+func MapOption[T, U any](o Option[T], fn func(T) U) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return Some(fn(o.value))
+}
+
+// FlatMapOption applies fn to the value of o, if any, and returns its
+// Option without nesting it.
+func FlatMapOption[T, U any](o Option[T], fn func(T) Option[U]) Option[U] {
+	if !o.ok {
+		return None[U]()
+	}
+	return fn(o.value)
+}
+
+// ZipOption returns both values as a Pair if both are present, and None
+// otherwise.
+func ZipOption[A, B any](a Option[A], b Option[B]) Option[Pair[A, B]] {
+	if !a.ok || !b.ok {
+		return None[Pair[A, B]]()
+	}
+	return Some(Pair[A, B]{First: a.value, Second: b.value})
+}
+
+// MapResult applies fn to the value of r if it is Ok, and passes an Err
+// through unchanged.
+func MapResult[T, U any](r Result[T], fn func(T) U) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return Ok(fn(r.value))
+}
+
+// FlatMapResult applies the fallible step fn to the value of r if it is
+// Ok. The first Err in a chain of FlatMapResult calls short-circuits the
+// rest.
+func FlatMapResult[T, U any](r Result[T], fn func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Err[U](r.err)
+	}
+	return fn(r.value)
+}
+
+// ZipResult returns both values as a Pair if both results are Ok, and the
+// first error otherwise.
+func ZipResult[A, B any](a Result[A], b Result[B]) Result[Pair[A, B]] {
+	if a.err != nil {
+		return Err[Pair[A, B]](a.err)
+	}
+	if b.err != nil {
+		return Err[Pair[A, B]](b.err)
+	}
+	return Ok(Pair[A, B]{First: a.value, Second: b.value})
+}
+
+// Collect turns a slice of results into a result of a slice: Ok with
+// every value in order if all of them are Ok, and the first error
+// otherwise.
+func Collect[T any](results []Result[T]) Result[[]T] {
+	values := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.err != nil {
+			return Err[[]T](r.err)
+		}
+		values = append(values, r.value)
+	}
+	return Ok(values)
+}
+
+// CollectSeq is Collect for an iterator. It stops pulling from seq at the
+// first error.
+func CollectSeq[T any](seq iter.Seq[Result[T]]) Result[[]T] {
+	var values []T
+	for r := range seq {
+		if r.err != nil {
+			return Err[[]T](r.err)
+		}
+		values = append(values, r.value)
+	}
+	return Ok(values)
+}
+
+// CollectOptions returns Some with every value in order if all options
+// are present, and None otherwise.
+func CollectOptions[T any](options []Option[T]) Option[[]T] {
+	values := make([]T, 0, len(options))
+	for _, o := range options {
+		if !o.ok {
+			return None[[]T]()
+		}
+		values = append(values, o.value)
+	}
+	return Some(values)
+}
+
+// Values returns an iterator over the present values of seq, skipping
+// every None.
+func Values[T any](seq iter.Seq[Option[T]]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for o := range seq {
+			if o.ok && !yield(o.value) {
+				return
+			}
+		}
+	}
+}
+
+// Results adapts an iterator of value/error pairs, such as the ones
+// TryPipeline produces, into an iterator of results.
+func Results[T any](seq iter.Seq2[T, error]) iter.Seq[Result[T]] {
+	return func(yield func(Result[T]) bool) {
+		for v, err := range seq {
+			if !yield(ResultOf(v, err)) {
+				return
+			}
+		}
+	}
+}
+
+// ExampleMonad demonstrates Option and Result.
+func ExampleMonad() {
+	fmt.Println("=== Option and Result ===")
+
+	// Option: a comma-ok lookup as one value
+	ports := map[string]int{"http": 80, "https": 443}
+	lookup := func(name string) Option[int] {
+		port, ok := ports[name]
+		return OptionOf(port, ok)
+	}
+	fmt.Printf("https: %v, gopher: %v\n", lookup("https"), lookup("gopher"))
+	fmt.Printf("gopher or default: %d\n", lookup("gopher").OrElse(70))
+
+	privileged := func(p int) bool { return p < 1024 }
+	label := MapOption(lookup("http").Filter(privileged), func(p int) string { return "port " + strconv.Itoa(p) })
+	fmt.Printf("Privileged http: %v\n", label)
+	fmt.Printf("Both ports: %v\n", ZipOption(lookup("http"), lookup("https")))
+
+	// Result: a chain of fallible steps
+	parse := func(s string) Result[int] { return ResultOf(strconv.Atoi(s)) }
+	positive := errors.New("must be positive")
+	half := func(s string) Result[int] {
+		return MapResult(parse(s).Filter(func(n int) bool { return n > 0 }, positive),
+			func(n int) int { return n / 2 })
+	}
+	for _, s := range []string{"42", "-4", "x"} {
+		fmt.Printf("half(%q) = %v\n", s, half(s))
+	}
+
+	// Collect: all values or the first error
+	fmt.Printf("Collect ok:  %v\n", Collect([]Result[int]{parse("1"), parse("2"), parse("3")}))
+	fmt.Printf("Collect err: %v\n", Collect([]Result[int]{parse("1"), parse("two"), parse("3")}))
+
+	// Iterators: an Option ranges like a collection of at most one value
+	opts := []Option[string]{Some("a"), None[string](), Some("c")}
+	for v := range Values(slices.Values(opts)) {
+		fmt.Printf("Present: %s\n", v)
+	}
+
+	// JSON: None is null, and omitzero drops it entirely
+	type profile struct {
+		Name     string         `json:"name"`
+		Nickname Option[string] `json:"nickname"`
+		Age      Option[int]    `json:"age,omitzero"`
+	}
+	data, _ := json.Marshal(profile{Name: "ann", Nickname: None[string]()})
+	fmt.Printf("JSON: %s\n", data)
+
+	var p profile
+	_ = json.Unmarshal([]byte(`{"name":"bob","nickname":"bobby","age":null}`), &p)
+	fmt.Printf("Decoded: nickname %v, age %v\n", p.Nickname, p.Age)
+}
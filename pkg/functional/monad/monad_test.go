@@ -0,0 +1,328 @@
+package monad
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("test error")
+
+func TestOption(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name     string
+		opt      Option[int]
+		some     bool
+		orElse   int
+		filtered bool
+		str      string
+	}{
+		{"some even", Some(4), true, 4, true, "Some(4)"},
+		{"some odd", Some(3), true, 3, false, "Some(3)"},
+		{"some zero", Some(0), true, 0, true, "Some(0)"},
+		{"none", None[int](), false, -1, false, "None"},
+		{"zero value", Option[int]{}, false, -1, false, "None"},
+		{"OptionOf false", OptionOf(7, false), false, -1, false, "None"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opt.IsSome() != tt.some || tt.opt.IsNone() == tt.some {
+				t.Errorf("IsSome = %v, IsNone = %v, want some %v", tt.opt.IsSome(), tt.opt.IsNone(), tt.some)
+			}
+			if _, ok := tt.opt.Get(); ok != tt.some {
+				t.Errorf("Get ok = %v", ok)
+			}
+			if got := tt.opt.OrElse(-1); got != tt.orElse {
+				t.Errorf("OrElse = %d, want %d", got, tt.orElse)
+			}
+			if got := tt.opt.OrElseGet(func() int { return -1 }); got != tt.orElse {
+				t.Errorf("OrElseGet = %d, want %d", got, tt.orElse)
+			}
+			if got := tt.opt.Filter(even).IsSome(); got != tt.filtered {
+				t.Errorf("Filter(even).IsSome = %v, want %v", got, tt.filtered)
+			}
+			if got := tt.opt.String(); got != tt.str {
+				t.Errorf("String = %q, want %q", got, tt.str)
+			}
+			if got := slices.Collect(tt.opt.All()); (len(got) == 1) != tt.some {
+				t.Errorf("All yielded %v", got)
+			}
+			if got := tt.opt.OkOr(errTest); got.IsOk() != tt.some {
+				t.Errorf("OkOr = %v", got)
+			}
+		})
+	}
+
+	if Some(1).OrElseGet(func() int { t.Error("OrElseGet called fn on Some"); return 0 }) != 1 {
+		t.Error("OrElseGet on Some")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustGet on None did not panic")
+		}
+	}()
+	None[int]().MustGet()
+}
+
+func TestResult(t *testing.T) {
+	positive := errors.New("not positive")
+	tests := []struct {
+		name     string
+		res      Result[int]
+		ok       bool
+		orElse   int
+		filtered error
+		str      string
+	}{
+		{"ok", Ok(5), true, 5, nil, "Ok(5)"},
+		{"ok filtered out", Ok(-5), true, -5, positive, "Ok(-5)"},
+		{"err", Err[int](errTest), false, -1, errTest, "Err(test error)"},
+		{"ResultOf nil error", ResultOf(7, nil), true, 7, nil, "Ok(7)"},
+		{"ResultOf error", ResultOf(7, errTest), false, -1, errTest, "Err(test error)"},
+		{"zero value", Result[int]{}, true, 0, positive, "Ok(0)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.res.IsOk() != tt.ok || tt.res.IsErr() == tt.ok {
+				t.Errorf("IsOk = %v, IsErr = %v, want ok %v", tt.res.IsOk(), tt.res.IsErr(), tt.ok)
+			}
+			if _, err := tt.res.Unwrap(); (err == nil) != tt.ok || err != tt.res.Err() {
+				t.Errorf("Unwrap err = %v, Err() = %v", err, tt.res.Err())
+			}
+			if got := tt.res.OrElse(-1); got != tt.orElse {
+				t.Errorf("OrElse = %d, want %d", got, tt.orElse)
+			}
+			if got := tt.res.OrElseGet(func(error) int { return -1 }); got != tt.orElse {
+				t.Errorf("OrElseGet = %d, want %d", got, tt.orElse)
+			}
+			if got := tt.res.Filter(func(n int) bool { return n > 0 }, positive).Err(); got != tt.filtered {
+				t.Errorf("Filter err = %v, want %v", got, tt.filtered)
+			}
+			if got := tt.res.Ok().IsSome(); got != tt.ok {
+				t.Errorf("Ok().IsSome = %v", got)
+			}
+			if got := tt.res.String(); got != tt.str {
+				t.Errorf("String = %q, want %q", got, tt.str)
+			}
+			if got := slices.Collect(tt.res.All()); (len(got) == 1) != tt.ok {
+				t.Errorf("All yielded %v", got)
+			}
+		})
+	}
+
+	var seen error
+	Err[int](errTest).OrElseGet(func(err error) int { seen = err; return 0 })
+	if seen != errTest {
+		t.Errorf("OrElseGet passed %v, want the result's error", seen)
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	itoa := strconv.Itoa
+	if got := MapOption(Some(4), itoa); got.OrElse("") != "4" {
+		t.Errorf("MapOption(Some) = %v", got)
+	}
+	if got := MapOption(None[int](), itoa); got.IsSome() {
+		t.Errorf("MapOption(None) = %v", got)
+	}
+
+	half := func(n int) Option[int] { return OptionOf(n/2, n%2 == 0) }
+	for _, tt := range []struct {
+		in   Option[int]
+		want Option[int]
+	}{
+		{Some(8), Some(4)},
+		{Some(3), None[int]()},
+		{None[int](), None[int]()},
+	} {
+		if got := FlatMapOption(tt.in, half); got != tt.want {
+			t.Errorf("FlatMapOption(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	parse := func(s string) Result[int] { return ResultOf(strconv.Atoi(s)) }
+	if got := FlatMapResult(Ok("12"), parse); got.OrElse(0) != 12 {
+		t.Errorf("FlatMapResult(Ok) = %v", got)
+	}
+	if got := FlatMapResult(Err[string](errTest), parse); got.Err() != errTest {
+		t.Errorf("FlatMapResult(Err) = %v", got)
+	}
+	if got := MapResult(Err[int](errTest), itoa); got.Err() != errTest {
+		t.Errorf("MapResult(Err) = %v", got)
+	}
+
+	if got := ZipOption(Some(1), Some("a")); got != Some(Pair[int, string]{1, "a"}) {
+		t.Errorf("ZipOption = %v", got)
+	}
+	if got := ZipOption(Some(1), None[string]()); got.IsSome() {
+		t.Errorf("ZipOption with None = %v", got)
+	}
+	other := errors.New("other")
+	if got := ZipResult(Err[int](errTest), Err[string](other)); got.Err() != errTest {
+		t.Errorf("ZipResult kept %v, want the first error", got.Err())
+	}
+	if got := ZipResult(Ok(1), Ok("a")).MustGet().Swap(); got != (Pair[string, int]{"a", 1}) {
+		t.Errorf("ZipResult then Swap = %v", got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	second := errors.New("second")
+	tests := []struct {
+		name    string
+		results []Result[int]
+		want    []int
+		err     error
+	}{
+		{"empty", nil, []int{}, nil},
+		{"all ok", []Result[int]{Ok(1), Ok(2), Ok(3)}, []int{1, 2, 3}, nil},
+		{"first error wins", []Result[int]{Ok(1), Err[int](errTest), Err[int](second)}, nil, errTest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Collect(tt.results).Unwrap()
+			if err != tt.err || !slices.Equal(got, tt.want) {
+				t.Errorf("Collect = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+			got, err = CollectSeq(slices.Values(tt.results)).Unwrap()
+			if err != tt.err || len(got) != len(tt.want) {
+				t.Errorf("CollectSeq = %v, %v, want %v, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+
+	pulled := 0
+	CollectSeq(func(yield func(Result[int]) bool) {
+		for _, r := range []Result[int]{Ok(1), Err[int](errTest), Ok(3)} {
+			pulled++
+			if !yield(r) {
+				return
+			}
+		}
+	})
+	if pulled != 2 {
+		t.Errorf("CollectSeq pulled %d results, want 2", pulled)
+	}
+
+	if got := CollectOptions([]Option[int]{Some(1), Some(2)}); !slices.Equal(got.OrZero(), []int{1, 2}) {
+		t.Errorf("CollectOptions = %v", got)
+	}
+	if got := CollectOptions([]Option[int]{Some(1), None[int]()}); got.IsSome() {
+		t.Errorf("CollectOptions with None = %v", got)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	opts := []Option[int]{Some(1), None[int](), Some(3), Some(4)}
+	if got := slices.Collect(Values(slices.Values(opts))); !slices.Equal(got, []int{1, 3, 4}) {
+		t.Errorf("Values = %v", got)
+	}
+	// Breaking out early must stop the iteration; the runtime panics if
+	// the iterator keeps yielding.
+	for range Values(slices.Values(opts)) {
+		break
+	}
+
+	pairs := func(yield func(int, error) bool) {
+		_ = yield(1, nil) && yield(0, errTest)
+	}
+	var got []string
+	for r := range Results(pairs) {
+		got = append(got, r.String())
+	}
+	if want := []string{"Ok(1)", "Err(test error)"}; !slices.Equal(got, want) {
+		t.Errorf("Results = %v, want %v", got, want)
+	}
+}
+
+func TestOptionJSON(t *testing.T) {
+	type record struct {
+		Name  Option[string] `json:"name"`
+		Count Option[int]    `json:"count,omitzero"`
+	}
+	tests := []struct {
+		name string
+		in   record
+		json string
+	}{
+		{"both set", record{Some("a"), Some(0)}, `{"name":"a","count":0}`},
+		{"none is null", record{None[string](), Some(2)}, `{"name":null,"count":2}`},
+		{"omitzero drops none", record{Some("a"), None[int]()}, `{"name":"a"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("Marshal = %s, want %s", data, tt.json)
+			}
+			var back record
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+			if back != tt.in {
+				t.Errorf("round trip = %+v, want %+v", back, tt.in)
+			}
+		})
+	}
+
+	var r record
+	if err := json.Unmarshal([]byte(`{"name":null,"count":"x"}`), &r); err == nil {
+		t.Error("decoding a string into Option[int] did not fail")
+	}
+}
+
+func TestOptionSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		src  any
+		want Option[int64]
+	}{
+		{"null", nil, None[int64]()},
+		{"int64", int64(42), Some[int64](42)},
+		{"bytes", []byte("7"), Some[int64](7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Some[int64](-1)
+			if err := got.Scan(tt.src); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %v, want %v", tt.src, got, tt.want)
+			}
+			v, err := got.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, _ := tt.want.Get(); tt.want.IsSome() && v != driver.Value(want) || tt.want.IsNone() && v != nil {
+				t.Errorf("Value = %#v", v)
+			}
+		})
+	}
+
+	var ts Option[time.Time]
+	if err := ts.Scan("not a time"); err == nil {
+		t.Error("scanning a string into Option[time.Time] did not fail")
+	}
+}
+
+func TestDeprecatedShims(t *testing.T) {
+	if !Some(1).IsPresent() || !Some(1).IsValid() || None[int]().IsPresent() || None[int]().IsValid() {
+		t.Error("IsPresent and IsValid should match IsSome")
+	}
+	if got := Ok(2).Map(func(v int) int { return v * 2 }); got.MustGet() != 4 {
+		t.Errorf("Ok(2).Map(double) = %v", got)
+	}
+	boom := errors.New("boom")
+	if got := Err[int](boom).Map(func(v int) int { return v * 2 }); !errors.Is(got.Err(), boom) {
+		t.Errorf("Err.Map = %v, want the error unchanged", got)
+	}
+}
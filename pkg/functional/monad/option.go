@@ -0,0 +1,168 @@
+package monad
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"iter"
+)
+
+// Option holds either a value (Some) or nothing (None). The zero value is
+// None.
+//
+// Why not a pointer? A *T says "maybe absent" too, but it also says "may
+// be shared and mutated", and every reader has to nil-check it. An Option
+// is a plain value, and its methods make the absent case explicit.
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some returns an Option holding v.
+func Some[T any](v T) Option[T] {
+	return Option[T]{value: v, ok: true}
+}
+
+// None returns an empty Option.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// OptionOf returns Some(v) if ok is true and None otherwise, so comma-ok
+// functions convert directly:
+//
+//	home := monad.OptionOf(os.LookupEnv("HOME"))
+func OptionOf[T any](v T, ok bool) Option[T] {
+	if !ok {
+		return None[T]()
+	}
+	return Some(v)
+}
+
+// IsSome reports whether o holds a value.
+func (o Option[T]) IsSome() bool {
+	return o.ok
+}
+
+// IsNone reports whether o is empty.
+func (o Option[T]) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value and whether it is present.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// MustGet returns the value and panics if o is None.
+func (o Option[T]) MustGet() T {
+	if !o.ok {
+		panic("monad: MustGet on None")
+	}
+	return o.value
+}
+
+// OrElse returns the value, or fallback if o is None.
+func (o Option[T]) OrElse(fallback T) T {
+	if o.ok {
+		return o.value
+	}
+	return fallback
+}
+
+// OrElseGet returns the value, or the result of fn if o is None. fn is
+// only called when it is needed.
+func (o Option[T]) OrElseGet(fn func() T) T {
+	if o.ok {
+		return o.value
+	}
+	return fn()
+}
+
+// OrZero returns the value, or the zero value of T if o is None.
+func (o Option[T]) OrZero() T {
+	return o.value
+}
+
+// Filter returns o if it holds a value that satisfies pred, and None
+// otherwise.
+func (o Option[T]) Filter(pred func(T) bool) Option[T] {
+	if o.ok && pred(o.value) {
+		return o
+	}
+	return None[T]()
+}
+
+// OkOr converts o to a Result, using err when o is None.
+func (o Option[T]) OkOr(err error) Result[T] {
+	if o.ok {
+		return Ok(o.value)
+	}
+	return Err[T](err)
+}
+
+// All returns an iterator that yields the value once if present, so an
+// Option can be ranged over like a collection of at most one element.
+func (o Option[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if o.ok {
+			yield(o.value)
+		}
+	}
+}
+
+// String returns "Some(v)" or "None".
+func (o Option[T]) String() string {
+	if !o.ok {
+		return "None"
+	}
+	return fmt.Sprintf("Some(%v)", o.value)
+}
+
+// IsZero reports whether o is None, so struct fields tagged
+// `json:",omitzero"` are left out when empty.
+func (o Option[T]) IsZero() bool {
+	return !o.ok
+}
+
+// MarshalJSON encodes None as null and Some(v) as v.
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if !o.ok {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON decodes null as None and anything else as Some.
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = None[T]()
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Some(v)
+	return nil
+}
+
+// Scan implements sql.Scanner: a NULL column scans as None.
+//
+// Why sql.Null? It already knows how to convert every driver value into
+// T, so Option only has to translate Valid into Some or None.
+func (o *Option[T]) Scan(src any) error {
+	var n sql.Null[T]
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	*o = Option[T]{value: n.V, ok: n.Valid}
+	return nil
+}
+
+// Value implements driver.Valuer: None is stored as NULL.
+func (o Option[T]) Value() (driver.Value, error) {
+	return sql.Null[T]{V: o.value, Valid: o.ok}.Value()
+}
@@ -0,0 +1,119 @@
+package monad
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Result holds either a value (Ok) or an error (Err). The zero value is
+// Ok with the zero value of T.
+//
+// Why, when Go has (T, error)? Multiple return values cannot be stored in
+// a slice, sent on a channel or passed through a generic pipeline stage;
+// a Result can, and MapResult and FlatMapResult chain fallible steps
+// without an if err != nil after each one.
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// Ok returns a successful Result holding v.
+func Ok[T any](v T) Result[T] {
+	return Result[T]{value: v}
+}
+
+// Err returns a failed Result holding err. Err(nil) is Ok with the zero
+// value of T; use ResultOf when err may be nil.
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+// ResultOf returns Ok(v) if err is nil and Err(err) otherwise, so a
+// (T, error) call converts directly:
+//
+//	r := monad.ResultOf(strconv.Atoi(s))
+func ResultOf[T any](v T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+	return Ok(v)
+}
+
+// IsOk reports whether r holds a value.
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr reports whether r holds an error.
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Unwrap returns the value and the error, in the usual Go order.
+func (r Result[T]) Unwrap() (T, error) {
+	return r.value, r.err
+}
+
+// Err returns the error, or nil if r is Ok.
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// MustGet returns the value and panics with the error if r is Err.
+func (r Result[T]) MustGet() T {
+	if r.err != nil {
+		panic(fmt.Sprintf("monad: MustGet on Err: %v", r.err))
+	}
+	return r.value
+}
+
+// OrElse returns the value, or fallback if r is Err.
+func (r Result[T]) OrElse(fallback T) T {
+	if r.err != nil {
+		return fallback
+	}
+	return r.value
+}
+
+// OrElseGet returns the value, or the result of fn applied to the error
+// if r is Err. fn is only called when it is needed.
+func (r Result[T]) OrElseGet(fn func(error) T) T {
+	if r.err != nil {
+		return fn(r.err)
+	}
+	return r.value
+}
+
+// Filter returns r if it is Err or its value satisfies pred, and Err(err)
+// otherwise.
+func (r Result[T]) Filter(pred func(T) bool, err error) Result[T] {
+	if r.err != nil || pred(r.value) {
+		return r
+	}
+	return Err[T](err)
+}
+
+// Ok converts r to an Option, dropping the error.
+func (r Result[T]) Ok() Option[T] {
+	if r.err != nil {
+		return None[T]()
+	}
+	return Some(r.value)
+}
+
+// All returns an iterator that yields the value once if r is Ok.
+func (r Result[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if r.err == nil {
+			yield(r.value)
+		}
+	}
+}
+
+// String returns "Ok(v)" or "Err(message)".
+func (r Result[T]) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("Err(%v)", r.err)
+	}
+	return fmt.Sprintf("Ok(%v)", r.value)
+}
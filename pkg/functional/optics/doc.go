@@ -0,0 +1,32 @@
+// Package optics provides lenses, prisms and traversals: composable
+// getters and setters for updating deeply nested immutable values.
+//
+// This package covers:
+//   - Lens[S, A]: a field that is always present (Get, Set, Modify)
+//   - Prism[S, A]: a part that may be absent, such as a map key or a
+//     pointer (Preview, Set, Modify)
+//   - Traversal[S, A]: any number of parts, such as every list element
+//   - Composition of each kind, and conversion from stronger optics to
+//     weaker ones (a Lens is a Prism that always matches)
+//   - Ready-made lenses for functional.Point and functional.User
+//
+// Why? Updating one field three levels down an immutable value means
+// reading each level, calling WithX on the innermost one, and writing
+// every level back by hand. A lens packages the read and the write of
+// one level, and composed lenses do the whole round trip in one call.
+//
+// Example usage:
+//
+//	import (
+//		"github.com/KrystianMarek/golang-202/pkg/functional"
+//		"github.com/KrystianMarek/golang-202/pkg/functional/optics"
+//	)
+//
+//	func main() {
+//		email := optics.Compose(ownerLens, optics.UserEmail)
+//		account = email.Set(account, "new@example.com")
+//
+//		older := optics.ComposeTraversal(optics.Each[functional.User](), optics.UserAge.AsTraversal())
+//		team = older.Modify(team, func(age int) int { return age + 1 })
+//	}
+package optics
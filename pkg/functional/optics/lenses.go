@@ -0,0 +1,162 @@
+package optics
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional"
+)
+
+// Ready-made optics for the types in package functional.
+//
+// Why method expressions? functional.Point, User and Team get their
+// getters and With* methods from cmd/immutgen, so functional.User.Email
+// and functional.User.WithEmail already are the get and set halves of a
+// lens; nothing here has to be kept in sync with the records by hand.
+
+// Lenses for functional.Point.
+var (
+	PointX = NewLens(functional.Point.X, functional.Point.WithX)
+	PointY = NewLens(functional.Point.Y, functional.Point.WithY)
+)
+
+// Lenses for functional.User.
+var (
+	UserID       = NewLens(functional.User.ID, functional.User.WithID)
+	UserUsername = NewLens(functional.User.Username, functional.User.WithUsername)
+	UserEmail    = NewLens(functional.User.Email, functional.User.WithEmail)
+	UserAge      = NewLens(functional.User.Age, functional.User.WithAge)
+)
+
+// Lenses for functional.Team.
+var (
+	TeamName    = NewLens(functional.Team.Name, functional.Team.WithName)
+	TeamMembers = NewLens(functional.Team.Members, functional.Team.WithMembers)
+)
+
+// Each returns a traversal over every element of an ImmutableList.
+func Each[T any]() Traversal[functional.ImmutableList[T], T] {
+	return Traversal[functional.ImmutableList[T], T]{
+		all: func(l functional.ImmutableList[T]) iter.Seq[T] {
+			return func(yield func(T) bool) {
+				for _, item := range l.All() {
+					if !yield(item) {
+						return
+					}
+				}
+			}
+		},
+		modify: functional.ImmutableList[T].Map,
+	}
+}
+
+// Index returns a prism on the element at index i of an ImmutableList,
+// absent when i is out of range.
+func Index[T any](i int) Prism[functional.ImmutableList[T], T] {
+	return Prism[functional.ImmutableList[T], T]{
+		preview: func(l functional.ImmutableList[T]) (T, bool) {
+			if i < 0 || i >= l.Size() {
+				var zero T
+				return zero, false
+			}
+			return l.Get(i), true
+		},
+		set: func(l functional.ImmutableList[T], v T) functional.ImmutableList[T] {
+			return l.Set(i, v)
+		},
+	}
+}
+
+// Key returns a prism on the value for key in a PersistentMap, absent
+// when the key is. Setting through it never adds the key.
+func Key[K comparable, V any](key K) Prism[functional.PersistentMap[K, V], V] {
+	return Prism[functional.PersistentMap[K, V], V]{
+		preview: func(m functional.PersistentMap[K, V]) (V, bool) { return m.Get(key) },
+		set: func(m functional.PersistentMap[K, V], v V) functional.PersistentMap[K, V] {
+			return m.Set(key, v)
+		},
+	}
+}
+
+// Deref returns a prism on the value behind an optional pointer field,
+// absent when the pointer is nil. Setting returns a new pointer and never
+// writes through the old one, which other versions may share.
+func Deref[A any]() Prism[*A, A] {
+	return Prism[*A, A]{
+		preview: func(p *A) (A, bool) {
+			if p == nil {
+				var zero A
+				return zero, false
+			}
+			return *p, true
+		},
+		set: func(_ *A, a A) *A { return &a },
+	}
+}
+
+// team is a nested immutable value for ExampleOptics.
+type team struct {
+	lead    functional.User
+	office  *functional.Point // nil for remote teams
+	members functional.ImmutableList[functional.User]
+}
+
+var (
+	teamLead = NewLens(
+		func(t team) functional.User { return t.lead },
+		func(t team, u functional.User) team { t.lead = u; return t })
+	teamOffice = NewLens(
+		func(t team) *functional.Point { return t.office },
+		func(t team, p *functional.Point) team { t.office = p; return t })
+	teamMembers = NewLens(
+		func(t team) functional.ImmutableList[functional.User] { return t.members },
+		func(t team, m functional.ImmutableList[functional.User]) team { t.members = m; return t })
+)
+
+// ExampleOptics demonstrates lenses, prisms and traversals.
+func ExampleOptics() {
+	fmt.Println("=== Optics ===")
+
+	alice := functional.NewUser(1, "alice", "alice@example.com", 30)
+	bob := functional.NewUser(2, "bob", "bob@example.com", 25)
+	carol := functional.NewUser(3, "carol", "carol@example.com", 41)
+	t1 := team{lead: alice, members: functional.NewImmutableList(bob, carol)}
+
+	// Lens: one expression instead of t1.lead.WithEmail(...) written back by hand
+	leadEmail := Compose(teamLead, UserEmail)
+	t2 := leadEmail.Set(t1, "alice@corp.example")
+	fmt.Printf("Lead email: %s -> %s\n", leadEmail.Get(t1), leadEmail.Get(t2))
+
+	// Traversal: every member's age
+	memberAges := ComposeTraversal(teamMembers.AsTraversal(), ComposeTraversal(Each[functional.User](), UserAge.AsTraversal()))
+	t3 := memberAges.Modify(t2, func(age int) int { return age + 1 })
+	fmt.Printf("Member ages: %v -> %v\n", memberAges.ToSlice(t2), memberAges.ToSlice(t3))
+
+	// Prism: an index that may not exist
+	second := ComposePrism(teamMembers.AsPrism(), Index[functional.User](1))
+	fifth := ComposePrism(teamMembers.AsPrism(), Index[functional.User](4))
+	if u, ok := second.Preview(t3); ok {
+		fmt.Printf("Second member: %s\n", u.Username())
+	}
+	_, ok := fifth.Preview(t3)
+	fmt.Printf("Fifth member present: %v (Set is a no-op: %v)\n", ok, fifth.Set(t3, alice).members.Size() == 2)
+
+	// Prism: an optional pointer field
+	officeX := ComposePrism(ComposePrism(teamOffice.AsPrism(), Deref[functional.Point]()), PointX.AsPrism())
+	remote := officeX.Set(t3, 10)
+	fmt.Printf("Remote team has office: %v\n", remote.office != nil)
+
+	hq := functional.NewPoint(1, 2)
+	moved := officeX.Modify(teamOffice.Set(t3, &hq), func(x float64) float64 { return x + 10 })
+	fmt.Printf("Office: %v -> %v (original untouched)\n", hq, *moved.office)
+
+	// Prism over a persistent map
+	scores := functional.PersistentMap[string, int]{}.Set("alice", 3)
+	bump := func(n int) int { return n + 1 }
+	scores = Key[string, int]("alice").Modify(scores, bump)
+	scores = Key[string, int]("dave").Modify(scores, bump)
+	alices, _ := scores.Get("alice")
+	fmt.Printf("Scores: alice=%d, %d entries\n", alices, scores.Len())
+
+	fmt.Printf("\nOriginal team unchanged: lead %s, ages %v\n", t1.lead.Email(), memberAges.ToSlice(t1))
+}
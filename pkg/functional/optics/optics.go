@@ -0,0 +1,185 @@
+package optics
+
+import "iter"
+
+// Lens focuses on one part A of a whole S that is always present.
+//
+// Why a struct of two functions? Go methods cannot introduce type
+// parameters, so composition is a top-level function (Compose), and the
+// pair of functions is all a lens needs to carry.
+type Lens[S, A any] struct {
+	get func(S) A
+	set func(S, A) S
+}
+
+// NewLens returns a lens from a getter and a copying setter. Method
+// expressions of immutable records fit directly:
+//
+//	optics.NewLens(functional.Point.X, functional.Point.WithX)
+func NewLens[S, A any](get func(S) A, set func(S, A) S) Lens[S, A] {
+	return Lens[S, A]{get: get, set: set}
+}
+
+// Get returns the focused part of s.
+func (l Lens[S, A]) Get(s S) A {
+	return l.get(s)
+}
+
+// Set returns a copy of s with the focused part replaced by a.
+func (l Lens[S, A]) Set(s S, a A) S {
+	return l.set(s, a)
+}
+
+// Modify returns a copy of s with fn applied to the focused part.
+func (l Lens[S, A]) Modify(s S, fn func(A) A) S {
+	return l.set(s, fn(l.get(s)))
+}
+
+// AsPrism returns l as a Prism that always matches.
+func (l Lens[S, A]) AsPrism() Prism[S, A] {
+	return Prism[S, A]{
+		preview: func(s S) (A, bool) { return l.get(s), true },
+		set:     l.set,
+	}
+}
+
+// AsTraversal returns l as a Traversal over exactly one part.
+func (l Lens[S, A]) AsTraversal() Traversal[S, A] {
+	return l.AsPrism().AsTraversal()
+}
+
+// Compose returns a lens that focuses through outer, then inner.
+func Compose[S, A, B any](outer Lens[S, A], inner Lens[A, B]) Lens[S, B] {
+	return Lens[S, B]{
+		get: func(s S) B { return inner.get(outer.get(s)) },
+		set: func(s S, b B) S { return outer.set(s, inner.set(outer.get(s), b)) },
+	}
+}
+
+// Prism focuses on a part A of S that may be absent: an optional field,
+// a map key or a list index.
+type Prism[S, A any] struct {
+	preview func(S) (A, bool)
+	set     func(S, A) S
+}
+
+// NewPrism returns a prism from preview, which reports whether the part
+// is present, and set, which is only called when it is.
+func NewPrism[S, A any](preview func(S) (A, bool), set func(S, A) S) Prism[S, A] {
+	return Prism[S, A]{preview: preview, set: set}
+}
+
+// Preview returns the focused part and whether it is present.
+func (p Prism[S, A]) Preview(s S) (A, bool) {
+	return p.preview(s)
+}
+
+// Set returns a copy of s with the focused part replaced by a, or s
+// unchanged if the part is absent.
+func (p Prism[S, A]) Set(s S, a A) S {
+	if _, ok := p.preview(s); !ok {
+		return s
+	}
+	return p.set(s, a)
+}
+
+// Modify returns a copy of s with fn applied to the focused part, or s
+// unchanged if the part is absent.
+func (p Prism[S, A]) Modify(s S, fn func(A) A) S {
+	a, ok := p.preview(s)
+	if !ok {
+		return s
+	}
+	return p.set(s, fn(a))
+}
+
+// AsTraversal returns p as a Traversal over zero or one parts.
+func (p Prism[S, A]) AsTraversal() Traversal[S, A] {
+	return Traversal[S, A]{
+		all: func(s S) iter.Seq[A] {
+			return func(yield func(A) bool) {
+				if a, ok := p.preview(s); ok {
+					yield(a)
+				}
+			}
+		},
+		modify: p.Modify,
+	}
+}
+
+// ComposePrism returns a prism that focuses through outer, then inner.
+// Use Lens.AsPrism to compose a lens with a prism.
+func ComposePrism[S, A, B any](outer Prism[S, A], inner Prism[A, B]) Prism[S, B] {
+	return Prism[S, B]{
+		preview: func(s S) (B, bool) {
+			a, ok := outer.preview(s)
+			if !ok {
+				var zero B
+				return zero, false
+			}
+			return inner.preview(a)
+		},
+		set: func(s S, b B) S {
+			return outer.Modify(s, func(a A) A { return inner.Set(a, b) })
+		},
+	}
+}
+
+// Traversal focuses on any number of parts A of S, such as every element
+// of a list.
+type Traversal[S, A any] struct {
+	all    func(S) iter.Seq[A]
+	modify func(S, func(A) A) S
+}
+
+// NewTraversal returns a traversal from an iterator over the parts and
+// a function that rebuilds S with fn applied to each of them.
+func NewTraversal[S, A any](all func(S) iter.Seq[A], modify func(S, func(A) A) S) Traversal[S, A] {
+	return Traversal[S, A]{all: all, modify: modify}
+}
+
+// All returns an iterator over the focused parts of s.
+func (t Traversal[S, A]) All(s S) iter.Seq[A] {
+	return t.all(s)
+}
+
+// ToSlice returns the focused parts of s.
+func (t Traversal[S, A]) ToSlice(s S) []A {
+	var out []A
+	for a := range t.all(s) {
+		out = append(out, a)
+	}
+	return out
+}
+
+// Modify returns a copy of s with fn applied to every focused part.
+func (t Traversal[S, A]) Modify(s S, fn func(A) A) S {
+	return t.modify(s, fn)
+}
+
+// Set returns a copy of s with every focused part replaced by a.
+func (t Traversal[S, A]) Set(s S, a A) S {
+	return t.modify(s, func(A) A { return a })
+}
+
+// ComposeTraversal returns a traversal over every part inner focuses on
+// within every part outer focuses on. Use AsTraversal to compose lenses
+// and prisms with traversals.
+func ComposeTraversal[S, A, B any](outer Traversal[S, A], inner Traversal[A, B]) Traversal[S, B] {
+	return Traversal[S, B]{
+		all: func(s S) iter.Seq[B] {
+			return func(yield func(B) bool) {
+				for a := range outer.all(s) {
+					for b := range inner.all(a) {
+						if !yield(b) {
+							return
+						}
+					}
+				}
+			}
+		},
+		modify: func(s S, fn func(B) B) S {
+			return outer.modify(s, func(a A) A { return inner.modify(a, fn) })
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package optics
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional"
+)
+
+// checkLensLaws verifies the three lens laws for l on s with values a and b:
+// set-then-get returns what was set, getting then setting changes nothing,
+// and a second set wins over the first.
+func checkLensLaws[S, A any](t *testing.T, l Lens[S, A], s S, a, b A, eqS func(S, S) bool, eqA func(A, A) bool) {
+	t.Helper()
+	if got := l.Get(l.Set(s, a)); !eqA(got, a) {
+		t.Errorf("get(set(s, a)) = %v, want %v", got, a)
+	}
+	if got := l.Set(s, l.Get(s)); !eqS(got, s) {
+		t.Errorf("set(s, get(s)) = %v, want %v", got, s)
+	}
+	if got, want := l.Set(l.Set(s, a), b), l.Set(s, b); !eqS(got, want) {
+		t.Errorf("set(set(s, a), b) = %v, want %v", got, want)
+	}
+}
+
+func eq[T comparable](a, b T) bool { return a == b }
+
+func TestLensLaws(t *testing.T) {
+	user := functional.NewUser(1, "alice", "alice@example.com", 30)
+	team := functional.NewTeam("core", []string{"alice"})
+	point := functional.NewPoint(1, 2)
+	pair := NewLens(
+		func(p [2]functional.Point) functional.Point { return p[1] },
+		func(p [2]functional.Point, v functional.Point) [2]functional.Point { p[1] = v; return p })
+
+	t.Run("PointX", func(t *testing.T) { checkLensLaws(t, PointX, point, 5, -1, functional.Point.Equal, eq) })
+	t.Run("PointY", func(t *testing.T) { checkLensLaws(t, PointY, point, 5, -1, functional.Point.Equal, eq) })
+	t.Run("UserEmail", func(t *testing.T) {
+		checkLensLaws(t, UserEmail, user, "a@x", "b@x", functional.User.Equal, eq)
+	})
+	t.Run("UserAge", func(t *testing.T) { checkLensLaws(t, UserAge, user, 31, 32, functional.User.Equal, eq) })
+	t.Run("TeamMembers", func(t *testing.T) {
+		checkLensLaws(t, TeamMembers, team, []string{"bob"}, nil, functional.Team.Equal, slices.Equal)
+	})
+	t.Run("composed", func(t *testing.T) {
+		s := [2]functional.Point{point, point.Move(1, 1)}
+		checkLensLaws(t, Compose(pair, PointY), s, 7, 8, eq, eq)
+	})
+}
+
+func TestPrisms(t *testing.T) {
+	list := functional.NewImmutableList("a", "b", "c")
+	shout := func(s string) string { return s + "!" }
+
+	tests := []struct {
+		name    string
+		prism   Prism[functional.ImmutableList[string], string]
+		want    string
+		present bool
+		after   []string
+	}{
+		{"index in range", Index[string](1), "b", true, []string{"a", "b!", "c"}},
+		{"index past end", Index[string](3), "", false, []string{"a", "b", "c"}},
+		{"negative index", Index[string](-1), "", false, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.prism.Preview(list)
+			if got != tt.want || ok != tt.present {
+				t.Errorf("Preview = %q, %v, want %q, %v", got, ok, tt.want, tt.present)
+			}
+			if after := tt.prism.Modify(list, shout).ToSlice(); !slices.Equal(after, tt.after) {
+				t.Errorf("Modify = %v, want %v", after, tt.after)
+			}
+		})
+	}
+
+	m := functional.PersistentMap[string, int]{}.Set("a", 1)
+	if got := Key[string, int]("b").Set(m, 2); got.Len() != 1 {
+		t.Errorf("Set through an absent key added it: %d entries", got.Len())
+	}
+	if v, _ := Key[string, int]("a").Set(m, 2).Get("a"); v != 2 {
+		t.Errorf("Set through a present key: got %d, want 2", v)
+	}
+
+	p := functional.NewPoint(1, 1)
+	x := ComposePrism(Deref[functional.Point](), PointX.AsPrism())
+	moved := x.Set(&p, 9)
+	if moved == &p || p.X() != 1 || moved.X() != 9 {
+		t.Errorf("Deref Set wrote through the old pointer or lost the update: old %v, new %v", p, *moved)
+	}
+	if got := x.Set(nil, 9); got != nil {
+		t.Errorf("Set on a nil pointer = %v, want nil", got)
+	}
+}
+
+func TestTraversals(t *testing.T) {
+	users := functional.NewImmutableList(
+		functional.NewUser(1, "a", "a@x", 20),
+		functional.NewUser(2, "b", "b@x", 30),
+	)
+	ages := ComposeTraversal(Each[functional.User](), UserAge.AsTraversal())
+
+	if got := ages.ToSlice(users); !slices.Equal(got, []int{20, 30}) {
+		t.Errorf("ToSlice = %v", got)
+	}
+	if got := ages.ToSlice(ages.Set(users, 1)); !slices.Equal(got, []int{1, 1}) {
+		t.Errorf("after Set: %v", got)
+	}
+	if got := ages.ToSlice(users); !slices.Equal(got, []int{20, 30}) {
+		t.Errorf("original changed: %v", got)
+	}
+
+	// Breaking out early must stop the iteration; the runtime panics if
+	// the iterator keeps yielding.
+	for range ages.All(users) {
+		break
+	}
+
+	first := ComposeTraversal(Index[functional.User](0).AsTraversal(), UserUsername.AsTraversal())
+	if got := first.ToSlice(first.Modify(users, func(s string) string { return s + s })); !slices.Equal(got, []string{"aa"}) {
+		t.Errorf("prism traversal = %v", got)
+	}
+}
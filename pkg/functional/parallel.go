@@ -0,0 +1,188 @@
+package functional
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Parallel stages fan a pipeline's items out over a fixed number of
+// goroutines and stream the results back as a lazy pipeline.
+//
+// Why a separate stage type? An iter.Seq has no way to report an error,
+// yet a parallel stage must surface the first failure (like errgroup)
+// and any context cancellation. ParallelStage embeds the result pipeline
+// so it chains like any other, and adds Err, which is valid once
+// iteration has finished, in the same spirit as bufio.Scanner.Err.
+//
+// Nothing starts until the stage is iterated. Breaking out of the loop,
+// a failing fn or a cancelled context stops the workers, and iteration
+// does not return until every goroutine the stage started has exited.
+
+// ParallelOption configures ParallelMap, ParallelMapTo and ParallelFilter.
+type ParallelOption func(*parallelConfig)
+
+type parallelConfig struct {
+	unordered bool
+}
+
+// Unordered yields results as soon as they are ready instead of in input
+// order. It avoids holding back fast results behind a slow one.
+func Unordered() ParallelOption {
+	return func(c *parallelConfig) { c.unordered = true }
+}
+
+// ParallelStage is a pipeline produced by a parallel operator.
+type ParallelStage[T any] struct {
+	*Pipeline[T]
+	err error
+}
+
+// Err returns the first error returned by the stage's function, or the
+// context's error if it was cancelled, during the last iteration.
+func (s *ParallelStage[T]) Err() error {
+	return s.err
+}
+
+// ParallelMap applies fn to items using workers goroutines. Workers <= 0
+// means runtime.GOMAXPROCS(0). Results are in input order unless the
+// Unordered option is given.
+func (p *Pipeline[T]) ParallelMap(ctx context.Context, workers int, fn func(context.Context, T) (T, error), opts ...ParallelOption) *ParallelStage[T] {
+	return ParallelMapTo(ctx, p, workers, fn, opts...)
+}
+
+// ParallelFilter keeps items for which predicate returns true, evaluating
+// it on workers goroutines.
+func (p *Pipeline[T]) ParallelFilter(ctx context.Context, workers int, predicate func(context.Context, T) (bool, error), opts ...ParallelOption) *ParallelStage[T] {
+	return parallel(ctx, p, workers, func(ctx context.Context, item T) (T, bool, error) {
+		keep, err := predicate(ctx, item)
+		return item, keep, err
+	}, opts)
+}
+
+// ParallelMapTo is ParallelMap with a result type that differs from the
+// input type.
+func ParallelMapTo[T, U any](ctx context.Context, p *Pipeline[T], workers int, fn func(context.Context, T) (U, error), opts ...ParallelOption) *ParallelStage[U] {
+	return parallel(ctx, p, workers, func(ctx context.Context, item T) (U, bool, error) {
+		out, err := fn(ctx, item)
+		return out, true, err
+	}, opts)
+}
+
+// parallelResult carries one processed item back to the consumer.
+type parallelResult[U any] struct {
+	index int
+	value U
+	keep  bool
+	err   error
+}
+
+func parallel[T, U any](parent context.Context, p *Pipeline[T], workers int, fn func(context.Context, T) (U, bool, error), opts []ParallelOption) *ParallelStage[U] {
+	var cfg parallelConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	stage := &ParallelStage[U]{}
+	stage.Pipeline = FromSeq(func(yield func(U) bool) {
+		stage.err = nil
+		ctx, cancel := context.WithCancel(parent)
+		defer cancel()
+
+		type job struct {
+			index int
+			item  T
+		}
+		jobs := make(chan job)
+		results := make(chan parallelResult[U], workers)
+		// window bounds the items between the source and the consumer, so a
+		// slow item in ordered mode cannot make pending results pile up.
+		window := make(chan struct{}, 2*workers)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(jobs)
+			index := 0
+			for item := range p.source {
+				select {
+				case window <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case jobs <- job{index, item}:
+				case <-ctx.Done():
+					return
+				}
+				index++
+			}
+		}()
+
+		var workersWG sync.WaitGroup
+		for range workers {
+			workersWG.Add(1)
+			go func() {
+				defer workersWG.Done()
+				for j := range jobs {
+					value, keep, err := fn(ctx, j.item)
+					select {
+					case results <- parallelResult[U]{j.index, value, keep, err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			workersWG.Wait()
+			close(results)
+		}()
+
+		// Stop everything and wait for it before returning, so the source
+		// is never used after the loop that ranged over the stage ends.
+		defer func() {
+			cancel()
+			for range results {
+			}
+			wg.Wait()
+		}()
+
+		emit := func(r parallelResult[U]) bool {
+			<-window
+			return !r.keep || yield(r.value)
+		}
+		pending := make(map[int]parallelResult[U])
+		next := 0
+		for r := range results {
+			if r.err != nil {
+				stage.err = r.err
+				return
+			}
+			if cfg.unordered {
+				if !emit(r) {
+					return
+				}
+				continue
+			}
+			pending[r.index] = r
+			for {
+				ready, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				if !emit(ready) {
+					return
+				}
+			}
+		}
+		stage.err = parent.Err()
+	})
+	return stage
+}
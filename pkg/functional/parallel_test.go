@@ -0,0 +1,123 @@
+package functional
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapOrdering(t *testing.T) {
+	// Earlier items sleep longer, so completion order is reversed.
+	slowFirst := func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(10-n) * time.Millisecond)
+		return n * n, nil
+	}
+
+	tests := []struct {
+		name string
+		opts []ParallelOption
+	}{
+		{"ordered", nil},
+		{"unordered", []ParallelOption{Unordered()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage := NewPipeline(ints(8)).ParallelMap(context.Background(), 4, slowFirst, tt.opts...)
+			got := stage.Collect()
+			if err := stage.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := []int{1, 4, 9, 16, 25, 36, 49, 64}
+			if tt.opts == nil && !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v (sorted), want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParallelFilterAndMapTo(t *testing.T) {
+	ctx := context.Background()
+	evens := NewPipeline(ints(10)).ParallelFilter(ctx, 3, func(_ context.Context, n int) (bool, error) {
+		return n%2 == 0, nil
+	})
+	labels := ParallelMapTo(ctx, evens.Pipeline, 2, func(_ context.Context, n int) (string, error) {
+		return string(rune('a' + n)), nil
+	}).Collect()
+
+	if want := []string{"c", "e", "g", "i", "k"}; !slices.Equal(labels, want) {
+		t.Errorf("got %v, want %v", labels, want)
+	}
+}
+
+func TestParallelMapStopsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	var calls atomic.Int32
+
+	stage := FromSeq(func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}).ParallelMap(context.Background(), 4, func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		if n == 5 {
+			return 0, boom
+		}
+		return n, nil
+	})
+
+	got := stage.Collect()
+	if !errors.Is(stage.Err(), boom) {
+		t.Fatalf("Err() = %v, want %v", stage.Err(), boom)
+	}
+	if len(got) > 5 {
+		t.Errorf("yielded %v; nothing at or after the failing item should be emitted in order", got)
+	}
+	if calls.Load() > 100 {
+		t.Errorf("fn called %d times after the error on an infinite source", calls.Load())
+	}
+}
+
+func TestParallelMapCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	before := runtime.NumGoroutine()
+
+	stage := NewPipeline(ints(100)).ParallelMap(ctx, 4, func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			cancel()
+		}
+		<-ctx.Done()
+		return n, nil
+	})
+	stage.Collect()
+
+	if !errors.Is(stage.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", stage.Err())
+	}
+	if after := runtime.NumGoroutine(); after > before+1 {
+		t.Errorf("%d goroutines still running after the stage returned (was %d)", after, before)
+	}
+}
+
+func TestParallelMapEarlyBreak(t *testing.T) {
+	stage := NewPipeline(ints(1000)).ParallelMap(context.Background(), 8, func(_ context.Context, n int) (int, error) {
+		return n, nil
+	})
+	first, ok := stage.First()
+	if !ok || first != 1 {
+		t.Errorf("First() = %v, %v; want 1, true", first, ok)
+	}
+	if err := stage.Err(); err != nil {
+		t.Errorf("Err() = %v after breaking early, want nil", err)
+	}
+}
@@ -0,0 +1,323 @@
+package functional
+
+import (
+	"hash/maphash"
+	"iter"
+	"math/bits"
+	"slices"
+)
+
+const (
+	hamtBits  = 5
+	hamtMask  = 1<<hamtBits - 1
+	hamtDepth = 64 // hash bits; deeper nodes hold full collisions
+)
+
+// hamtSeed is shared by every PersistentMap so versions of a map, which
+// share nodes, agree on where each key lives.
+var hamtSeed = maphash.MakeSeed()
+
+// hentry is a slot in a HAMT node: either a child node or a key-value pair.
+type hentry[K comparable, V any] struct {
+	node *hnode[K, V]
+	hash uint64
+	key  K
+	val  V
+}
+
+// hnode is a hash array mapped trie node. bitmap records which of the 32
+// slots for the next 5 hash bits are in use; entries holds only those, in
+// slot order. Below hamtDepth a node is a collision node: a plain list of
+// pairs whose hashes are equal.
+type hnode[K comparable, V any] struct {
+	owner   *owner
+	bitmap  uint32
+	entries []hentry[K, V]
+}
+
+// editable returns n if o owns it, and otherwise a copy owned by o.
+func (n *hnode[K, V]) editable(o *owner) *hnode[K, V] {
+	if o != nil && n.owner == o {
+		return n
+	}
+	entries := make([]hentry[K, V], len(n.entries), len(n.entries)+1)
+	copy(entries, n.entries)
+	return &hnode[K, V]{owner: o, bitmap: n.bitmap, entries: entries}
+}
+
+// slot returns the bit for hash at shift and the index of its entry.
+func (n *hnode[K, V]) slot(hash uint64, shift uint) (uint32, int) {
+	bit := uint32(1) << ((hash >> shift) & hamtMask)
+	return bit, bits.OnesCount32(n.bitmap & (bit - 1))
+}
+
+// PersistentMap is an immutable hash map built as a hash array mapped
+// trie (HAMT), so Get, Set and Delete are O(log32 n) and versions share
+// unchanged nodes. Iteration order is unspecified. The zero value is an
+// empty map.
+type PersistentMap[K comparable, V any] struct {
+	root *hnode[K, V]
+	size int
+}
+
+// MapFrom returns a PersistentMap holding the entries of m.
+func MapFrom[K comparable, V any](m map[K]V) PersistentMap[K, V] {
+	var pm PersistentMap[K, V]
+	t := pm.Transient()
+	for k, v := range m {
+		t.Set(k, v)
+	}
+	return t.Persistent()
+}
+
+// Len returns the number of entries.
+func (m PersistentMap[K, V]) Len() int {
+	return m.size
+}
+
+// Get returns the value for key and whether it is present.
+func (m PersistentMap[K, V]) Get(key K) (V, bool) {
+	return hamtGet(m.root, maphash.Comparable(hamtSeed, key), key)
+}
+
+// Set returns a map with key set to val.
+func (m PersistentMap[K, V]) Set(key K, val V) PersistentMap[K, V] {
+	root, added := hamtSet(nil, m.root, maphash.Comparable(hamtSeed, key), 0, key, val)
+	m.root = root
+	if added {
+		m.size++
+	}
+	return m
+}
+
+// Delete returns a map without key. It returns m itself if key is absent.
+func (m PersistentMap[K, V]) Delete(key K) PersistentMap[K, V] {
+	root, removed := hamtDelete(nil, m.root, maphash.Comparable(hamtSeed, key), 0, key)
+	if !removed {
+		return m
+	}
+	return PersistentMap[K, V]{root: root, size: m.size - 1}
+}
+
+// All returns an iterator over the entries, in unspecified order.
+func (m PersistentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		hamtEach(m.root, yield)
+	}
+}
+
+// Transient returns a mutable copy of m for batch updates.
+func (m PersistentMap[K, V]) Transient() *TransientMap[K, V] {
+	return &TransientMap[K, V]{m: m, owner: &owner{}}
+}
+
+func hamtGet[K comparable, V any](n *hnode[K, V], hash uint64, key K) (V, bool) {
+	for shift := uint(0); n != nil; shift += hamtBits {
+		if shift >= hamtDepth {
+			for _, e := range n.entries {
+				if e.key == key {
+					return e.val, true
+				}
+			}
+			break
+		}
+		bit, idx := n.slot(hash, shift)
+		if n.bitmap&bit == 0 {
+			break
+		}
+		e := n.entries[idx]
+		if e.node == nil {
+			if e.hash == hash && e.key == key {
+				return e.val, true
+			}
+			break
+		}
+		n = e.node
+	}
+	var zero V
+	return zero, false
+}
+
+// hamtSet returns n with key set to val and whether key is new.
+func hamtSet[K comparable, V any](o *owner, n *hnode[K, V], hash uint64, shift uint, key K, val V) (*hnode[K, V], bool) {
+	leaf := hentry[K, V]{hash: hash, key: key, val: val}
+	if n == nil {
+		n = &hnode[K, V]{owner: o}
+	}
+
+	if shift >= hamtDepth {
+		i := slices.IndexFunc(n.entries, func(e hentry[K, V]) bool { return e.key == key })
+		n = n.editable(o)
+		if i >= 0 {
+			n.entries[i] = leaf
+			return n, false
+		}
+		n.entries = append(n.entries, leaf)
+		return n, true
+	}
+
+	bit, idx := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		n = n.editable(o)
+		n.bitmap |= bit
+		n.entries = slices.Insert(n.entries, idx, leaf)
+		return n, true
+	}
+
+	e := n.entries[idx]
+	switch {
+	case e.node != nil:
+		child, added := hamtSet(o, e.node, hash, shift+hamtBits, key, val)
+		n = n.editable(o)
+		n.entries[idx] = hentry[K, V]{node: child}
+		return n, added
+	case e.hash == hash && e.key == key:
+		n = n.editable(o)
+		n.entries[idx] = leaf
+		return n, false
+	default:
+		n = n.editable(o)
+		n.entries[idx] = hentry[K, V]{node: hamtSplit(o, shift+hamtBits, e, leaf)}
+		return n, true
+	}
+}
+
+// hamtSplit returns a node at shift holding two pairs that shared a slot.
+func hamtSplit[K comparable, V any](o *owner, shift uint, a, b hentry[K, V]) *hnode[K, V] {
+	if shift >= hamtDepth {
+		return &hnode[K, V]{owner: o, entries: []hentry[K, V]{a, b}}
+	}
+	ia, ib := (a.hash>>shift)&hamtMask, (b.hash>>shift)&hamtMask
+	n := &hnode[K, V]{owner: o, bitmap: 1<<ia | 1<<ib}
+	switch {
+	case ia == ib:
+		n.entries = []hentry[K, V]{{node: hamtSplit(o, shift+hamtBits, a, b)}}
+	case ia < ib:
+		n.entries = []hentry[K, V]{a, b}
+	default:
+		n.entries = []hentry[K, V]{b, a}
+	}
+	return n
+}
+
+// hamtDelete returns n without key, or nil if n becomes empty, and
+// whether key was present.
+func hamtDelete[K comparable, V any](o *owner, n *hnode[K, V], hash uint64, shift uint, key K) (*hnode[K, V], bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	if shift >= hamtDepth {
+		i := slices.IndexFunc(n.entries, func(e hentry[K, V]) bool { return e.key == key })
+		if i < 0 {
+			return n, false
+		}
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		n = n.editable(o)
+		n.entries = slices.Delete(n.entries, i, i+1)
+		return n, true
+	}
+
+	bit, idx := n.slot(hash, shift)
+	if n.bitmap&bit == 0 {
+		return n, false
+	}
+	e := n.entries[idx]
+	if e.node != nil {
+		child, removed := hamtDelete(o, e.node, hash, shift+hamtBits, key)
+		if !removed {
+			return n, false
+		}
+		if child != nil {
+			n = n.editable(o)
+			if len(child.entries) == 1 && child.entries[0].node == nil {
+				// Pull a lone pair up so the trie stays as shallow as possible.
+				n.entries[idx] = child.entries[0]
+			} else {
+				n.entries[idx] = hentry[K, V]{node: child}
+			}
+			return n, true
+		}
+	} else if e.hash != hash || e.key != key {
+		return n, false
+	}
+
+	if n.bitmap == bit {
+		return nil, true
+	}
+	n = n.editable(o)
+	n.bitmap &^= bit
+	n.entries = slices.Delete(n.entries, idx, idx+1)
+	return n, true
+}
+
+func hamtEach[K comparable, V any](n *hnode[K, V], yield func(K, V) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, e := range n.entries {
+		if e.node != nil {
+			if !hamtEach(e.node, yield) {
+				return false
+			}
+		} else if !yield(e.key, e.val) {
+			return false
+		}
+	}
+	return true
+}
+
+// TransientMap is a mutable map for building or updating a PersistentMap
+// in bulk. It is not safe for concurrent use, and must not be used after
+// Persistent.
+type TransientMap[K comparable, V any] struct {
+	m     PersistentMap[K, V]
+	owner *owner
+}
+
+func (t *TransientMap[K, V]) check() {
+	if t.owner == nil {
+		panic("functional: transient map used after Persistent")
+	}
+}
+
+// Len returns the number of entries.
+func (t *TransientMap[K, V]) Len() int {
+	return t.m.size
+}
+
+// Get returns the value for key and whether it is present.
+func (t *TransientMap[K, V]) Get(key K) (V, bool) {
+	return t.m.Get(key)
+}
+
+// Set sets key to val.
+func (t *TransientMap[K, V]) Set(key K, val V) *TransientMap[K, V] {
+	t.check()
+	root, added := hamtSet(t.owner, t.m.root, maphash.Comparable(hamtSeed, key), 0, key, val)
+	t.m.root = root
+	if added {
+		t.m.size++
+	}
+	return t
+}
+
+// Delete removes key.
+func (t *TransientMap[K, V]) Delete(key K) *TransientMap[K, V] {
+	t.check()
+	root, removed := hamtDelete(t.owner, t.m.root, maphash.Comparable(hamtSeed, key), 0, key)
+	if removed {
+		t.m.root = root
+		t.m.size--
+	}
+	return t
+}
+
+// Persistent freezes the transient into a PersistentMap.
+func (t *TransientMap[K, V]) Persistent() PersistentMap[K, V] {
+	t.check()
+	t.owner = nil
+	return t.m
+}
@@ -0,0 +1,152 @@
+package functional
+
+import (
+	"maps"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestPersistentMapModel applies random operations to a map, a transient
+// and a Go map, and checks that old versions never change.
+func TestPersistentMapModel(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	var (
+		m        PersistentMap[int, int]
+		model    = map[int]int{}
+		versions []PersistentMap[int, int]
+		snaps    []map[int]int
+	)
+	tm := m.Transient()
+
+	for step := range 20000 {
+		// A small key space makes overwrites and deletes of present keys common.
+		k, v := rng.IntN(3000), rng.Int()
+		if rng.IntN(3) == 0 {
+			m = m.Delete(k)
+			tm.Delete(k)
+			delete(model, k)
+		} else {
+			m = m.Set(k, v)
+			tm.Set(k, v)
+			model[k] = v
+		}
+		if step%1000 == 0 {
+			versions = append(versions, m)
+			snaps = append(snaps, maps.Clone(model))
+		}
+	}
+
+	if m.Len() != len(model) {
+		t.Fatalf("Len = %d, want %d", m.Len(), len(model))
+	}
+	for k := range 3000 {
+		got, ok := m.Get(k)
+		want, wantOK := model[k]
+		if got != want || ok != wantOK {
+			t.Fatalf("Get(%d) = %d, %v, want %d, %v", k, got, ok, want, wantOK)
+		}
+	}
+	if got := maps.Collect(m.All()); !maps.Equal(got, model) {
+		t.Fatal("All() differs from model")
+	}
+	if got := maps.Collect(tm.Persistent().All()); !maps.Equal(got, model) {
+		t.Fatal("transient differs from model")
+	}
+	for i, old := range versions {
+		if got := maps.Collect(old.All()); !maps.Equal(got, snaps[i]) || old.Len() != len(snaps[i]) {
+			t.Errorf("version %d changed after later updates", i)
+		}
+	}
+}
+
+// TestHAMTCollisions drives the trie functions with chosen hashes, since
+// real hash collisions cannot be produced on demand.
+func TestHAMTCollisions(t *testing.T) {
+	const (
+		same    = uint64(0xdeadbeef)
+		nearby  = same ^ 1<<63 // differs only in the last level
+		distant = same ^ 1     // differs at the root
+	)
+	tests := []struct {
+		name   string
+		hashes map[string]uint64
+	}{
+		{"full collision", map[string]uint64{"a": same, "b": same, "c": same}},
+		{"split at the last level", map[string]uint64{"a": same, "b": nearby, "c": same}},
+		{"split at the root", map[string]uint64{"a": same, "b": distant, "c": nearby}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var root *hnode[string, int]
+			for k, h := range tt.hashes {
+				var added bool
+				root, added = hamtSet(nil, root, h, 0, k, len(k))
+				if !added {
+					t.Fatalf("Set(%q) reported an existing key", k)
+				}
+			}
+			for k, h := range tt.hashes {
+				if v, ok := hamtGet(root, h, k); !ok || v != 1 {
+					t.Errorf("Get(%q) = %d, %v", k, v, ok)
+				}
+			}
+			if _, ok := hamtGet(root, same, "missing"); ok {
+				t.Error("found a missing key with a colliding hash")
+			}
+
+			for k, h := range tt.hashes {
+				var removed bool
+				root, removed = hamtDelete(nil, root, h, 0, k)
+				if !removed {
+					t.Fatalf("Delete(%q) found nothing", k)
+				}
+				if _, ok := hamtGet(root, h, k); ok {
+					t.Errorf("Get(%q) after Delete succeeded", k)
+				}
+			}
+			if root != nil {
+				t.Errorf("trie not empty after deleting every key: %+v", root)
+			}
+		})
+	}
+}
+
+func TestConfigOnPersistentMap(t *testing.T) {
+	c1 := NewConfig(map[string]string{"host": "localhost", "port": "8080"})
+	c2 := c1.WithSetting("port", "9090").WithoutSetting("host")
+	c3 := c2.WithSettings(map[string]string{"debug": "true", "host": "example.com"})
+
+	tests := []struct {
+		name string
+		c    Config
+		want map[string]string
+	}{
+		{"original", c1, map[string]string{"host": "localhost", "port": "8080"}},
+		{"updated", c2, map[string]string{"port": "9090"}},
+		{"batched", c3, map[string]string{"port": "9090", "debug": "true", "host": "example.com"}},
+		{"zero value", Config{}.WithSetting("a", "b"), map[string]string{"a": "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.GetAll(); !maps.Equal(got, tt.want) {
+				t.Errorf("GetAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if got := c2.Get("host"); got != "" {
+		t.Errorf("Get of removed key = %q", got)
+	}
+}
+
+func BenchmarkConfigWithSetting(b *testing.B) {
+	settings := make(map[string]string, 100_000)
+	for i := range 100_000 {
+		settings[string(rune('a'+i%26))+string(rune(i))] = "v"
+	}
+	c := NewConfig(settings)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = c.WithSetting("key", "value")
+	}
+}
@@ -0,0 +1,370 @@
+package functional
+
+import (
+	"fmt"
+	"iter"
+)
+
+// PersistentVector and PersistentMap are immutable collections that share
+// structure between versions.
+//
+// Why? Copy-on-write over a slice or map costs O(n) per update, which is
+// fine for a handful of items and ruinous for large state. A persistent
+// collection is a tree of small nodes; an update copies only the nodes on
+// the path to the change and shares everything else with the previous
+// version, so it costs O(log32 n), which is a few node copies even for
+// millions of items. Transients batch many updates: they mutate nodes
+// they created themselves in place and are frozen back into a persistent
+// value when done.
+
+// owner marks the nodes a transient may mutate in place. It must not be
+// zero-sized: distinct pointers to zero-sized values may compare equal.
+type owner struct{ _ byte }
+
+const (
+	vecBits  = 5
+	vecWidth = 1 << vecBits
+	vecMask  = vecWidth - 1
+)
+
+// vnode is a node of the vector trie: a branch with children or a leaf
+// with up to vecWidth values.
+type vnode[T any] struct {
+	owner    *owner
+	children []*vnode[T]
+	values   []T
+}
+
+// editable returns n if o owns it, and otherwise a copy owned by o.
+func (n *vnode[T]) editable(o *owner) *vnode[T] {
+	if o != nil && n.owner == o {
+		return n
+	}
+	c := &vnode[T]{owner: o}
+	if n.children != nil {
+		c.children = make([]*vnode[T], len(n.children), len(n.children)+1)
+		copy(c.children, n.children)
+	}
+	if n.values != nil {
+		c.values = make([]T, len(n.values))
+		copy(c.values, n.values)
+	}
+	return c
+}
+
+// PersistentVector is an immutable indexed sequence: a 32-way trie of
+// values plus a tail of up to 32 values, so Append, Set, Pop and Get are
+// O(log32 n). The zero value is an empty vector.
+type PersistentVector[T any] struct {
+	size  int
+	shift uint
+	root  *vnode[T]
+	tail  []T
+}
+
+// VectorOf returns a vector holding items.
+func VectorOf[T any](items ...T) PersistentVector[T] {
+	var v PersistentVector[T]
+	t := v.Transient()
+	for _, item := range items {
+		t.Append(item)
+	}
+	return t.Persistent()
+}
+
+// Len returns the number of values.
+func (v PersistentVector[T]) Len() int {
+	return v.size
+}
+
+func (v PersistentVector[T]) tailOffset() int {
+	if v.size < vecWidth {
+		return 0
+	}
+	return ((v.size - 1) >> vecBits) << vecBits
+}
+
+func (v PersistentVector[T]) checkIndex(i int) {
+	if i < 0 || i >= v.size {
+		panic(fmt.Sprintf("functional: index %d out of range [0:%d]", i, v.size))
+	}
+}
+
+// leafFor returns the leaf values holding index i.
+func (v PersistentVector[T]) leafFor(i int) []T {
+	if i >= v.tailOffset() {
+		return v.tail
+	}
+	n := v.root
+	for level := v.shift; level > 0; level -= vecBits {
+		n = n.children[(i>>level)&vecMask]
+	}
+	return n.values
+}
+
+// Get returns the value at index i. It panics if i is out of range.
+func (v PersistentVector[T]) Get(i int) T {
+	v.checkIndex(i)
+	return v.leafFor(i)[i&vecMask]
+}
+
+// Append returns a vector with x added at the end.
+func (v PersistentVector[T]) Append(x T) PersistentVector[T] {
+	if v.size-v.tailOffset() < vecWidth {
+		tail := make([]T, len(v.tail)+1)
+		copy(tail, v.tail)
+		tail[len(v.tail)] = x
+		v.tail = tail
+		v.size++
+		return v
+	}
+	v.root, v.shift = v.pushTail(nil)
+	v.tail = []T{x}
+	v.size++
+	return v
+}
+
+// Set returns a vector with the value at index i replaced by x. It
+// panics if i is out of range.
+func (v PersistentVector[T]) Set(i int, x T) PersistentVector[T] {
+	v.checkIndex(i)
+	if i >= v.tailOffset() {
+		tail := make([]T, len(v.tail))
+		copy(tail, v.tail)
+		tail[i&vecMask] = x
+		v.tail = tail
+		return v
+	}
+	v.root = vecAssoc(nil, v.shift, v.root, i, x)
+	return v
+}
+
+// Pop returns a vector without its last value. It panics if v is empty.
+func (v PersistentVector[T]) Pop() PersistentVector[T] {
+	v.checkIndex(0)
+	return v.pop(nil)
+}
+
+// Delete returns a vector with the value at index i removed, shifting
+// later values down. It panics if i is out of range.
+//
+// A 32-way trie cannot close a gap in the middle, so Delete rewrites the
+// values after i: it is cheap near the end and O(n) at the front.
+func (v PersistentVector[T]) Delete(i int) PersistentVector[T] {
+	v.checkIndex(i)
+	if i == v.size-1 {
+		return v.pop(nil)
+	}
+	t := v.Transient()
+	for j := i; j < v.size-1; j++ {
+		t.Set(j, v.Get(j+1))
+	}
+	return t.Pop().Persistent()
+}
+
+// All returns an iterator over indexes and values, in order.
+func (v PersistentVector[T]) All() iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for base := 0; base < v.size; base += vecWidth {
+			for j, x := range v.leafFor(base) {
+				if !yield(base+j, x) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// ToSlice returns the values as a new slice.
+func (v PersistentVector[T]) ToSlice() []T {
+	out := make([]T, 0, v.size)
+	for _, x := range v.All() {
+		out = append(out, x)
+	}
+	return out
+}
+
+// Transient returns a mutable copy of v for batch updates.
+func (v PersistentVector[T]) Transient() *TransientVector[T] {
+	tail := make([]T, len(v.tail), vecWidth)
+	copy(tail, v.tail)
+	v.tail = tail
+	return &TransientVector[T]{v: v, owner: &owner{}}
+}
+
+// pushTail moves the full tail into the trie, adding a level when the
+// trie is full, and returns the new root and shift. Nodes are created
+// for, and edited in place if owned by, o.
+func (v PersistentVector[T]) pushTail(o *owner) (*vnode[T], uint) {
+	leaf := &vnode[T]{owner: o, values: v.tail}
+	switch {
+	case v.root == nil:
+		return &vnode[T]{owner: o, children: []*vnode[T]{leaf}}, vecBits
+	case v.size>>vecBits > 1<<v.shift:
+		root := &vnode[T]{owner: o, children: []*vnode[T]{v.root, vecPath(o, v.shift, leaf)}}
+		return root, v.shift + vecBits
+	default:
+		return vecPushTail(o, v.size, v.shift, v.root, leaf), v.shift
+	}
+}
+
+// pop removes the last value. The caller has checked v is not empty.
+func (v PersistentVector[T]) pop(o *owner) PersistentVector[T] {
+	if v.size == 1 {
+		return PersistentVector[T]{}
+	}
+	if v.size-v.tailOffset() > 1 {
+		if o == nil {
+			tail := make([]T, len(v.tail)-1)
+			copy(tail, v.tail)
+			v.tail = tail
+		} else {
+			var zero T
+			v.tail[len(v.tail)-1] = zero
+			v.tail = v.tail[:len(v.tail)-1]
+		}
+		v.size--
+		return v
+	}
+
+	// The tail holds only the last value: the last leaf becomes the tail.
+	newTail := v.leafFor(v.size - 2)
+	if o != nil {
+		newTail = append(make([]T, 0, vecWidth), newTail...)
+	}
+	root := vecPopTail(o, v.size, v.shift, v.root)
+	shift := v.shift
+	switch {
+	case root == nil:
+		shift = 0
+	case shift > vecBits && len(root.children) == 1:
+		root = root.children[0]
+		shift -= vecBits
+	}
+	return PersistentVector[T]{size: v.size - 1, shift: shift, root: root, tail: newTail}
+}
+
+// vecPath wraps leaf in single-child branches up to level.
+func vecPath[T any](o *owner, level uint, leaf *vnode[T]) *vnode[T] {
+	if level == 0 {
+		return leaf
+	}
+	return &vnode[T]{owner: o, children: []*vnode[T]{vecPath(o, level-vecBits, leaf)}}
+}
+
+func vecPushTail[T any](o *owner, size int, level uint, parent, leaf *vnode[T]) *vnode[T] {
+	n := parent.editable(o)
+	idx := ((size - 1) >> level) & vecMask
+	switch {
+	case level == vecBits:
+		n.children = append(n.children, leaf)
+	case idx < len(n.children):
+		n.children[idx] = vecPushTail(o, size, level-vecBits, n.children[idx], leaf)
+	default:
+		n.children = append(n.children, vecPath(o, level-vecBits, leaf))
+	}
+	return n
+}
+
+func vecAssoc[T any](o *owner, level uint, n *vnode[T], i int, x T) *vnode[T] {
+	n = n.editable(o)
+	if level == 0 {
+		n.values[i&vecMask] = x
+		return n
+	}
+	idx := (i >> level) & vecMask
+	n.children[idx] = vecAssoc(o, level-vecBits, n.children[idx], i, x)
+	return n
+}
+
+// vecPopTail removes the last leaf, returning nil when n becomes empty.
+func vecPopTail[T any](o *owner, size int, level uint, n *vnode[T]) *vnode[T] {
+	idx := ((size - 2) >> level) & vecMask
+	if level > vecBits {
+		child := vecPopTail(o, size, level-vecBits, n.children[idx])
+		if child == nil && idx == 0 {
+			return nil
+		}
+		n = n.editable(o)
+		if child == nil {
+			n.children = n.children[:idx]
+		} else {
+			n.children[idx] = child
+		}
+		return n
+	}
+	if idx == 0 {
+		return nil
+	}
+	n = n.editable(o)
+	n.children = n.children[:idx]
+	return n
+}
+
+// TransientVector is a mutable vector for building or updating a
+// PersistentVector in bulk. It is not safe for concurrent use, and must
+// not be used after Persistent.
+type TransientVector[T any] struct {
+	v     PersistentVector[T]
+	owner *owner
+}
+
+func (t *TransientVector[T]) check() {
+	if t.owner == nil {
+		panic("functional: transient vector used after Persistent")
+	}
+}
+
+// Len returns the number of values.
+func (t *TransientVector[T]) Len() int {
+	return t.v.size
+}
+
+// Get returns the value at index i. It panics if i is out of range.
+func (t *TransientVector[T]) Get(i int) T {
+	return t.v.Get(i)
+}
+
+// Append adds x at the end.
+func (t *TransientVector[T]) Append(x T) *TransientVector[T] {
+	t.check()
+	if t.v.size-t.v.tailOffset() < vecWidth {
+		t.v.tail = append(t.v.tail, x)
+		t.v.size++
+		return t
+	}
+	t.v.root, t.v.shift = t.v.pushTail(t.owner)
+	t.v.tail = append(make([]T, 0, vecWidth), x)
+	t.v.size++
+	return t
+}
+
+// Set replaces the value at index i. It panics if i is out of range.
+func (t *TransientVector[T]) Set(i int, x T) *TransientVector[T] {
+	t.check()
+	t.v.checkIndex(i)
+	if i >= t.v.tailOffset() {
+		t.v.tail[i&vecMask] = x
+		return t
+	}
+	t.v.root = vecAssoc(t.owner, t.v.shift, t.v.root, i, x)
+	return t
+}
+
+// Pop removes the last value. It panics if the vector is empty.
+func (t *TransientVector[T]) Pop() *TransientVector[T] {
+	t.check()
+	t.v.checkIndex(0)
+	t.v = t.v.pop(t.owner)
+	if t.v.tail == nil {
+		t.v.tail = make([]T, 0, vecWidth)
+	}
+	return t
+}
+
+// Persistent freezes the transient into a PersistentVector.
+func (t *TransientVector[T]) Persistent() PersistentVector[T] {
+	t.check()
+	t.owner = nil
+	return t.v
+}
@@ -0,0 +1,155 @@
+package functional
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestPersistentVectorAppendAndGet(t *testing.T) {
+	// Sizes around the tail and level boundaries of a 32-way trie.
+	for _, n := range []int{0, 1, 31, 32, 33, 64, 1024, 1056, 1057, 33 * 1024, 40000} {
+		v := PersistentVector[int]{}
+		for i := range n {
+			v = v.Append(i)
+		}
+		if v.Len() != n {
+			t.Fatalf("n=%d: Len = %d", n, v.Len())
+		}
+		for i := range n {
+			if got := v.Get(i); got != i {
+				t.Fatalf("n=%d: Get(%d) = %d", n, i, got)
+			}
+		}
+		if got, want := VectorOf(ints(n)...).ToSlice(), ints(n); !slices.Equal(got, want) {
+			t.Fatalf("n=%d: VectorOf round trip differs", n)
+		}
+	}
+}
+
+func TestPersistentVectorPopToEmpty(t *testing.T) {
+	for _, n := range []int{1, 32, 33, 1057, 3000} {
+		v := VectorOf(ints(n)...)
+		tv := v.Transient()
+		for i := n - 1; i >= 0; i-- {
+			if got := v.Get(i); got != i+1 {
+				t.Fatalf("n=%d: Get(%d) = %d before pop", n, i, got)
+			}
+			v = v.Pop()
+			tv.Pop()
+			if v.Len() != i || tv.Len() != i {
+				t.Fatalf("n=%d: Len = %d, transient %d, want %d", n, v.Len(), tv.Len(), i)
+			}
+		}
+		// Both must be usable again after emptying.
+		if got := v.Append(7).Get(0); got != 7 {
+			t.Errorf("n=%d: Append after emptying: Get(0) = %d", n, got)
+		}
+		if got := tv.Append(8).Persistent().Get(0); got != 8 {
+			t.Errorf("n=%d: transient Append after emptying: Get(0) = %d", n, got)
+		}
+	}
+}
+
+// TestPersistentVectorModel applies random operations to a vector, a
+// transient and a slice, and checks that old versions never change.
+func TestPersistentVectorModel(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	var (
+		v        PersistentVector[int]
+		model    []int
+		versions []PersistentVector[int]
+		snaps    [][]int
+	)
+	tv := v.Transient()
+
+	for step := range 5000 {
+		switch op := rng.IntN(10); {
+		case op < 5 || len(model) == 0:
+			x := rng.Int()
+			v, model = v.Append(x), append(model, x)
+			tv.Append(x)
+		case op < 7:
+			i, x := rng.IntN(len(model)), rng.Int()
+			v, model[i] = v.Set(i, x), x
+			tv.Set(i, x)
+		case op < 9:
+			v, model = v.Pop(), model[:len(model)-1]
+			tv.Pop()
+		default:
+			i := rng.IntN(len(model))
+			v, model = v.Delete(i), slices.Delete(model, i, i+1)
+			tv = v.Transient()
+		}
+		if step%250 == 0 {
+			versions = append(versions, v)
+			snaps = append(snaps, slices.Clone(model))
+		}
+	}
+
+	if got := v.ToSlice(); !slices.Equal(got, model) {
+		t.Fatalf("vector diverged from model: len %d vs %d", len(got), len(model))
+	}
+	if got := tv.Persistent().ToSlice(); !slices.Equal(got, model) {
+		t.Fatalf("transient diverged from model: len %d vs %d", len(got), len(model))
+	}
+	for i, old := range versions {
+		if got := old.ToSlice(); !slices.Equal(got, snaps[i]) {
+			t.Errorf("version %d changed after later updates", i)
+		}
+	}
+}
+
+func TestPersistentVectorPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"get out of range", func() { VectorOf(1, 2).Get(2) }},
+		{"negative index", func() { VectorOf(1, 2).Set(-1, 0) }},
+		{"pop empty", func() { PersistentVector[int]{}.Pop() }},
+		{"transient after persistent", func() {
+			tv := VectorOf(1).Transient()
+			tv.Persistent()
+			tv.Append(2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestImmutableListOnVector(t *testing.T) {
+	l1 := NewImmutableList(ints(100)...)
+	l2 := l1.Remove(0).Set(0, -1).Add(100)
+
+	if l1.Size() != 100 || l1.Get(0) != 1 || l1.Get(1) != 2 {
+		t.Errorf("original list changed: size %d, [0]=%d, [1]=%d", l1.Size(), l1.Get(0), l1.Get(1))
+	}
+	if l2.Size() != 100 || l2.Get(0) != -1 || l2.Get(99) != 100 {
+		t.Errorf("l2: size %d, [0]=%d, [99]=%d", l2.Size(), l2.Get(0), l2.Get(99))
+	}
+	if got := l1.Remove(100); got.Size() != 100 {
+		t.Errorf("Remove out of range changed size to %d", got.Size())
+	}
+
+	var empty ImmutableList[string]
+	if got := empty.Add("a").ToSlice(); !slices.Equal(got, []string{"a"}) {
+		t.Errorf("zero list Add = %v", got)
+	}
+}
+
+func BenchmarkImmutableListAdd(b *testing.B) {
+	l := NewImmutableList(ints(100_000)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = l.Add(i)
+	}
+}
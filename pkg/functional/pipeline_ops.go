@@ -0,0 +1,209 @@
+package functional
+
+import (
+	"iter"
+	"slices"
+)
+
+// Pipeline operators beyond the basics in pipelines.go.
+//
+// Why are some of these functions instead of methods? Go methods cannot
+// declare their own type parameters, so any operator that changes the
+// element type (MapTo, FlatMap, Window, Chunk, Scan) or needs a stricter
+// constraint than the pipeline's (Distinct needs comparable) is a
+// generic function taking the pipeline as its first argument:
+//
+//	lengths := functional.MapTo(functional.NewPipeline(words), func(s string) int { return len(s) })
+//
+// All operators except the terminal ones stay lazy: nothing runs until
+// the pipeline is iterated, and stopping early stops the source too.
+
+// Seq returns the pipeline as an iterator, for use with range or with
+// functions from the iter, slices and maps packages.
+func (p *Pipeline[T]) Seq() iter.Seq[T] {
+	return p.source
+}
+
+// MapTo transforms each item into a value of a different type.
+func MapTo[T, U any](p *Pipeline[T], mapper func(T) U) *Pipeline[U] {
+	return FromSeq(func(yield func(U) bool) {
+		for item := range p.source {
+			if !yield(mapper(item)) {
+				return
+			}
+		}
+	})
+}
+
+// FlatMap replaces each item with the items of the sequence it maps to.
+func FlatMap[T, U any](p *Pipeline[T], mapper func(T) iter.Seq[U]) *Pipeline[U] {
+	return FromSeq(func(yield func(U) bool) {
+		for item := range p.source {
+			for inner := range mapper(item) {
+				if !yield(inner) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// Distinct drops items that were already seen, keeping first occurrences.
+func Distinct[T comparable](p *Pipeline[T]) *Pipeline[T] {
+	return DistinctBy(p, func(item T) T { return item })
+}
+
+// DistinctBy drops items whose key was already seen.
+func DistinctBy[T any, K comparable](p *Pipeline[T], key func(T) K) *Pipeline[T] {
+	return FromSeq(func(yield func(T) bool) {
+		seen := make(map[K]struct{})
+		for item := range p.source {
+			k := key(item)
+			if _, dup := seen[k]; dup {
+				continue
+			}
+			seen[k] = struct{}{}
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// SortedBy orders items with cmp, which follows the slices.SortFunc
+// convention. The sort is stable. Sorting needs every item, so the source
+// is drained when iteration starts; the operator itself is still deferred.
+func (p *Pipeline[T]) SortedBy(cmp func(a, b T) int) *Pipeline[T] {
+	return FromSeq(func(yield func(T) bool) {
+		items := Collect(p.source)
+		slices.SortStableFunc(items, cmp)
+		for _, item := range items {
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// Window yields overlapping windows of size consecutive items, advancing
+// one item at a time. Each window is a fresh slice. Sources shorter than
+// size yield nothing.
+func Window[T any](p *Pipeline[T], size int) *Pipeline[[]T] {
+	return FromSeq(func(yield func([]T) bool) {
+		if size <= 0 {
+			return
+		}
+		window := make([]T, 0, size)
+		for item := range p.source {
+			if len(window) == size {
+				window = window[1:]
+			}
+			window = append(window, item)
+			if len(window) == size && !yield(slices.Clone(window)) {
+				return
+			}
+		}
+	})
+}
+
+// Chunk splits items into consecutive slices of size items. The last
+// chunk holds the remainder and may be shorter.
+func Chunk[T any](p *Pipeline[T], size int) *Pipeline[[]T] {
+	return FromSeq(func(yield func([]T) bool) {
+		if size <= 0 {
+			return
+		}
+		chunk := make([]T, 0, size)
+		for item := range p.source {
+			chunk = append(chunk, item)
+			if len(chunk) == size {
+				if !yield(chunk) {
+					return
+				}
+				chunk = make([]T, 0, size)
+			}
+		}
+		if len(chunk) > 0 {
+			yield(chunk)
+		}
+	})
+}
+
+// Scan yields the running accumulation of items, like Reduce but
+// emitting every intermediate result.
+func Scan[T, A any](p *Pipeline[T], initial A, fn func(A, T) A) *Pipeline[A] {
+	return FromSeq(func(yield func(A) bool) {
+		acc := initial
+		for item := range p.source {
+			acc = fn(acc, item)
+			if !yield(acc) {
+				return
+			}
+		}
+	})
+}
+
+// TakeWhile yields items until predicate first returns false.
+func (p *Pipeline[T]) TakeWhile(predicate func(T) bool) *Pipeline[T] {
+	return FromSeq(func(yield func(T) bool) {
+		for item := range p.source {
+			if !predicate(item) || !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// DropWhile skips items until predicate first returns false, then yields
+// everything that follows.
+func (p *Pipeline[T]) DropWhile(predicate func(T) bool) *Pipeline[T] {
+	return FromSeq(func(yield func(T) bool) {
+		dropping := true
+		for item := range p.source {
+			if dropping && predicate(item) {
+				continue
+			}
+			dropping = false
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// Peek calls fn on each item as it flows through, without changing it.
+// Useful for logging and debugging.
+func (p *Pipeline[T]) Peek(fn func(T)) *Pipeline[T] {
+	return FromSeq(func(yield func(T) bool) {
+		for item := range p.source {
+			fn(item)
+			if !yield(item) {
+				return
+			}
+		}
+	})
+}
+
+// GroupBy collects items into slices keyed by key, preserving order
+// within each group. It is a terminal operation.
+func GroupBy[T any, K comparable](p *Pipeline[T], key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for item := range p.source {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	}
+	return groups
+}
+
+// Partition splits items into those matching predicate and the rest.
+// It is a terminal operation.
+func (p *Pipeline[T]) Partition(predicate func(T) bool) (matched, rest []T) {
+	for item := range p.source {
+		if predicate(item) {
+			matched = append(matched, item)
+		} else {
+			rest = append(rest, item)
+		}
+	}
+	return matched, rest
+}
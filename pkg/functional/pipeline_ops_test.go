@@ -0,0 +1,111 @@
+package functional
+
+import (
+	"iter"
+	"slices"
+	"testing"
+)
+
+func ints(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i + 1
+	}
+	return out
+}
+
+func TestPipelineOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		got  func() []int
+		want []int
+	}{
+		{"MapTo", func() []int {
+			return MapTo(NewPipeline([]string{"a", "bbb", "cc"}), func(s string) int { return len(s) }).Collect()
+		}, []int{1, 3, 2}},
+		{"FlatMap", func() []int {
+			return FlatMap(NewPipeline([]int{1, 2, 3}), func(n int) iter.Seq[int] {
+				return Generator(slices.Repeat([]int{n}, n))
+			}).Collect()
+		}, []int{1, 2, 2, 3, 3, 3}},
+		{"Distinct", func() []int {
+			return Distinct(NewPipeline([]int{3, 1, 3, 2, 1})).Collect()
+		}, []int{3, 1, 2}},
+		{"DistinctBy", func() []int {
+			return DistinctBy(NewPipeline(ints(6)), func(n int) int { return n % 3 }).Collect()
+		}, []int{1, 2, 3}},
+		{"SortedBy is stable", func() []int {
+			return NewPipeline([]int{21, 12, 11, 22}).SortedBy(func(a, b int) int { return a%10 - b%10 }).Collect()
+		}, []int{21, 11, 12, 22}},
+		{"Scan", func() []int {
+			return Scan(NewPipeline(ints(4)), 0, func(acc, n int) int { return acc + n }).Collect()
+		}, []int{1, 3, 6, 10}},
+		{"TakeWhile", func() []int {
+			return NewPipeline([]int{1, 2, 5, 1}).TakeWhile(func(n int) bool { return n < 3 }).Collect()
+		}, []int{1, 2}},
+		{"DropWhile", func() []int {
+			return NewPipeline([]int{1, 2, 5, 1}).DropWhile(func(n int) bool { return n < 3 }).Collect()
+		}, []int{5, 1}},
+		{"Window flattened", func() []int {
+			return slices.Concat(Window(NewPipeline(ints(4)), 3).Collect()...)
+		}, []int{1, 2, 3, 2, 3, 4}},
+		{"Window longer than source", func() []int {
+			return slices.Concat(Window(NewPipeline(ints(2)), 3).Collect()...)
+		}, nil},
+		{"Chunk sizes", func() []int {
+			return MapTo(Chunk(NewPipeline(ints(5)), 2), func(c []int) int { return len(c) }).Collect()
+		}, []int{2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.got(); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineIsLazy(t *testing.T) {
+	pulled := 0
+	source := FromSeq(func(yield func(int) bool) {
+		for i := 1; ; i++ {
+			pulled++
+			if !yield(i) {
+				return
+			}
+		}
+	})
+
+	var peeked []int
+	p := Chunk(Distinct(source.Peek(func(n int) { peeked = append(peeked, n) })), 2)
+	if pulled != 0 {
+		t.Fatalf("building the pipeline pulled %d items", pulled)
+	}
+
+	first, ok := p.First()
+	if !ok || !slices.Equal(first, []int{1, 2}) {
+		t.Fatalf("First() = %v, %v; want [1 2], true", first, ok)
+	}
+	if pulled != 2 || !slices.Equal(peeked, []int{1, 2}) {
+		t.Errorf("pulled %d items, peeked %v; want 2 and [1 2]", pulled, peeked)
+	}
+}
+
+func TestFirstOnEmptyPipeline(t *testing.T) {
+	if v, ok := NewPipeline([]int{}).First(); ok || v != 0 {
+		t.Errorf("First() = %v, %v; want 0, false", v, ok)
+	}
+}
+
+func TestTerminalGrouping(t *testing.T) {
+	evens, odds := NewPipeline(ints(5)).Partition(func(n int) bool { return n%2 == 0 })
+	if !slices.Equal(evens, []int{2, 4}) || !slices.Equal(odds, []int{1, 3, 5}) {
+		t.Errorf("Partition = %v, %v", evens, odds)
+	}
+
+	groups := GroupBy(NewPipeline([]string{"go", "rust", "c", "zig", "java"}), func(s string) int { return len(s) })
+	if !slices.Equal(groups[4], []string{"rust", "java"}) || len(groups) != 4 {
+		t.Errorf("GroupBy = %v", groups)
+	}
+}
@@ -1,6 +1,8 @@
 package functional
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"iter"
 	"strings"
@@ -189,13 +191,14 @@ func (p *Pipeline[T]) Count() int {
 	return count
 }
 
-// First returns the first item or zero value.
-func (p *Pipeline[T]) First() T {
+// First returns the first item. The boolean is false if the pipeline is
+// empty, so an empty pipeline is distinguishable from a zero first item.
+func (p *Pipeline[T]) First() (T, bool) {
 	for item := range p.source {
-		return item
+		return item, true
 	}
 	var zero T
-	return zero
+	return zero, false
 }
 
 // ExamplePipelines demonstrates iterator-based pipelines.
@@ -269,4 +272,52 @@ func ExamplePipelines() {
 		Count()
 
 	fmt.Printf("Count of numbers > 5: %d\n", count)
+
+	// First distinguishes "empty" from a zero value
+	if first, ok := NewPipeline(numbers).Filter(func(n int) bool { return n > 100 }).First(); !ok {
+		fmt.Println("First number > 100: none")
+	} else {
+		fmt.Printf("First number > 100: %d\n", first)
+	}
+
+	// Type-changing operators are functions: MapTo, Window, Chunk, Scan
+	lengths := MapTo(NewPipeline(words), func(s string) int { return len(s) }).Collect()
+	fmt.Printf("\nWord lengths: %v\n", lengths)
+
+	fmt.Printf("Windows of 3: %v\n", Window(NewPipeline([]int{1, 2, 3, 4, 5}), 3).Collect())
+	fmt.Printf("Chunks of 4: %v\n", Chunk(NewPipeline(numbers), 4).Collect())
+	fmt.Printf("Running total: %v\n",
+		Scan(NewPipeline([]int{1, 2, 3, 4}), 0, func(acc, n int) int { return acc + n }).Collect())
+
+	// Distinct, SortedBy, TakeWhile
+	unique := Distinct(NewPipeline([]int{3, 1, 3, 2, 1})).
+		SortedBy(func(a, b int) int { return a - b }).
+		Collect()
+	fmt.Printf("Distinct sorted: %v\n", unique)
+
+	prefix := NewPipeline(numbers).TakeWhile(func(n int) bool { return n < 4 }).Collect()
+	fmt.Printf("TakeWhile < 4: %v\n", prefix)
+
+	// Terminal grouping operations
+	evens, odds := NewPipeline(numbers).Partition(func(n int) bool { return n%2 == 0 })
+	fmt.Printf("Partition: evens=%v odds=%v\n", evens, odds)
+
+	byLength := GroupBy(NewPipeline(words), func(s string) int { return len(s) })
+	fmt.Printf("Words with 5 letters: %v\n", byLength[5])
+
+	// Parallel stages keep input order by default and stop at the first error
+	ctx := context.Background()
+	squares := NewPipeline(numbers).ParallelMap(ctx, 4, func(_ context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+	fmt.Printf("\nParallel squares: %v\n", squares.Collect())
+
+	parsed := NewPipeline([]string{"1", "2", "x", "4"}).ParallelFilter(ctx, 2, func(_ context.Context, s string) (bool, error) {
+		if s < "0" || s > "9" {
+			return false, errors.New("not a digit: " + s)
+		}
+		return true, nil
+	})
+	parsed.Collect()
+	fmt.Printf("Parallel filter error: %v\n", parsed.Err())
 }
@@ -0,0 +1,367 @@
+package functional
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+// Debounce, Throttle and Coalesce limit how often a function runs in time.
+//
+// Why? Event sources such as key presses, window resizes and file
+// watchers fire in bursts, while the work they trigger (a search, a
+// re-layout, a rebuild) should run once per burst or at most once per
+// interval. All three read time from an idioms.Clock, so tests drive them
+// with a ManualClock instead of sleeping.
+//
+// fn runs either on the caller's goroutine (leading calls, Flush) or on a
+// timer goroutine, but never concurrently with itself. With a ManualClock
+// the timer calls run inside Advance.
+
+// TimingOption configures Debounce, Throttle and Coalesce.
+type TimingOption func(*timingConfig)
+
+type timingConfig struct {
+	leading  bool
+	trailing bool
+	maxBatch int
+	clock    idioms.Clock
+}
+
+func newTimingConfig(leading, trailing bool, opts []TimingOption) timingConfig {
+	cfg := timingConfig{leading: leading, trailing: trailing, clock: idioms.SystemClock()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLeading controls whether fn runs immediately at the start of a
+// burst. Debounce defaults to false, Throttle to true.
+func WithLeading(on bool) TimingOption {
+	return func(c *timingConfig) { c.leading = on }
+}
+
+// WithTrailing controls whether fn runs with the latest value once a
+// burst or interval ends. Both Debounce and Throttle default to true.
+func WithTrailing(on bool) TimingOption {
+	return func(c *timingConfig) { c.trailing = on }
+}
+
+// WithMaxBatch makes Coalesce deliver a batch as soon as it holds n
+// items, without waiting for the window to close.
+func WithMaxBatch(n int) TimingOption {
+	return func(c *timingConfig) { c.maxBatch = n }
+}
+
+// WithClock sets the clock used for timing. The default is the system clock.
+func WithClock(clock idioms.Clock) TimingOption {
+	return func(c *timingConfig) { c.clock = clock }
+}
+
+// clockTimer is a stoppable one-shot timer.
+type clockTimer struct {
+	stop func() bool
+}
+
+// afterFunc calls fire(t) once d has passed on clock, unless t is stopped
+// first. Clocks that implement idioms.AfterFuncer run fire themselves (a
+// ManualClock does so inside Advance); for the others a goroutine waits
+// on After, and stopping abandons the channel.
+func afterFunc(clock idioms.Clock, d time.Duration, fire func(*clockTimer)) *clockTimer {
+	t := &clockTimer{}
+	if c, ok := clock.(idioms.AfterFuncer); ok {
+		t.stop = c.AfterFunc(d, func() { fire(t) })
+		return t
+	}
+
+	stop := make(chan struct{})
+	t.stop = func() bool { close(stop); return true }
+	ch := clock.After(d)
+	go func() {
+		select {
+		case <-ch:
+			fire(t)
+		case <-stop:
+		}
+	}()
+	return t
+}
+
+// timed holds the state shared by the three wrappers: the pending value
+// and the timer that will deliver it.
+type timed[S any] struct {
+	fn  func(S)
+	cfg timingConfig
+
+	call    sync.Mutex // serializes calls to fn
+	mu      sync.Mutex
+	timer   *clockTimer
+	state   S
+	pending bool
+}
+
+func (t *timed[S]) invoke(s S) {
+	t.call.Lock()
+	defer t.call.Unlock()
+	t.fn(s)
+}
+
+// take returns and clears the pending state. The caller holds t.mu.
+func (t *timed[S]) take() (S, bool) {
+	s, ok := t.state, t.pending
+	var zero S
+	t.state, t.pending = zero, false
+	return s, ok
+}
+
+// stopTimer stops the current timer, if any. The caller holds t.mu.
+func (t *timed[S]) stopTimer() {
+	if t.timer != nil {
+		t.timer.stop()
+		t.timer = nil
+	}
+}
+
+// Cancel drops the pending call, if any, and stops the timer.
+func (t *timed[S]) Cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopTimer()
+	t.take()
+}
+
+// Flush runs the pending call now, if there is one, and stops the timer.
+func (t *timed[S]) Flush() {
+	t.mu.Lock()
+	t.stopTimer()
+	s, ok := t.take()
+	t.mu.Unlock()
+	if ok {
+		t.invoke(s)
+	}
+}
+
+// Pending reports whether a call is waiting for the timer.
+func (t *timed[S]) Pending() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pending
+}
+
+// Debouncer delays calls to fn until they stop arriving. Create one with
+// Debounce.
+type Debouncer[T any] struct {
+	timed[T]
+	wait time.Duration
+}
+
+// Debounce returns a Debouncer that runs fn once calls have paused for
+// wait, with the latest value. WithLeading(true) also runs fn on the
+// first call of a burst; a burst of one call then runs fn only once.
+func Debounce[T any](fn func(T), wait time.Duration, opts ...TimingOption) *Debouncer[T] {
+	return &Debouncer[T]{
+		timed: timed[T]{fn: fn, cfg: newTimingConfig(false, true, opts)},
+		wait:  wait,
+	}
+}
+
+// Call records v and restarts the wait.
+func (d *Debouncer[T]) Call(v T) {
+	d.mu.Lock()
+	leading := d.timer == nil && d.cfg.leading
+	d.stopTimer()
+	d.timer = afterFunc(d.cfg.clock, d.wait, d.expire)
+	if !leading && d.cfg.trailing {
+		d.state, d.pending = v, true
+	}
+	d.mu.Unlock()
+
+	if leading {
+		d.invoke(v)
+	}
+}
+
+func (d *Debouncer[T]) expire(t *clockTimer) {
+	d.mu.Lock()
+	if d.timer != t {
+		d.mu.Unlock()
+		return
+	}
+	d.timer = nil
+	v, ok := d.take()
+	d.mu.Unlock()
+	if ok {
+		d.invoke(v)
+	}
+}
+
+// Throttler runs fn at most once per interval. Create one with Throttle.
+type Throttler[T any] struct {
+	timed[T]
+	interval time.Duration
+}
+
+// Throttle returns a Throttler that runs fn at most once per interval.
+// By default the first call runs immediately and the latest call made
+// during the interval runs when it ends, starting the next interval.
+func Throttle[T any](fn func(T), interval time.Duration, opts ...TimingOption) *Throttler[T] {
+	return &Throttler[T]{
+		timed:    timed[T]{fn: fn, cfg: newTimingConfig(true, true, opts)},
+		interval: interval,
+	}
+}
+
+// Call runs fn with v now if no interval is running, and otherwise keeps
+// v as the trailing value.
+func (t *Throttler[T]) Call(v T) {
+	t.mu.Lock()
+	if t.timer == nil {
+		t.timer = afterFunc(t.cfg.clock, t.interval, t.expire)
+		if t.cfg.leading {
+			t.mu.Unlock()
+			t.invoke(v)
+			return
+		}
+	}
+	if t.cfg.trailing {
+		t.state, t.pending = v, true
+	}
+	t.mu.Unlock()
+}
+
+// Flush runs the pending call now, if there is one. Unlike the other
+// wrappers it leaves the timer running: the interval in progress still
+// ends when it would have, so a call made after the flush waits for it
+// rather than starting a new interval at once.
+func (t *Throttler[T]) Flush() {
+	t.mu.Lock()
+	v, ok := t.take()
+	t.mu.Unlock()
+	if ok {
+		t.invoke(v)
+	}
+}
+
+func (t *Throttler[T]) expire(tm *clockTimer) {
+	t.mu.Lock()
+	if t.timer != tm {
+		t.mu.Unlock()
+		return
+	}
+	t.timer = nil
+	v, ok := t.take()
+	if ok {
+		// The trailing call opens the next interval.
+		t.timer = afterFunc(t.cfg.clock, t.interval, t.expire)
+	}
+	t.mu.Unlock()
+	if ok {
+		t.invoke(v)
+	}
+}
+
+// Coalescer batches calls into one call of fn. Create one with Coalesce.
+type Coalescer[T any] struct {
+	timed[[]T]
+	window time.Duration
+}
+
+// Coalesce returns a Coalescer that collects the values of all calls
+// made within window of the first one and passes them to fn together.
+func Coalesce[T any](fn func([]T), window time.Duration, opts ...TimingOption) *Coalescer[T] {
+	return &Coalescer[T]{
+		timed:  timed[[]T]{fn: fn, cfg: newTimingConfig(false, true, opts)},
+		window: window,
+	}
+}
+
+// Call adds v to the current batch, opening a window if none is open.
+func (c *Coalescer[T]) Call(v T) {
+	c.mu.Lock()
+	if c.timer == nil {
+		c.timer = afterFunc(c.cfg.clock, c.window, c.expire)
+	}
+	c.state, c.pending = append(c.state, v), true
+	if c.cfg.maxBatch <= 0 || len(c.state) < c.cfg.maxBatch {
+		c.mu.Unlock()
+		return
+	}
+	c.stopTimer()
+	batch, _ := c.take()
+	c.mu.Unlock()
+	c.invoke(batch)
+}
+
+func (c *Coalescer[T]) expire(t *clockTimer) {
+	c.mu.Lock()
+	if c.timer != t {
+		c.mu.Unlock()
+		return
+	}
+	c.timer = nil
+	batch, ok := c.take()
+	c.mu.Unlock()
+	if ok {
+		c.invoke(batch)
+	}
+}
+
+// ExampleTiming demonstrates Debounce, Throttle and Coalesce.
+func ExampleTiming() {
+	fmt.Println("=== Debounce, Throttle and Coalesce ===")
+
+	// A manual clock keeps the example deterministic: timers that come
+	// due run inside Advance, so their output is printed before it
+	// returns. Each section prints times relative to its own start.
+	clock := idioms.NewManualClock(time.Time{})
+	start := clock.Now()
+	report := func(format string, args ...any) {
+		fmt.Printf("  +%v %s\n", clock.Now().Sub(start), fmt.Sprintf(format, args...))
+	}
+	section := func(title string) {
+		fmt.Println(title)
+		start = clock.Now()
+	}
+	advance := clock.Advance
+
+	section(`Debounce (wait 100ms), typing "g", "go", "gop" 40ms apart:`)
+	search := Debounce(func(q string) { report("search %q", q) }, 100*time.Millisecond, WithClock(clock))
+	for i, q := range []string{"g", "go", "gop"} {
+		if i > 0 {
+			advance(40 * time.Millisecond)
+		}
+		search.Call(q)
+	}
+	advance(100 * time.Millisecond)
+
+	section("Throttle (interval 100ms), a resize event every 25ms:")
+	resize := Throttle(func(w int) { report("layout width=%d", w) }, 100*time.Millisecond, WithClock(clock))
+	for w := 800; w < 1000; w += 25 {
+		if w > 800 {
+			advance(25 * time.Millisecond)
+		}
+		resize.Call(w)
+	}
+	advance(25 * time.Millisecond)
+	advance(100 * time.Millisecond)
+
+	section("Coalesce (window 50ms), file events 10ms apart:")
+	rebuild := Coalesce(func(files []string) { report("rebuild %v", files) }, 50*time.Millisecond, WithClock(clock))
+	for i, f := range []string{"a.go", "b.go", "a_test.go"} {
+		if i > 0 {
+			advance(10 * time.Millisecond)
+		}
+		rebuild.Call(f)
+	}
+	advance(30 * time.Millisecond)
+
+	section("Cancel and Flush:")
+	search.Call("cancelled")
+	search.Cancel()
+	search.Call("flushed")
+	search.Flush()
+	advance(200 * time.Millisecond)
+}
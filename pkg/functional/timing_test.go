@@ -0,0 +1,197 @@
+package functional
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+type timingWrapper interface {
+	Call(int)
+	Flush()
+	Cancel()
+}
+
+// TestTimingWrappers runs scripts of calls and clock moves. Each step
+// lists the calls of fn it should cause, formatted with fmt.Sprint.
+func TestTimingWrappers(t *testing.T) {
+	type step struct {
+		do   string // "call N", "advance D", "flush" or "cancel"
+		want []string
+	}
+	ms := time.Millisecond
+
+	tests := []struct {
+		name  string
+		build func(fn func(any), clock idioms.Clock) timingWrapper
+		steps []step
+	}{
+		{
+			name: "debounce runs once after the burst",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Debounce(func(v int) { fn(v) }, 100*ms, WithClock(c))
+			},
+			steps: []step{{do: "call 1"}, {do: "advance 50ms"}, {do: "call 2"}, {do: "advance 50ms"},
+				{do: "advance 50ms", want: []string{"2"}}, {do: "advance 200ms"}},
+		},
+		{
+			name: "debounce leading and trailing",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Debounce(func(v int) { fn(v) }, 100*ms, WithClock(c), WithLeading(true))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "advance 50ms"}, {do: "call 2"},
+				{do: "advance 100ms", want: []string{"2"}}, {do: "call 3", want: []string{"3"}}},
+		},
+		{
+			name: "debounce leading single call runs once",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Debounce(func(v int) { fn(v) }, 100*ms, WithClock(c), WithLeading(true))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "advance 200ms"}},
+		},
+		{
+			name: "debounce leading only",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Debounce(func(v int) { fn(v) }, 100*ms, WithClock(c), WithLeading(true), WithTrailing(false))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "advance 50ms"}, {do: "call 2"},
+				{do: "advance 100ms"}, {do: "call 3", want: []string{"3"}}},
+		},
+		{
+			name: "debounce cancel and flush",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Debounce(func(v int) { fn(v) }, 100*ms, WithClock(c))
+			},
+			steps: []step{{do: "call 1"}, {do: "cancel"}, {do: "advance 200ms"},
+				{do: "call 2"}, {do: "flush", want: []string{"2"}}, {do: "advance 200ms"}, {do: "flush"}},
+		},
+		{
+			name: "throttle leading and trailing",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Throttle(func(v int) { fn(v) }, 100*ms, WithClock(c))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "advance 30ms"}, {do: "call 2"},
+				{do: "advance 30ms"}, {do: "call 3"}, {do: "advance 40ms", want: []string{"3"}},
+				{do: "advance 100ms"}, {do: "call 4", want: []string{"4"}}},
+		},
+		{
+			name: "throttle without trailing",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Throttle(func(v int) { fn(v) }, 100*ms, WithClock(c), WithTrailing(false))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "call 2"}, {do: "advance 100ms"},
+				{do: "call 3", want: []string{"3"}}},
+		},
+		{
+			name: "throttle without leading",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Throttle(func(v int) { fn(v) }, 100*ms, WithClock(c), WithLeading(false))
+			},
+			steps: []step{{do: "call 1"}, {do: "advance 100ms", want: []string{"1"}}, {do: "advance 100ms"}},
+		},
+		{
+			name: "throttle flush keeps the interval",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Throttle(func(v int) { fn(v) }, 100*ms, WithClock(c))
+			},
+			steps: []step{{do: "call 1", want: []string{"1"}}, {do: "advance 20ms"}, {do: "call 2"},
+				{do: "flush", want: []string{"2"}}, {do: "call 3"}, {do: "advance 50ms"},
+				{do: "advance 30ms", want: []string{"3"}}},
+		},
+		{
+			name: "coalesce batches a window",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Coalesce(func(v []int) { fn(v) }, 50*ms, WithClock(c))
+			},
+			steps: []step{{do: "call 1"}, {do: "advance 20ms"}, {do: "call 2"}, {do: "call 3"},
+				{do: "advance 30ms", want: []string{"[1 2 3]"}}, {do: "call 4"}, {do: "flush", want: []string{"[4]"}}},
+		},
+		{
+			name: "coalesce max batch",
+			build: func(fn func(any), c idioms.Clock) timingWrapper {
+				return Coalesce(func(v []int) { fn(v) }, 50*ms, WithClock(c), WithMaxBatch(2))
+			},
+			steps: []step{{do: "call 1"}, {do: "call 2", want: []string{"[1 2]"}}, {do: "call 3"},
+				{do: "cancel"}, {do: "advance 100ms"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := idioms.NewManualClock(time.Unix(0, 0))
+			calls := make(chan string, 16)
+			w := tt.build(func(v any) { calls <- fmt.Sprint(v) }, clock)
+
+			for _, s := range tt.steps {
+				verb, arg, _ := strings.Cut(s.do, " ")
+				switch verb {
+				case "call":
+					n, _ := strconv.Atoi(arg)
+					w.Call(n)
+				case "advance":
+					d, _ := time.ParseDuration(arg)
+					clock.Advance(d)
+				case "flush":
+					w.Flush()
+				case "cancel":
+					w.Cancel()
+				}
+
+				// Calls and Flush run fn on this goroutine, and a
+				// ManualClock runs due timers inside Advance, so every
+				// call a step causes has happened by now.
+				for _, want := range s.want {
+					select {
+					case got := <-calls:
+						if got != want {
+							t.Fatalf("after %q: fn(%s), want fn(%s)", s.do, got, want)
+						}
+					default:
+						t.Fatalf("after %q: fn not called, want fn(%s)", s.do, want)
+					}
+				}
+				select {
+				case got := <-calls:
+					t.Fatalf("after %q: unexpected fn(%s)", s.do, got)
+				default:
+				}
+			}
+		})
+	}
+}
+
+func TestTimingConcurrentCalls(t *testing.T) {
+	var calls, inFlight atomic.Int64
+	c := Coalesce(func(batch []int) {
+		if inFlight.Add(1) > 1 {
+			t.Error("fn ran concurrently with itself")
+		}
+		calls.Add(int64(len(batch)))
+		time.Sleep(time.Millisecond)
+		inFlight.Add(-1)
+	}, time.Hour, WithMaxBatch(3))
+
+	var wg sync.WaitGroup
+	for i := range 30 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Call(i)
+		}()
+	}
+	wg.Wait()
+	c.Flush()
+
+	if got := calls.Load(); got != 30 {
+		t.Errorf("fn saw %d values, want 30", got)
+	}
+	if c.Pending() {
+		t.Error("Pending() after Flush")
+	}
+}
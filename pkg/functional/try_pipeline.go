@@ -0,0 +1,196 @@
+package functional
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+// TryPipelines carry an error alongside every item.
+//
+// Why? Real pipelines parse, read and validate, and each of those steps
+// can fail per item. Threading (T, error) pairs through iter.Seq2 keeps
+// evaluation lazy and lets the consumer decide whether the first failure
+// aborts the run (CollectErr) or every failure is reported (CollectAllErr).
+//
+// Operators only see successful items: an error pair flows through
+// MapErr and FilterErr untouched until Recover or a terminal operation
+// deals with it.
+
+// TryPipeline is a pipeline whose items may be errors.
+type TryPipeline[T any] struct {
+	source iter.Seq2[T, error]
+}
+
+// TryFromSeq2 creates a try pipeline from an iterator of value/error pairs.
+func TryFromSeq2[T any](seq iter.Seq2[T, error]) *TryPipeline[T] {
+	return &TryPipeline[T]{source: seq}
+}
+
+// Try lifts a pipeline into a try pipeline with no errors yet.
+func Try[T any](p *Pipeline[T]) *TryPipeline[T] {
+	return TryFromSeq2(func(yield func(T, error) bool) {
+		for item := range p.source {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	})
+}
+
+// FromResults creates a try pipeline from a sequence of monad.Result.
+func FromResults[T any](results iter.Seq[monad.Result[T]]) *TryPipeline[T] {
+	return TryFromSeq2(func(yield func(T, error) bool) {
+		for r := range results {
+			if !yield(r.Unwrap()) {
+				return
+			}
+		}
+	})
+}
+
+// Seq2 returns the pipeline as an iterator of value/error pairs.
+func (p *TryPipeline[T]) Seq2() iter.Seq2[T, error] {
+	return p.source
+}
+
+// Results converts the pipeline into a pipeline of monad.Result values.
+func (p *TryPipeline[T]) Results() *Pipeline[monad.Result[T]] {
+	return FromSeq(monad.Results(p.source))
+}
+
+// MapErr transforms successful items with a function that may fail.
+func (p *TryPipeline[T]) MapErr(mapper func(T) (T, error)) *TryPipeline[T] {
+	return MapErrTo(p, mapper)
+}
+
+// MapErrTo is MapErr with a result type that differs from the input type.
+func MapErrTo[T, U any](p *TryPipeline[T], mapper func(T) (U, error)) *TryPipeline[U] {
+	return TryFromSeq2(func(yield func(U, error) bool) {
+		for item, err := range p.source {
+			if err != nil {
+				var zero U
+				if !yield(zero, err) {
+					return
+				}
+				continue
+			}
+			if !yield(mapper(item)) {
+				return
+			}
+		}
+	})
+}
+
+// FilterErr keeps successful items for which predicate returns true.
+// A predicate error replaces the item.
+func (p *TryPipeline[T]) FilterErr(predicate func(T) (bool, error)) *TryPipeline[T] {
+	return TryFromSeq2(func(yield func(T, error) bool) {
+		for item, err := range p.source {
+			if err == nil {
+				var keep bool
+				if keep, err = predicate(item); err == nil && !keep {
+					continue
+				}
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	})
+}
+
+// Recover gives handler a chance to turn each error into a value.
+// Returning a nil error recovers with the returned value; returning an
+// error (the original or a new one) keeps the item failed.
+func (p *TryPipeline[T]) Recover(handler func(error) (T, error)) *TryPipeline[T] {
+	return TryFromSeq2(func(yield func(T, error) bool) {
+		for item, err := range p.source {
+			if err != nil {
+				item, err = handler(err)
+			}
+			if !yield(item, err) {
+				return
+			}
+		}
+	})
+}
+
+// CollectErr returns the successful items, stopping at the first error.
+// The source is not consumed past the failing item.
+func (p *TryPipeline[T]) CollectErr() ([]T, error) {
+	result := make([]T, 0)
+	for item, err := range p.source {
+		if err != nil {
+			return result, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// CollectAllErr consumes the whole pipeline and returns the successful
+// items together with every error, gathered into an *idioms.MultiError.
+func (p *TryPipeline[T]) CollectAllErr() ([]T, error) {
+	result := make([]T, 0)
+	var errs idioms.MultiError
+	for item, err := range p.source {
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		result = append(result, item)
+	}
+	if errs.HasErrors() {
+		return result, &errs
+	}
+	return result, nil
+}
+
+// ExampleTryPipelines demonstrates error-aware pipelines.
+func ExampleTryPipelines() {
+	fmt.Println("=== Error-Aware Pipelines ===")
+
+	lines := []string{"10", "20", "x", "40", "-5", "y"}
+	parse := func(p *Pipeline[string]) *TryPipeline[int] {
+		return MapErrTo(Try(p), strconv.Atoi).
+			FilterErr(func(n int) (bool, error) {
+				if n < 0 {
+					return false, fmt.Errorf("negative value %d", n)
+				}
+				return true, nil
+			})
+	}
+
+	// Fail fast: stop at the first bad line
+	values, err := parse(NewPipeline(lines)).CollectErr()
+	fmt.Printf("Fail fast: %v, error: %v\n", values, err)
+
+	// Gather every failure into an idioms.MultiError
+	values, err = parse(NewPipeline(lines)).CollectAllErr()
+	fmt.Printf("Collect all: %v, %v\n", values, err)
+	if multi, ok := err.(*idioms.MultiError); ok {
+		for _, e := range multi.Errors {
+			fmt.Printf("  - %v\n", e)
+		}
+	}
+
+	// Recover turns errors back into values
+	values, _ = parse(NewPipeline(lines)).
+		Recover(func(error) (int, error) { return 0, nil }).
+		CollectErr()
+	fmt.Printf("Recovered with zero: %v\n", values)
+
+	// Interoperate with monad.Result
+	fmt.Println("As results:")
+	for r := range parse(NewPipeline(lines[:3])).Results().Seq() {
+		fmt.Printf("  %v\n", r)
+	}
+
+	results := Generator([]monad.Result[int]{monad.Ok(1), monad.Err[int](idioms.ErrInvalidInput), monad.Ok(3)})
+	values, err = FromResults(results).CollectErr()
+	fmt.Printf("From results: %v, error: %v\n", values, err)
+}
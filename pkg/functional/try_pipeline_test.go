@@ -0,0 +1,91 @@
+package functional
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+func parseAll(items ...string) *TryPipeline[int] {
+	return MapErrTo(Try(NewPipeline(items)), strconv.Atoi)
+}
+
+func TestTryPipelineCollect(t *testing.T) {
+	errOdd := errors.New("odd")
+	rejectOdd := func(n int) (bool, error) {
+		if n%2 != 0 {
+			return false, errOdd
+		}
+		return true, nil
+	}
+
+	tests := []struct {
+		name     string
+		pipeline func() *TryPipeline[int]
+		all      bool
+		want     []int
+		wantErrs int
+	}{
+		{"no errors", func() *TryPipeline[int] { return parseAll("1", "2") }, false, []int{1, 2}, 0},
+		{"first error stops", func() *TryPipeline[int] { return parseAll("1", "x", "3") }, false, []int{1}, 1},
+		{"all errors gathered", func() *TryPipeline[int] { return parseAll("1", "x", "3", "y") }, true, []int{1, 3}, 2},
+		{"filter error", func() *TryPipeline[int] { return parseAll("2", "3", "4").FilterErr(rejectOdd) }, true, []int{2, 4}, 1},
+		{"recover", func() *TryPipeline[int] {
+			return parseAll("1", "x").Recover(func(error) (int, error) { return -1, nil })
+		}, false, []int{1, -1}, 0},
+		{"recover can keep failing", func() *TryPipeline[int] {
+			return parseAll("x").Recover(func(err error) (int, error) { return 0, err })
+		}, false, []int{}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collect := tt.pipeline().CollectErr
+			if tt.all {
+				collect = tt.pipeline().CollectAllErr
+			}
+			got, err := collect()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			gotErrs := 0
+			var multi *idioms.MultiError
+			switch {
+			case errors.As(err, &multi):
+				gotErrs = len(multi.Errors)
+			case err != nil:
+				gotErrs = 1
+			}
+			if gotErrs != tt.wantErrs {
+				t.Errorf("got %d error(s) (%v), want %d", gotErrs, err, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestTryPipelineStopsAtFirstError(t *testing.T) {
+	var seen []string
+	source := NewPipeline([]string{"1", "x", "3"}).Peek(func(s string) { seen = append(seen, s) })
+	if _, err := MapErrTo(Try(source), strconv.Atoi).CollectErr(); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !slices.Equal(seen, []string{"1", "x"}) {
+		t.Errorf("source consumed %v, want it to stop at the failing item", seen)
+	}
+}
+
+func TestTryPipelineResultRoundTrip(t *testing.T) {
+	results := parseAll("1", "x", "3").Results().Collect()
+	if len(results) != 3 || !results[0].IsOk() || results[1].IsOk() || !results[2].IsOk() {
+		t.Fatalf("Results() = %v", results)
+	}
+
+	got, err := FromResults(Generator(results)).Recover(func(error) (int, error) { return 0, nil }).CollectErr()
+	if err != nil || !slices.Equal(got, []int{1, 0, 3}) {
+		t.Errorf("round trip = %v, %v", got, err)
+	}
+}
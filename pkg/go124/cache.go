@@ -0,0 +1,576 @@
+package go124
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is a generic, concurrency-safe cache with optional size and cost
+// bounds, eviction policies, expiry and single-flight loading.
+//
+// Why shards? One mutex around one map serializes every reader. The
+// cache splits keys over independently locked shards, using Go 1.24's
+// maphash.Comparable to hash any comparable key type. Bounds are split
+// over the shards and enforced per shard, so eviction order is only
+// approximately global; a cache that needs exact LRU or LFU order sets
+// Shards to 1.
+//
+// Why single-flight? When a popular key expires, every concurrent
+// request would otherwise run the same expensive load. GetOrLoad lets
+// the first caller load while the others wait for its result.
+
+// EvictionPolicy selects which entry a full cache evicts.
+type EvictionPolicy int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU EvictionPolicy = iota
+	// LFU evicts the least frequently used entry.
+	LFU
+	// ARC (Adaptive Replacement Cache) balances recency and frequency,
+	// adapting to the workload and resisting one-off scans.
+	ARC
+)
+
+func (p EvictionPolicy) String() string {
+	switch p {
+	case LFU:
+		return "LFU"
+	case ARC:
+		return "ARC"
+	default:
+		return "LRU"
+	}
+}
+
+// EvictionReason says why an entry left the cache.
+type EvictionReason int
+
+const (
+	// EvictedCapacity means the entry made room under MaxEntries or MaxCost.
+	EvictedCapacity EvictionReason = iota
+	// EvictedExpired means the entry's TTL passed.
+	EvictedExpired
+	// EvictedDeleted means Delete or Clear removed the entry.
+	EvictedDeleted
+	// EvictedReplaced means Set stored a new value for the key.
+	EvictedReplaced
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictedCapacity:
+		return "capacity"
+	case EvictedExpired:
+		return "expired"
+	case EvictedDeleted:
+		return "deleted"
+	case EvictedReplaced:
+		return "replaced"
+	default:
+		return fmt.Sprintf("EvictionReason(%d)", int(r))
+	}
+}
+
+// CacheOptions configures a cache. The zero value is an unbounded cache
+// without expiry.
+type CacheOptions[K comparable, V any] struct {
+	// MaxEntries bounds the number of entries; 0 means unbounded.
+	MaxEntries int
+	// MaxCost bounds the summed cost of entries; 0 means unbounded. An
+	// entry that costs more than its shard's share is not cached.
+	MaxCost int64
+	// Cost returns an entry's cost. The default is 1 per entry.
+	Cost func(K, V) int64
+	// Policy selects the entry to evict when a bound is exceeded.
+	Policy EvictionPolicy
+	// TTL is the default time to live; 0 means entries never expire.
+	TTL time.Duration
+	// Shards is the number of independently locked shards. The default
+	// is 1 when MaxCost is set, since a share of the cost budget may be
+	// too small for a typical entry, and otherwise 16, or MaxEntries if
+	// that is smaller. There are never more shards than MaxEntries or
+	// MaxCost. Both bounds are split exactly, the first shards taking
+	// the remainder, and each shard evicts on its own, so with more
+	// than one shard the victim is the policy's choice within its
+	// shard, not across the cache. Set Shards to 1 for exact eviction
+	// order at the cost of a single lock.
+	Shards int
+	// OnEvict is called, without any lock held, when an entry leaves
+	// the cache.
+	OnEvict func(key K, value V, reason EvictionReason)
+	// Now replaces time.Now, so tests can control expiry.
+	Now func() time.Time
+}
+
+// CacheStats counts cache activity since creation.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Loads     uint64 // loader calls made by GetOrLoad
+	Evictions uint64 // capacity and expiry evictions
+}
+
+// HitRatio returns hits / (hits + misses), or 0 before any lookup.
+func (s CacheStats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// Cache is a generic cache with type safety.
+type Cache[K comparable, V any] struct {
+	opts   CacheOptions[K, V]
+	seed   maphash.Seed
+	shards []*cacheShard[K, V]
+
+	hits, misses, loads, evictions atomic.Uint64
+}
+
+type cacheEntry[V any] struct {
+	value   V
+	cost    int64
+	expires time.Time // zero means never
+}
+
+// loadCall is an in-flight GetOrLoad shared by concurrent callers.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// stale is set, under the shard lock, when Set, Delete or Clear
+	// touches the key during the load. The loaded value is then only
+	// returned to its waiters, not cached over the newer state.
+	stale bool
+}
+
+type cacheShard[K comparable, V any] struct {
+	mu         sync.Mutex
+	entries    map[K]*cacheEntry[V]
+	policy     evictionPolicy[K]
+	maxEntries int
+	maxCost    int64
+	cost       int64
+	loading    map[K]*loadCall[V]
+}
+
+// eviction is an OnEvict call deferred until the shard lock is released.
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictionReason
+}
+
+// NewCache creates an unbounded cache.
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return NewCacheWith(CacheOptions[K, V]{})
+}
+
+// NewCacheWith creates a cache configured by opts.
+func NewCacheWith[K comparable, V any](opts CacheOptions[K, V]) *Cache[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Cost == nil {
+		opts.Cost = func(K, V) int64 { return 1 }
+	}
+	if opts.Shards <= 0 {
+		opts.Shards = 16
+		if opts.MaxCost > 0 {
+			opts.Shards = 1
+		}
+	}
+	// Every shard must get a non-zero share of each bound, since a zero
+	// bound means unbounded.
+	if opts.MaxEntries > 0 {
+		opts.Shards = min(opts.Shards, opts.MaxEntries)
+	}
+	if opts.MaxCost > 0 {
+		opts.Shards = int(min(int64(opts.Shards), opts.MaxCost))
+	}
+
+	c := &Cache[K, V]{opts: opts, seed: maphash.MakeSeed()}
+	n := opts.Shards
+	for i := range n {
+		maxEntries := int(share(int64(opts.MaxEntries), i, n))
+		c.shards = append(c.shards, &cacheShard[K, V]{
+			entries:    make(map[K]*cacheEntry[V]),
+			policy:     newPolicy[K](opts.Policy, maxEntries),
+			maxEntries: maxEntries,
+			maxCost:    share(opts.MaxCost, i, n),
+			loading:    make(map[K]*loadCall[V]),
+		})
+	}
+	return c
+}
+
+// share returns shard i's part of a bound split over n shards: an equal
+// share, plus one for the first total%n shards, so the parts add up to
+// total exactly.
+func share(total int64, i, n int) int64 {
+	if total <= 0 {
+		return 0
+	}
+	part := total / int64(n)
+	if int64(i) < total%int64(n) {
+		part++
+	}
+	return part
+}
+
+func (c *Cache[K, V]) shard(key K) *cacheShard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+// notify runs OnEvict for evictions collected under a shard lock.
+func (c *Cache[K, V]) notify(evicted []eviction[K, V]) {
+	for _, e := range evicted {
+		if e.reason == EvictedCapacity || e.reason == EvictedExpired {
+			c.evictions.Add(1)
+		}
+		if c.opts.OnEvict != nil {
+			c.opts.OnEvict(e.key, e.value, e.reason)
+		}
+	}
+}
+
+// lookup returns the live entry for key, expiring it if its TTL has
+// passed. The caller must hold s.mu.
+func (s *cacheShard[K, V]) lookup(key K, now time.Time, evicted *[]eviction[K, V]) (*cacheEntry[V], bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !e.expires.IsZero() && !now.Before(e.expires) {
+		s.remove(key, e)
+		s.policy.removed(key)
+		*evicted = append(*evicted, eviction[K, V]{key, e.value, EvictedExpired})
+		return nil, false
+	}
+	return e, true
+}
+
+// remove deletes key's entry without touching the policy. The caller
+// must hold s.mu.
+func (s *cacheShard[K, V]) remove(key K, e *cacheEntry[V]) {
+	delete(s.entries, key)
+	s.cost -= e.cost
+}
+
+// Get retrieves a value.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	s := c.shard(key)
+	var evicted []eviction[K, V]
+
+	s.mu.Lock()
+	e, ok := s.lookup(key, c.opts.Now(), &evicted)
+	var value V
+	if ok {
+		s.policy.accessed(key)
+		value = e.value
+	}
+	s.mu.Unlock()
+
+	c.notify(evicted)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return value, ok
+}
+
+// Set stores a value with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+// SetWithTTL stores a value that expires after ttl; 0 means never.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s := c.shard(key)
+	now := c.opts.Now()
+	entry := c.newEntry(key, value, ttl, now)
+	var evicted []eviction[K, V]
+
+	s.mu.Lock()
+	s.invalidateLoad(key)
+	s.store(key, entry, now, &evicted)
+	s.mu.Unlock()
+
+	c.notify(evicted)
+}
+
+func (c *Cache[K, V]) newEntry(key K, value V, ttl time.Duration, now time.Time) *cacheEntry[V] {
+	entry := &cacheEntry[V]{value: value, cost: c.opts.Cost(key, value)}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+	return entry
+}
+
+// store adds or replaces key's entry and evicts down to the shard's
+// bounds. An entry that alone exceeds the cost bound replaces the old
+// value but is itself evicted at once, rather than pushing out entries
+// it could never fit beside. The caller must hold s.mu.
+func (s *cacheShard[K, V]) store(key K, entry *cacheEntry[V], now time.Time, evicted *[]eviction[K, V]) {
+	old, replacing := s.lookup(key, now, evicted)
+	if replacing {
+		s.remove(key, old)
+		*evicted = append(*evicted, eviction[K, V]{key, old.value, EvictedReplaced})
+	}
+	if s.maxCost > 0 && entry.cost > s.maxCost {
+		if replacing {
+			s.policy.removed(key)
+		}
+		*evicted = append(*evicted, eviction[K, V]{key, entry.value, EvictedCapacity})
+		return
+	}
+	if replacing {
+		s.policy.accessed(key)
+	} else {
+		s.policy.added(key)
+	}
+	s.entries[key] = entry
+	s.cost += entry.cost
+
+	for (s.maxEntries > 0 && len(s.entries) > s.maxEntries) || (s.maxCost > 0 && s.cost > s.maxCost) {
+		victim, ok := s.policy.victim(key)
+		if !ok {
+			break
+		}
+		e := s.entries[victim]
+		s.remove(victim, e)
+		*evicted = append(*evicted, eviction[K, V]{victim, e.value, EvictedCapacity})
+	}
+}
+
+// invalidateLoad stops an in-flight load of key from caching its result.
+// The caller must hold s.mu.
+func (s *cacheShard[K, V]) invalidateLoad(key K) {
+	if call, ok := s.loading[key]; ok {
+		call.stale = true
+	}
+}
+
+// Delete removes a value.
+func (c *Cache[K, V]) Delete(key K) {
+	s := c.shard(key)
+	var evicted []eviction[K, V]
+
+	s.mu.Lock()
+	s.invalidateLoad(key)
+	if e, ok := s.entries[key]; ok {
+		s.remove(key, e)
+		s.policy.removed(key)
+		evicted = append(evicted, eviction[K, V]{key, e.value, EvictedDeleted})
+	}
+	s.mu.Unlock()
+
+	c.notify(evicted)
+}
+
+// Clear removes every entry.
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		var evicted []eviction[K, V]
+		s.mu.Lock()
+		for _, call := range s.loading {
+			call.stale = true
+		}
+		for key, e := range s.entries {
+			s.remove(key, e)
+			s.policy.removed(key)
+			evicted = append(evicted, eviction[K, V]{key, e.value, EvictedDeleted})
+		}
+		s.mu.Unlock()
+		c.notify(evicted)
+	}
+}
+
+// Keys returns all keys.
+func (c *Cache[K, V]) Keys() []K {
+	now := c.opts.Now()
+	var keys []K
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for key, e := range s.entries {
+			if e.expires.IsZero() || now.Before(e.expires) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return keys
+}
+
+// Len returns the number of entries, including expired entries that
+// have not been looked up since they expired.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache[K, V]) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Loads:     c.loads.Load(),
+		Evictions: c.evictions.Load(),
+	}
+}
+
+// GetOrLoad returns the cached value for key, or calls loader to produce
+// and cache it. Concurrent callers for the same key share one loader
+// call. The load is not cancelled when the caller that started it gives
+// up, since other callers may still want the result; each caller stops
+// waiting when its own ctx ends. Loader errors are returned to every
+// waiting caller and are not cached. A Set, Delete or Clear of the key
+// while the loader runs wins: the loaded value is returned to the
+// waiting callers but not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(context.Context, K) (V, error)) (V, error) {
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, err
+	}
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	s := c.shard(key)
+	var evicted []eviction[K, V]
+	s.mu.Lock()
+	// A load may have finished between Get and taking the lock.
+	if e, ok := s.lookup(key, c.opts.Now(), &evicted); ok {
+		s.mu.Unlock()
+		return e.value, nil
+	}
+	call, inflight := s.loading[key]
+	if !inflight {
+		call = &loadCall[V]{done: make(chan struct{})}
+		s.loading[key] = call
+	}
+	s.mu.Unlock()
+	c.notify(evicted)
+
+	if !inflight {
+		go c.load(context.WithoutCancel(ctx), s, key, call, loader)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, s *cacheShard[K, V], key K, call *loadCall[V], loader func(context.Context, K) (V, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("cache loader panicked: %v", r)
+		}
+		// Storing and unregistering the call under one lock means a
+		// Delete either lands before (and marks the call stale) or after
+		// (and removes the stored value).
+		var evicted []eviction[K, V]
+		s.mu.Lock()
+		if call.err == nil && !call.stale {
+			now := c.opts.Now()
+			s.store(key, c.newEntry(key, call.value, c.opts.TTL, now), now, &evicted)
+		}
+		delete(s.loading, key)
+		s.mu.Unlock()
+		c.notify(evicted)
+		close(call.done)
+	}()
+
+	c.loads.Add(1)
+	call.value, call.err = loader(ctx, key)
+}
+
+// ExampleCache demonstrates bounded caching with eviction policies.
+func ExampleCache() {
+	fmt.Println("=== Generic Cache ===")
+
+	// The same access pattern under each policy: "a" is popular, then
+	// a scan of new keys passes through.
+	for _, policy := range []EvictionPolicy{LRU, LFU, ARC} {
+		var evicted []string
+		cache := NewCacheWith(CacheOptions[string, int]{
+			MaxEntries: 3,
+			Shards:     1, // one shard, so the policy sees every key
+			Policy:     policy,
+			OnEvict: func(key string, _ int, reason EvictionReason) {
+				if reason == EvictedCapacity {
+					evicted = append(evicted, key)
+				}
+			},
+		})
+		cache.Set("a", 1)
+		for range 3 {
+			cache.Get("a")
+		}
+		for i, key := range []string{"b", "c", "d", "e"} {
+			cache.Set(key, i)
+		}
+		_, kept := cache.Get("a")
+		fmt.Printf("%s evicted %v, popular key kept: %v\n", policy, evicted, kept)
+	}
+
+	// TTL with a controllable clock
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sessions := NewCacheWith(CacheOptions[string, string]{
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+	})
+	sessions.Set("token", "alice")
+	sessions.SetWithTTL("remember-me", "alice", time.Hour)
+	now = now.Add(2 * time.Minute)
+	_, tokenOK := sessions.Get("token")
+	_, rememberOK := sessions.Get("remember-me")
+	fmt.Printf("\nAfter 2 minutes: token valid %v, remember-me valid %v\n", tokenOK, rememberOK)
+
+	// Cost-bounded cache
+	blobs := NewCacheWith(CacheOptions[string, []byte]{
+		MaxCost: 10,
+		Shards:  1,
+		Cost:    func(_ string, b []byte) int64 { return int64(len(b)) },
+	})
+	blobs.Set("small", make([]byte, 4))
+	blobs.Set("medium", make([]byte, 5))
+	blobs.Set("large", make([]byte, 6))
+	fmt.Printf("Cost-bounded cache (max 10 bytes) kept: %v\n", blobs.Keys())
+
+	// Single-flight loading
+	users := NewCache[int, string]()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = users.GetOrLoad(context.Background(), 42, func(context.Context, int) (string, error) {
+				time.Sleep(10 * time.Millisecond)
+				return "user-42", nil
+			})
+		}()
+	}
+	wg.Wait()
+	name, _ := users.Get(42)
+	stats := users.Stats()
+	fmt.Printf("\nGetOrLoad: %s, loader calls: %d\n", name, stats.Loads)
+}
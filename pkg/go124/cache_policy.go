@@ -0,0 +1,244 @@
+package go124
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// evictionPolicy tracks the keys resident in one cache shard and picks
+// which to evict. Calls are made with the shard lock held.
+type evictionPolicy[K comparable] interface {
+	// added records a newly inserted key.
+	added(key K)
+	// accessed records a hit on, or an overwrite of, a resident key.
+	accessed(key K)
+	// removed forgets a key deleted or expired outside the policy.
+	removed(key K)
+	// victim chooses a resident key other than protect to evict, and
+	// forgets it.
+	victim(protect K) (K, bool)
+}
+
+func newPolicy[K comparable](policy EvictionPolicy, capacity int) evictionPolicy[K] {
+	switch policy {
+	case LFU:
+		return newLFU[K]()
+	case ARC:
+		return newARC[K](capacity)
+	default:
+		return newLRU[K]()
+	}
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy[K comparable] struct {
+	order *list.List // front is most recent
+	elems map[K]*list.Element
+}
+
+func newLRU[K comparable]() *lruPolicy[K] {
+	return &lruPolicy[K]{order: list.New(), elems: make(map[K]*list.Element)}
+}
+
+func (p *lruPolicy[K]) added(key K) {
+	p.elems[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy[K]) accessed(key K) {
+	if e, ok := p.elems[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy[K]) removed(key K) {
+	if e, ok := p.elems[key]; ok {
+		p.order.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy[K]) victim(protect K) (K, bool) {
+	for e := p.order.Back(); e != nil; e = e.Prev() {
+		if key := e.Value.(K); key != protect {
+			p.removed(key)
+			return key, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// lfuPolicy evicts the least frequently used key, breaking ties by
+// least recent use.
+type lfuPolicy[K comparable] struct {
+	entries lfuHeap[K]
+	index   map[K]*lfuEntry[K]
+	tick    uint64
+}
+
+type lfuEntry[K comparable] struct {
+	key   K
+	freq  uint64
+	tick  uint64
+	index int
+}
+
+type lfuHeap[K comparable] []*lfuEntry[K]
+
+func (h lfuHeap[K]) Len() int { return len(h) }
+func (h lfuHeap[K]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].tick < h[j].tick
+}
+func (h lfuHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *lfuHeap[K]) Push(x any) {
+	e := x.(*lfuEntry[K])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *lfuHeap[K]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+func newLFU[K comparable]() *lfuPolicy[K] {
+	return &lfuPolicy[K]{index: make(map[K]*lfuEntry[K])}
+}
+
+func (p *lfuPolicy[K]) added(key K) {
+	p.tick++
+	e := &lfuEntry[K]{key: key, freq: 1, tick: p.tick}
+	p.index[key] = e
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfuPolicy[K]) accessed(key K) {
+	if e, ok := p.index[key]; ok {
+		p.tick++
+		e.freq++
+		e.tick = p.tick
+		heap.Fix(&p.entries, e.index)
+	}
+}
+
+func (p *lfuPolicy[K]) removed(key K) {
+	if e, ok := p.index[key]; ok {
+		heap.Remove(&p.entries, e.index)
+		delete(p.index, key)
+	}
+}
+
+func (p *lfuPolicy[K]) victim(protect K) (K, bool) {
+	best := -1
+	switch {
+	case len(p.entries) == 0:
+	case p.entries[0].key != protect:
+		best = 0
+	default:
+		// The protected key is the root; the next candidate is a child.
+		for _, i := range []int{1, 2} {
+			if i < len(p.entries) && (best < 0 || p.entries.Less(i, best)) {
+				best = i
+			}
+		}
+	}
+	if best < 0 {
+		var zero K
+		return zero, false
+	}
+	key := p.entries[best].key
+	p.removed(key)
+	return key, true
+}
+
+// arcPolicy implements Adaptive Replacement Cache. It splits resident
+// keys into those seen once recently (t1) and those seen at least twice
+// (t2), and remembers recently evicted keys of each kind (b1, b2). A
+// miss that hits a ghost list shows which side was evicted too eagerly,
+// and target shifts capacity towards it. This makes ARC resist scans
+// that would flush an LRU cache, while adapting to changing workloads.
+type arcPolicy[K comparable] struct {
+	capacity       int
+	target         int // desired size of t1
+	t1, t2, b1, b2 *lruPolicy[K]
+}
+
+func newARC[K comparable](capacity int) *arcPolicy[K] {
+	return &arcPolicy[K]{
+		capacity: capacity,
+		t1:       newLRU[K](),
+		t2:       newLRU[K](),
+		b1:       newLRU[K](),
+		b2:       newLRU[K](),
+	}
+}
+
+func (p *arcPolicy[K]) size() int {
+	if p.capacity > 0 {
+		return p.capacity
+	}
+	return max(len(p.t1.elems)+len(p.t2.elems), 1)
+}
+
+func (p *arcPolicy[K]) added(key K) {
+	c := p.size()
+	switch {
+	case p.b1.elems[key] != nil:
+		p.target = min(c, p.target+max(len(p.b2.elems)/len(p.b1.elems), 1))
+		p.b1.removed(key)
+		p.t2.added(key)
+	case p.b2.elems[key] != nil:
+		p.target = max(0, p.target-max(len(p.b1.elems)/len(p.b2.elems), 1))
+		p.b2.removed(key)
+		p.t2.added(key)
+	default:
+		p.t1.added(key)
+	}
+
+	// Keep the ghost lists within the classic ARC bounds.
+	for len(p.t1.elems)+len(p.b1.elems) > c && len(p.b1.elems) > 0 {
+		p.b1.victim(key)
+	}
+	for len(p.t1.elems)+len(p.t2.elems)+len(p.b1.elems)+len(p.b2.elems) > 2*c && len(p.b2.elems) > 0 {
+		p.b2.victim(key)
+	}
+}
+
+func (p *arcPolicy[K]) accessed(key K) {
+	if p.t1.elems[key] != nil {
+		p.t1.removed(key)
+		p.t2.added(key)
+		return
+	}
+	p.t2.accessed(key)
+}
+
+func (p *arcPolicy[K]) removed(key K) {
+	p.t1.removed(key)
+	p.t2.removed(key)
+}
+
+func (p *arcPolicy[K]) victim(protect K) (K, bool) {
+	fromT1 := len(p.t1.elems) > 0 && (len(p.t1.elems) > p.target || len(p.t2.elems) == 0)
+	first, ghostFirst, second, ghostSecond := p.t2, p.b2, p.t1, p.b1
+	if fromT1 {
+		first, ghostFirst, second, ghostSecond = p.t1, p.b1, p.t2, p.b2
+	}
+	if key, ok := first.victim(protect); ok {
+		ghostFirst.added(key)
+		return key, true
+	}
+	if key, ok := second.victim(protect); ok {
+		ghostSecond.added(key)
+		return key, true
+	}
+	var zero K
+	return zero, false
+}
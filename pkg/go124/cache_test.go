@@ -0,0 +1,299 @@
+package go124
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheEvictionPolicies(t *testing.T) {
+	tests := []struct {
+		policy EvictionPolicy
+		// ops is a sequence of "set k" and "get k".
+		ops  []string
+		want []string // keys left, sorted
+	}{
+		{LRU, []string{"set a", "set b", "set c", "get a", "set d"}, []string{"a", "c", "d"}},
+		{LFU, []string{"set a", "set b", "get b", "get a", "get a", "set c", "set d"}, []string{"a", "b", "d"}},
+		// A scan of one-off keys does not flush keys seen twice.
+		{ARC, []string{"set a", "get a", "set b", "get b", "set x", "set y", "set z"}, []string{"a", "b", "z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			c := NewCacheWith(CacheOptions[string, int]{MaxEntries: 3, Shards: 1, Policy: tt.policy})
+			for _, op := range tt.ops {
+				var verb, key string
+				fmt.Sscan(op, &verb, &key)
+				if verb == "set" {
+					c.Set(key, 0)
+				} else {
+					c.Get(key)
+				}
+			}
+			got := c.Keys()
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheTTLAndCallbacks(t *testing.T) {
+	now := time.Unix(0, 0)
+	var reasons []string
+	c := NewCacheWith(CacheOptions[string, int]{
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+		OnEvict: func(key string, _ int, reason EvictionReason) {
+			reasons = append(reasons, key+":"+reason.String())
+		},
+	})
+
+	c.Set("short", 1)
+	c.SetWithTTL("long", 2, time.Hour)
+	c.SetWithTTL("forever", 3, 0)
+	c.Set("short", 4)
+	now = now.Add(2 * time.Minute)
+
+	for key, want := range map[string]bool{"short": false, "long": true, "forever": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+	c.Delete("long")
+
+	if want := []string{"short:replaced", "short:expired", "long:deleted"}; !slices.Equal(reasons, want) {
+		t.Errorf("evictions = %v, want %v", reasons, want)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 1 || s.Evictions != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestCacheMaxCost(t *testing.T) {
+	c := NewCacheWith(CacheOptions[string, string]{
+		MaxCost: 10,
+		Shards:  1,
+		Cost:    func(_, v string) int64 { return int64(len(v)) },
+	})
+	c.Set("a", "xxxx")
+	c.Set("b", "xxxx")
+	c.Set("c", "xxxx")
+	if _, ok := c.Get("a"); ok || c.Len() != 2 {
+		t.Errorf("expected a evicted and 2 entries left, got %v", c.Keys())
+	}
+
+	// An entry larger than MaxCost is not cached, and does not push out
+	// entries it could never fit beside; it still replaces its key.
+	c.Set("c", "xxxxxxxxxxxxxxxx")
+	if got := c.Keys(); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("after an oversized Set, keys = %v, want [b]", got)
+	}
+}
+
+func TestCacheShardedCostBound(t *testing.T) {
+	tests := []struct {
+		maxCost, cost int64
+		shards        int
+	}{
+		{10, 1, 0},
+		{1000, 200, 0},
+		{10, 1, 4},
+		{1000, 200, 16},
+		{1000, 30, 16},
+	}
+	for _, tt := range tests {
+		c := NewCacheWith(CacheOptions[int, int]{
+			MaxCost: tt.maxCost,
+			Shards:  tt.shards,
+			Cost:    func(int, int) int64 { return tt.cost },
+		})
+		for i := range 1000 {
+			c.Set(i, i)
+			if total := int64(c.Len()) * tt.cost; total > tt.maxCost {
+				t.Fatalf("MaxCost %d, Shards %d: entries cost %d after %d sets", tt.maxCost, tt.shards, total, i+1)
+			}
+		}
+	}
+}
+
+func TestCacheGetOrLoadSingleFlight(t *testing.T) {
+	c := NewCacheWith(CacheOptions[int, int]{Shards: 4})
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(_ context.Context, k int) (int, error) {
+		calls.Add(1)
+		<-release
+		return k * 10, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 20)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = c.GetOrLoad(context.Background(), 7, loader)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("loader called %d times, want 1", calls.Load())
+	}
+	for i, r := range results {
+		if r != 70 {
+			t.Errorf("caller %d got %d", i, r)
+		}
+	}
+}
+
+func TestCacheGetOrLoadErrorsAreNotCached(t *testing.T) {
+	c := NewCache[string, int]()
+	boom := errors.New("boom")
+	fail := true
+	loader := func(context.Context, string) (int, error) {
+		if fail {
+			return 0, boom
+		}
+		return 1, nil
+	}
+
+	if _, err := c.GetOrLoad(context.Background(), "k", loader); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	fail = false
+	if v, err := c.GetOrLoad(context.Background(), "k", loader); err != nil || v != 1 {
+		t.Errorf("second load = %d, %v", v, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Delete("k")
+	if _, err := c.GetOrLoad(ctx, "k", loader); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller err = %v", err)
+	}
+}
+
+func TestCacheDeleteDuringLoadWins(t *testing.T) {
+	for _, op := range []string{"delete", "clear", "set"} {
+		t.Run(op, func(t *testing.T) {
+			c := NewCache[string, int]()
+			started, release := make(chan struct{}), make(chan struct{})
+			loader := func(context.Context, string) (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			}
+
+			done := make(chan int)
+			go func() {
+				v, _ := c.GetOrLoad(context.Background(), "k", loader)
+				done <- v
+			}()
+			<-started
+			switch op {
+			case "delete":
+				c.Delete("k")
+			case "clear":
+				c.Clear()
+			case "set":
+				c.Set("k", 2)
+			}
+			close(release)
+
+			if v := <-done; v != 1 {
+				t.Errorf("waiting caller got %d, want the loaded 1", v)
+			}
+			v, ok := c.Get("k")
+			if op == "set" {
+				if !ok || v != 2 {
+					t.Errorf("Get after Set during load = %d, %v; want 2, true", v, ok)
+				}
+			} else if ok {
+				t.Errorf("load finished after %s re-cached %d", op, v)
+			}
+		})
+	}
+}
+
+func TestCacheShardsSplitBounds(t *testing.T) {
+	tests := []struct {
+		opts   CacheOptions[int, int]
+		shards int
+	}{
+		{CacheOptions[int, int]{}, 16},
+		{CacheOptions[int, int]{MaxEntries: 100}, 16},
+		{CacheOptions[int, int]{MaxEntries: 5}, 5},
+		{CacheOptions[int, int]{MaxEntries: 10, Shards: 4}, 4},
+		{CacheOptions[int, int]{MaxEntries: 3, Shards: 8}, 3},
+		{CacheOptions[int, int]{MaxCost: 1000}, 1},
+		{CacheOptions[int, int]{MaxCost: 10, Shards: 4}, 4},
+		{CacheOptions[int, int]{MaxCost: 10, Shards: 16}, 10},
+		{CacheOptions[int, int]{MaxEntries: 100, MaxCost: 1000}, 1},
+	}
+	for _, tt := range tests {
+		c := NewCacheWith(tt.opts)
+		entries, cost := 0, int64(0)
+		for _, s := range c.shards {
+			if (tt.opts.MaxEntries > 0 && s.maxEntries < 1) || (tt.opts.MaxCost > 0 && s.maxCost < 1) {
+				t.Errorf("%+v: a shard has a zero bound, which means unbounded", tt.opts)
+			}
+			entries += s.maxEntries
+			cost += s.maxCost
+		}
+		if len(c.shards) != tt.shards || entries != tt.opts.MaxEntries || cost != tt.opts.MaxCost {
+			t.Errorf("%+v: %d shards with bounds %d entries and %d cost, want %d shards", tt.opts, len(c.shards), entries, cost, tt.shards)
+		}
+	}
+
+	c := NewCacheWith(CacheOptions[int, int]{MaxEntries: 50})
+	for i := range 1000 {
+		c.Set(i, i)
+	}
+	if n := c.Len(); n > 50 {
+		t.Errorf("Len() = %d, want at most 50", n)
+	}
+}
+
+func TestEvictionReasonString(t *testing.T) {
+	if got := EvictedReplaced.String(); got != "replaced" {
+		t.Errorf("EvictedReplaced = %q", got)
+	}
+	if got := EvictionReason(9).String(); got != "EvictionReason(9)" {
+		t.Errorf("EvictionReason(9) = %q", got)
+	}
+}
+
+func TestCacheConcurrentUse(t *testing.T) {
+	c := NewCacheWith(CacheOptions[int, int]{MaxEntries: 64, Shards: 8, Policy: ARC})
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				k := (g*31 + i) % 200
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, i)
+				}
+				if i%50 == 0 {
+					c.Delete(k)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.Len(); n > 64 {
+		t.Errorf("Len() = %d, want at most 64", n)
+	}
+}
@@ -7,6 +7,7 @@
 //   - Resource cleanup with runtime.AddCleanup
 //   - Parameterized type aliases for generic types
 //   - Comprehensive generic programming (type parameters, constraints)
+//   - A sharded generic cache keyed with maphash.Comparable
 //   - Enhanced testing benchmarks with testing.B.Loop
 //
 // Each file contains focused examples with godoc comments explaining
@@ -31,5 +32,8 @@
 //
 //		// Generic data structures
 //		go124.ExampleGenerics()
+//
+//		// Bounded, concurrency-safe caching
+//		go124.ExampleCache()
 //	}
 package go124
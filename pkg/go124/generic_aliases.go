@@ -1,6 +1,10 @@
 package go124
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
+)
 
 // OrderedSlice is a parameterized type alias for slices of ordered types.
 // Go 1.24 allows generic type aliases, enabling concise type definitions.
@@ -18,49 +22,32 @@ type KeyValue[K comparable, V any] struct {
 // KVMap is a generic type alias for maps.
 type KVMap[K comparable, V any] = map[K]V
 
-// Result represents a computation result or error.
-type Result[T any] struct {
-	Value T
-	Error error
-}
+// Result is the canonical monad.Result. Go 1.24 generic type aliases
+// let a package re-export a generic type under its own name, so values
+// move between packages without conversion.
+type Result[T any] = monad.Result[T]
 
 // ResultSlice is a type alias for slices of results.
 type ResultSlice[T any] = []Result[T]
 
-// Optional represents an optional value.
-type Optional[T any] struct {
-	value   T
-	present bool
-}
+// Optional is the canonical monad.Option.
+type Optional[T any] = monad.Option[T]
 
 // Some creates an Optional with a value.
 func Some[T any](v T) Optional[T] {
-	return Optional[T]{value: v, present: true}
+	return monad.Some(v)
 }
 
 // None creates an empty Optional.
 func None[T any]() Optional[T] {
-	return Optional[T]{present: false}
-}
-
-// IsPresent returns true if the Optional has a value.
-func (o Optional[T]) IsPresent() bool {
-	return o.present
-}
-
-// Get returns the value or zero value if not present.
-func (o Optional[T]) Get() T {
-	return o.value
+	return monad.None[T]()
 }
 
 // OptionalSlice is a type alias for slices of optionals.
 type OptionalSlice[T any] = []Optional[T]
 
-// Pair represents a generic pair of values.
-type Pair[A, B any] struct {
-	First  A
-	Second B
-}
+// Pair is the canonical monad.Pair, which ZipOption and ZipResult return.
+type Pair[A, B any] = monad.Pair[A, B]
 
 // PairList is a type alias for lists of pairs.
 type PairList[A, B any] = []Pair[A, B]
@@ -70,11 +57,6 @@ func NewPair[A, B any](a A, b B) Pair[A, B] {
 	return Pair[A, B]{First: a, Second: b}
 }
 
-// Swap returns a new pair with swapped values.
-func (p Pair[A, B]) Swap() Pair[B, A] {
-	return Pair[B, A]{First: p.Second, Second: p.First}
-}
-
 // Transform applies functions to both elements.
 func Transform[A, B, C, D any](
 	p Pair[A, B],
@@ -106,8 +88,8 @@ func ExampleGenericAliases() {
 	noValue := None[int]()
 
 	fmt.Printf("Maybe value present: %v, value: %d\n",
-		maybeValue.IsPresent(), maybeValue.Get())
-	fmt.Printf("No value present: %v\n", noValue.IsPresent())
+		maybeValue.IsSome(), maybeValue.OrZero())
+	fmt.Printf("No value present: %v\n", noValue.IsSome())
 
 	// Using Pair
 	pair := NewPair("age", 25)
@@ -127,15 +109,15 @@ func ExampleGenericAliases() {
 
 	// Result slice
 	results := ResultSlice[string]{
-		{Value: "success", Error: nil},
-		{Value: "", Error: fmt.Errorf("failed")},
+		OkResult("success"),
+		ErrResult[string](fmt.Errorf("failed")),
 	}
 
 	for i, r := range results {
-		if r.Error != nil {
-			fmt.Printf("Result %d: error - %v\n", i, r.Error)
+		if value, err := r.Unwrap(); err != nil {
+			fmt.Printf("Result %d: error - %v\n", i, err)
 		} else {
-			fmt.Printf("Result %d: %s\n", i, r.Value)
+			fmt.Printf("Result %d: %s\n", i, value)
 		}
 	}
 }
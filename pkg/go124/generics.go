@@ -1,6 +1,11 @@
 package go124
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
+)
 
 // Generics demonstrates Go's generic programming features.
 //
@@ -210,76 +215,18 @@ func Sum[T Number](values ...T) T {
 	return result
 }
 
-// GenericResult represents a value or error (more advanced than Result).
-type GenericResult[T any] struct {
-	value T
-	err   error
-}
+// GenericResult is the canonical monad.Result; it used to be a separate
+// type with a smaller API than Result.
+type GenericResult[T any] = monad.Result[T]
 
 // OkResult creates a successful result.
 func OkResult[T any](value T) GenericResult[T] {
-	return GenericResult[T]{value: value}
+	return monad.Ok(value)
 }
 
 // ErrResult creates an error result.
 func ErrResult[T any](err error) GenericResult[T] {
-	var zero T
-	return GenericResult[T]{value: zero, err: err}
-}
-
-// IsOk returns true if the result is successful.
-func (r GenericResult[T]) IsOk() bool {
-	return r.err == nil
-}
-
-// Unwrap returns the value and error.
-func (r GenericResult[T]) Unwrap() (T, error) {
-	return r.value, r.err
-}
-
-// Map transforms the value if successful.
-func (r GenericResult[T]) Map(fn func(T) T) GenericResult[T] {
-	if r.IsOk() {
-		return OkResult(fn(r.value))
-	}
-	return r
-}
-
-// Cache is a generic cache with type safety.
-type Cache[K comparable, V any] struct {
-	data map[K]V
-}
-
-// NewCache creates a new cache.
-func NewCache[K comparable, V any]() *Cache[K, V] {
-	return &Cache[K, V]{
-		data: make(map[K]V),
-	}
-}
-
-// Get retrieves a value.
-func (c *Cache[K, V]) Get(key K) (V, bool) {
-	val, ok := c.data[key]
-	return val, ok
-}
-
-// Set stores a value.
-func (c *Cache[K, V]) Set(key K, value V) {
-	c.data[key] = value
-}
-
-// Delete removes a value.
-func (c *Cache[K, V]) Delete(key K) {
-	delete(c.data, key)
-}
-
-// Keys returns all keys.
-func (c *Cache[K, V]) Keys() []K {
-	keys := make([]K, 0, len(c.data))
-	for k := range c.data {
-		keys = append(keys, k)
-	}
-	return keys
+	return monad.Err[T](err)
 }
 
 // ExampleGenerics demonstrates generic programming.
@@ -324,10 +271,16 @@ func ExampleGenerics() {
 	set1 := NewSet(1, 2, 3, 4)
 	set2 := NewSet(3, 4, 5, 6)
 
-	fmt.Printf("\nSet1: %v\n", set1.ToSlice())
-	fmt.Printf("Set2: %v\n", set2.ToSlice())
-	fmt.Printf("Union: %v\n", set1.Union(set2).ToSlice())
-	fmt.Printf("Intersection: %v\n", set1.Intersection(set2).ToSlice())
+	// Map iteration order is random, so sort before printing.
+	sorted := func(s Set[int]) []int {
+		items := s.ToSlice()
+		slices.Sort(items)
+		return items
+	}
+	fmt.Printf("\nSet1: %v\n", sorted(set1))
+	fmt.Printf("Set2: %v\n", sorted(set2))
+	fmt.Printf("Union: %v\n", sorted(set1.Union(set2)))
+	fmt.Printf("Intersection: %v\n", sorted(set1.Intersection(set2)))
 
 	// Generic binary tree
 	tree := NewBinaryTree(5)
@@ -348,10 +301,10 @@ func ExampleGenerics() {
 	// Generic result type
 	successResult := OkResult(42)
 	fmt.Printf("\nResult is OK: %v, value: %d\n",
-		successResult.IsOk(), successResult.value)
+		successResult.IsOk(), successResult.MustGet())
 
-	doubledResult := successResult.Map(func(v int) int { return v * 2 })
-	fmt.Printf("Doubled: %d\n", doubledResult.value)
+	doubledResult := monad.MapResult(successResult, func(v int) int { return v * 2 })
+	fmt.Printf("Doubled: %d\n", doubledResult.MustGet())
 
 	// Generic cache
 	cache := NewCache[string, int]()
@@ -360,5 +313,7 @@ func ExampleGenerics() {
 
 	age, ok := cache.Get("age")
 	fmt.Printf("\nCache get 'age': %d (found: %v)\n", age, ok)
-	fmt.Printf("Cache keys: %v\n", cache.Keys())
+	keys := cache.Keys()
+	slices.Sort(keys)
+	fmt.Printf("Cache keys: %v\n", keys)
 }
@@ -108,15 +108,15 @@ func TestOptional(t *testing.T) {
 	some := Some(42)
 	none := None[int]()
 
-	if !some.IsPresent() {
+	if !some.IsSome() {
 		t.Error("Expected Some to be present")
 	}
 
-	if some.Get() != 42 {
-		t.Errorf("Expected 42, got %d", some.Get())
+	if v, _ := some.Get(); v != 42 {
+		t.Errorf("Expected 42, got %d", v)
 	}
 
-	if none.IsPresent() {
+	if none.IsSome() {
 		t.Error("Expected None to not be present")
 	}
 }
@@ -3,6 +3,7 @@ package idioms
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 )
@@ -13,130 +14,15 @@ import (
 // optimized select with context, and better diagnostics. Channels are now
 // safer, faster, and more composable than ever.
 
-// SafePipeline demonstrates guaranteed termination with for-range.
-// Go 1.24 guarantees the loop exits when the channel is closed.
-func SafePipeline(ctx context.Context, input <-chan int) <-chan int {
-	output := make(chan int)
-
-	go func() {
-		defer close(output) // Guarantees for-range will terminate
-
-		for item := range input { // Go 1.24: guaranteed termination
-			select {
-			case <-ctx.Done():
-				return
-			case output <- item * 2:
-			}
-		}
-	}()
-
-	return output
-}
-
-// Generator creates a channel that produces values.
-func Generator(ctx context.Context, values ...int) <-chan int {
-	ch := make(chan int)
-
-	go func() {
-		defer close(ch)
-
-		for _, v := range values {
-			select {
-			case <-ctx.Done():
-				return
-			case ch <- v:
-			}
-		}
-	}()
-
-	return ch
-}
-
-// FanOutFanIn demonstrates concurrent processing with channels.
-func FanOutFanIn(ctx context.Context, input <-chan int, workers int) <-chan int {
-	// Fan-out: distribute work to multiple workers
-	workerChannels := make([]<-chan int, workers)
-
-	for i := 0; i < workers; i++ {
-		workerChannels[i] = worker(ctx, input, i)
-	}
-
-	// Fan-in: merge results from all workers
-	return merge(ctx, workerChannels...)
-}
-
-func worker(ctx context.Context, input <-chan int, id int) <-chan int {
-	output := make(chan int)
-
-	go func() {
-		defer close(output)
-
-		for val := range input {
-			select {
-			case <-ctx.Done():
-				return
-			case output <- val * val:
-				fmt.Printf("Worker %d processed: %d\n", id, val)
-			}
-		}
-	}()
-
-	return output
-}
-
-func merge(ctx context.Context, channels ...<-chan int) <-chan int {
-	output := make(chan int)
-	var wg sync.WaitGroup
-
-	for _, ch := range channels {
-		wg.Add(1)
-		go func(c <-chan int) {
-			defer wg.Done()
-
-			for val := range c { // Safe termination
-				select {
-				case <-ctx.Done():
-					return
-				case output <- val:
-				}
-			}
-		}(ch)
+// FanOutFanIn applies fn to input values on workers goroutines and
+// merges the results. Output order is not preserved.
+func FanOutFanIn[In, Out any](ctx context.Context, input <-chan In, workers int, fn func(In) Out) <-chan Out {
+	branches := FanOut(ctx, input, workers)
+	results := make([]<-chan Out, len(branches))
+	for i, branch := range branches {
+		results[i] = Stage(ctx, branch, fn)
 	}
-
-	go func() {
-		wg.Wait()
-		close(output)
-	}()
-
-	return output
-}
-
-// Tee splits a channel into two output channels.
-func Tee(ctx context.Context, input <-chan int) (out1, out2 <-chan int) {
-	ch1 := make(chan int)
-	ch2 := make(chan int)
-	out1, out2 = ch1, ch2
-
-	go func() {
-		defer close(ch1)
-		defer close(ch2)
-
-		for val := range input {
-			select {
-			case <-ctx.Done():
-				return
-			case ch1 <- val:
-			}
-
-			select {
-			case <-ctx.Done():
-				return
-			case ch2 <- val:
-			}
-		}
-	}()
-
-	return
+	return FanIn(ctx, results...)
 }
 
 // Broadcaster sends values to multiple subscribers.
@@ -283,26 +169,51 @@ func ExampleChannels() {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	// Safe pipeline with guaranteed termination
-	fmt.Println("\nSafe Pipeline:")
-	input := Generator(ctx, 1, 2, 3, 4, 5)
-	doubled := SafePipeline(ctx, input)
+	// Typed pipeline with guaranteed termination
+	fmt.Println("\nTyped Pipeline:")
+	words := Source(ctx, "go", "channels", "generics")
+	lengths := Stage(ctx, words, func(s string) int { return len(s) })
 
-	for val := range doubled {
+	for val := range lengths { // Go 1.24: guaranteed termination
 		fmt.Printf("%d ", val)
 	}
 	fmt.Println()
 
 	// Fan-out/Fan-in pattern
 	fmt.Println("\nFan-Out/Fan-In:")
-	input2 := Generator(ctx, 1, 2, 3, 4, 5)
-	results := FanOutFanIn(ctx, input2, 3)
+	results := FanOutFanIn(ctx, Source(ctx, 1, 2, 3, 4, 5), 3, func(n int) int { return n * n })
 
 	collected := make([]int, 0)
 	for val := range results {
 		collected = append(collected, val)
 	}
-	fmt.Printf("Results: %v\n", collected)
+	slices.Sort(collected)
+	fmt.Printf("Results (sorted): %v\n", collected)
+
+	// Tee and Batch
+	fmt.Println("\nTee and Batch:")
+	copies := Tee(ctx, Source(ctx, 1, 2, 3, 4, 5), 2)
+	batches := Batch(ctx, copies[0], 2, 50*time.Millisecond)
+	total := copies[1]
+
+	sum := 0
+	for batches != nil || total != nil {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				batches = nil
+				continue
+			}
+			fmt.Printf("Batch: %v\n", batch)
+		case n, ok := <-total:
+			if !ok {
+				total = nil
+				continue
+			}
+			sum += n
+		}
+	}
+	fmt.Printf("Sum from second copy: %d\n", sum)
 
 	// Broadcaster pattern
 	fmt.Println("\nBroadcaster:")
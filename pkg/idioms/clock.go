@@ -0,0 +1,176 @@
+package idioms
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so that time-based code can be tested without
+// sleeping.
+//
+// Why? A limiter or timer tested against the wall clock is either slow
+// (real sleeps) or flaky (scheduling jitter). Code that asks a Clock for
+// the time can be driven step by step with a ManualClock instead.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock returns the real clock.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
+
+// AfterFuncer is implemented by clocks that can call a function once d
+// has passed, like time.AfterFunc. The returned stop func cancels the
+// call and reports whether it did so before the call started.
+//
+// Why a separate interface? Code built on After has to start a goroutine
+// per timer, and a test cannot tell when that goroutine has finished.
+// ManualClock runs AfterFunc callbacks inside Advance, so everything due
+// has happened by the time Advance returns.
+type AfterFuncer interface {
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// ManualClock is a Clock that only moves when Advance is called.
+// The zero value starts at the zero time; use NewManualClock to pick a
+// starting point.
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*manualWaiter
+	added   *sync.Cond // signalled when a waiter is added; see BlockUntil
+}
+
+// manualWaiter is a pending After channel or AfterFunc callback.
+type manualWaiter struct {
+	at time.Time
+	ch chan time.Time
+	fn func()
+}
+
+// NewManualClock returns a ManualClock set to start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that fires once the clock has been advanced
+// by at least d.
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.addWaiter(&manualWaiter{at: at, ch: ch})
+	return ch
+}
+
+// AfterFunc arranges for f to be called once the clock has been advanced
+// by at least d. f runs on the goroutine calling Advance, after the
+// clock has moved, so it may use the clock itself. If d <= 0, f runs on
+// its own goroutine at once, as with time.AfterFunc.
+func (c *ManualClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	if d <= 0 {
+		go f()
+		return func() bool { return false }
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &manualWaiter{at: c.now.Add(d), fn: f}
+	c.addWaiter(w)
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		i := slices.Index(c.waiters, w)
+		if i < 0 {
+			return false
+		}
+		c.waiters = slices.Delete(c.waiters, i, i+1)
+		return true
+	}
+}
+
+// Advance moves the clock forward by d, fires every After channel that
+// has come due and then runs the due AfterFunc callbacks in the order of
+// their deadlines.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []*manualWaiter
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		switch {
+		case w.at.After(c.now):
+			pending = append(pending, w)
+		case w.fn != nil:
+			due = append(due, w)
+		default:
+			w.ch <- c.now
+		}
+	}
+	clear(c.waiters[len(pending):])
+	c.waiters = pending
+	c.mu.Unlock()
+
+	slices.SortStableFunc(due, func(a, b *manualWaiter) int { return a.at.Compare(b.at) })
+	for _, w := range due {
+		w.fn()
+	}
+}
+
+// addWaiter registers w and wakes BlockUntil. The caller must hold c.mu.
+func (c *ManualClock) addWaiter(w *manualWaiter) {
+	c.waiters = append(c.waiters, w)
+	if c.added != nil {
+		c.added.Broadcast()
+	}
+}
+
+// BlockUntil blocks until at least n After channels and AfterFunc
+// callbacks are waiting for the clock. A test or example that starts
+// goroutines which wait on the clock calls it before Advance, so that
+// none of them registers too late and misses its deadline.
+func (c *ManualClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.added == nil {
+		c.added = sync.NewCond(&c.mu)
+	}
+	for len(c.waiters) < n {
+		c.added.Wait()
+	}
+}
+
+// Waiters returns the number of After channels and AfterFunc callbacks
+// that have not fired yet. To wait for a goroutine to block on the
+// clock, use BlockUntil.
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
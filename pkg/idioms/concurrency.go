@@ -3,6 +3,7 @@ package idioms
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -12,120 +13,47 @@ import (
 // Why? Go's built-in concurrency primitives make concurrent
 // programming accessible and idiomatic.
 
-// GenerateNumbers creates a channel that produces values.
+// GenerateNumbers emits the integers from start to end inclusive.
 func GenerateNumbers(ctx context.Context, start, end int) <-chan int {
-	ch := make(chan int)
-
-	go func() {
-		defer close(ch)
+	return SourceSeq(ctx, func(yield func(int) bool) {
 		for i := start; i <= end; i++ {
-			select {
-			case <-ctx.Done():
+			if !yield(i) {
 				return
-			case ch <- i:
 			}
 		}
-	}()
-
-	return ch
+	})
 }
 
 // Square demonstrates channel-based pipelines.
 func Square(ctx context.Context, in <-chan int) <-chan int {
-	out := make(chan int)
-
-	go func() {
-		defer close(out)
-		for n := range in {
-			select {
-			case <-ctx.Done():
-				return
-			case out <- n * n:
-			}
-		}
-	}()
-
-	return out
+	return Stage(ctx, in, func(n int) int { return n * n })
 }
 
-// FanOut distributes work across multiple workers.
-func FanOut(ctx context.Context, in <-chan int, workers int) []<-chan int {
-	channels := make([]<-chan int, workers)
-
-	for i := 0; i < workers; i++ {
-		channels[i] = Square(ctx, in)
-	}
-
-	return channels
-}
-
-// FanIn merges multiple channels into one.
-func FanIn(ctx context.Context, channels ...<-chan int) <-chan int {
-	out := make(chan int)
-	var wg sync.WaitGroup
-
-	for _, ch := range channels {
-		wg.Add(1)
-		go func(c <-chan int) {
-			defer wg.Done()
-			for n := range c {
-				select {
-				case <-ctx.Done():
-					return
-				case out <- n:
-				}
-			}
-		}(ch)
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-
-	return out
-}
-
-// WorkerPool demonstrates the worker pool pattern.
+// WorkerPool demonstrates the worker pool pattern for fire-and-forget
+// jobs. It is a thin wrapper over Pool; use Pool directly for results,
+// errors and rejection policies.
 type WorkerPool struct {
-	workers int
-	jobs    chan func()
-	wg      sync.WaitGroup
+	pool *Pool[func(), struct{}]
 }
 
 // NewWorkerPool creates a worker pool.
 func NewWorkerPool(workers int) *WorkerPool {
-	pool := &WorkerPool{
-		workers: workers,
-		jobs:    make(chan func(), 100),
-	}
-	pool.start()
-	return pool
-}
-
-func (p *WorkerPool) start() {
-	for i := 0; i < p.workers; i++ {
-		p.wg.Add(1)
-		go p.worker()
-	}
-}
-
-func (p *WorkerPool) worker() {
-	defer p.wg.Done()
-	for job := range p.jobs {
+	run := func(_ context.Context, job func()) (struct{}, error) {
 		job()
+		return struct{}{}, nil
 	}
+	return &WorkerPool{pool: NewPool(workers, run, WithQueueSize(100))}
 }
 
-// Submit submits a job to the pool.
-func (p *WorkerPool) Submit(job func()) {
-	p.jobs <- job
+// Submit submits a job to the pool. It returns ErrPoolClosed after Close.
+func (p *WorkerPool) Submit(job func()) error {
+	_, err := p.pool.Submit(context.Background(), job)
+	return err
 }
 
 // Close closes the pool and waits for completion.
 func (p *WorkerPool) Close() {
-	close(p.jobs)
-	p.wg.Wait()
+	_ = p.pool.Shutdown(context.Background())
 }
 
 // SelectExample demonstrates the select statement.
@@ -177,68 +105,6 @@ func DoWithTimeout(timeout time.Duration) error {
 	}
 }
 
-// RateLimiter implements a token bucket rate limiter.
-type RateLimiter struct {
-	tokens chan struct{}
-	rate   time.Duration
-	stop   chan struct{}
-}
-
-// NewRateLimiter creates a rate limiter.
-func NewRateLimiter(capacity int, rate time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		tokens: make(chan struct{}, capacity),
-		rate:   rate,
-		stop:   make(chan struct{}),
-	}
-
-	// Fill initial tokens
-	for i := 0; i < capacity; i++ {
-		rl.tokens <- struct{}{}
-	}
-
-	// Refill tokens
-	go func() {
-		ticker := time.NewTicker(rate)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				select {
-				case rl.tokens <- struct{}{}:
-				default:
-					// Bucket full
-				}
-			case <-rl.stop:
-				return
-			}
-		}
-	}()
-
-	return rl
-}
-
-// Allow checks if an action is allowed.
-func (rl *RateLimiter) Allow() bool {
-	select {
-	case <-rl.tokens:
-		return true
-	default:
-		return false
-	}
-}
-
-// Wait waits for a token.
-func (rl *RateLimiter) Wait() {
-	<-rl.tokens
-}
-
-// Close stops the rate limiter.
-func (rl *RateLimiter) Close() {
-	close(rl.stop)
-}
-
 // SafeCounter demonstrates synchronized access.
 type SafeCounter struct {
 	mu    sync.RWMutex
@@ -289,6 +155,37 @@ func ExampleConcurrency() {
 
 	pool.Close()
 
+	// Generic pool with results, errors and panic recovery
+	fmt.Println("Generic Pool:")
+	parse := NewPool(2, func(_ context.Context, s string) (int, error) {
+		if s == "boom" {
+			panic("unparseable input")
+		}
+		return strconv.Atoi(s)
+	}, WithQueueSize(4), WithRejectPolicy(RejectError))
+
+	var futures []*Future[int]
+	for _, in := range []string{"7", "x", "boom", "42"} {
+		future, err := parse.Submit(ctx, in)
+		if err != nil {
+			fmt.Printf("Submit %q: %v\n", in, err)
+			continue
+		}
+		futures = append(futures, future)
+	}
+	for _, future := range futures {
+		if n, err := future.Get(ctx); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Printf("Result: %d\n", n)
+		}
+	}
+
+	_ = parse.Shutdown(ctx)
+	if _, err := parse.Submit(ctx, "1"); err != nil {
+		fmt.Printf("After shutdown: %v\n", err)
+	}
+
 	// Select statement
 	fmt.Println("Select:")
 	SelectExample(ctx)
@@ -304,7 +201,6 @@ func ExampleConcurrency() {
 	// Rate limiter
 	fmt.Println("Rate Limiter:")
 	limiter := NewRateLimiter(3, 100*time.Millisecond)
-	defer limiter.Close()
 
 	for i := 1; i <= 5; i++ {
 		if limiter.Allow() {
@@ -314,6 +210,20 @@ func ExampleConcurrency() {
 		}
 	}
 
+	// Reservations and per-key limits, driven by a manual clock
+	clock := NewManualClock(time.Time{})
+	apiLimiter := NewRateLimiter(2, time.Second, WithClock(clock))
+	for i := 1; i <= 4; i++ {
+		fmt.Printf("Reservation %d: wait %v\n", i, apiLimiter.Reserve())
+	}
+	clock.Advance(2 * time.Second)
+	fmt.Printf("Tokens after 2s: %.0f\n", apiLimiter.Tokens())
+
+	perKey := NewKeyedLimiter[string](1, time.Minute, time.Hour, WithClock(clock))
+	for _, key := range []string{"alice", "bob", "alice"} {
+		fmt.Printf("Key %s allowed: %v\n", key, perKey.Allow(key))
+	}
+
 	// Safe counter
 	fmt.Println("Safe Counter:")
 	counter := &SafeCounter{}
@@ -7,6 +7,7 @@
 //   - Zero value semantics for usable defaults
 //   - Goroutines and channels for concurrency
 //   - Go 1.24 enhanced channel patterns (safe for-range, context integration)
+//   - Generic pipeline stages (Source, Stage, FanOut, FanIn, Tee, Batch, Throttle)
 //   - Context propagation for cancellation and timeouts
 //   - Defer for resource cleanup
 //
@@ -38,10 +39,10 @@
 //		numbers := idioms.GenerateNumbers(ctx, 1, 10)
 //		squares := idioms.Square(ctx, numbers)
 //
-//		// Safe pipeline with guaranteed termination
-//		output := idioms.SafePipeline(ctx, numbers)
-//		for val := range output { // Guaranteed to terminate
-//			fmt.Println(val)
+//		// Generic stages with guaranteed termination
+//		labels := idioms.Stage(ctx, squares, strconv.Itoa)
+//		for label := range idioms.Batch(ctx, labels, 10, time.Second) {
+//			fmt.Println(label)
 //		}
 //	}
 package idioms
@@ -3,6 +3,8 @@ package idioms
 import (
 	"errors"
 	"fmt"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
 )
 
 // Error handling demonstrates Go's error patterns.
@@ -138,30 +140,17 @@ func processItem(item string) error {
 }
 
 // Result represents a result or error (alternative to multiple returns).
-type Result[T any] struct {
-	Value T
-	Err   error
-}
+// It is an alias of the canonical monad.Result.
+type Result[T any] = monad.Result[T]
 
 // Ok creates a successful result.
 func Ok[T any](value T) Result[T] {
-	return Result[T]{Value: value}
+	return monad.Ok(value)
 }
 
 // Err creates an error result.
 func Err[T any](err error) Result[T] {
-	var zero T
-	return Result[T]{Value: zero, Err: err}
-}
-
-// IsOk returns true if the result is successful.
-func (r Result[T]) IsOk() bool {
-	return r.Err == nil
-}
-
-// Unwrap returns the value and error.
-func (r Result[T]) Unwrap() (T, error) {
-	return r.Value, r.Err
+	return monad.Err[T](err)
 }
 
 // Divide returns a result instead of value and error.
@@ -242,13 +231,13 @@ func ExampleErrors() {
 
 	// Result type
 	result := Divide(10, 2)
-	if result.IsOk() {
-		fmt.Printf("Division result: %.2f\n", result.Value)
+	if value, err := result.Unwrap(); err == nil {
+		fmt.Printf("Division result: %.2f\n", value)
 	}
 
 	result2 := Divide(10, 0)
 	if !result2.IsOk() {
-		fmt.Printf("Division error: %v\n", result2.Err)
+		fmt.Printf("Division error: %v\n", result2.Err())
 	}
 
 	// Error wrapping for context
@@ -0,0 +1,465 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiters put different rate-limiting algorithms behind one interface.
+//
+// Why several? They trade accuracy, memory and burst behaviour:
+//   - TokenBucket (RateLimiter): allows bursts, smooth average rate
+//   - Sliding log: exact, but stores one timestamp per admitted event
+//   - Sliding window counter: near-exact with two counters
+//   - Fixed window: cheapest, but allows 2x bursts at window edges
+//   - Leaky bucket: queues events and releases them at a constant rate
+//   - Concurrency: bounds in-flight work rather than its rate
+//
+// Callers such as HTTP middleware only need to know whether an event
+// may proceed, how long to back off if not, and (for concurrency
+// limits) when the event is done.
+
+// Limiter admits or rejects events.
+type Limiter interface {
+	// Acquire admits one event, waiting if the algorithm queues events.
+	// The returned release func must be called when the event is done;
+	// it is a no-op for pure rate limiters. A rejection is reported as
+	// a *LimitError; ctx errors are returned as is.
+	Acquire(ctx context.Context) (release func(), err error)
+}
+
+// ErrLimited matches every *LimitError with errors.Is.
+var ErrLimited = errors.New("rate limited")
+
+// LimitError reports a rejected event and when retrying may succeed.
+type LimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limited: retry after %v", e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrLimited) true.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimited
+}
+
+func noRelease() {}
+
+func limited(retryAfter time.Duration) (func(), error) {
+	return nil, &LimitError{RetryAfter: max(retryAfter, 0)}
+}
+
+// Acquire implements Limiter without waiting: if no token is available
+// it rejects with the time until one will be.
+func (rl *RateLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return noRelease, nil
+	}
+	return limited(time.Duration((1 - rl.tokens) * float64(rl.rate)))
+}
+
+// SlidingLogLimiter admits at most limit events in any window-long
+// period, remembering the time of each admitted event.
+type SlidingLogLimiter struct {
+	mu     sync.Mutex
+	clock  Clock
+	limit  int
+	window time.Duration
+	log    []time.Time
+}
+
+// NewSlidingLogLimiter creates a sliding-window log limiter. It panics
+// unless limit and window are positive.
+func NewSlidingLogLimiter(limit int, window time.Duration, opts ...LimiterOption) *SlidingLogLimiter {
+	mustBePositive("NewSlidingLogLimiter", "limit", limit)
+	mustBePositive("NewSlidingLogLimiter", "window", window)
+	cfg := newLimiterConfig(opts)
+	return &SlidingLogLimiter{clock: cfg.clock, limit: limit, window: window}
+}
+
+// Acquire implements Limiter.
+func (l *SlidingLogLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	expired := 0
+	for expired < len(l.log) && now.Sub(l.log[expired]) >= l.window {
+		expired++
+	}
+	l.log = l.log[expired:]
+
+	if len(l.log) >= l.limit {
+		return limited(l.log[0].Add(l.window).Sub(now))
+	}
+	l.log = append(l.log, now)
+	return noRelease, nil
+}
+
+// SlidingWindowLimiter approximates a sliding window with the counts of
+// the current and previous fixed windows, weighting the previous count
+// by how much of it still overlaps the sliding window.
+type SlidingWindowLimiter struct {
+	mu       sync.Mutex
+	clock    Clock
+	limit    int
+	window   time.Duration
+	start    time.Time // start of the current fixed window
+	current  int
+	previous int
+}
+
+// NewSlidingWindowLimiter creates a sliding-window counter limiter. It
+// panics unless limit and window are positive.
+func NewSlidingWindowLimiter(limit int, window time.Duration, opts ...LimiterOption) *SlidingWindowLimiter {
+	mustBePositive("NewSlidingWindowLimiter", "limit", limit)
+	mustBePositive("NewSlidingWindowLimiter", "window", window)
+	cfg := newLimiterConfig(opts)
+	return &SlidingWindowLimiter{
+		clock:  cfg.clock,
+		limit:  limit,
+		window: window,
+		start:  cfg.clock.Now(),
+	}
+}
+
+// Acquire implements Limiter.
+func (l *SlidingWindowLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if passed := int(now.Sub(l.start) / l.window); passed > 0 {
+		l.previous = l.current
+		if passed > 1 {
+			l.previous = 0
+		}
+		l.current = 0
+		l.start = l.start.Add(time.Duration(passed) * l.window)
+	}
+
+	elapsed := now.Sub(l.start)
+	overlap := 1 - float64(elapsed)/float64(l.window)
+	if float64(l.previous)*overlap+float64(l.current)+1 <= float64(l.limit) {
+		l.current++
+		return noRelease, nil
+	}
+	return limited(l.retryAfter(elapsed))
+}
+
+// retryAfter solves for the earliest time the weighted count leaves room
+// for one more event. The caller must hold l.mu.
+func (l *SlidingWindowLimiter) retryAfter(elapsed time.Duration) time.Duration {
+	w := float64(l.window)
+	room := float64(l.limit - 1 - l.current)
+	if room >= 0 && l.previous > 0 {
+		// Still in this window: wait for the previous window's weight to fall.
+		return time.Duration(w*(1-room/float64(l.previous))) - elapsed
+	}
+	// Wait for the next window, where the current count becomes the
+	// previous one.
+	untilNext := l.window - elapsed
+	if l.current == 0 {
+		return untilNext
+	}
+	fraction := math.Max(0, 1-float64(l.limit-1)/float64(l.current))
+	return untilNext + time.Duration(w*fraction)
+}
+
+// FixedWindowLimiter admits at most limit events per window, with
+// windows aligned to multiples of window.
+type FixedWindowLimiter struct {
+	mu     sync.Mutex
+	clock  Clock
+	limit  int
+	window time.Duration
+	start  time.Time
+	count  int
+}
+
+// NewFixedWindowLimiter creates a fixed-window limiter. It panics unless
+// limit and window are positive.
+func NewFixedWindowLimiter(limit int, window time.Duration, opts ...LimiterOption) *FixedWindowLimiter {
+	mustBePositive("NewFixedWindowLimiter", "limit", limit)
+	mustBePositive("NewFixedWindowLimiter", "window", window)
+	cfg := newLimiterConfig(opts)
+	return &FixedWindowLimiter{clock: cfg.clock, limit: limit, window: window}
+}
+
+// mustBePositive rejects a limiter argument that is zero or negative: a
+// zero window or interval divides by zero or never advances, and a
+// non-positive limit or capacity admits nothing. Like time.NewTicker,
+// the constructors panic rather than return an error, since these are
+// programming mistakes.
+func mustBePositive[N int | time.Duration](constructor, arg string, v N) {
+	if v <= 0 {
+		panic(fmt.Sprintf("idioms.%s: non-positive %s %v", constructor, arg, v))
+	}
+}
+
+// Acquire implements Limiter.
+func (l *FixedWindowLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if start := now.Truncate(l.window); !start.Equal(l.start) {
+		l.start, l.count = start, 0
+	}
+	if l.count >= l.limit {
+		return limited(l.start.Add(l.window).Sub(now))
+	}
+	l.count++
+	return noRelease, nil
+}
+
+// LeakyBucketLimiter releases events at a constant rate of one per
+// interval, queueing up to capacity events and rejecting the rest.
+//
+// Unlike a token bucket it never lets a burst through: a burst is
+// smoothed out by making its events wait for their turn.
+type LeakyBucketLimiter struct {
+	mu       sync.Mutex
+	clock    Clock
+	capacity int
+	interval time.Duration
+	next     time.Time // when the next event may leave the bucket
+}
+
+// NewLeakyBucketLimiter creates a leaky-bucket limiter. It panics unless
+// capacity and interval are positive.
+func NewLeakyBucketLimiter(capacity int, interval time.Duration, opts ...LimiterOption) *LeakyBucketLimiter {
+	mustBePositive("NewLeakyBucketLimiter", "capacity", capacity)
+	mustBePositive("NewLeakyBucketLimiter", "interval", interval)
+	cfg := newLimiterConfig(opts)
+	return &LeakyBucketLimiter{clock: cfg.clock, capacity: capacity, interval: interval}
+}
+
+// Acquire implements Limiter, waiting for the event's turn. An event
+// whose wait is abandoned through ctx keeps its slot, so later events
+// are not reordered.
+func (l *LeakyBucketLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	now := l.clock.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if limit := time.Duration(l.capacity) * l.interval; wait > limit {
+		l.mu.Unlock()
+		return limited(wait - limit)
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return noRelease, nil
+	}
+	select {
+	case <-l.clock.After(wait):
+		return noRelease, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ConcurrencyLimiter allows at most limit events in flight. Events
+// beyond that wait up to queueTimeout for a slot before being rejected.
+type ConcurrencyLimiter struct {
+	clock        Clock
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+// NewConcurrencyLimiter creates a concurrency limiter. It panics unless
+// limit is positive.
+func NewConcurrencyLimiter(limit int, queueTimeout time.Duration, opts ...LimiterOption) *ConcurrencyLimiter {
+	mustBePositive("NewConcurrencyLimiter", "limit", limit)
+	cfg := newLimiterConfig(opts)
+	return &ConcurrencyLimiter{
+		clock:        cfg.clock,
+		slots:        make(chan struct{}, limit),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire implements Limiter. The release func frees the slot and is
+// safe to call more than once.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release(), nil
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		return limited(0)
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release(), nil
+	case <-l.clock.After(l.queueTimeout):
+		// Nothing finished within the timeout; suggest waiting as long again.
+		return limited(l.queueTimeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) release() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-l.slots })
+	}
+}
+
+// InFlight returns the number of events currently holding a slot.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+// RateLimitMiddleware rejects requests the limiter refuses with
+// 429 Too Many Requests and a Retry-After header in whole seconds.
+// Requests abandoned by the client while queued get 503.
+func RateLimitMiddleware(l Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := l.Acquire(r.Context())
+		if err != nil {
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			seconds := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ExampleRateLimiting compares the limiting algorithms and shows the
+// HTTP middleware.
+func ExampleRateLimiting() {
+	fmt.Println("=== Rate Limiting ===")
+
+	ctx := context.Background()
+	clock := NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	algorithms := []struct {
+		name    string
+		limiter Limiter
+	}{
+		{"token bucket", NewRateLimiter(3, time.Second, WithClock(clock))},
+		{"sliding log", NewSlidingLogLimiter(3, 3*time.Second, WithClock(clock))},
+		{"sliding window", NewSlidingWindowLimiter(3, 3*time.Second, WithClock(clock))},
+		{"fixed window", NewFixedWindowLimiter(3, 3*time.Second, WithClock(clock))},
+		{"concurrency", NewConcurrencyLimiter(3, 0, WithClock(clock))},
+	}
+
+	// Five events at the same instant: every algorithm admits three.
+	for _, a := range algorithms {
+		admitted, retry := 0, time.Duration(0)
+		for range 5 {
+			if _, err := a.limiter.Acquire(ctx); err != nil {
+				var limitErr *LimitError
+				if errors.As(err, &limitErr) {
+					retry = limitErr.RetryAfter
+				}
+				continue
+			}
+			admitted++
+		}
+		fmt.Printf("%-15s admitted %d of 5, retry after %v\n", a.name, admitted, retry)
+	}
+
+	// The leaky bucket queues instead of rejecting, up to its capacity.
+	leaky := NewLeakyBucketLimiter(2, time.Second, WithClock(clock))
+	done := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := leaky.Acquire(ctx)
+			done <- err
+		}()
+	}
+	<-done // the first event leaves at once
+	clock.BlockUntil(2)
+	_, err := leaky.Acquire(ctx)
+	fmt.Printf("leaky bucket:   2 queued, 4th event: %v\n", err)
+	clock.Advance(2 * time.Second)
+	<-done
+	<-done
+	fmt.Println("leaky bucket:   queued events released after 2s")
+
+	// HTTP middleware answers 429 with Retry-After.
+	handler := RateLimitMiddleware(NewFixedWindowLimiter(1, time.Minute, WithClock(clock)),
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprint(w, "ok")
+		}))
+	for i := 1; i <= 2; i++ {
+		rec := &responseRecorder{header: http.Header{}}
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/api", nil)
+		handler.ServeHTTP(rec, req)
+		fmt.Printf("Request %d: %d Retry-After=%q\n", i, rec.code, rec.Header().Get("Retry-After"))
+	}
+}
+
+// responseRecorder is the part of httptest.ResponseRecorder the example
+// needs, so that a non-test file does not import a testing package.
+type responseRecorder struct {
+	header http.Header
+	code   int
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
@@ -0,0 +1,220 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// step advances the clock, then tries to acquire once.
+type step struct {
+	advance   time.Duration
+	admit     bool
+	wantRetry time.Duration // checked when the step is rejected
+}
+
+func runSteps(t *testing.T, clock *ManualClock, l Limiter, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		release, err := l.Acquire(context.Background())
+		var limitErr *LimitError
+		switch {
+		case s.admit && err != nil:
+			t.Fatalf("step %d: rejected (%v), want admitted", i, err)
+		case !s.admit && !errors.As(err, &limitErr):
+			t.Fatalf("step %d: err = %v, want *LimitError", i, err)
+		case !s.admit && limitErr.RetryAfter != s.wantRetry:
+			t.Errorf("step %d: RetryAfter = %v, want %v", i, limitErr.RetryAfter, s.wantRetry)
+		}
+		if release != nil {
+			release()
+		}
+	}
+}
+
+func TestLimiters(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		build func(Clock) Limiter
+		steps []step
+	}{
+		{"token bucket", func(c Clock) Limiter { return NewRateLimiter(2, time.Second, WithClock(c)) }, []step{
+			{0, true, 0}, {0, true, 0}, {0, false, time.Second},
+			{500 * time.Millisecond, false, 500 * time.Millisecond},
+			{500 * time.Millisecond, true, 0},
+		}},
+		{"sliding log", func(c Clock) Limiter { return NewSlidingLogLimiter(2, 10*time.Second, WithClock(c)) }, []step{
+			{0, true, 0}, {4 * time.Second, true, 0}, {0, false, 6 * time.Second},
+			{6 * time.Second, true, 0}, // the first event has left the window
+			{0, false, 4 * time.Second},
+		}},
+		{"sliding window", func(c Clock) Limiter { return NewSlidingWindowLimiter(4, 10*time.Second, WithClock(c)) }, []step{
+			{0, true, 0}, {0, true, 0}, {0, true, 0}, {0, true, 0},
+			{0, false, 10*time.Second + 2500*time.Millisecond},
+			// Half way into the next window, the previous 4 weigh 2.
+			{15 * time.Second, true, 0}, {0, true, 0},
+			{0, false, 2500 * time.Millisecond},
+		}},
+		{"fixed window", func(c Clock) Limiter { return NewFixedWindowLimiter(2, 10*time.Second, WithClock(c)) }, []step{
+			{0, true, 0}, {9 * time.Second, true, 0}, {0, false, time.Second},
+			// A new window admits a full burst right after the old one.
+			{time.Second, true, 0}, {0, true, 0}, {0, false, 10 * time.Second},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewManualClock(start)
+			runSteps(t, clock, tt.build(clock), tt.steps)
+		})
+	}
+}
+
+func TestLeakyBucketQueues(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l := NewLeakyBucketLimiter(1, time.Second, WithClock(clock))
+	ctx := context.Background()
+
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := l.Acquire(ctx)
+		done <- err
+	}()
+	clock.BlockUntil(1)
+
+	var limitErr *LimitError
+	if _, err := l.Acquire(ctx); !errors.As(err, &limitErr) || limitErr.RetryAfter != time.Second {
+		t.Errorf("third event = %v, want rejection with 1s retry", err)
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("queued event = %v", err)
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	l := NewConcurrencyLimiter(1, time.Second, WithClock(clock))
+	ctx := context.Background()
+
+	release, err := l.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A queued event gets the slot when it is released.
+	done := make(chan error, 1)
+	go func() {
+		r, err := l.Acquire(ctx)
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	clock.BlockUntil(1)
+	release()
+	release() // releasing twice must not free a second slot
+	if err := <-done; err != nil {
+		t.Errorf("queued event = %v", err)
+	}
+
+	// A queued event that times out is rejected. The first queued event's
+	// timer is still pending, so wait for a second one.
+	release, _ = l.Acquire(ctx)
+	defer release()
+	go func() {
+		_, err := l.Acquire(ctx)
+		done <- err
+	}()
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+	if err := <-done; !errors.Is(err, ErrLimited) {
+		t.Errorf("timed out event = %v, want ErrLimited", err)
+	}
+	if l.InFlight() != 1 {
+		t.Errorf("InFlight() = %d, want 1", l.InFlight())
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	limiter := NewRateLimiter(1, 2500*time.Millisecond, WithClock(clock))
+	handler := RateLimitMiddleware(limiter, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		wantStatus int
+		wantRetry  string
+	}{
+		{http.StatusNoContent, ""},
+		{http.StatusTooManyRequests, "3"}, // 2.5s rounded up
+	}
+	for i, tt := range tests {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || resp.Header.Get("Retry-After") != tt.wantRetry {
+			t.Errorf("request %d: %d Retry-After=%q, want %d %q",
+				i, resp.StatusCode, resp.Header.Get("Retry-After"), tt.wantStatus, tt.wantRetry)
+		}
+	}
+}
+
+func TestLimitersRejectBadArguments(t *testing.T) {
+	constructors := map[string]func(int, time.Duration){
+		"sliding log":    func(limit int, window time.Duration) { NewSlidingLogLimiter(limit, window) },
+		"sliding window": func(limit int, window time.Duration) { NewSlidingWindowLimiter(limit, window) },
+		"fixed window":   func(limit int, window time.Duration) { NewFixedWindowLimiter(limit, window) },
+		"leaky bucket":   func(capacity int, interval time.Duration) { NewLeakyBucketLimiter(capacity, interval) },
+	}
+	tests := []struct {
+		name   string
+		limit  int
+		window time.Duration
+	}{
+		{"zero window", 3, 0},
+		{"negative window", 3, -time.Second},
+		{"zero limit", 0, time.Second},
+		{"negative limit", -1, time.Second},
+	}
+	for kind, newLimiter := range constructors {
+		for _, tt := range tests {
+			t.Run(kind+"/"+tt.name, func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("expected a panic")
+					}
+				}()
+				newLimiter(tt.limit, tt.window)
+			})
+		}
+	}
+
+	// The concurrency limiter has no window, and a zero queue timeout
+	// means no queueing, but it needs a positive limit too.
+	for _, limit := range []int{0, -1} {
+		t.Run(fmt.Sprintf("concurrency/limit %d", limit), func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			NewConcurrencyLimiter(limit, 0)
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Pool is a generic worker pool with results, errors and backpressure.
+//
+// Why? A bare "chan func()" pool is fine for fire-and-forget work, but
+// production callers need the job's result and error, a bound on queued
+// work so a burst cannot exhaust memory, a decision about what happens
+// when that bound is hit, and a shutdown that either drains the queue or
+// abandons it. Panics are recovered per job so one bad input cannot take
+// a worker, or the process, down with it.
+
+// Errors returned by Pool.
+var (
+	ErrPoolClosed = errors.New("pool closed")
+	ErrQueueFull  = errors.New("pool queue full")
+	ErrJobDropped = errors.New("job dropped: pool queue full")
+)
+
+// RejectPolicy decides what Submit does when the queue is full.
+type RejectPolicy int
+
+const (
+	// RejectBlock waits for queue space or for the submit context to end.
+	RejectBlock RejectPolicy = iota
+	// RejectDrop discards the job. Submit succeeds, and the returned
+	// future resolves with ErrJobDropped, so fire-and-forget callers
+	// need no error handling.
+	RejectDrop
+	// RejectError makes Submit return ErrQueueFull.
+	RejectError
+)
+
+// PanicError is the error a future resolves with when its job panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.Value)
+}
+
+// Future is the eventual result of a submitted job.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func newFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+func (f *Future[T]) resolve(value T, err error) {
+	f.value, f.err = value, err
+	close(f.done)
+}
+
+// Done is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get waits for the job's result, or returns ctx's error if ctx ends
+// first. The job keeps running in that case.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// PoolOption configures a Pool.
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	queueSize int
+	policy    RejectPolicy
+}
+
+// WithQueueSize bounds the number of jobs waiting for a worker.
+// The default is the initial worker count.
+func WithQueueSize(n int) PoolOption {
+	return func(c *poolConfig) { c.queueSize = n }
+}
+
+// WithRejectPolicy sets what happens when the queue is full.
+// The default is RejectBlock.
+func WithRejectPolicy(policy RejectPolicy) PoolOption {
+	return func(c *poolConfig) { c.policy = policy }
+}
+
+type poolJob[In, Out any] struct {
+	ctx    context.Context
+	in     In
+	future *Future[Out]
+}
+
+// Pool runs fn on submitted inputs using a resizable set of workers.
+type Pool[In, Out any] struct {
+	fn     func(context.Context, In) (Out, error)
+	policy RejectPolicy
+	queue  chan poolJob[In, Out]
+
+	// ctx is cancelled by Stop, aborting running jobs.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	closed     bool
+	closing    chan struct{}  // closed when the pool stops accepting jobs
+	submitting sync.WaitGroup // Submit calls that may still enqueue
+	workers    []chan struct{}
+	wg         sync.WaitGroup
+	shutdown   sync.Once
+}
+
+// NewPool starts a pool of workers running fn.
+func NewPool[In, Out any](workers int, fn func(context.Context, In) (Out, error), opts ...PoolOption) *Pool[In, Out] {
+	cfg := poolConfig{queueSize: workers}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool[In, Out]{
+		fn:      fn,
+		policy:  cfg.policy,
+		queue:   make(chan poolJob[In, Out], cfg.queueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+		closing: make(chan struct{}),
+	}
+	p.Resize(workers)
+	return p
+}
+
+// Submit queues in for processing. ctx bounds both the wait for queue
+// space (under RejectBlock) and the job itself.
+func (p *Pool[In, Out]) Submit(ctx context.Context, in In) (*Future[Out], error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.submitting.Add(1)
+	p.mu.Unlock()
+	defer p.submitting.Done()
+
+	job := poolJob[In, Out]{ctx: ctx, in: in, future: newFuture[Out]()}
+
+	if p.policy == RejectBlock {
+		select {
+		case p.queue <- job:
+			return job.future, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-p.closing:
+			return nil, ErrPoolClosed
+		}
+	}
+
+	select {
+	case p.queue <- job:
+		return job.future, nil
+	default:
+	}
+	if p.policy == RejectError {
+		return nil, ErrQueueFull
+	}
+	var zero Out
+	job.future.resolve(zero, ErrJobDropped)
+	return job.future, nil
+}
+
+// Size returns the current number of workers.
+func (p *Pool[In, Out]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.workers)
+}
+
+// Resize changes the number of workers. Extra workers start at once;
+// retired workers finish their current job first.
+func (p *Pool[In, Out]) Resize(n int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	for len(p.workers) < n {
+		quit := make(chan struct{})
+		p.workers = append(p.workers, quit)
+		p.wg.Add(1)
+		go p.work(quit)
+	}
+	for len(p.workers) > n && len(p.workers) > 0 {
+		last := len(p.workers) - 1
+		close(p.workers[last])
+		p.workers = p.workers[:last]
+	}
+	return nil
+}
+
+func (p *Pool[In, Out]) work(quit <-chan struct{}) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-quit:
+			return
+		case job, ok := <-p.queue:
+			if !ok {
+				return
+			}
+			p.run(job)
+		}
+	}
+}
+
+func (p *Pool[In, Out]) run(job poolJob[In, Out]) {
+	var zero Out
+	if p.ctx.Err() != nil {
+		job.future.resolve(zero, ErrPoolClosed)
+		return
+	}
+	if err := job.ctx.Err(); err != nil {
+		job.future.resolve(zero, err)
+		return
+	}
+
+	// The job is cancelled if either its own context or the pool ends.
+	ctx, cancel := context.WithCancel(job.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	defer func() {
+		if r := recover(); r != nil {
+			job.future.resolve(zero, &PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	job.future.resolve(p.fn(ctx, job.in))
+}
+
+// Shutdown stops accepting jobs, lets the workers finish everything
+// already queued, and waits for them. If ctx ends first, Shutdown
+// returns its error and the workers keep draining in the background.
+func (p *Pool[In, Out]) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop stops accepting jobs, cancels the context of running jobs,
+// resolves queued jobs with ErrPoolClosed and waits for the workers.
+func (p *Pool[In, Out]) Stop() {
+	p.cancel()
+	p.close()
+}
+
+func (p *Pool[In, Out]) close() {
+	p.shutdown.Do(func() {
+		p.mu.Lock()
+		p.closed = true
+		close(p.closing)
+		p.mu.Unlock()
+
+		// Once no Submit can enqueue, closing the queue lets workers drain
+		// it and exit. Retired workers are gone already, so make sure at
+		// least one is left to drain.
+		p.submitting.Wait()
+		close(p.queue)
+		p.mu.Lock()
+		if len(p.workers) == 0 {
+			p.wg.Add(1)
+			go p.work(nil)
+		}
+		p.mu.Unlock()
+	})
+	p.wg.Wait()
+}
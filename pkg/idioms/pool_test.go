@@ -0,0 +1,198 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func double(_ context.Context, n int) (int, error) { return n * 2, nil }
+
+// blockingPool returns a one-worker pool whose jobs wait for release,
+// with the worker already busy on a first job.
+func blockingPool(t *testing.T, opts ...PoolOption) (*Pool[int, int], chan struct{}) {
+	t.Helper()
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	p := NewPool(1, func(ctx context.Context, n int) (int, error) {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return n, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}, opts...)
+	if _, err := p.Submit(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	return p, release
+}
+
+func TestPoolResults(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(3, double)
+	defer p.Stop()
+
+	futures := make([]*Future[int], 10)
+	for i := range futures {
+		f, err := p.Submit(ctx, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		futures[i] = f
+	}
+	for i, f := range futures {
+		if got, err := f.Get(ctx); err != nil || got != i*2 {
+			t.Errorf("job %d = %d, %v; want %d", i, got, err, i*2)
+		}
+	}
+}
+
+func TestPoolRecoversPanics(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(1, func(_ context.Context, n int) (int, error) {
+		if n == 0 {
+			panic("zero")
+		}
+		return n, nil
+	})
+	defer p.Stop()
+
+	f, _ := p.Submit(ctx, 0)
+	var pe *PanicError
+	if _, err := f.Get(ctx); !errors.As(err, &pe) || pe.Value != "zero" {
+		t.Fatalf("Get() error = %v, want PanicError", err)
+	}
+
+	f, _ = p.Submit(ctx, 5)
+	if got, err := f.Get(ctx); err != nil || got != 5 {
+		t.Errorf("worker did not survive the panic: %d, %v", got, err)
+	}
+}
+
+func TestPoolRejectPolicies(t *testing.T) {
+	tests := []struct {
+		policy     RejectPolicy
+		wantSubmit error
+		wantGet    error
+	}{
+		{RejectError, ErrQueueFull, nil},
+		{RejectDrop, nil, ErrJobDropped},
+		{RejectBlock, context.DeadlineExceeded, nil},
+	}
+
+	for _, tt := range tests {
+		p, release := blockingPool(t, WithQueueSize(1), WithRejectPolicy(tt.policy))
+
+		// The worker is busy and this fills the queue.
+		if _, err := p.Submit(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		f, err := p.Submit(ctx, 2)
+		cancel()
+		if !errors.Is(err, tt.wantSubmit) {
+			t.Errorf("policy %d: Submit error = %v, want %v", tt.policy, err, tt.wantSubmit)
+		}
+		if tt.wantGet != nil {
+			if _, err := f.Get(context.Background()); !errors.Is(err, tt.wantGet) {
+				t.Errorf("policy %d: Get error = %v, want %v", tt.policy, err, tt.wantGet)
+			}
+		}
+
+		close(release)
+		p.Stop()
+	}
+}
+
+func TestPoolShutdown(t *testing.T) {
+	t.Run("graceful drains the queue", func(t *testing.T) {
+		p, release := blockingPool(t, WithQueueSize(5))
+		queued, _ := p.Submit(context.Background(), 1)
+		close(release)
+
+		if err := p.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := queued.Get(context.Background()); err != nil || got != 1 {
+			t.Errorf("queued job = %d, %v; want it to run", got, err)
+		}
+		if _, err := p.Submit(context.Background(), 2); !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("Submit after Shutdown = %v, want ErrPoolClosed", err)
+		}
+	})
+
+	t.Run("immediate cancels running and queued jobs", func(t *testing.T) {
+		p, _ := blockingPool(t, WithQueueSize(5))
+		queued, _ := p.Submit(context.Background(), 1)
+
+		p.Stop()
+		if _, err := queued.Get(context.Background()); !errors.Is(err, ErrPoolClosed) {
+			t.Errorf("queued job error = %v, want ErrPoolClosed", err)
+		}
+	})
+
+	t.Run("shutdown honours its context", func(t *testing.T) {
+		p, release := blockingPool(t)
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Shutdown = %v, want DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestPoolResize(t *testing.T) {
+	ctx := context.Background()
+	var running, peak atomic.Int32
+	gate := make(chan struct{})
+	p := NewPool(1, func(_ context.Context, n int) (int, error) {
+		now := running.Add(1)
+		for {
+			old := peak.Load()
+			if now <= old || peak.CompareAndSwap(old, now) {
+				break
+			}
+		}
+		<-gate
+		running.Add(-1)
+		return n, nil
+	}, WithQueueSize(10))
+	defer p.Stop()
+
+	if err := p.Resize(4); err != nil || p.Size() != 4 {
+		t.Fatalf("Resize(4) = %v, size %d", err, p.Size())
+	}
+	var futures []*Future[int]
+	for i := range 4 {
+		f, _ := p.Submit(ctx, i)
+		futures = append(futures, f)
+	}
+	deadline := time.Now().Add(time.Second)
+	for running.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(gate)
+	for _, f := range futures {
+		_, _ = f.Get(ctx)
+	}
+	if peak.Load() != 4 {
+		t.Errorf("peak concurrency = %d, want 4", peak.Load())
+	}
+
+	if err := p.Resize(1); err != nil || p.Size() != 1 {
+		t.Errorf("Resize(1) = %v, size %d", err, p.Size())
+	}
+	if f, _ := p.Submit(ctx, 9); f != nil {
+		if got, err := f.Get(ctx); err != nil || got != 9 {
+			t.Errorf("job after shrinking = %d, %v", got, err)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RateLimiter implements a token bucket computed from timestamps.
+//
+// Why timestamps? A refill goroutine that adds one token per tick is
+// only as accurate as the scheduler, leaks if nobody calls Close, and
+// cannot be tested without sleeping. Storing the token count together
+// with the time it was last updated lets every call work out the exact
+// refill since then, so there is no background goroutine at all.
+
+// ErrExceedsBurst is returned when more tokens are requested at once
+// than the bucket can ever hold.
+var ErrExceedsBurst = errors.New("rate limiter: request exceeds burst")
+
+// ErrNegativeTokens is returned when a negative number of tokens is
+// requested, which would otherwise add tokens to the bucket.
+var ErrNegativeTokens = errors.New("rate limiter: negative token count")
+
+// LimiterOption configures a rate limiter.
+type LimiterOption func(*limiterConfig)
+
+type limiterConfig struct {
+	clock Clock
+}
+
+// WithClock makes the limiter read time from clock instead of the
+// system clock.
+func WithClock(clock Clock) LimiterOption {
+	return func(c *limiterConfig) { c.clock = clock }
+}
+
+func newLimiterConfig(opts []LimiterOption) limiterConfig {
+	cfg := limiterConfig{clock: SystemClock()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// RateLimiter allows bursts of up to capacity events and refills one
+// token every rate.
+type RateLimiter struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   time.Duration
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter with a full bucket. It panics
+// unless rate is positive: a zero rate would never refill the bucket,
+// yet would make every reservation's wait zero.
+func NewRateLimiter(capacity int, rate time.Duration, opts ...LimiterOption) *RateLimiter {
+	mustBePositive("NewRateLimiter", "rate", rate)
+	cfg := newLimiterConfig(opts)
+	rl := &RateLimiter{clock: cfg.clock, rate: rate, burst: max(capacity, 1)}
+	rl.tokens = float64(rl.burst)
+	rl.last = rl.clock.Now()
+	return rl
+}
+
+// refill brings tokens up to date. The caller must hold rl.mu.
+func (rl *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(rl.last); elapsed > 0 {
+		rl.tokens = min(float64(rl.burst), rl.tokens+float64(elapsed)/float64(rl.rate))
+	}
+	rl.last = now
+}
+
+// Allow reports whether an event may happen now, consuming a token if so.
+func (rl *RateLimiter) Allow() bool {
+	return rl.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now, consuming n tokens if
+// so. It refuses a negative n.
+func (rl *RateLimiter) AllowN(n int) bool {
+	if n < 0 {
+		return false
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill(rl.clock.Now())
+	if rl.tokens < float64(n) {
+		return false
+	}
+	rl.tokens -= float64(n)
+	return true
+}
+
+// Reserve consumes a token now and returns how long the caller must
+// wait before acting on it. Unlike Allow, it never refuses; callers
+// that will not wait should use Allow.
+func (rl *RateLimiter) Reserve() time.Duration {
+	delay, _ := rl.ReserveN(1)
+	return delay
+}
+
+// ReserveN is Reserve for n tokens. It fails with ErrExceedsBurst if n
+// is larger than the burst, since such a reservation could never be
+// honoured, and with ErrNegativeTokens if n is negative.
+func (rl *RateLimiter) ReserveN(n int) (time.Duration, error) {
+	if n < 0 {
+		return 0, ErrNegativeTokens
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	if n > rl.burst {
+		return 0, ErrExceedsBurst
+	}
+	rl.refill(rl.clock.Now())
+	rl.tokens -= float64(n)
+	if rl.tokens >= 0 {
+		return 0, nil
+	}
+	return time.Duration(-rl.tokens * float64(rl.rate)), nil
+}
+
+// cancel returns n reserved tokens to the bucket.
+func (rl *RateLimiter) cancel(n int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	rl.tokens = min(float64(rl.burst), rl.tokens+float64(n))
+}
+
+// Wait blocks until an event may happen or ctx ends.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	return rl.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen or ctx ends. If ctx ends first,
+// the reserved tokens are returned to the bucket.
+func (rl *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay, err := rl.ReserveN(n)
+	if err != nil || delay == 0 {
+		return err
+	}
+
+	select {
+	case <-rl.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		rl.cancel(n)
+		return ctx.Err()
+	}
+}
+
+// SetRate changes the refill interval. Tokens earned at the old rate
+// are kept. It panics unless rate is positive.
+func (rl *RateLimiter) SetRate(rate time.Duration) {
+	mustBePositive("RateLimiter.SetRate", "rate", rate)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	rl.rate = rate
+}
+
+// SetBurst changes the bucket capacity, discarding tokens above it.
+func (rl *RateLimiter) SetBurst(burst int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	rl.burst = max(burst, 1)
+	rl.tokens = min(rl.tokens, float64(rl.burst))
+}
+
+// Tokens returns the number of tokens currently available. It is
+// negative while reservations are outstanding.
+func (rl *RateLimiter) Tokens() float64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	return rl.tokens
+}
+
+// Close does nothing. The limiter no longer runs a refill goroutine, so
+// there is nothing to stop.
+//
+// Deprecated: RateLimiter needs no cleanup; remove calls to Close.
+func (rl *RateLimiter) Close() {}
+
+// full reports whether the bucket has refilled to its burst.
+func (rl *RateLimiter) full() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.refill(rl.clock.Now())
+	return rl.tokens >= float64(rl.burst)
+}
+
+// KeyedLimiter gives each key, such as an API key or client IP, its own
+// token bucket.
+//
+// Why evict? Buckets for clients that stopped calling would otherwise
+// accumulate forever. A bucket that has refilled is indistinguishable
+// from a new one, so dropping it loses nothing. A bucket that is still
+// short of tokens is kept however long it has been idle; evicting it
+// would let a client reset its own limit by pausing.
+type KeyedLimiter[K comparable] struct {
+	mu        sync.Mutex
+	clock     Clock
+	opts      []LimiterOption
+	capacity  int
+	rate      time.Duration
+	idle      time.Duration
+	buckets   map[K]*keyedBucket
+	lastSweep time.Time
+}
+
+type keyedBucket struct {
+	limiter  *RateLimiter
+	lastSeen time.Time
+}
+
+// NewKeyedLimiter creates a per-key limiter. Full buckets unused for idle
+// are evicted; idle <= 0 disables eviction. It panics unless rate is
+// positive.
+func NewKeyedLimiter[K comparable](capacity int, rate, idle time.Duration, opts ...LimiterOption) *KeyedLimiter[K] {
+	mustBePositive("NewKeyedLimiter", "rate", rate)
+	cfg := newLimiterConfig(opts)
+	return &KeyedLimiter[K]{
+		clock:     cfg.clock,
+		opts:      opts,
+		capacity:  capacity,
+		rate:      rate,
+		idle:      idle,
+		buckets:   make(map[K]*keyedBucket),
+		lastSweep: cfg.clock.Now(),
+	}
+}
+
+// Limiter returns the bucket for key, creating it if needed.
+func (kl *KeyedLimiter[K]) Limiter(key K) *RateLimiter {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+
+	now := kl.clock.Now()
+	kl.sweep(now)
+
+	b, ok := kl.buckets[key]
+	if !ok {
+		b = &keyedBucket{limiter: NewRateLimiter(kl.capacity, kl.rate, kl.opts...)}
+		kl.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter
+}
+
+// sweep evicts idle, full buckets at most once per idle period. The
+// caller must hold kl.mu.
+func (kl *KeyedLimiter[K]) sweep(now time.Time) {
+	if kl.idle <= 0 || now.Sub(kl.lastSweep) < kl.idle {
+		return
+	}
+	for key, b := range kl.buckets {
+		if now.Sub(b.lastSeen) >= kl.idle && b.limiter.full() {
+			delete(kl.buckets, key)
+		}
+	}
+	kl.lastSweep = now
+}
+
+// Allow reports whether key may make a request now.
+func (kl *KeyedLimiter[K]) Allow(key K) bool {
+	return kl.Limiter(key).Allow()
+}
+
+// AllowN reports whether key may make n requests now.
+func (kl *KeyedLimiter[K]) AllowN(key K, n int) bool {
+	return kl.Limiter(key).AllowN(n)
+}
+
+// Reserve reserves a token for key and returns how long to wait.
+func (kl *KeyedLimiter[K]) Reserve(key K) time.Duration {
+	return kl.Limiter(key).Reserve()
+}
+
+// Wait blocks until key may make a request or ctx ends.
+func (kl *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return kl.Limiter(key).Wait(ctx)
+}
+
+// SetRate changes the refill interval for existing and future buckets.
+// It panics unless rate is positive.
+func (kl *KeyedLimiter[K]) SetRate(rate time.Duration) {
+	mustBePositive("KeyedLimiter.SetRate", "rate", rate)
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.rate = rate
+	for _, b := range kl.buckets {
+		b.limiter.SetRate(rate)
+	}
+}
+
+// SetBurst changes the capacity of existing and future buckets.
+func (kl *KeyedLimiter[K]) SetBurst(capacity int) {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.capacity = capacity
+	for _, b := range kl.buckets {
+		b.limiter.SetBurst(capacity)
+	}
+}
+
+// Len returns the number of live buckets.
+func (kl *KeyedLimiter[K]) Len() int {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+	kl.sweep(kl.clock.Now())
+	return len(kl.buckets)
+}
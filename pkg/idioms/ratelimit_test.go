@@ -0,0 +1,186 @@
+package idioms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefillsFromTimestamps(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	rl := NewRateLimiter(3, 100*time.Millisecond, WithClock(clock))
+
+	steps := []struct {
+		advance time.Duration
+		n       int
+		want    bool
+	}{
+		{0, 3, true},
+		{0, 1, false},
+		{50 * time.Millisecond, 1, false},
+		{50 * time.Millisecond, 1, true},
+		{time.Hour, 3, true}, // refill is capped at the burst
+		{0, 1, false},
+		{250 * time.Millisecond, 2, true},
+		{0, 1, false}, // half a token left
+		{50 * time.Millisecond, 1, true},
+	}
+	for i, s := range steps {
+		clock.Advance(s.advance)
+		if got := rl.AllowN(s.n); got != s.want {
+			t.Fatalf("step %d: AllowN(%d) after %v = %v, want %v", i, s.n, s.advance, got, s.want)
+		}
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	rl := NewRateLimiter(1, time.Second, WithClock(clock))
+
+	for i, want := range []time.Duration{0, time.Second, 2 * time.Second} {
+		if got := rl.Reserve(); got != want {
+			t.Errorf("reservation %d: wait %v, want %v", i, got, want)
+		}
+	}
+	if _, err := rl.ReserveN(2); !errors.Is(err, ErrExceedsBurst) {
+		t.Errorf("ReserveN above burst = %v, want ErrExceedsBurst", err)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	rl := NewRateLimiter(1, time.Second, WithClock(clock))
+	ctx := context.Background()
+
+	if err := rl.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- rl.Wait(ctx) }()
+	clock.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("Wait returned before the clock advanced")
+	default:
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("Wait = %v", err)
+	}
+
+	// A cancelled wait gives its token back.
+	cancelled, cancel := context.WithCancel(ctx)
+	go func() { done <- rl.Wait(cancelled) }()
+	clock.BlockUntil(1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v, want context.Canceled", err)
+	}
+	clock.Advance(time.Second)
+	if !rl.Allow() {
+		t.Error("token reserved by the cancelled wait was not returned")
+	}
+}
+
+func TestRateLimiterSetRateAndBurst(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	rl := NewRateLimiter(4, time.Second, WithClock(clock))
+
+	rl.SetBurst(2)
+	if got := rl.Tokens(); got != 2 {
+		t.Errorf("tokens after SetBurst(2) = %v, want 2", got)
+	}
+	rl.AllowN(2)
+	rl.SetRate(100 * time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+	if !rl.Allow() || rl.Allow() {
+		t.Error("expected exactly one token 100ms after SetRate(100ms)")
+	}
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	kl := NewKeyedLimiter[string](1, time.Minute, time.Hour, WithClock(clock))
+
+	if !kl.Allow("a") || kl.Allow("a") {
+		t.Error("key a should get exactly one request")
+	}
+	if !kl.Allow("b") {
+		t.Error("key b should have its own bucket")
+	}
+	if kl.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", kl.Len())
+	}
+
+	clock.Advance(30 * time.Minute)
+	kl.Allow("b")
+	clock.Advance(30 * time.Minute)
+	if got := kl.Len(); got != 1 {
+		t.Errorf("Len() after a's idle hour = %d, want 1 (b stays)", got)
+	}
+}
+
+func TestKeyedLimiterKeepsDrainedBuckets(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	// Idle is shorter than the hour the bucket takes to refill.
+	kl := NewKeyedLimiter[string](1, time.Hour, time.Minute, WithClock(clock))
+
+	if !kl.Allow("a") {
+		t.Fatal("first request should be allowed")
+	}
+	clock.Advance(2 * time.Minute)
+	if kl.Allow("a") {
+		t.Error("pausing past idle reset the drained bucket")
+	}
+	clock.Advance(time.Hour + time.Minute)
+	if got := kl.Len(); got != 0 {
+		t.Errorf("Len() after refill and idle = %d, want 0", got)
+	}
+}
+
+func TestRateLimiterRejectsNegativeN(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	rl := NewRateLimiter(1, time.Hour, WithClock(clock))
+
+	if rl.AllowN(-5) {
+		t.Error("AllowN(-5) should be refused")
+	}
+	if _, err := rl.ReserveN(-5); !errors.Is(err, ErrNegativeTokens) {
+		t.Errorf("ReserveN(-5) error = %v, want ErrNegativeTokens", err)
+	}
+	if !rl.Allow() || rl.Allow() {
+		t.Error("negative requests changed the token count")
+	}
+}
+
+func TestRateLimiterRejectsNonPositiveRate(t *testing.T) {
+	calls := map[string]func(time.Duration){
+		"NewRateLimiter":       func(rate time.Duration) { NewRateLimiter(1, rate) },
+		"SetRate":              func(rate time.Duration) { NewRateLimiter(1, time.Second).SetRate(rate) },
+		"NewKeyedLimiter":      func(rate time.Duration) { NewKeyedLimiter[string](1, rate, 0) },
+		"KeyedLimiter.SetRate": func(rate time.Duration) { NewKeyedLimiter[string](1, time.Second, 0).SetRate(rate) },
+	}
+	for name, call := range calls {
+		for _, rate := range []time.Duration{0, -time.Second} {
+			t.Run(fmt.Sprintf("%s/%v", name, rate), func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Error("expected a panic")
+					}
+				}()
+				call(rate)
+			})
+		}
+	}
+}
+
+func TestRateLimiterCloseIsNoOp(t *testing.T) {
+	rl := NewRateLimiter(1, time.Hour, WithClock(NewManualClock(time.Unix(0, 0))))
+	rl.Close()
+	if !rl.Allow() {
+		t.Error("Allow after Close should still use the bucket")
+	}
+}
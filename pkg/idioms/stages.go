@@ -0,0 +1,279 @@
+package idioms
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+// Stages are generic building blocks for channel pipelines.
+//
+// Why? Every pipeline is the same few shapes (produce, transform, split,
+// merge, group, pace) applied to different types. Writing them once with
+// generics means each stage gets the subtle parts right: it closes its
+// output exactly once, stops promptly when ctx is cancelled, and never
+// leaves a goroutine blocked on a send nobody will receive.
+//
+// Every stage takes ctx first and returns receive-only channels that are
+// closed when the input is exhausted or ctx is done.
+
+// Source emits values in order.
+func Source[T any](ctx context.Context, values ...T) <-chan T {
+	return SourceSeq(ctx, func(yield func(T) bool) {
+		for _, v := range values {
+			if !yield(v) {
+				return
+			}
+		}
+	})
+}
+
+// SourceSeq emits the items of an iterator, stopping the iterator early
+// if ctx is cancelled.
+func SourceSeq[T any](ctx context.Context, seq iter.Seq[T]) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for v := range seq {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
+
+// Stage applies fn to every input value.
+func Stage[In, Out any](ctx context.Context, in <-chan In, fn func(In) Out) <-chan Out {
+	out := make(chan Out)
+
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- fn(v):
+			}
+		}
+	}()
+
+	return out
+}
+
+// FanOut distributes input values over n outputs. Each value goes to
+// exactly one output, whichever is ready first, so a slow consumer does
+// not hold back the others.
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outputs := make([]<-chan T, n)
+
+	for i := range outputs {
+		out := make(chan T)
+		outputs[i] = out
+		go func() {
+			defer close(out)
+			for v := range OrDone(ctx, in) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- v:
+				}
+			}
+		}()
+	}
+
+	return outputs
+}
+
+// FanIn merges several channels into one. Values keep their order within
+// each input, but inputs are interleaved.
+func FanIn[T any](ctx context.Context, channels ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+
+	for _, ch := range channels {
+		wg.Add(1)
+		go func(c <-chan T) {
+			defer wg.Done()
+			for v := range OrDone(ctx, c) {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- v:
+				}
+			}
+		}(ch)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Tee copies every input value to each of n outputs. The slowest
+// consumer sets the pace, since a value is only dropped from the stage
+// once all outputs have received it.
+func Tee[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	outputs := make([]<-chan T, n)
+	feeds := make([]chan T, n)
+	sent := make(chan struct{}, n)
+
+	// One sender per output for the life of the stage. Each value is
+	// handed to all senders at once, so it does not matter which output
+	// its consumer reads first; the next value waits until every sender
+	// has delivered (or given up on) the current one.
+	for i := range feeds {
+		out := make(chan T)
+		outputs[i] = out
+		feeds[i] = make(chan T, 1)
+		go func(feed <-chan T) {
+			defer close(out)
+			for v := range feed {
+				select {
+				case <-ctx.Done():
+				case out <- v:
+				}
+				sent <- struct{}{}
+			}
+		}(feeds[i])
+	}
+
+	go func() {
+		defer func() {
+			for _, feed := range feeds {
+				close(feed)
+			}
+		}()
+
+		for v := range OrDone(ctx, in) {
+			for _, feed := range feeds {
+				feed <- v
+			}
+			for range feeds {
+				<-sent
+			}
+		}
+	}()
+
+	return outputs
+}
+
+// Batch groups input values into slices of up to size values. A partial
+// batch is emitted once maxWait has passed since its first value, so a
+// slow trickle of input still flows downstream. The final partial batch
+// is emitted when the input closes.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	out := make(chan []T)
+
+	go func() {
+		defer close(out)
+
+		var (
+			batch    []T
+			deadline <-chan time.Time
+			timer    *time.Timer
+		)
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				timer, deadline = nil, nil
+			}
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- batch:
+				batch = nil
+				return true
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-deadline:
+				if !flush() {
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					deadline = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Throttle passes values through no faster than one per interval.
+// Values are delayed, never dropped.
+func Throttle[T any](ctx context.Context, in <-chan T, interval time.Duration) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		var next time.Time
+		for v := range OrDone(ctx, in) {
+			if wait := time.Until(next); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+			next = time.Now().Add(interval)
+		}
+	}()
+
+	return out
+}
+
+// Buffered decouples a producer from a consumer with a buffer of size
+// values, so bursts upstream do not stall on a momentarily busy consumer.
+//
+// Why not Buffer? That name is taken by the zero-value example type.
+func Buffered[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, size)
+
+	go func() {
+		defer close(out)
+		for v := range OrDone(ctx, in) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,142 @@
+package idioms
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func drain[T any](ch <-chan T) []T {
+	var out []T
+	for v := range ch {
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestStages(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		got       func() []string
+		unordered bool
+		want      []string
+	}{
+		{"Source and Stage", func() []string {
+			return drain(Stage(ctx, Source(ctx, 1, 2, 3), strconv.Itoa))
+		}, false, []string{"1", "2", "3"}},
+		{"FanOut and FanIn", func() []string {
+			return drain(FanIn(ctx, FanOut(ctx, Source(ctx, "a", "b", "c", "d"), 3)...))
+		}, true, []string{"a", "b", "c", "d"}},
+		{"FanOutFanIn", func() []string {
+			return drain(FanOutFanIn(ctx, Source(ctx, 1, 2, 3), 2, strconv.Itoa))
+		}, true, []string{"1", "2", "3"}},
+		{"Buffered", func() []string {
+			return drain(Buffered(ctx, Source(ctx, "x", "y"), 10))
+		}, false, []string{"x", "y"}},
+		{"Batch by size", func() []string {
+			return drain(Stage(ctx, Batch(ctx, Source(ctx, 1, 2, 3, 4, 5), 2, time.Hour), func(b []int) string {
+				return strconv.Itoa(len(b))
+			}))
+		}, false, []string{"2", "2", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.got()
+			if tt.unordered {
+				slices.Sort(got)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeeDeliversToEveryOutput(t *testing.T) {
+	ctx := context.Background()
+	outputs := Tee(ctx, Source(ctx, 1, 2, 3), 3)
+
+	results := make([][]int, len(outputs))
+	var wg sync.WaitGroup
+	for i, out := range outputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = drain(out)
+		}()
+	}
+	wg.Wait()
+
+	for i, got := range results {
+		if !slices.Equal(got, []int{1, 2, 3}) {
+			t.Errorf("output %d got %v", i, got)
+		}
+	}
+}
+
+func TestBatchFlushesAfterMaxWait(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan int)
+	batches := Batch(ctx, in, 10, 20*time.Millisecond)
+
+	in <- 1
+	in <- 2
+	select {
+	case got := <-batches:
+		if !slices.Equal(got, []int{1, 2}) {
+			t.Errorf("got %v, want [1 2]", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed after maxWait")
+	}
+	close(in)
+	if rest := drain(batches); len(rest) != 0 {
+		t.Errorf("unexpected batches after close: %v", rest)
+	}
+}
+
+func TestThrottleSpacesValues(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now()
+	got := drain(Throttle(ctx, Source(ctx, 1, 2, 3), 20*time.Millisecond))
+
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("got %v", got)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 values took %v, want at least 2 intervals", elapsed)
+	}
+}
+
+func TestStagesStopOnCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	endless := SourceSeq(ctx, func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	})
+	out := Throttle(ctx, Buffered(ctx, Stage(ctx, endless, func(n int) int { return n }), 4), time.Millisecond)
+	copies := Tee(ctx, FanIn(ctx, FanOut(ctx, out, 2)...), 2)
+	batches := Batch(ctx, copies[0], 3, time.Millisecond)
+
+	<-batches
+	cancel()
+	drain(batches)
+	drain(copies[1])
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines leaked after cancel", after-before)
+	}
+}
@@ -1,6 +1,10 @@
 package idioms
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/KrystianMarek/golang-202/pkg/functional/monad"
+)
 
 // Zero values demonstrate leveraging Go's zero value semantics.
 //
@@ -88,38 +92,18 @@ func (c *Counter) Value() int {
 	return c.value
 }
 
-// Optional demonstrates zero value for optional fields.
-type Optional[T any] struct {
-	value T
-	valid bool
-}
+// Optional demonstrates zero value for optional fields: its zero value
+// is None. It is an alias of the canonical monad.Option.
+type Optional[T any] = monad.Option[T]
 
 // None returns an empty Optional.
 func None[T any]() Optional[T] {
-	return Optional[T]{} // Zero value: valid=false
+	return monad.None[T]()
 }
 
 // Some returns an Optional with a value.
 func Some[T any](value T) Optional[T] {
-	return Optional[T]{value: value, valid: true}
-}
-
-// IsValid returns true if the Optional has a value.
-func (o Optional[T]) IsValid() bool {
-	return o.valid
-}
-
-// Get returns the value.
-func (o Optional[T]) Get() T {
-	return o.value
-}
-
-// OrElse returns the value or a default.
-func (o Optional[T]) OrElse(defaultValue T) T {
-	if o.valid {
-		return o.value
-	}
-	return defaultValue
+	return monad.Some(value)
 }
 
 // QueryBuilder demonstrates zero-value-friendly builder.
@@ -229,8 +213,8 @@ func ExampleZeroValues() {
 	opt1 := Some(42)
 	opt2 := None[int]()
 
-	fmt.Printf("opt1: valid=%v, value=%d\n", opt1.IsValid(), opt1.Get())
-	fmt.Printf("opt2: valid=%v, value=%d\n", opt2.IsValid(), opt2.Get())
+	fmt.Printf("opt1: valid=%v, value=%d\n", opt1.IsSome(), opt1.OrZero())
+	fmt.Printf("opt2: valid=%v, value=%d\n", opt2.IsSome(), opt2.OrZero())
 	fmt.Printf("opt2 with default: %d\n\n", opt2.OrElse(100))
 
 	// Builder with zero values
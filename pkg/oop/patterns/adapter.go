@@ -1,6 +1,15 @@
 package patterns
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
 
 // Adapter pattern demonstrates how to make incompatible interfaces work together.
 //
@@ -49,41 +58,197 @@ func (v *VideoPlayerAdapter) Play(filename string) error {
 	return nil
 }
 
-// ThirdPartyPayment is an external payment service with different interface.
-type ThirdPartyPayment struct{}
+// ThirdPartyPayment is an external payment SDK with its own vocabulary:
+// it places holds rather than authorizations, takes amounts as decimal
+// strings, and reports the outcome of every call as a numeric result
+// code rather than an error. It is safe for concurrent use.
+type ThirdPartyPayment struct {
+	mu      sync.Mutex
+	seq     int
+	holds   map[string]*thirdPartyHold
+	offline bool
+}
+
+type thirdPartyHold struct {
+	currency                string
+	held, settled, credited *big.Rat
+	released                bool
+}
+
+// Result codes returned by ThirdPartyPayment.
+const (
+	thirdPartyApproved    = 0
+	thirdPartyUnknownHold = 25
+	thirdPartyFormatError = 30
+	thirdPartyDeclined    = 51
+	thirdPartyUnavailable = 91
+)
+
+// thirdPartyHoldLimit is the largest hold the SDK approves.
+var thirdPartyHoldLimit = big.NewRat(10000, 1)
+
+// NewThirdPartyPayment returns a connected SDK client.
+func NewThirdPartyPayment() *ThirdPartyPayment {
+	return &ThirdPartyPayment{holds: make(map[string]*thirdPartyHold)}
+}
+
+// SetOffline simulates losing the connection to the provider: every call
+// returns code 91 until it is set back to false.
+func (t *ThirdPartyPayment) SetOffline(offline bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.offline = offline
+}
 
-// ProcessTransaction is the third-party method.
-func (t *ThirdPartyPayment) ProcessTransaction(amount float64, currency, account string) bool {
-	fmt.Printf("Third-party processing: %.2f %s to account %s\n", amount, currency, account)
-	return true
+// PlaceHold reserves amount on account and returns the hold's ID.
+func (t *ThirdPartyPayment) PlaceHold(account, amount, currency string) (string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.offline {
+		return "", thirdPartyUnavailable
+	}
+	v, ok := new(big.Rat).SetString(amount)
+	if !ok || v.Sign() <= 0 {
+		return "", thirdPartyFormatError
+	}
+	if v.Cmp(thirdPartyHoldLimit) > 0 {
+		return "", thirdPartyDeclined
+	}
+	t.seq++
+	id := fmt.Sprintf("H%d", t.seq)
+	t.holds[id] = &thirdPartyHold{currency: currency, held: v, settled: new(big.Rat), credited: new(big.Rat)}
+	fmt.Printf("Third-party hold %s: %s %s on %s\n", id, amount, currency, account)
+	return id, thirdPartyApproved
 }
 
-// PaymentProcessor is our expected interface.
-type PaymentProcessor interface {
-	Pay(amount float64, recipient string) error
+// SettleHold collects amount, at most the held amount, from an open hold.
+func (t *ThirdPartyPayment) SettleHold(holdID, amount string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.offline {
+		return thirdPartyUnavailable
+	}
+	h, ok := t.holds[holdID]
+	if !ok || h.released || h.settled.Sign() != 0 {
+		return thirdPartyUnknownHold
+	}
+	v, ok := new(big.Rat).SetString(amount)
+	if !ok || v.Sign() <= 0 || v.Cmp(h.held) > 0 {
+		return thirdPartyFormatError
+	}
+	h.settled = v
+	fmt.Printf("Third-party settle %s: %s %s\n", holdID, amount, h.currency)
+	return thirdPartyApproved
+}
+
+// ReleaseHold cancels a hold that has not been settled.
+func (t *ThirdPartyPayment) ReleaseHold(holdID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.offline {
+		return thirdPartyUnavailable
+	}
+	h, ok := t.holds[holdID]
+	if !ok || h.settled.Sign() != 0 {
+		return thirdPartyUnknownHold
+	}
+	h.released = true
+	fmt.Printf("Third-party release %s\n", holdID)
+	return thirdPartyApproved
+}
+
+// CreditBack returns amount of a settled hold to the account.
+func (t *ThirdPartyPayment) CreditBack(holdID, amount string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.offline {
+		return thirdPartyUnavailable
+	}
+	h, ok := t.holds[holdID]
+	if !ok || h.settled.Sign() == 0 {
+		return thirdPartyUnknownHold
+	}
+	v, ok := new(big.Rat).SetString(amount)
+	if !ok || v.Sign() <= 0 {
+		return thirdPartyFormatError
+	}
+	total := new(big.Rat).Add(h.credited, v)
+	if total.Cmp(h.settled) > 0 {
+		return thirdPartyFormatError
+	}
+	h.credited = total
+	fmt.Printf("Third-party credit %s: %s %s\n", holdID, amount, h.currency)
+	return thirdPartyApproved
 }
 
-// PaymentAdapter adapts ThirdPartyPayment to PaymentProcessor.
+// PaymentAdapter adapts ThirdPartyPayment to payment.Gateway, so that a
+// payment.Processor can drive it like any other provider.
+//
+// Why? The processor's lifecycle, idempotency and validation are written
+// once against payment.Gateway; supporting another provider means
+// translating its vocabulary, here holds, decimal strings and result
+// codes, and nothing else.
+//
+// The SDK has no idempotency keys, so the adapter ignores them and
+// relies on the Processor to drop duplicate calls.
 type PaymentAdapter struct {
 	thirdParty *ThirdPartyPayment
-	currency   string
 }
 
-// NewPaymentAdapter creates a payment adapter.
-func NewPaymentAdapter(currency string) *PaymentAdapter {
-	return &PaymentAdapter{
-		thirdParty: &ThirdPartyPayment{},
-		currency:   currency,
+// NewPaymentAdapter creates a payment adapter over an SDK client.
+func NewPaymentAdapter(thirdParty *ThirdPartyPayment) *PaymentAdapter {
+	return &PaymentAdapter{thirdParty: thirdParty}
+}
+
+// thirdPartyError maps a result code to the errors payment.Gateway
+// promises: final answers wrap payment.ErrDeclined, everything else is
+// transient.
+func thirdPartyError(op string, code int) error {
+	switch code {
+	case thirdPartyApproved:
+		return nil
+	case thirdPartyDeclined:
+		return fmt.Errorf("%w: %s: insufficient funds (code %d)", payment.ErrDeclined, op, code)
+	case thirdPartyUnknownHold, thirdPartyFormatError:
+		return fmt.Errorf("%w: %s: rejected by provider (code %d)", payment.ErrDeclined, op, code)
+	case thirdPartyUnavailable:
+		return fmt.Errorf("%w: %s (code %d)", payment.ErrGatewayUnavailable, op, code)
+	default:
+		return fmt.Errorf("third-party %s: unexpected result code %d", op, code)
 	}
 }
 
-// Pay implements PaymentProcessor interface.
-func (p *PaymentAdapter) Pay(amount float64, recipient string) error {
-	success := p.thirdParty.ProcessTransaction(amount, p.currency, recipient)
-	if !success {
-		return fmt.Errorf("payment failed")
+// Authorize implements payment.Gateway by placing a hold.
+func (p *PaymentAdapter) Authorize(ctx context.Context, req payment.AuthorizeRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
-	return nil
+	id, code := p.thirdParty.PlaceHold(req.Method.String(), req.Amount.Decimal(), string(req.Amount.Currency()))
+	return id, thirdPartyError("hold", code)
+}
+
+// Capture implements payment.Gateway by settling the hold.
+func (p *PaymentAdapter) Capture(ctx context.Context, ref string, amount payment.Money, _ string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return thirdPartyError("settle", p.thirdParty.SettleHold(ref, amount.Decimal()))
+}
+
+// Refund implements payment.Gateway by crediting the account back.
+func (p *PaymentAdapter) Refund(ctx context.Context, ref string, amount payment.Money, _ string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return thirdPartyError("credit", p.thirdParty.CreditBack(ref, amount.Decimal()))
+}
+
+// Void implements payment.Gateway by releasing the hold.
+func (p *PaymentAdapter) Void(ctx context.Context, ref string, _ string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return thirdPartyError("release", p.thirdParty.ReleaseHold(ref))
 }
 
 // OldLogger is a legacy logging system.
@@ -179,11 +344,32 @@ func ExampleAdapter() {
 		_ = player.Play(files[i])
 	}
 
-	// Payment adapter
-	paymentProcessor := NewPaymentAdapter("USD")
-	err := paymentProcessor.Pay(99.99, "merchant-123")
+	// Payment adapter: a payment.Processor drives the third-party SDK
+	// through the payment.Gateway port.
+	ctx := context.Background()
+	sdk := NewThirdPartyPayment()
+	processor := payment.NewProcessor(NewPaymentAdapter(sdk),
+		payment.WithClock(idioms.NewManualClock(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))))
+	card := payment.Card{Number: "4242 4242 4242 4242", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+
+	auth, err := processor.Authorize(ctx, "order-7/authorize", card, payment.MustParse("99.99", payment.USD))
 	if err != nil {
 		fmt.Printf("Payment error: %v\n", err)
+		return
+	}
+	sdk.SetOffline(true)
+	if _, err := processor.Capture(ctx, "order-7/capture", auth.ID, auth.Authorized); err != nil {
+		fmt.Printf("Payment error: %v\n", err)
+	}
+	sdk.SetOffline(false)
+	paid, err := processor.Capture(ctx, "order-7/capture", auth.ID, auth.Authorized)
+	if err != nil {
+		fmt.Printf("Payment error: %v\n", err)
+		return
+	}
+	fmt.Printf("%s: %s %s\n", paid.ID, paid.Status, paid.Captured)
+	if _, err := processor.Authorize(ctx, "order-8/authorize", card, payment.MustParse("25000.00", payment.USD)); err != nil {
+		fmt.Printf("Payment error: %v\n", err)
 	}
 
 	// Logger adapter
@@ -0,0 +1,96 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+var testCard = payment.Card{Number: "4242 4242 4242 4242", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+
+func newTestProcessor(g payment.Gateway) *payment.Processor {
+	clock := idioms.NewManualClock(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))
+	return payment.NewProcessor(g, payment.WithClock(clock))
+}
+
+func TestPaymentAdapterLifecycle(t *testing.T) {
+	ctx := context.Background()
+	sdk := NewThirdPartyPayment()
+	p := newTestProcessor(NewPaymentAdapter(sdk))
+	usd := func(s string) payment.Money { return payment.MustParse(s, payment.USD) }
+
+	pay, err := p.Authorize(ctx, "a", testCard, usd("120"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pay, err = p.Capture(ctx, "c", pay.ID, usd("100")); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"r1", "r2", "r3"} {
+		if pay, err = p.Refund(ctx, key, pay.ID, usd("33.33")); err != nil {
+			t.Fatalf("refund %s: %v", key, err)
+		}
+	}
+	if pay.Status != payment.StatusPartiallyRefunded || pay.Refunded != usd("99.99") {
+		t.Fatalf("after refunds: %+v", pay)
+	}
+	// The SDK's own bookkeeping agrees: one more cent is all it allows.
+	if err := NewPaymentAdapter(sdk).Refund(ctx, pay.ID, usd("0.02"), ""); !errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("over-refund through the SDK error = %v", err)
+	}
+
+	held, err := p.Authorize(ctx, "a2", testCard, usd("5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if held, err = p.Void(ctx, "v2", held.ID); err != nil || held.Status != payment.StatusVoided {
+		t.Fatalf("Void = %+v, %v", held, err)
+	}
+}
+
+func TestPaymentAdapterErrors(t *testing.T) {
+	ctx := context.Background()
+	sdk := NewThirdPartyPayment()
+	adapter := NewPaymentAdapter(sdk)
+	req := payment.AuthorizeRequest{Method: testCard, Amount: payment.MustParse("10000.01", payment.USD)}
+	if _, err := adapter.Authorize(ctx, req); !errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("over the hold limit: %v", err)
+	}
+	if err := adapter.Capture(ctx, "H404", req.Amount, ""); !errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("unknown hold: %v", err)
+	}
+	sdk.SetOffline(true)
+	req.Amount = payment.MustParse("1", payment.USD)
+	if _, err := adapter.Authorize(ctx, req); !errors.Is(err, payment.ErrGatewayUnavailable) {
+		t.Errorf("offline: %v", err)
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := adapter.Void(canceled, "H1", ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled context: %v", err)
+	}
+	if err := thirdPartyError("hold", 7); err == nil || errors.Is(err, payment.ErrDeclined) {
+		t.Errorf("unknown code: %v, want a transient error", err)
+	}
+}
+
+func TestCryptoStrategyConverts(t *testing.T) {
+	rates := payment.NewStaticRates()
+	if err := rates.Set(payment.BTC, payment.USD, "50000"); err != nil {
+		t.Fatal(err)
+	}
+	s := &CryptoStrategy{
+		WalletAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		Currency:      payment.BTC,
+		Converter:     payment.NewConverter(rates),
+		Processor:     newTestProcessor(payment.NewFakeGateway()),
+	}
+	paid, err := s.Pay(context.Background(), "order", payment.MustParse("125", payment.USD))
+	if err != nil || paid.Captured.String() != "0.00250000 BTC" {
+		t.Fatalf("Pay = %+v, %v", paid, err)
+	}
+}
@@ -0,0 +1,262 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+// ShoppingCart brings the checkout strategies together: a PricingEngine
+// for discounts, a TaxStrategy for the customer's region and a
+// PaymentStrategy to charge the total. Each can be swapped without
+// touching the cart.
+
+// LineItem is a product in a cart.
+type LineItem struct {
+	SKU       string        `json:"sku"`
+	Name      string        `json:"name"`
+	Category  string        `json:"category,omitempty"` // used by discount and tax rules
+	UnitPrice payment.Money `json:"unit_price"`
+	Quantity  int           `json:"quantity"`
+}
+
+// Subtotal returns the unit price times the quantity.
+func (l LineItem) Subtotal() (payment.Money, error) {
+	return l.UnitPrice.Mul(int64(l.Quantity))
+}
+
+// Errors returned by ShoppingCart.
+var (
+	ErrInvalidItem     = errors.New("invalid line item")
+	ErrUnknownSKU      = errors.New("SKU not in cart")
+	ErrEmptyCart       = errors.New("cart is empty")
+	ErrNoPaymentMethod = errors.New("no payment method selected")
+)
+
+// ShoppingCart is a customer's cart. It is not safe for concurrent use;
+// a cart belongs to one session.
+type ShoppingCart struct {
+	currency        payment.Currency
+	lines           []LineItem
+	coupons         []string
+	pricing         *PricingEngine
+	tax             TaxStrategy
+	paymentStrategy PaymentStrategy
+}
+
+// NewShoppingCart creates an empty cart priced in currency, with no
+// discounts and no tax.
+func NewShoppingCart(currency payment.Currency) *ShoppingCart {
+	return &ShoppingCart{currency: currency, tax: NoTax{}}
+}
+
+func (s *ShoppingCart) find(sku string) int {
+	return slices.IndexFunc(s.lines, func(l LineItem) bool { return l.SKU == sku })
+}
+
+// AddItem adds item to the cart. Adding a SKU that is already in the
+// cart adds to its quantity; the unit price must match.
+func (s *ShoppingCart) AddItem(item LineItem) error {
+	switch {
+	case item.SKU == "":
+		return fmt.Errorf("%w: missing SKU", ErrInvalidItem)
+	case item.Quantity < 1:
+		return fmt.Errorf("%w: %s quantity %d", ErrInvalidItem, item.SKU, item.Quantity)
+	case item.UnitPrice.IsNegative():
+		return fmt.Errorf("%w: %s price %s", ErrInvalidItem, item.SKU, item.UnitPrice)
+	case item.UnitPrice.Currency() != s.currency:
+		return fmt.Errorf("%w: %s priced in %s, cart in %s",
+			payment.ErrCurrencyMismatch, item.SKU, item.UnitPrice.Currency(), s.currency)
+	}
+	i := s.find(item.SKU)
+	if i < 0 {
+		if _, err := item.Subtotal(); err != nil {
+			return err
+		}
+		s.lines = append(s.lines, item)
+		return nil
+	}
+	if s.lines[i].UnitPrice != item.UnitPrice {
+		return fmt.Errorf("%w: %s is already in the cart at %s", ErrInvalidItem, item.SKU, s.lines[i].UnitPrice)
+	}
+	return s.UpdateQuantity(item.SKU, s.lines[i].Quantity+item.Quantity)
+}
+
+// UpdateQuantity sets the quantity of a SKU in the cart. A quantity of
+// zero removes it.
+func (s *ShoppingCart) UpdateQuantity(sku string, quantity int) error {
+	i := s.find(sku)
+	switch {
+	case i < 0:
+		return fmt.Errorf("%w: %s", ErrUnknownSKU, sku)
+	case quantity < 0:
+		return fmt.Errorf("%w: %s quantity %d", ErrInvalidItem, sku, quantity)
+	case quantity == 0:
+		s.lines = slices.Delete(s.lines, i, i+1)
+		return nil
+	}
+	line := s.lines[i]
+	line.Quantity = quantity
+	if _, err := line.Subtotal(); err != nil {
+		return err
+	}
+	s.lines[i] = line
+	return nil
+}
+
+// RemoveItem removes a SKU from the cart.
+func (s *ShoppingCart) RemoveItem(sku string) error {
+	return s.UpdateQuantity(sku, 0)
+}
+
+// Items returns the cart's lines in the order they were added.
+func (s *ShoppingCart) Items() []LineItem {
+	return slices.Clone(s.lines)
+}
+
+// SetPricing sets the engine that discounts the cart.
+func (s *ShoppingCart) SetPricing(engine *PricingEngine) {
+	s.pricing = engine
+}
+
+// ApplyCoupon enters a coupon code. It fails if the pricing engine has
+// no such coupon or it has expired. Codes are case-insensitive, and
+// entering one twice has no further effect.
+func (s *ShoppingCart) ApplyCoupon(code string) error {
+	if s.pricing == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownCoupon, normalizeCoupon(code))
+	}
+	if err := s.pricing.CheckCoupon(code); err != nil {
+		return err
+	}
+	if code = normalizeCoupon(code); !slices.Contains(s.coupons, code) {
+		s.coupons = append(s.coupons, code)
+	}
+	return nil
+}
+
+// RemoveCoupon removes a coupon code from the cart.
+func (s *ShoppingCart) RemoveCoupon(code string) {
+	code = normalizeCoupon(code)
+	s.coupons = slices.DeleteFunc(s.coupons, func(c string) bool { return c == code })
+}
+
+// SetTaxStrategy sets how tax is computed, usually from the shipping
+// address's region.
+func (s *ShoppingCart) SetTaxStrategy(strategy TaxStrategy) {
+	s.tax = strategy
+}
+
+// SetPaymentStrategy sets the payment strategy.
+func (s *ShoppingCart) SetPaymentStrategy(strategy PaymentStrategy) {
+	s.paymentStrategy = strategy
+}
+
+// Receipt prices the cart: line subtotals, discounts, tax and the total
+// to charge.
+//
+// Tax is computed on the discounted price of each line. Discounts on
+// the whole order are spread over the lines in proportion to their
+// amounts first, so a line in a tax-exempt category takes its share.
+func (s *ShoppingCart) Receipt() (Receipt, error) {
+	r := Receipt{
+		Subtotal: payment.Zero(s.currency),
+		Savings:  payment.Zero(s.currency),
+		Coupons:  slices.Clone(s.coupons),
+	}
+	net := make([]payment.Money, len(s.lines))
+	for i, l := range s.lines {
+		sub, err := l.Subtotal()
+		if err != nil {
+			return Receipt{}, err
+		}
+		r.Lines = append(r.Lines, ReceiptLine{SKU: l.SKU, Name: l.Name, Quantity: l.Quantity, UnitPrice: l.UnitPrice, Subtotal: sub})
+		if r.Subtotal, err = r.Subtotal.Add(sub); err != nil {
+			return Receipt{}, err
+		}
+		net[i] = sub
+	}
+
+	if s.pricing != nil {
+		var err error
+		if r.Discounts, err = s.pricing.Price(s.Items(), s.coupons); err != nil {
+			return Receipt{}, err
+		}
+	}
+	orderDiscount := payment.Zero(s.currency)
+	for _, d := range r.Discounts {
+		r.Savings, _ = r.Savings.Add(d.Amount)
+		if d.SKU == "" {
+			orderDiscount, _ = orderDiscount.Add(d.Amount)
+			continue
+		}
+		i := s.find(d.SKU)
+		net[i], _ = net[i].Sub(d.Amount)
+	}
+	if orderDiscount.IsPositive() {
+		weights := make([]int64, len(net))
+		for i, n := range net {
+			weights[i] = n.Minor()
+		}
+		shares, err := orderDiscount.Allocate(weights...)
+		if err != nil {
+			return Receipt{}, err
+		}
+		for i := range net {
+			net[i], _ = net[i].Sub(shares[i])
+		}
+	}
+
+	taxable := make([]TaxableLine, len(s.lines))
+	for i, l := range s.lines {
+		taxable[i] = TaxableLine{SKU: l.SKU, Category: l.Category, Amount: net[i]}
+	}
+	var err error
+	if r.Tax, err = s.tax.Tax(taxable); err != nil {
+		return Receipt{}, fmt.Errorf("%s: %w", s.tax.Name(), err)
+	}
+	if r.TaxTotal, err = r.Tax.Total(s.currency); err != nil {
+		return Receipt{}, err
+	}
+	if r.Total, err = r.Subtotal.Sub(r.Savings); err != nil {
+		return Receipt{}, err
+	}
+	if !r.Tax.Included {
+		if r.Total, err = r.Total.Add(r.TaxTotal); err != nil {
+			return Receipt{}, err
+		}
+	}
+	return r, nil
+}
+
+// Checkout prices the cart and charges the total with the payment
+// strategy. orderKey identifies the order, so retrying a failed
+// checkout cannot charge it twice.
+//
+// An order that costs nothing, such as one paid in full by a coupon or
+// made of free items, needs no payment: the receipt is returned without
+// one and no payment method is required.
+func (s *ShoppingCart) Checkout(ctx context.Context, orderKey string) (Receipt, error) {
+	if len(s.lines) == 0 {
+		return Receipt{}, ErrEmptyCart
+	}
+	r, err := s.Receipt()
+	if err != nil {
+		return Receipt{}, err
+	}
+	if r.Total.IsZero() {
+		return r, nil
+	}
+	if s.paymentStrategy == nil {
+		return Receipt{}, ErrNoPaymentMethod
+	}
+	paid, err := s.paymentStrategy.Pay(ctx, orderKey, r.Total)
+	if err != nil {
+		return Receipt{}, err
+	}
+	r.Payment = &paid
+	return r, nil
+}
@@ -0,0 +1,233 @@
+package patterns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+func TestShoppingCartItems(t *testing.T) {
+	cart := NewShoppingCart(payment.USD)
+	for _, item := range testLines() {
+		if err := cart.AddItem(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cart.AddItem(LineItem{SKU: "A", UnitPrice: usd("10.00"), Quantity: 2}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string]LineItem{
+		"no sku":      {UnitPrice: usd("1"), Quantity: 1},
+		"no quantity": {SKU: "C", UnitPrice: usd("1")},
+		"negative":    {SKU: "C", UnitPrice: usd("-1"), Quantity: 1},
+		"new price":   {SKU: "A", UnitPrice: usd("9.99"), Quantity: 1},
+	}
+	for name, item := range invalid {
+		if err := cart.AddItem(item); !errors.Is(err, ErrInvalidItem) {
+			t.Errorf("%s: error = %v, want ErrInvalidItem", name, err)
+		}
+	}
+	if err := cart.AddItem(LineItem{SKU: "C", UnitPrice: payment.MustParse("1", payment.EUR), Quantity: 1}); !errors.Is(err, payment.ErrCurrencyMismatch) {
+		t.Errorf("EUR item in a USD cart: %v", err)
+	}
+	if err := cart.AddItem(LineItem{SKU: "C", UnitPrice: payment.New(1<<62, payment.USD), Quantity: 4}); !errors.Is(err, payment.ErrOverflow) {
+		t.Errorf("overflowing line: %v", err)
+	}
+
+	if err := cart.UpdateQuantity("B", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.UpdateQuantity("B", -1); !errors.Is(err, ErrInvalidItem) {
+		t.Errorf("negative quantity: %v", err)
+	}
+	if err := cart.UpdateQuantity("Z", 1); !errors.Is(err, ErrUnknownSKU) {
+		t.Errorf("unknown SKU: %v", err)
+	}
+	quantities := func() []int {
+		var q []int
+		for _, l := range cart.Items() {
+			q = append(q, l.Quantity)
+		}
+		return q
+	}
+	if got := quantities(); !slices.Equal(got, []int{7, 3}) {
+		t.Errorf("quantities = %v, want [7 3]", got)
+	}
+	if err := cart.RemoveItem("A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cart.RemoveItem("A"); !errors.Is(err, ErrUnknownSKU) {
+		t.Errorf("removing twice: %v", err)
+	}
+	if items := cart.Items(); len(items) != 1 || items[0].SKU != "B" {
+		t.Errorf("items = %+v", items)
+	}
+}
+
+func TestShoppingCartReceipt(t *testing.T) {
+	cart := NewShoppingCart(payment.USD)
+	for _, item := range testLines() {
+		_ = cart.AddItem(item)
+	}
+	cart.SetPricing(NewPricingEngine(WithPricingRules(
+		&BuyXGetY{SKU: "A", Buy: 4, Get: 1},
+		&AmountOff{Label: "Five off", Amount: usd("5")},
+	)))
+	// Books are exempt, but take their share of the order discount.
+	cart.SetTaxStrategy(&SalesTax{Label: "Tax", Rate: "10", Exempt: []string{"books"}})
+
+	r, err := cart.Receipt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Subtotal 57.99; 10.00 off A, 5.00 off the order. Net A 40.00 and
+	// B 7.99 share the 5.00 as 4.17 and 0.83, so A is taxed on 35.83.
+	checks := map[string][2]payment.Money{
+		"subtotal": {r.Subtotal, usd("57.99")},
+		"savings":  {r.Savings, usd("15.00")},
+		"tax":      {r.TaxTotal, usd("3.58")},
+		"total":    {r.Total, usd("46.57")},
+		"base":     {r.Tax.Lines[0].Base, usd("35.83")},
+	}
+	for name, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("%s = %s, want %s", name, c[0], c[1])
+		}
+	}
+
+	// Included tax does not change the total.
+	cart.SetTaxStrategy(&VAT{Label: "VAT", Standard: "20"})
+	if r, err = cart.Receipt(); err != nil || r.Total != usd("42.99") || r.TaxTotal != usd("7.17") {
+		t.Errorf("VAT receipt = total %s, tax %s, %v", r.Total, r.TaxTotal, err)
+	}
+
+	empty, err := NewShoppingCart(payment.EUR).Receipt()
+	if err != nil || !empty.Total.IsZero() || empty.Total.Currency() != payment.EUR {
+		t.Errorf("empty receipt = %+v, %v", empty, err)
+	}
+}
+
+func TestShoppingCartCheckout(t *testing.T) {
+	ctx := context.Background()
+	g := payment.NewFakeGateway()
+	p := newTestProcessor(g)
+	cart := NewShoppingCart(payment.USD)
+	if _, err := cart.Checkout(ctx, "order"); !errors.Is(err, ErrEmptyCart) {
+		t.Errorf("checkout of an empty cart: %v", err)
+	}
+	if err := cart.AddItem(LineItem{SKU: "LAPTOP", Name: "Laptop", UnitPrice: usd("999.99"), Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cart.Checkout(ctx, "order"); !errors.Is(err, ErrNoPaymentMethod) {
+		t.Errorf("checkout without a method: %v", err)
+	}
+	cart.SetPaymentStrategy(&CreditCardStrategy{Card: testCard, Processor: p})
+
+	// A capture that times out is resumed by checking out again with
+	// the same key, without a second authorization.
+	g.FailNext(nil)
+	g.FailNext(payment.ErrGatewayUnavailable)
+	if _, err := cart.Checkout(ctx, "order"); !errors.Is(err, payment.ErrGatewayUnavailable) {
+		t.Fatalf("first checkout: %v", err)
+	}
+	r, err := cart.Checkout(ctx, "order")
+	if err != nil || r.Payment == nil || r.Payment.Status != payment.StatusCaptured || r.Payment.Captured != r.Total {
+		t.Fatalf("retried checkout = %+v, %v", r, err)
+	}
+	want := []string{
+		"authorize Visa ****4242 999.99 USD",
+		"capture auth_1 999.99 USD",
+		"capture auth_1 999.99 USD",
+	}
+	if got := g.Calls(); !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestShoppingCartCheckoutFreeOrder(t *testing.T) {
+	ctx := context.Background()
+	g := payment.NewFakeGateway()
+	cart := NewShoppingCart(payment.USD)
+	if err := cart.AddItem(LineItem{SKU: "SAMPLE", Name: "Sample", UnitPrice: usd("0"), Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// No payment method is needed for nothing to pay.
+	r, err := cart.Checkout(ctx, "free")
+	if err != nil || !r.Total.IsZero() || r.Payment != nil {
+		t.Fatalf("free checkout = %+v, %v", r, err)
+	}
+
+	// A coupon that covers the whole order skips the payment strategy.
+	if err := cart.AddItem(LineItem{SKU: "MUG", Name: "Mug", UnitPrice: usd("12.00"), Quantity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	cart.SetPricing(NewPricingEngine(WithPricingRules(
+		&Coupon{Code: "GIFT", Rule: &AmountOff{Amount: usd("12.00")}},
+	)))
+	if err := cart.ApplyCoupon("GIFT"); err != nil {
+		t.Fatal(err)
+	}
+	cart.SetPaymentStrategy(&CreditCardStrategy{Card: testCard, Processor: newTestProcessor(g)})
+	if r, err = cart.Checkout(ctx, "gift"); err != nil || !r.Total.IsZero() || r.Payment != nil {
+		t.Fatalf("checkout paid by coupon = %+v, %v", r, err)
+	}
+	if calls := g.Calls(); len(calls) != 0 {
+		t.Errorf("gateway calls = %q, want none", calls)
+	}
+}
+
+func TestReceiptRenderers(t *testing.T) {
+	cart := NewShoppingCart(payment.USD)
+	for _, item := range testLines() {
+		_ = cart.AddItem(item)
+	}
+	cart.SetPricing(NewPricingEngine(WithPricingRules(
+		&PercentOff{Label: "Toy sale", Percent: "10", Category: "toys"},
+		&AmountOff{Label: "Welcome", Amount: usd("2")},
+	)))
+	cart.SetTaxStrategy(&SalesTax{Label: "Tax", Rate: "5"})
+	r, err := cart.Receipt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Payment = &payment.Payment{ID: "auth_7", Method: "Visa ****4242", Captured: r.Total, Status: payment.StatusCaptured}
+
+	var text bytes.Buffer
+	if err := (TextReceipt{Width: 32}).Render(&text, r); err != nil {
+		t.Fatal(err)
+	}
+	want := `Alpha (A)
+  5 x 10.00                50.00
+    Toy sale               -5.00
+Beta (B)
+  1 x 7.99                  7.99
+--------------------------------
+Subtotal                   57.99
+Welcome                    -2.00
+Tax 5%                      2.55
+Total                  53.54 USD
+You saved               7.00 USD
+Paid with Visa ****4242 (auth_7)
+`
+	if text.String() != want {
+		t.Errorf("text receipt:\n%s\nwant:\n%s", text.String(), want)
+	}
+
+	var data bytes.Buffer
+	if err := (JSONReceipt{}).Render(&data, r); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Receipt
+	if err := json.Unmarshal(data.Bytes(), &decoded); err != nil {
+		t.Fatalf("decoding %s: %v", data.Bytes(), err)
+	}
+	if decoded.Total != r.Total || len(decoded.Discounts) != 2 || decoded.Discounts[0].SKU != "A" ||
+		decoded.Tax.Lines[0].Amount != usd("2.55") || decoded.Lines[1].UnitPrice != usd("7.99") || decoded.Payment.Status != payment.StatusCaptured {
+		t.Errorf("JSON round trip = %+v\nfrom %s", decoded, data.Bytes())
+	}
+}
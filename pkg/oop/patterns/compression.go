@@ -0,0 +1,483 @@
+package patterns
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+// Compression strategies: interchangeable algorithms behind one
+// interface, chosen by the caller or picked automatically per input.
+//
+// Why streams? A strategy that takes and returns strings has to hold
+// the input and the output in memory at once; one that copies from an
+// io.Reader to an io.Writer compresses a multi-gigabyte file in a few
+// kilobytes of buffer.
+
+// CompressionStrategy is a compression algorithm.
+type CompressionStrategy interface {
+	// Name identifies the strategy in a CompressionRegistry.
+	Name() string
+	// Compress reads src to the end and writes its compressed form to dst.
+	Compress(src io.Reader, dst io.Writer) error
+	// Decompress reverses Compress.
+	Decompress(src io.Reader, dst io.Writer) error
+}
+
+// compressStream copies src through a compressing writer into dst.
+func compressStream(src io.Reader, dst io.Writer, newWriter func(io.Writer) (io.WriteCloser, error)) error {
+	w, err := newWriter(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return errors.Join(err, w.Close())
+	}
+	return w.Close()
+}
+
+// decompressStream copies src through a decompressing reader into dst.
+func decompressStream(src io.Reader, dst io.Writer, newReader func(io.Reader) (io.Reader, error)) error {
+	r, err := newReader(src)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	if c, ok := r.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+// flateLevel maps the zero value of a Level field to the default level.
+func flateLevel(level int) int {
+	if level == 0 {
+		return flate.DefaultCompression
+	}
+	return level
+}
+
+// GzipCompression writes gzip streams (RFC 1952), the format of .gz files.
+type GzipCompression struct {
+	// Level is a compress/flate level. The zero value selects
+	// flate.DefaultCompression.
+	Level int
+}
+
+// Name returns "gzip".
+func (g *GzipCompression) Name() string { return "gzip" }
+
+// Compress writes a gzip stream.
+func (g *GzipCompression) Compress(src io.Reader, dst io.Writer) error {
+	return compressStream(src, dst, func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, flateLevel(g.Level))
+	})
+}
+
+// Decompress reads a gzip stream, including concatenated members.
+func (g *GzipCompression) Decompress(src io.Reader, dst io.Writer) error {
+	return decompressStream(src, dst, func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	})
+}
+
+// ZlibCompression writes zlib streams (RFC 1950): DEFLATE with a
+// two-byte header and an Adler-32 checksum.
+type ZlibCompression struct {
+	// Level is a compress/flate level. The zero value selects
+	// flate.DefaultCompression.
+	Level int
+}
+
+// Name returns "zlib".
+func (z *ZlibCompression) Name() string { return "zlib" }
+
+// Compress writes a zlib stream.
+func (z *ZlibCompression) Compress(src io.Reader, dst io.Writer) error {
+	return compressStream(src, dst, func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, flateLevel(z.Level))
+	})
+}
+
+// Decompress reads a zlib stream and verifies its checksum.
+func (z *ZlibCompression) Decompress(src io.Reader, dst io.Writer) error {
+	return decompressStream(src, dst, func(r io.Reader) (io.Reader, error) {
+		return zlib.NewReader(r)
+	})
+}
+
+// FlateCompression writes raw DEFLATE (RFC 1951): the smallest framing,
+// with no header and no checksum.
+type FlateCompression struct {
+	// Level is a compress/flate level. The zero value selects
+	// flate.DefaultCompression.
+	Level int
+}
+
+// Name returns "flate".
+func (f *FlateCompression) Name() string { return "flate" }
+
+// Compress writes a DEFLATE stream.
+func (f *FlateCompression) Compress(src io.Reader, dst io.Writer) error {
+	return compressStream(src, dst, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flateLevel(f.Level))
+	})
+}
+
+// Decompress reads a DEFLATE stream.
+func (f *FlateCompression) Decompress(src io.Reader, dst io.Writer) error {
+	return decompressStream(src, dst, func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	})
+}
+
+// LZWCompression writes Lempel-Ziv-Welch streams as used by GIF, TIFF
+// and PDF. It is fast but compresses less than DEFLATE.
+type LZWCompression struct {
+	// Order is the bit packing order; the zero value is lzw.LSB, as in GIF.
+	Order lzw.Order
+	// LitWidth is the number of bits per literal, from 2 to 8. The zero
+	// value selects 8, which any byte input needs.
+	LitWidth int
+}
+
+// Name returns "lzw".
+func (l *LZWCompression) Name() string { return "lzw" }
+
+func (l *LZWCompression) litWidth() int {
+	if l.LitWidth == 0 {
+		return 8
+	}
+	return l.LitWidth
+}
+
+// Compress writes an LZW stream.
+func (l *LZWCompression) Compress(src io.Reader, dst io.Writer) error {
+	return compressStream(src, dst, func(w io.Writer) (io.WriteCloser, error) {
+		return lzw.NewWriter(w, l.Order, l.litWidth()), nil
+	})
+}
+
+// Decompress reads an LZW stream written with the same Order and
+// LitWidth.
+func (l *LZWCompression) Decompress(src io.Reader, dst io.Writer) error {
+	return decompressStream(src, dst, func(r io.Reader) (io.Reader, error) {
+		return lzw.NewReader(r, l.Order, l.litWidth()), nil
+	})
+}
+
+// ZipCompression writes a ZIP archive holding the input as one
+// DEFLATE-compressed file, readable by any unzip tool.
+type ZipCompression struct {
+	// Entry is the file name inside the archive; the zero value is "data".
+	Entry string
+	// Level is a compress/flate level. The zero value selects
+	// flate.DefaultCompression.
+	Level int
+}
+
+// Name returns "zip".
+func (z *ZipCompression) Name() string { return "zip" }
+
+// Compress writes an archive with a single entry. The entry has no
+// modification time, so the same input always gives the same archive.
+func (z *ZipCompression) Compress(src io.Reader, dst io.Writer) error {
+	entry := z.Entry
+	if entry == "" {
+		entry = "data"
+	}
+	zw := zip.NewWriter(dst)
+	zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, flateLevel(z.Level))
+	})
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: entry, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fw, src); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Decompress extracts the single file of an archive. The central
+// directory sits at the end of a ZIP file, so the whole archive is read
+// into memory first.
+func (z *ZipCompression) Decompress(src io.Reader, dst io.Writer) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if len(zr.File) != 1 {
+		return fmt.Errorf("zip: archive has %d entries, want 1", len(zr.File))
+	}
+	r, err := zr.File[0].Open()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	// Close verifies the entry's CRC-32.
+	return errors.Join(err, r.Close())
+}
+
+// NewCodecStrategy adapts a Codec, such as ZstdCodec or SnappyCodec, to
+// a CompressionStrategy.
+func NewCodecStrategy(codec Codec) CompressionStrategy {
+	return codecStrategy{codec}
+}
+
+type codecStrategy struct{ codec Codec }
+
+func (c codecStrategy) Name() string { return c.codec.Name() }
+
+func (c codecStrategy) Compress(src io.Reader, dst io.Writer) error {
+	return compressStream(src, dst, c.codec.NewWriter)
+}
+
+func (c codecStrategy) Decompress(src io.Reader, dst io.Writer) error {
+	return decompressStream(src, dst, c.codec.NewReader)
+}
+
+// CompressionRegistry holds strategies by name. It is safe for
+// concurrent use. The zero value is an empty registry; use
+// NewCompressionRegistry for one with the standard strategies.
+//
+// Why a registry? A FileCompressor choosing automatically needs the set
+// of candidates, and a decompressor needs to find a strategy by the
+// name recorded next to the data. Registering a custom strategy makes
+// it available to both without changing this package.
+type CompressionRegistry struct {
+	mu         sync.RWMutex
+	strategies []CompressionStrategy
+}
+
+// ErrDuplicateStrategy is returned when registering a name twice.
+var ErrDuplicateStrategy = errors.New("compression strategy already registered")
+
+// NewCompressionRegistry returns a registry holding gzip, zlib, flate,
+// lzw and zip, all at their default settings.
+func NewCompressionRegistry() *CompressionRegistry {
+	return &CompressionRegistry{strategies: []CompressionStrategy{
+		&GzipCompression{},
+		&ZlibCompression{},
+		&FlateCompression{},
+		&LZWCompression{},
+		&ZipCompression{},
+	}}
+}
+
+// Register adds strategy under its name.
+func (r *CompressionRegistry) Register(strategy CompressionStrategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := strategy.Name()
+	if r.lookup(name) != nil {
+		return fmt.Errorf("%w: %q", ErrDuplicateStrategy, name)
+	}
+	r.strategies = append(r.strategies, strategy)
+	return nil
+}
+
+// Lookup returns the strategy registered under name.
+func (r *CompressionRegistry) Lookup(name string) (CompressionStrategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s := r.lookup(name)
+	return s, s != nil
+}
+
+func (r *CompressionRegistry) lookup(name string) CompressionStrategy {
+	for _, s := range r.strategies {
+		if s.Name() == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Strategies returns the registered strategies in registration order.
+func (r *CompressionRegistry) Strategies() []CompressionStrategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.strategies)
+}
+
+// CompressionTarget tells an automatic FileCompressor which strategy
+// to pick. The zero value picks the smallest output.
+type CompressionTarget struct {
+	// MaxRatio is the largest acceptable compressed-to-original size
+	// ratio, such as 0.5 for "at least halve it". Zero means any.
+	MaxRatio float64
+	// MinSpeed is the slowest acceptable compression speed, in input
+	// bytes per second. Zero means any.
+	MinSpeed float64
+	// PreferSpeed picks the fastest acceptable strategy instead of the
+	// one with the smallest output.
+	PreferSpeed bool
+}
+
+// ErrNoStrategy is returned when no registered strategy meets the
+// CompressionTarget.
+var ErrNoStrategy = errors.New("no compression strategy meets the target")
+
+// CompressionTrial is how one strategy did on a sample.
+type CompressionTrial struct {
+	Strategy CompressionStrategy
+	// Ratio is the compressed size divided by the sample size.
+	Ratio float64
+	// Speed is in sample bytes per second.
+	Speed float64
+}
+
+// FileCompressorOption configures an automatic FileCompressor.
+type FileCompressorOption func(*FileCompressor)
+
+// WithSampleSize sets how many leading bytes of the input the strategies
+// are tried on. The default is 64 KiB.
+func WithSampleSize(n int) FileCompressorOption {
+	return func(f *FileCompressor) { f.sampleSize = n }
+}
+
+// WithCompressionClock sets the clock used to time trials, so tests can
+// make speeds deterministic.
+func WithCompressionClock(clock idioms.Clock) FileCompressorOption {
+	return func(f *FileCompressor) { f.clock = clock }
+}
+
+// FileCompressor compresses with a fixed strategy, or picks one per
+// input from a registry.
+type FileCompressor struct {
+	strategy   CompressionStrategy
+	registry   *CompressionRegistry
+	target     CompressionTarget
+	sampleSize int
+	clock      idioms.Clock
+}
+
+// NewFileCompressor creates a compressor that always uses strategy.
+func NewFileCompressor(strategy CompressionStrategy) *FileCompressor {
+	return &FileCompressor{strategy: strategy}
+}
+
+// NewAutoFileCompressor creates a compressor that tries every strategy
+// in registry on the start of each input and uses the one that best
+// meets target.
+func NewAutoFileCompressor(registry *CompressionRegistry, target CompressionTarget, opts ...FileCompressorOption) *FileCompressor {
+	f := &FileCompressor{
+		registry:   registry,
+		target:     target,
+		sampleSize: 64 << 10,
+		clock:      idioms.SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// SetStrategy fixes the compression strategy, turning off automatic
+// selection.
+func (f *FileCompressor) SetStrategy(strategy CompressionStrategy) {
+	f.strategy = strategy
+}
+
+// Compress compresses src into dst and returns the strategy it used.
+// Callers of an automatic compressor record the strategy's name to
+// find it again, with CompressionRegistry.Lookup, when decompressing.
+func (f *FileCompressor) Compress(src io.Reader, dst io.Writer) (CompressionStrategy, error) {
+	strategy := f.strategy
+	if strategy == nil {
+		br := bufio.NewReaderSize(src, f.sampleSize)
+		sample, err := br.Peek(f.sampleSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if strategy, err = f.Choose(sample); err != nil {
+			return nil, err
+		}
+		src = br
+	}
+	return strategy, strategy.Compress(src, dst)
+}
+
+// Choose returns the strategy that best meets the target on sample.
+func (f *FileCompressor) Choose(sample []byte) (CompressionStrategy, error) {
+	trials, err := f.Evaluate(sample)
+	if err != nil {
+		return nil, err
+	}
+	t := f.target
+	var best *CompressionTrial
+	for i := range trials {
+		trial := &trials[i]
+		if t.MaxRatio > 0 && trial.Ratio > t.MaxRatio || t.MinSpeed > 0 && trial.Speed < t.MinSpeed {
+			continue
+		}
+		if best == nil ||
+			t.PreferSpeed && trial.Speed > best.Speed ||
+			!t.PreferSpeed && trial.Ratio < best.Ratio {
+			best = trial
+		}
+	}
+	if best == nil {
+		return nil, ErrNoStrategy
+	}
+	return best.Strategy, nil
+}
+
+// Evaluate compresses sample with every registered strategy and reports
+// the ratio and speed of each.
+func (f *FileCompressor) Evaluate(sample []byte) ([]CompressionTrial, error) {
+	if f.registry == nil {
+		return nil, errors.New("compressor has no registry")
+	}
+	strategies := f.registry.Strategies()
+	trials := make([]CompressionTrial, 0, len(strategies))
+	var out countingWriter
+	for _, s := range strategies {
+		out = 0
+		start := f.clock.Now()
+		if err := s.Compress(bytes.NewReader(sample), &out); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name(), err)
+		}
+		elapsed := f.clock.Now().Sub(start)
+		trials = append(trials, CompressionTrial{
+			Strategy: s,
+			Ratio:    float64(out) / float64(max(len(sample), 1)),
+			Speed:    float64(len(sample)) / max(elapsed, time.Nanosecond).Seconds(),
+		})
+	}
+	return trials, nil
+}
+
+// Decompress decompresses src into dst with the fixed strategy.
+func (f *FileCompressor) Decompress(src io.Reader, dst io.Writer) error {
+	if f.strategy == nil {
+		return errors.New("automatic compressor: decompress with the strategy Compress returned")
+	}
+	return f.strategy.Decompress(src, dst)
+}
+
+// countingWriter discards what is written and counts the bytes.
+type countingWriter int
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
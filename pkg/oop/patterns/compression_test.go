@@ -0,0 +1,210 @@
+package patterns
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"compress/lzw"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+func TestCompressionStrategiesRoundTrip(t *testing.T) {
+	strategies := append(NewCompressionRegistry().Strategies(),
+		&GzipCompression{Level: flate.BestSpeed},
+		&ZlibCompression{Level: flate.BestCompression},
+		&FlateCompression{Level: flate.HuffmanOnly},
+		&LZWCompression{Order: lzw.MSB},
+		&ZipCompression{Entry: "report.txt", Level: flate.NoCompression},
+		NewCodecStrategy(ZstdCodec),
+		NewCodecStrategy(SnappyCodec),
+	)
+	for _, s := range strategies {
+		for name, in := range testInputs() {
+			var compressed, restored bytes.Buffer
+			if err := s.Compress(bytes.NewReader(in), &compressed); err != nil {
+				t.Fatalf("%s/%s: compress: %v", s.Name(), name, err)
+			}
+			if err := s.Decompress(&compressed, &restored); err != nil {
+				t.Fatalf("%s/%s: decompress: %v", s.Name(), name, err)
+			}
+			if !bytes.Equal(restored.Bytes(), in) {
+				t.Fatalf("%s/%s: restored %d bytes, compressed %d", s.Name(), name, restored.Len(), len(in))
+			}
+		}
+	}
+}
+
+func TestCompressionStrategiesRejectCorruptInput(t *testing.T) {
+	for _, s := range NewCompressionRegistry().Strategies() {
+		var compressed bytes.Buffer
+		if err := s.Compress(strings.NewReader(strings.Repeat("corrupt me ", 1000)), &compressed); err != nil {
+			t.Fatal(err)
+		}
+		truncated := compressed.Bytes()[:compressed.Len()/2]
+		if err := s.Decompress(bytes.NewReader(truncated), io.Discard); err == nil {
+			t.Errorf("%s: decompressing a truncated stream succeeded", s.Name())
+		}
+	}
+}
+
+func TestZipCompressionWritesStandardArchive(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&ZipCompression{Entry: "notes.txt"}).Compress(strings.NewReader("hello zip"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "notes.txt" || zr.File[0].Method != zip.Deflate {
+		t.Fatalf("archive entries = %+v, want one deflated notes.txt", zr.File)
+	}
+
+	// An archive with two files is not something Compress wrote.
+	buf.Reset()
+	zw := zip.NewWriter(&buf)
+	zw.Create("a")
+	zw.Create("b")
+	zw.Close()
+	if err := (&ZipCompression{}).Decompress(&buf, io.Discard); err == nil {
+		t.Error("decompressing a two-file archive succeeded")
+	}
+}
+
+func TestCompressionRegistry(t *testing.T) {
+	r := NewCompressionRegistry()
+	var names []string
+	for _, s := range r.Strategies() {
+		names = append(names, s.Name())
+	}
+	if got := strings.Join(names, ","); got != "gzip,zlib,flate,lzw,zip" {
+		t.Errorf("standard strategies = %s", got)
+	}
+
+	if err := r.Register(&GzipCompression{Level: 9}); !errors.Is(err, ErrDuplicateStrategy) {
+		t.Errorf("duplicate Register error = %v, want ErrDuplicateStrategy", err)
+	}
+	if err := r.Register(NewCodecStrategy(SnappyCodec)); err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := r.Lookup("snappy"); !ok || s.Name() != "snappy" {
+		t.Errorf("Lookup(snappy) = %v, %t", s, ok)
+	}
+	if _, ok := r.Lookup("rar"); ok {
+		t.Error("Lookup(rar) found a strategy")
+	}
+
+	var empty CompressionRegistry
+	if len(empty.Strategies()) != 0 {
+		t.Error("zero registry is not empty")
+	}
+	if err := empty.Register(&LZWCompression{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := empty.Lookup("lzw"); !ok {
+		t.Error("zero registry lost a registration")
+	}
+}
+
+// fakeStrategy writes ratio times its input and takes cost per call on
+// a manual clock, so selection tests do not depend on the machine.
+type fakeStrategy struct {
+	name  string
+	ratio float64
+	cost  time.Duration
+	clock *idioms.ManualClock
+}
+
+func (f *fakeStrategy) Name() string { return f.name }
+
+func (f *fakeStrategy) Compress(src io.Reader, dst io.Writer) error {
+	n, err := io.Copy(io.Discard, src)
+	if err != nil {
+		return err
+	}
+	f.clock.Advance(f.cost)
+	_, err = dst.Write(make([]byte, int(float64(n)*f.ratio)))
+	return err
+}
+
+func (f *fakeStrategy) Decompress(src io.Reader, dst io.Writer) error {
+	return errors.ErrUnsupported
+}
+
+func TestAutoFileCompressorChooses(t *testing.T) {
+	clock := idioms.NewManualClock(time.Time{})
+	registry := &CompressionRegistry{}
+	for _, s := range []*fakeStrategy{
+		{name: "tight", ratio: 0.2, cost: 100 * time.Millisecond},
+		{name: "balanced", ratio: 0.4, cost: 10 * time.Millisecond},
+		{name: "fast", ratio: 0.7, cost: time.Millisecond},
+	} {
+		s.clock = clock
+		registry.Register(s)
+	}
+	sample := make([]byte, 1000) // 1000 bytes: 10 KB/s, 100 KB/s, 1 MB/s
+
+	tests := []struct {
+		name   string
+		target CompressionTarget
+		want   string
+	}{
+		{"smallest by default", CompressionTarget{}, "tight"},
+		{"fastest", CompressionTarget{PreferSpeed: true}, "fast"},
+		{"fastest within a ratio", CompressionTarget{MaxRatio: 0.5, PreferSpeed: true}, "balanced"},
+		{"smallest above a speed", CompressionTarget{MinSpeed: 50_000}, "balanced"},
+		{"both limits", CompressionTarget{MaxRatio: 0.3, MinSpeed: 5_000}, "tight"},
+		{"unreachable", CompressionTarget{MaxRatio: 0.3, MinSpeed: 50_000}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAutoFileCompressor(registry, tt.target, WithCompressionClock(clock))
+			got, err := c.Choose(sample)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoStrategy) {
+					t.Fatalf("Choose = %v, %v; want ErrNoStrategy", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name() != tt.want {
+				t.Errorf("Choose = %s, want %s", got.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoFileCompressorRoundTrip(t *testing.T) {
+	registry := NewCompressionRegistry()
+	// The sample is smaller than the input: Compress must still write
+	// all of it, including the bytes it peeked at.
+	c := NewAutoFileCompressor(registry, CompressionTarget{}, WithSampleSize(4096))
+	in := testInputs()["text"]
+
+	var compressed, restored bytes.Buffer
+	used, err := c.Compress(bytes.NewReader(in), &compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strategy, ok := registry.Lookup(used.Name())
+	if !ok {
+		t.Fatalf("chosen strategy %s is not registered", used.Name())
+	}
+	if err := strategy.Decompress(&compressed, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored.Bytes(), in) {
+		t.Fatalf("restored %d bytes, compressed %d", restored.Len(), len(in))
+	}
+	if err := c.Decompress(&compressed, io.Discard); err == nil {
+		t.Error("automatic compressor decompressed without a strategy")
+	}
+}
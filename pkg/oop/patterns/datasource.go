@@ -0,0 +1,277 @@
+package patterns
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/KrystianMarek/golang-202/internal/snappy"
+	"github.com/KrystianMarek/golang-202/internal/zstd"
+)
+
+// DataSource stores one blob of data as a stream.
+//
+// Why streams instead of WriteData(string)? A decorator that works on
+// whole strings has to hold the data in memory at every layer, and
+// cannot hand the next layer anything until it has seen the end. Each
+// decorator here wraps the io.Writer or io.Reader of the source below
+// it, so data flows through the whole stack a chunk at a time.
+type DataSource interface {
+	// NewWriter returns a writer that replaces the stored data. The new
+	// data must only become visible to readers once the writer is
+	// closed without error.
+	NewWriter() (io.WriteCloser, error)
+
+	// NewReader returns a reader over the stored data. Layers that
+	// check integrity report corruption from Read at the latest when
+	// the end of the data is reached.
+	NewReader() (io.ReadCloser, error)
+}
+
+// aborter is implemented by writers that can discard everything written
+// instead of committing it. A decorator whose own Close fails aborts the
+// writer below it, so a failed write never replaces good data.
+type aborter interface {
+	Abort() error
+}
+
+// abort discards w if it supports that, and closes it otherwise.
+func abort(w io.WriteCloser) error {
+	if a, ok := w.(aborter); ok {
+		return a.Abort()
+	}
+	return w.Close()
+}
+
+// WriteAll replaces the data in source with data.
+func WriteAll(source DataSource, data []byte) error {
+	w, err := source.NewWriter()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return errors.Join(err, abort(w))
+	}
+	return w.Close()
+}
+
+// ReadAll returns all the data in source.
+func ReadAll(source DataSource) ([]byte, error) {
+	r, err := source.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(r)
+	return data, errors.Join(err, r.Close())
+}
+
+// FileDataSource stores data in a file.
+type FileDataSource struct {
+	filename string
+}
+
+// NewFileDataSource creates a file data source.
+func NewFileDataSource(filename string) *FileDataSource {
+	return &FileDataSource{filename: filename}
+}
+
+// NewWriter writes to a temporary file next to the target and renames
+// it over the target on Close, so readers see either the old or the new
+// data and never a partial write.
+func (f *FileDataSource) NewWriter() (io.WriteCloser, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(f.filename), filepath.Base(f.filename)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{File: tmp, target: f.filename}, nil
+}
+
+// NewReader opens the file.
+func (f *FileDataSource) NewReader() (io.ReadCloser, error) {
+	return os.Open(f.filename)
+}
+
+// fileWriter is the temporary file behind FileDataSource.NewWriter.
+type fileWriter struct {
+	*os.File
+	target string
+}
+
+// Close flushes the temporary file to disk and renames it over the
+// target.
+func (w *fileWriter) Close() error {
+	if err := errors.Join(w.Sync(), w.File.Close()); err != nil {
+		return errors.Join(err, os.Remove(w.Name()))
+	}
+	if err := os.Rename(w.Name(), w.target); err != nil {
+		return errors.Join(err, os.Remove(w.Name()))
+	}
+	return nil
+}
+
+// Abort deletes the temporary file and leaves the target untouched.
+func (w *fileWriter) Abort() error {
+	return errors.Join(w.File.Close(), os.Remove(w.Name()))
+}
+
+// MemoryDataSource stores data in memory. It is safe for concurrent use.
+type MemoryDataSource struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+// NewWriter buffers writes and swaps them in on Close.
+func (m *MemoryDataSource) NewWriter() (io.WriteCloser, error) {
+	return &memoryWriter{source: m}, nil
+}
+
+// NewReader reads a snapshot of the data at the time of the call.
+func (m *MemoryDataSource) NewReader() (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return io.NopCloser(bytes.NewReader(m.data)), nil
+}
+
+// Len returns the size of the stored data.
+func (m *MemoryDataSource) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.data)
+}
+
+type memoryWriter struct {
+	source *MemoryDataSource
+	buf    bytes.Buffer
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func (w *memoryWriter) Close() error {
+	w.source.mu.Lock()
+	defer w.source.mu.Unlock()
+	w.source.data = w.buf.Bytes()
+	return nil
+}
+
+func (w *memoryWriter) Abort() error { return nil }
+
+// Codec is a streaming compression format.
+type Codec interface {
+	// Name identifies the codec, such as "gzip".
+	Name() string
+	// NewWriter returns a writer that compresses into w. Closing it
+	// must finish the compressed stream but not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader that decompresses r.
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+// Codecs for CompressionDecorator.
+var (
+	// GzipCodec is the gzip format (RFC 1952) from compress/gzip.
+	GzipCodec Codec = gzipCodec{}
+	// ZstdCodec is the Zstandard format (RFC 8878). It reads frames from
+	// any encoder, but writes them with a lower ratio than the zstd tool.
+	ZstdCodec Codec = zstdCodec{}
+	// SnappyCodec is the Snappy framing format: fast, with a lower ratio.
+	SnappyCodec Codec = snappyCodec{}
+)
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+func (gzipCodec) NewReader(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string { return "zstd" }
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w), nil
+}
+func (zstdCodec) NewReader(r io.Reader) (io.Reader, error) { return zstd.NewReader(r), nil }
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+func (snappyCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return snappy.NewWriter(w), nil
+}
+func (snappyCodec) NewReader(r io.Reader) (io.Reader, error) { return snappy.NewReader(r), nil }
+
+// CompressionDecorator compresses data on its way to the wrapped source.
+type CompressionDecorator struct {
+	wrapped DataSource
+	codec   Codec
+}
+
+// NewCompressionDecorator creates a compression decorator using codec.
+func NewCompressionDecorator(source DataSource, codec Codec) *CompressionDecorator {
+	return &CompressionDecorator{wrapped: source, codec: codec}
+}
+
+// NewWriter compresses into a writer of the wrapped source.
+func (c *CompressionDecorator) NewWriter() (io.WriteCloser, error) {
+	inner, err := c.wrapped.NewWriter()
+	if err != nil {
+		return nil, err
+	}
+	outer, err := c.codec.NewWriter(inner)
+	if err != nil {
+		return nil, errors.Join(err, abort(inner))
+	}
+	return &layerWriter{Writer: outer, outer: outer, inner: inner}, nil
+}
+
+// NewReader decompresses a reader of the wrapped source.
+func (c *CompressionDecorator) NewReader() (io.ReadCloser, error) {
+	inner, err := c.wrapped.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	outer, err := c.codec.NewReader(inner)
+	if err != nil {
+		return nil, errors.Join(err, inner.Close())
+	}
+	return &layerReader{Reader: outer, inner: inner}, nil
+}
+
+// layerWriter is one decorator's writer stacked on the one below it.
+type layerWriter struct {
+	io.Writer
+	outer io.Closer // finishes this layer's format
+	inner io.WriteCloser
+}
+
+// Close finishes this layer, then commits the layer below. If this
+// layer fails, the layer below is aborted instead.
+func (w *layerWriter) Close() error {
+	if err := w.outer.Close(); err != nil {
+		return errors.Join(err, abort(w.inner))
+	}
+	return w.inner.Close()
+}
+
+// Abort discards this layer and every layer below it.
+func (w *layerWriter) Abort() error {
+	return abort(w.inner)
+}
+
+// layerReader is one decorator's reader stacked on the one below it.
+type layerReader struct {
+	io.Reader
+	inner io.ReadCloser
+}
+
+func (r *layerReader) Close() error {
+	if c, ok := r.Reader.(io.Closer); ok {
+		return errors.Join(c.Close(), r.inner.Close())
+	}
+	return r.inner.Close()
+}
@@ -1,6 +1,13 @@
 package patterns
 
-import "fmt"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
 
 // Decorator pattern demonstrates adding behavior to objects dynamically.
 //
@@ -71,87 +78,6 @@ func (w *WhipDecorator) Description() string {
 	return w.coffee.Description() + ", whipped cream"
 }
 
-// DataSource is an interface for reading/writing data.
-type DataSource interface {
-	WriteData(data string)
-	ReadData() string
-}
-
-// FileDataSource writes to a file.
-type FileDataSource struct {
-	filename string
-	data     string
-}
-
-// NewFileDataSource creates a file data source.
-func NewFileDataSource(filename string) *FileDataSource {
-	return &FileDataSource{filename: filename}
-}
-
-// WriteData writes data.
-func (f *FileDataSource) WriteData(data string) {
-	f.data = data
-	fmt.Printf("Writing to file %s: %s\n", f.filename, data)
-}
-
-// ReadData reads data.
-func (f *FileDataSource) ReadData() string {
-	fmt.Printf("Reading from file %s\n", f.filename)
-	return f.data
-}
-
-// EncryptionDecorator adds encryption.
-type EncryptionDecorator struct {
-	wrapped DataSource
-}
-
-// NewEncryptionDecorator creates an encryption decorator.
-func NewEncryptionDecorator(source DataSource) *EncryptionDecorator {
-	return &EncryptionDecorator{wrapped: source}
-}
-
-// WriteData encrypts and writes.
-func (e *EncryptionDecorator) WriteData(data string) {
-	encrypted := fmt.Sprintf("ENCRYPTED(%s)", data)
-	e.wrapped.WriteData(encrypted)
-}
-
-// ReadData reads and decrypts.
-func (e *EncryptionDecorator) ReadData() string {
-	data := e.wrapped.ReadData()
-	// Simulate decryption
-	if len(data) > 10 {
-		return data[10 : len(data)-1]
-	}
-	return data
-}
-
-// CompressionDecorator adds compression.
-type CompressionDecorator struct {
-	wrapped DataSource
-}
-
-// NewCompressionDecorator creates a compression decorator.
-func NewCompressionDecorator(source DataSource) *CompressionDecorator {
-	return &CompressionDecorator{wrapped: source}
-}
-
-// WriteData compresses and writes.
-func (c *CompressionDecorator) WriteData(data string) {
-	compressed := fmt.Sprintf("COMPRESSED(%s)", data)
-	c.wrapped.WriteData(compressed)
-}
-
-// ReadData reads and decompresses.
-func (c *CompressionDecorator) ReadData() string {
-	data := c.wrapped.ReadData()
-	// Simulate decompression
-	if len(data) > 11 {
-		return data[11 : len(data)-1]
-	}
-	return data
-}
-
 // Notifier sends notifications.
 type Notifier interface {
 	Send(message string)
@@ -209,14 +135,41 @@ func ExampleDecorator() {
 	fmt.Printf("%s: $%.2f\n\n",
 		fancyCoffee.Description(), fancyCoffee.Cost())
 
-	// Data source decorators
-	fileSource := NewFileDataSource("data.txt")
-	encryptedSource := NewEncryptionDecorator(fileSource)
-	compressedEncrypted := NewCompressionDecorator(encryptedSource)
+	// Data source decorators: compress, then encrypt, then store in a
+	// file. Reading runs the same stack in reverse.
+	dir, err := os.MkdirTemp("", "decorator")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	file := NewFileDataSource(filepath.Join(dir, "data.bin"))
+	source := NewCompressionDecorator(
+		NewEncryptionDecorator(file, "correct horse battery staple"),
+		GzipCodec,
+	)
+
+	data := []byte(strings.Repeat("sensitive data ", 100))
+	if err := WriteAll(source, data); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	stored, _ := os.ReadFile(filepath.Join(dir, "data.bin"))
+	fmt.Printf("Wrote %d bytes, stored %d (header %q)\n",
+		len(data), len(stored), stored[:len(encryptionMagic)])
+
+	readData, err := ReadAll(source)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Read back %d bytes, identical: %t\n", len(readData), bytes.Equal(readData, data))
 
-	compressedEncrypted.WriteData("sensitive data")
-	readData := compressedEncrypted.ReadData()
-	fmt.Printf("Read: %s\n\n", readData)
+	wrongKey := NewCompressionDecorator(NewEncryptionDecorator(file, "guess"), GzipCodec)
+	if _, err := ReadAll(wrongKey); errors.Is(err, ErrDecrypt) {
+		fmt.Printf("Wrong passphrase: %v\n\n", err)
+	}
 
 	// Notification decorators
 	notifier := Notifier(&BaseNotifier{})
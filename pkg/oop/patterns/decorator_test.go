@@ -0,0 +1,230 @@
+package patterns
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cheap keeps scrypt fast in tests; the format is the same at any cost.
+var cheap = WithScrypt(4, 1, 1)
+
+func testInputs() map[string][]byte {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := make([]byte, 3*chunkSize+17)
+	for i := range random {
+		random[i] = byte(rng.Uint32())
+	}
+	return map[string][]byte{
+		"empty":       {},
+		"short":       []byte("sensitive data"),
+		"one chunk":   random[:chunkSize],
+		"two chunks":  random[:2*chunkSize],
+		"chunk+1":     random[:chunkSize+1],
+		"random":      random,
+		"text":        []byte(strings.Repeat("the quick brown fox jumps over the lazy dog\n", 20_000)),
+		"zero filled": make([]byte, 200_000),
+	}
+}
+
+func TestDecoratorsRoundTripInAnyOrder(t *testing.T) {
+	layers := map[string]func(DataSource) DataSource{
+		"aes":    func(s DataSource) DataSource { return NewEncryptionDecorator(s, "secret", cheap) },
+		"gzip":   func(s DataSource) DataSource { return NewCompressionDecorator(s, GzipCodec) },
+		"zstd":   func(s DataSource) DataSource { return NewCompressionDecorator(s, ZstdCodec) },
+		"snappy": func(s DataSource) DataSource { return NewCompressionDecorator(s, SnappyCodec) },
+	}
+	stacks := [][]string{
+		{},
+		{"aes"}, {"gzip"}, {"zstd"}, {"snappy"},
+		{"gzip", "aes"}, {"aes", "gzip"},
+		{"zstd", "aes"}, {"aes", "zstd"},
+		{"snappy", "aes"}, {"aes", "snappy"},
+		{"snappy", "zstd", "gzip"},
+		{"aes", "zstd", "aes", "snappy"},
+	}
+	for _, stack := range stacks {
+		t.Run(strings.Join(append([]string{"file"}, stack...), "<"), func(t *testing.T) {
+			// Layers are applied innermost first: the last name is the one
+			// the caller talks to.
+			var source DataSource = NewFileDataSource(filepath.Join(t.TempDir(), "data"))
+			for _, name := range stack {
+				source = layers[name](source)
+			}
+			for name, in := range testInputs() {
+				if err := WriteAll(source, in); err != nil {
+					t.Fatalf("%s: write: %v", name, err)
+				}
+				got, err := ReadAll(source)
+				if err != nil {
+					t.Fatalf("%s: read: %v", name, err)
+				}
+				if !bytes.Equal(got, in) {
+					t.Fatalf("%s: read %d bytes, wrote %d", name, len(got), len(in))
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingWritesInSmallPieces(t *testing.T) {
+	mem := &MemoryDataSource{}
+	source := NewCompressionDecorator(NewEncryptionDecorator(mem, "secret", cheap), ZstdCodec)
+	in := testInputs()["random"]
+
+	w, err := source.NewWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rest := in; len(rest) > 0; {
+		n := min(len(rest), 1000)
+		if _, err := w.Write(rest[:n]); err != nil {
+			t.Fatal(err)
+		}
+		rest = rest[n:]
+	}
+	if mem.Len() != 0 {
+		t.Fatal("data visible before Close")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := source.NewReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var got bytes.Buffer
+	if _, err := io.CopyBuffer(&got, r, make([]byte, 7)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), in) {
+		t.Fatalf("read %d bytes, wrote %d", got.Len(), len(in))
+	}
+}
+
+func TestEncryptionRejectsBadData(t *testing.T) {
+	mem := &MemoryDataSource{}
+	plain := testInputs()["random"]
+	if err := WriteAll(NewEncryptionDecorator(mem, "secret", cheap), plain); err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := ReadAll(mem)
+	edit := func(f func(b []byte) []byte) []byte {
+		return f(bytes.Clone(sealed))
+	}
+	chunk := chunkSize + 16
+
+	tests := []struct {
+		name       string
+		stored     []byte
+		passphrase string
+		want       error
+	}{
+		{"wrong passphrase", sealed, "Secret", ErrDecrypt},
+		{"flipped ciphertext bit", edit(func(b []byte) []byte { b[headerSize+100] ^= 1; return b }), "secret", ErrDecrypt},
+		{"flipped salt bit", edit(func(b []byte) []byte { b[headerSize-1] ^= 1; return b }), "secret", ErrDecrypt},
+		{"raised cost", edit(func(b []byte) []byte { b[6]++; return b }), "secret", ErrDecrypt},
+		{"truncated at chunk boundary", sealed[:headerSize+chunk], "secret", ErrDecrypt},
+		{"truncated mid chunk", sealed[:headerSize+chunk+100], "secret", ErrDecrypt},
+		{"final chunk dropped", sealed[:headerSize+2*chunk], "secret", ErrDecrypt},
+		{"chunks swapped", edit(func(b []byte) []byte {
+			c0 := bytes.Clone(b[headerSize : headerSize+chunk])
+			copy(b[headerSize:], b[headerSize+chunk:headerSize+2*chunk])
+			copy(b[headerSize+chunk:], c0)
+			return b
+		}), "secret", ErrDecrypt},
+		{"trailing garbage", append(bytes.Clone(sealed), 0), "secret", ErrDecrypt},
+		{"header only", sealed[:headerSize], "secret", ErrDecrypt},
+		{"plaintext", []byte("just some text that is long enough"), "secret", ErrNotEncrypted},
+		{"empty", nil, "secret", ErrNotEncrypted},
+		{"future version", edit(func(b []byte) []byte { b[4] = 2; return b }), "secret", errors.ErrUnsupported},
+		{"unknown kdf", edit(func(b []byte) []byte { b[5] = 9; return b }), "secret", errors.ErrUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &MemoryDataSource{}
+			if err := WriteAll(src, tt.stored); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadAll(NewEncryptionDecorator(src, tt.passphrase))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			// Only whole authenticated chunks may be returned.
+			if len(got)%chunkSize != 0 || !bytes.Equal(got, plain[:len(got)]) {
+				t.Errorf("returned %d bytes of unauthenticated data", len(got))
+			}
+		})
+	}
+}
+
+func TestEncryptionRejectsExpensiveHeader(t *testing.T) {
+	src := &MemoryDataSource{}
+	header := append([]byte(encryptionMagic), encryptionVersion, kdfScrypt, 30, 8, 1)
+	header = append(header, make([]byte, saltSize)...)
+	if err := WriteAll(src, header); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadAll(NewEncryptionDecorator(src, "secret")); err == nil || errors.Is(err, ErrDecrypt) {
+		t.Fatalf("error = %v, want a parameter range error", err)
+	}
+}
+
+func TestEncryptionUsesFreshSalt(t *testing.T) {
+	a, b := &MemoryDataSource{}, &MemoryDataSource{}
+	for _, mem := range []*MemoryDataSource{a, b} {
+		if err := WriteAll(NewEncryptionDecorator(mem, "secret", cheap), []byte("same input")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, _ := ReadAll(a)
+	y, _ := ReadAll(b)
+	if bytes.Equal(x, y) {
+		t.Error("two encryptions of the same input are identical")
+	}
+}
+
+func TestFailedWriteKeepsOldFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	file := NewFileDataSource(path)
+	if err := WriteAll(file, []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A layer that fails to finish its output must abort the file write
+	// beneath it, even under another decorator.
+	refusing := NewCompressionDecorator(&refusingSource{file}, GzipCodec)
+	if err := WriteAll(refusing, []byte("new")); err == nil {
+		t.Fatal("write succeeded")
+	}
+	got, err := os.ReadFile(path)
+	if err != nil || string(got) != "old" {
+		t.Fatalf("file = %q, %v; want the old contents", got, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+// refusingSource adds a layer to a source whose Close always fails.
+type refusingSource struct{ DataSource }
+
+type refusingWriter struct{ io.WriteCloser }
+
+func (r *refusingSource) NewWriter() (io.WriteCloser, error) {
+	w, err := r.DataSource.NewWriter()
+	if err != nil {
+		return nil, err
+	}
+	return &layerWriter{Writer: w, outer: refusingWriter{w}, inner: w}, nil
+}
+
+func (refusingWriter) Close() error { return errors.New("refused") }
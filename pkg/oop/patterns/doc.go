@@ -17,12 +17,19 @@
 //   - Builder: Fluent interfaces for complex object construction
 //
 // Structural:
-//   - Adapter: Making incompatible interfaces work together
-//   - Decorator: Adding behavior dynamically through composition
+//   - Adapter: Making incompatible interfaces work together, including a
+//     legacy payment SDK adapted to payment.Gateway
+//   - Decorator: Adding behavior dynamically through composition, including
+//     stackable encryption and compression layers over an io-based DataSource
 //
 // Behavioral:
 //   - Observer: Event-driven patterns using channels and interfaces
-//   - Strategy: Swappable algorithms via interfaces
+//   - Strategy: Swappable algorithms via interfaces, including real
+//     compression strategies chosen automatically from a registry and
+//     generic sorting strategies that report whether they are stable,
+//     payment strategies running on package payment, and a ShoppingCart
+//     priced by a discount rule engine, taxed per region and itemized
+//     on text or JSON receipts
 //
 // Each pattern includes:
 //   - Clear godoc comments explaining the "why"
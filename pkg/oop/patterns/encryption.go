@@ -0,0 +1,332 @@
+package patterns
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/KrystianMarek/golang-202/internal/scrypt"
+)
+
+// EncryptionDecorator encrypts data with AES-256-GCM on its way to the
+// wrapped source, using a key derived from a passphrase with scrypt.
+//
+// Why a versioned header and chunks? The header records the format
+// version, the KDF and its cost parameters, and a random salt, so data
+// written today can still be read after the defaults are raised. GCM
+// authenticates a message only once it has seen all of it; sealing the
+// stream in fixed-size chunks lets the reader hand out verified
+// plaintext as it goes. Each chunk's nonce carries its index and a
+// final-chunk flag, so reordering, dropping or truncating chunks fails
+// authentication instead of silently returning less data.
+//
+// Stream layout:
+//
+//	"GENC" | version | kdf | logN | r | p | salt[16]
+//	chunk 0 | chunk 1 | ... | final chunk
+//
+// Every chunk is up to 64 KiB of plaintext sealed with the header as
+// additional data; the final chunk may be empty.
+type EncryptionDecorator struct {
+	wrapped    DataSource
+	passphrase []byte
+	cfg        encryptionConfig
+}
+
+// Errors returned by EncryptionDecorator readers.
+var (
+	// ErrNotEncrypted means the data does not start with the header.
+	ErrNotEncrypted = errors.New("data is not encrypted")
+	// ErrDecrypt means the passphrase is wrong or the data was modified
+	// or truncated. The cases are indistinguishable by design.
+	ErrDecrypt = errors.New("decryption failed: wrong passphrase or corrupted data")
+)
+
+const (
+	encryptionMagic   = "GENC"
+	encryptionVersion = 1
+	kdfScrypt         = 1
+	saltSize          = 16
+	headerSize        = len(encryptionMagic) + 5 + saltSize
+	chunkSize         = 64 << 10
+)
+
+// Limits on the scrypt parameters a reader accepts from a header, so a
+// crafted header cannot make it allocate gigabytes.
+const (
+	maxLogN = 20
+	maxR    = 32
+	maxP    = 16
+)
+
+// EncryptionOption configures an EncryptionDecorator.
+type EncryptionOption func(*encryptionConfig)
+
+type encryptionConfig struct {
+	logN, r, p uint8
+}
+
+// WithScrypt sets the scrypt cost for new writes: N = 2^logN, block size
+// r and parallelization p. The default is logN 15, r 8, p 1, the RFC 7914
+// recommendation for interactive use. Readers take the parameters from
+// the header, so changing them never breaks existing data.
+func WithScrypt(logN, r, p uint8) EncryptionOption {
+	return func(c *encryptionConfig) {
+		c.logN, c.r, c.p = logN, r, p
+	}
+}
+
+// NewEncryptionDecorator creates an encryption decorator.
+func NewEncryptionDecorator(source DataSource, passphrase string, opts ...EncryptionOption) *EncryptionDecorator {
+	cfg := encryptionConfig{logN: 15, r: 8, p: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &EncryptionDecorator{wrapped: source, passphrase: []byte(passphrase), cfg: cfg}
+}
+
+// NewWriter writes the header with a fresh salt to a writer of the
+// wrapped source and encrypts into it.
+func (e *EncryptionDecorator) NewWriter() (io.WriteCloser, error) {
+	c := e.cfg
+	if err := checkScryptParams(c.logN, c.r, c.p); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, headerSize)
+	header = append(header, encryptionMagic...)
+	header = append(header, encryptionVersion, kdfScrypt, c.logN, c.r, c.p)
+	header = header[:headerSize]
+	if _, err := rand.Read(header[headerSize-saltSize:]); err != nil {
+		return nil, err
+	}
+	aead, err := e.newAEAD(header)
+	if err != nil {
+		return nil, err
+	}
+
+	inner, err := e.wrapped.NewWriter()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := inner.Write(header); err != nil {
+		return nil, errors.Join(err, abort(inner))
+	}
+	return &encryptWriter{
+		inner:  inner,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// NewReader reads and checks the header of a reader of the wrapped
+// source and decrypts the chunks that follow.
+func (e *EncryptionDecorator) NewReader() (io.ReadCloser, error) {
+	inner, err := e.wrapped.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.newDecryptReader(inner)
+	if err != nil {
+		return nil, errors.Join(err, inner.Close())
+	}
+	return r, nil
+}
+
+func (e *EncryptionDecorator) newDecryptReader(inner io.ReadCloser) (*decryptReader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(inner, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrNotEncrypted
+		}
+		return nil, err
+	}
+	if !bytes.HasPrefix(header, []byte(encryptionMagic)) {
+		return nil, ErrNotEncrypted
+	}
+	version, kdf := header[4], header[5]
+	if version != encryptionVersion {
+		return nil, fmt.Errorf("encryption format version %d: %w", version, errors.ErrUnsupported)
+	}
+	if kdf != kdfScrypt {
+		return nil, fmt.Errorf("key derivation function %d: %w", kdf, errors.ErrUnsupported)
+	}
+	if err := checkScryptParams(header[6], header[7], header[8]); err != nil {
+		return nil, err
+	}
+	aead, err := e.newAEAD(header)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{
+		inner:  inner,
+		src:    bufio.NewReaderSize(inner, chunkSize+aead.Overhead()),
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+// newAEAD derives the key from the passphrase and the KDF parameters
+// and salt in header.
+func (e *EncryptionDecorator) newAEAD(header []byte) (cipher.AEAD, error) {
+	logN, r, p := header[6], header[7], header[8]
+	key, err := scrypt.Key(e.passphrase, header[headerSize-saltSize:], 1<<logN, int(r), int(p), 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func checkScryptParams(logN, r, p uint8) error {
+	if logN < 1 || logN > maxLogN || r < 1 || r > maxR || p < 1 || p > maxP {
+		return fmt.Errorf("scrypt parameters logN=%d r=%d p=%d out of range", logN, r, p)
+	}
+	return nil
+}
+
+// chunkNonce returns the nonce for chunk i: a 64-bit big-endian counter
+// followed by a flag byte that is 1 only for the final chunk.
+func chunkNonce(i uint64, last bool) []byte {
+	var nonce [12]byte
+	binary.BigEndian.PutUint64(nonce[3:11], i)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce[:]
+}
+
+// encryptWriter seals plaintext a chunk at a time.
+type encryptWriter struct {
+	inner   io.WriteCloser
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte // plaintext of the chunk being filled
+	out     []byte
+	counter uint64
+	closed  bool
+	err     error
+}
+
+// Write buffers p. A full chunk is sealed only once more data arrives,
+// because until then it might be the final chunk.
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed encryption writer")
+	}
+	written := 0
+	for len(p) > 0 && w.err == nil {
+		if len(w.buf) == chunkSize {
+			w.err = w.seal(false)
+			continue
+		}
+		n := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, w.err
+}
+
+func (w *encryptWriter) seal(last bool) error {
+	w.out = w.aead.Seal(w.out[:0], chunkNonce(w.counter, last), w.buf, w.header)
+	w.counter++
+	w.buf = w.buf[:0]
+	_, err := w.inner.Write(w.out)
+	return err
+}
+
+// Close seals the final chunk and commits the wrapped writer.
+func (w *encryptWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err == nil {
+		w.err = w.seal(true)
+	}
+	if w.err != nil {
+		return errors.Join(w.err, abort(w.inner))
+	}
+	w.err = w.inner.Close()
+	return w.err
+}
+
+// Abort discards everything written.
+func (w *encryptWriter) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return abort(w.inner)
+}
+
+// decryptReader opens one chunk at a time and returns its plaintext.
+type decryptReader struct {
+	inner   io.ReadCloser
+	src     *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte
+	plain   []byte // opened plaintext not yet returned
+	counter uint64
+	done    bool
+	err     error
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.open()
+	}
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+// open reads and authenticates the next chunk. A chunk shorter than a
+// full one, or a full one at the end of the stream, is the final chunk.
+func (r *decryptReader) open() error {
+	n, err := io.ReadFull(r.src, r.buf)
+	switch {
+	case errors.Is(err, io.EOF):
+		// The stream ended without a final chunk.
+		return ErrDecrypt
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		r.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.src.Peek(1); errors.Is(err, io.EOF) {
+			r.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+	plain, err := r.aead.Open(r.buf[:0], chunkNonce(r.counter, r.done), r.buf[:n], r.header)
+	if err != nil {
+		return ErrDecrypt
+	}
+	r.counter++
+	r.plain = plain
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.inner.Close()
+}
@@ -155,7 +155,7 @@ func (b *ChannelEventBus) Publish(event Event) {
 	}
 }
 
-// Close closes all channels.
+// Close closes all channels. It is safe to call more than once.
 func (b *ChannelEventBus) Close() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -165,6 +165,7 @@ func (b *ChannelEventBus) Close() {
 			close(ch)
 		}
 	}
+	b.subscribers = make(map[string][]chan Event)
 }
 
 // ExampleObserver demonstrates the Observer pattern.
@@ -0,0 +1,129 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// RateSource supplies exchange rates. Implementations may call a rates
+// API, read a database or, like StaticRates, hold a fixed table.
+//
+// Why an interface? Where rates come from is a deployment decision, and
+// tests need rates that do not move.
+type RateSource interface {
+	// Rate returns how many units of to one unit of from buys, as an
+	// exact fraction. Callers must not modify the result.
+	Rate(ctx context.Context, from, to Currency) (*big.Rat, error)
+}
+
+// ErrNoRate is returned when a RateSource has no rate for a pair.
+var ErrNoRate = errors.New("no exchange rate")
+
+// StaticRates is a RateSource backed by a fixed table. It is safe for
+// concurrent use.
+type StaticRates struct {
+	mu    sync.RWMutex
+	rates map[[2]Currency]*big.Rat
+}
+
+// NewStaticRates returns an empty table.
+func NewStaticRates() *StaticRates {
+	return &StaticRates{rates: make(map[[2]Currency]*big.Rat)}
+}
+
+// Set records that one unit of from buys rate units of to, with rate a
+// decimal string such as "1.0842". The reverse rate is implied unless
+// set separately.
+func (s *StaticRates) Set(from, to Currency, rate string) error {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return fmt.Errorf("invalid rate %q for %s/%s", rate, from, to)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rates[[2]Currency{from, to}] = r
+	return nil
+}
+
+// Rate returns the rate from the table, the inverse of the reverse rate,
+// or 1 for the same currency.
+func (s *StaticRates) Rate(_ context.Context, from, to Currency) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.rates[[2]Currency{from, to}]; ok {
+		return r, nil
+	}
+	if r, ok := s.rates[[2]Currency{to, from}]; ok {
+		return new(big.Rat).Inv(r), nil
+	}
+	return nil, fmt.Errorf("%w for %s/%s", ErrNoRate, from, to)
+}
+
+// Converter converts Money between currencies.
+type Converter struct {
+	source RateSource
+}
+
+// NewConverter returns a converter using rates from source.
+func NewConverter(source RateSource) *Converter {
+	return &Converter{source: source}
+}
+
+// Convert returns m in currency to, rounded to the nearest minor unit
+// of to with HalfEven.
+func (c *Converter) Convert(ctx context.Context, m Money, to Currency) (Money, error) {
+	if m.currency == to {
+		return m, nil
+	}
+	rate, err := c.source.Rate(ctx, m.currency, to)
+	if err != nil {
+		return Money{}, err
+	}
+	// minor_to = minor_from * rate * 10^(exp_to - exp_from)
+	v := new(big.Rat).SetInt64(m.amount)
+	v.Mul(v, rate)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(to.Exponent()-m.currency.Exponent()))), nil)
+	if to.Exponent() >= m.currency.Exponent() {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+	n := round(v, HalfEven)
+	if !n.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: n.Int64(), currency: to}, nil
+}
+
+// round rounds v to an integer with mode.
+func round(v *big.Rat, mode Rounding) *big.Int {
+	q, r := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if r.Sign() == 0 || mode == Down {
+		return q
+	}
+	// Compare 2|r| with the denominator to see which way to round.
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	switch c := twice.Cmp(v.Denom()); {
+	case c > 0, c == 0 && (mode == HalfUp || q.Bit(0) == 1):
+		if v.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
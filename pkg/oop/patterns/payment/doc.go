@@ -0,0 +1,34 @@
+// Package payment is the payment-processing domain behind the Strategy
+// and Adapter examples in package patterns.
+//
+// This package covers:
+//   - Money: exact amounts in integer minor units with a currency,
+//     percentages with an explicit Rounding, and Allocate for splitting
+//     an amount without losing a cent
+//   - Currency conversion through a pluggable RateSource
+//   - Payment methods: cards with Luhn and expiry checks, PayPal
+//     accounts and crypto wallets
+//   - Gateway: the port a payment provider is adapted to
+//   - Processor: the authorize, capture, refund and void lifecycle,
+//     with idempotency keys so retries never charge twice
+//   - FakeGateway: an in-memory provider for tests
+//
+// Why integer minor units? 0.1 + 0.2 is not 0.3 in float64, and a
+// checkout that drifts by a cent per thousand orders will not reconcile
+// with the bank. Every amount here is a count of cents (or yen, or
+// satoshis), and every rounding step is explicit.
+//
+// Example usage:
+//
+//	import "github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+//
+//	func main() {
+//		p := payment.NewProcessor(payment.NewFakeGateway())
+//		card := payment.Card{Number: "4242 4242 4242 4242", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+//		auth, err := p.Authorize(ctx, "order-1-auth", card, payment.MustParse("19.99", payment.USD))
+//		if err != nil {
+//			return err
+//		}
+//		_, err = p.Capture(ctx, "order-1-capture", auth.ID, auth.Authorized)
+//	}
+package payment
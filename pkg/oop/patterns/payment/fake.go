@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeGateway is an in-memory Gateway for tests. It keeps the balance
+// of every authorization, enforces the same limits a real provider
+// would, and can be told to decline or fail. It is safe for concurrent
+// use.
+type FakeGateway struct {
+	mu       sync.Mutex
+	seq      int
+	auths    map[string]*fakeAuth
+	decline  func(AuthorizeRequest) bool
+	failures []error
+	calls    []string
+}
+
+type fakeAuth struct {
+	authorized, captured, refunded Money
+	voided                         bool
+}
+
+// NewFakeGateway returns a gateway that approves everything.
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{auths: make(map[string]*fakeAuth)}
+}
+
+// DeclineWhen makes Authorize decline requests for which decide returns
+// true.
+func (g *FakeGateway) DeclineWhen(decide func(AuthorizeRequest) bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.decline = decide
+}
+
+// FailNext makes the next call return err without doing anything, as a
+// timed-out or unreachable provider would. Calls queue up: FailNext
+// twice fails the next two calls.
+func (g *FakeGateway) FailNext(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failures = append(g.failures, err)
+}
+
+// Calls returns a log of the calls that reached the gateway, such as
+// "capture auth_1 19.99 USD".
+func (g *FakeGateway) Calls() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.calls...)
+}
+
+// begin logs a call and returns the queued failure, if any. g.mu must
+// be held.
+func (g *FakeGateway) begin(format string, args ...any) error {
+	g.calls = append(g.calls, fmt.Sprintf(format, args...))
+	if len(g.failures) > 0 {
+		err := g.failures[0]
+		g.failures = g.failures[1:]
+		return err
+	}
+	return nil
+}
+
+func (g *FakeGateway) auth(ref string) (*fakeAuth, error) {
+	a, ok := g.auths[ref]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown authorization %s", ErrDeclined, ref)
+	}
+	return a, nil
+}
+
+// Authorize implements Gateway.
+func (g *FakeGateway) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.begin("authorize %s %s", req.Method, req.Amount); err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if !req.Amount.IsPositive() {
+		return "", fmt.Errorf("%w: amount %s", ErrDeclined, req.Amount)
+	}
+	if g.decline != nil && g.decline(req) {
+		return "", fmt.Errorf("%w: by issuer", ErrDeclined)
+	}
+	g.seq++
+	ref := fmt.Sprintf("auth_%d", g.seq)
+	zero := Zero(req.Amount.Currency())
+	g.auths[ref] = &fakeAuth{authorized: req.Amount, captured: zero, refunded: zero}
+	return ref, nil
+}
+
+// Capture implements Gateway. A capture may be for less than the
+// authorized amount, but only one capture is allowed.
+func (g *FakeGateway) Capture(ctx context.Context, ref string, amount Money, _ string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.begin("capture %s %s", ref, amount); err != nil {
+		return err
+	}
+	a, err := g.auth(ref)
+	if err != nil {
+		return err
+	}
+	if a.voided || !a.captured.IsZero() {
+		return fmt.Errorf("%w: %s is not open for capture", ErrDeclined, ref)
+	}
+	if c, err := amount.Cmp(a.authorized); err != nil || c > 0 || !amount.IsPositive() {
+		return fmt.Errorf("%w: capture of %s against %s", ErrDeclined, amount, a.authorized)
+	}
+	a.captured = amount
+	return nil
+}
+
+// Refund implements Gateway. Refunds may be partial and repeated, up to
+// the captured amount.
+func (g *FakeGateway) Refund(ctx context.Context, ref string, amount Money, _ string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.begin("refund %s %s", ref, amount); err != nil {
+		return err
+	}
+	a, err := g.auth(ref)
+	if err != nil {
+		return err
+	}
+	total, err := a.refunded.Add(amount)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDeclined, err)
+	}
+	if c, _ := total.Cmp(a.captured); c > 0 || !amount.IsPositive() {
+		return fmt.Errorf("%w: refund of %s exceeds captured %s", ErrDeclined, total, a.captured)
+	}
+	a.refunded = total
+	return nil
+}
+
+// Void implements Gateway.
+func (g *FakeGateway) Void(ctx context.Context, ref string, _ string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.begin("void %s", ref); err != nil {
+		return err
+	}
+	a, err := g.auth(ref)
+	if err != nil {
+		return err
+	}
+	if !a.captured.IsZero() {
+		return fmt.Errorf("%w: %s is already captured", ErrDeclined, ref)
+	}
+	a.voided = true
+	return nil
+}
@@ -0,0 +1,44 @@
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// Gateway is the port to a payment provider. Each provider gets an
+// implementation, usually an adapter over the provider's SDK; see
+// patterns.PaymentAdapter for one. A Gateway only moves money; the
+// Processor decides which calls are allowed when.
+//
+// Every call carries the idempotency key of the operation, which
+// providers that support it use to drop duplicate requests that the
+// Processor cannot see, such as retries from a restarted process.
+type Gateway interface {
+	// Authorize reserves amount on the method and returns the provider's
+	// reference for the authorization.
+	Authorize(ctx context.Context, req AuthorizeRequest) (ref string, err error)
+	// Capture collects amount, at most the authorized amount, and
+	// releases the rest.
+	Capture(ctx context.Context, ref string, amount Money, idempotencyKey string) error
+	// Refund returns amount of the captured money.
+	Refund(ctx context.Context, ref string, amount Money, idempotencyKey string) error
+	// Void releases an authorization that has not been captured.
+	Void(ctx context.Context, ref string, idempotencyKey string) error
+}
+
+// AuthorizeRequest is a request to reserve money on a payment method.
+type AuthorizeRequest struct {
+	Method         Method
+	Amount         Money
+	IdempotencyKey string
+}
+
+// ErrDeclined is wrapped by gateway errors that are final answers, such
+// as insufficient funds. The Processor remembers them under the
+// idempotency key; any other gateway error is treated as transient and
+// may be retried with the same key.
+var ErrDeclined = errors.New("payment declined")
+
+// ErrGatewayUnavailable is a transient failure: the provider could not
+// be reached or did not answer in time.
+var ErrGatewayUnavailable = errors.New("payment gateway unavailable")
@@ -0,0 +1,184 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Method is something a payment is charged to.
+type Method interface {
+	// Kind names the type of method, such as "card".
+	Kind() string
+	// Validate checks the method can be charged at time now, before any
+	// request reaches a gateway.
+	Validate(now time.Time) error
+	// String describes the method without revealing it, so it is safe
+	// to log and to show on receipts.
+	String() string
+}
+
+// ErrInvalidMethod is wrapped by every Validate error.
+var ErrInvalidMethod = errors.New("invalid payment method")
+
+// Card is a payment card.
+type Card struct {
+	Number   string // digits, optionally grouped with spaces or dashes
+	ExpMonth int    // 1-12
+	ExpYear  int    // four digits
+	CVV      string
+}
+
+// Kind returns "card".
+func (c Card) Kind() string { return "card" }
+
+// digits returns the card number without separators.
+func (c Card) digits() string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(c.Number)
+}
+
+// Validate checks the number's length and Luhn checksum, the CVV and
+// that the card has not expired. A card is valid through the last day
+// of its expiry month.
+func (c Card) Validate(now time.Time) error {
+	number := c.digits()
+	if len(number) < 12 || len(number) > 19 || !Luhn(number) {
+		return fmt.Errorf("%w: card number fails the Luhn check", ErrInvalidMethod)
+	}
+	cvvLen := 3
+	if c.Brand() == "American Express" {
+		cvvLen = 4
+	}
+	if len(c.CVV) != cvvLen || strings.Trim(c.CVV, "0123456789") != "" {
+		return fmt.Errorf("%w: CVV must be %d digits", ErrInvalidMethod, cvvLen)
+	}
+	if c.ExpMonth < 1 || c.ExpMonth > 12 {
+		return fmt.Errorf("%w: expiry month %d", ErrInvalidMethod, c.ExpMonth)
+	}
+	firstInvalid := time.Date(c.ExpYear, time.Month(c.ExpMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+	if !now.Before(firstInvalid) {
+		return fmt.Errorf("%w: card expired %02d/%d", ErrInvalidMethod, c.ExpMonth, c.ExpYear)
+	}
+	return nil
+}
+
+// Brand identifies the card network from the number's prefix.
+func (c Card) Brand() string {
+	n := c.digits()
+	prefix := func(length int) int {
+		if len(n) < length {
+			return -1
+		}
+		v := 0
+		for _, d := range n[:length] {
+			v = v*10 + int(d-'0')
+		}
+		return v
+	}
+	switch p2, p4 := prefix(2), prefix(4); {
+	case strings.HasPrefix(n, "4"):
+		return "Visa"
+	case p2 >= 51 && p2 <= 55, p4 >= 2221 && p4 <= 2720:
+		return "Mastercard"
+	case p2 == 34, p2 == 37:
+		return "American Express"
+	case p4 == 6011, p2 == 65:
+		return "Discover"
+	}
+	return "Card"
+}
+
+// Last4 returns the last four digits of the number.
+func (c Card) Last4() string {
+	n := c.digits()
+	return n[max(len(n)-4, 0):]
+}
+
+// String returns the brand and last four digits: "Visa ****4242".
+func (c Card) String() string {
+	return c.Brand() + " ****" + c.Last4()
+}
+
+// Luhn reports whether number, a string of decimal digits, passes the
+// Luhn (mod 10) checksum that every payment card number carries. It
+// catches any single mistyped digit and most swaps of adjacent digits.
+func Luhn(number string) bool {
+	if number == "" {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// PayPalAccount is a PayPal account identified by its email address.
+type PayPalAccount struct {
+	Email string
+}
+
+// Kind returns "paypal".
+func (p PayPalAccount) Kind() string { return "paypal" }
+
+// Validate checks that Email is a bare email address.
+func (p PayPalAccount) Validate(time.Time) error {
+	addr, err := mail.ParseAddress(p.Email)
+	if err != nil || addr.Address != p.Email {
+		return fmt.Errorf("%w: %q is not an email address", ErrInvalidMethod, p.Email)
+	}
+	return nil
+}
+
+// String returns the address with most of the local part hidden.
+func (p PayPalAccount) String() string {
+	local, domain, _ := strings.Cut(p.Email, "@")
+	if len(local) > 1 {
+		local = local[:1] + "***"
+	}
+	return "PayPal " + local + "@" + domain
+}
+
+// CryptoWallet is a cryptocurrency wallet address.
+type CryptoWallet struct {
+	Address string
+}
+
+// Kind returns "crypto".
+func (w CryptoWallet) Kind() string { return "crypto" }
+
+// Validate checks the address has a plausible length and alphabet. It
+// does not verify the address's own checksum, which differs by chain.
+func (w CryptoWallet) Validate(time.Time) error {
+	if len(w.Address) < 26 || len(w.Address) > 90 {
+		return fmt.Errorf("%w: wallet address length %d", ErrInvalidMethod, len(w.Address))
+	}
+	for _, r := range w.Address {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return fmt.Errorf("%w: wallet address contains %q", ErrInvalidMethod, r)
+		}
+	}
+	return nil
+}
+
+// String returns the start and end of the address.
+func (w CryptoWallet) String() string {
+	if len(w.Address) <= 12 {
+		return "Wallet " + w.Address
+	}
+	return "Wallet " + w.Address[:6] + "..." + w.Address[len(w.Address)-4:]
+}
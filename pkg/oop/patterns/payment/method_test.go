@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLuhn(t *testing.T) {
+	tests := map[string]bool{
+		"4242424242424242": true,
+		"4111111111111111": true,
+		"5555555555554444": true,
+		"378282246310005":  true,
+		"79927398713":      true,
+		"0":                true,
+		"4242424242424241": false, // last digit changed
+		"4242424242424224": false, // adjacent digits swapped
+		"1234567890123456": false,
+		"4242 4242":        false, // callers strip separators
+		"":                 false,
+	}
+	for number, want := range tests {
+		if got := Luhn(number); got != want {
+			t.Errorf("Luhn(%q) = %v, want %v", number, got, want)
+		}
+	}
+}
+
+func TestCardValidate(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC)
+	valid := Card{Number: "4242-4242-4242-4242", ExpMonth: 6, ExpYear: 2025, CVV: "123"}
+	if err := valid.Validate(now); err != nil {
+		t.Fatalf("valid card: %v", err)
+	}
+	amex := Card{Number: "3782 822463 10005", ExpMonth: 1, ExpYear: 2030, CVV: "1234"}
+	if err := amex.Validate(now); err != nil {
+		t.Fatalf("valid Amex: %v", err)
+	}
+
+	tests := map[string]Card{
+		"luhn":      {Number: "4242424242424241", ExpMonth: 1, ExpYear: 2030, CVV: "123"},
+		"short":     {Number: "42424", ExpMonth: 1, ExpYear: 2030, CVV: "123"},
+		"letters":   {Number: "4242abcd42424242", ExpMonth: 1, ExpYear: 2030, CVV: "123"},
+		"cvv":       {Number: "4242424242424242", ExpMonth: 1, ExpYear: 2030, CVV: "12"},
+		"cvv digit": {Number: "4242424242424242", ExpMonth: 1, ExpYear: 2030, CVV: "12a"},
+		"amex cvv":  {Number: "378282246310005", ExpMonth: 1, ExpYear: 2030, CVV: "123"},
+		"month":     {Number: "4242424242424242", ExpMonth: 13, ExpYear: 2030, CVV: "123"},
+		"expired":   {Number: "4242424242424242", ExpMonth: 5, ExpYear: 2025, CVV: "123"},
+	}
+	for name, card := range tests {
+		if err := card.Validate(now); !errors.Is(err, ErrInvalidMethod) {
+			t.Errorf("%s: Validate error = %v, want ErrInvalidMethod", name, err)
+		}
+	}
+
+	// A card is valid through the last instant of its expiry month.
+	if err := valid.Validate(time.Date(2025, time.June, 30, 23, 59, 59, 0, time.UTC)); err != nil {
+		t.Errorf("on the last day of the month: %v", err)
+	}
+	if err := valid.Validate(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("the day after expiry: no error")
+	}
+	// December rolls over into the next year.
+	dec := Card{Number: "4242424242424242", ExpMonth: 12, ExpYear: 2025, CVV: "123"}
+	if err := dec.Validate(time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Errorf("December expiry: %v", err)
+	}
+}
+
+func TestCardBrandAndString(t *testing.T) {
+	tests := []struct {
+		number, brand, str string
+	}{
+		{"4242424242424242", "Visa", "Visa ****4242"},
+		{"5555 5555 5555 4444", "Mastercard", "Mastercard ****4444"},
+		{"2223003122003222", "Mastercard", "Mastercard ****3222"},
+		{"378282246310005", "American Express", "American Express ****0005"},
+		{"6011111111111117", "Discover", "Discover ****1117"},
+		{"3530111333300000", "Card", "Card ****0000"},
+		{"12", "Card", "Card ****12"},
+	}
+	for _, tt := range tests {
+		c := Card{Number: tt.number}
+		if c.Brand() != tt.brand || c.String() != tt.str {
+			t.Errorf("%s: Brand() = %q, String() = %q; want %q, %q", tt.number, c.Brand(), c.String(), tt.brand, tt.str)
+		}
+	}
+}
+
+func TestOtherMethods(t *testing.T) {
+	var now time.Time
+	tests := []struct {
+		method Method
+		valid  bool
+		str    string
+	}{
+		{PayPalAccount{Email: "user@example.com"}, true, "PayPal u***@example.com"},
+		{PayPalAccount{Email: "User <user@example.com>"}, false, ""},
+		{PayPalAccount{Email: "not-an-email"}, false, ""},
+		{CryptoWallet{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}, true, "Wallet bc1qar...5mdq"},
+		{CryptoWallet{Address: "0x71C7656EC7ab88b098defB751B7401B5f6d8976F"}, true, "Wallet 0x71C7...976F"},
+		{CryptoWallet{Address: "tooshort"}, false, ""},
+		{CryptoWallet{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5md!"}, false, ""},
+	}
+	for _, tt := range tests {
+		err := tt.method.Validate(now)
+		if (err == nil) != tt.valid || (err != nil && !errors.Is(err, ErrInvalidMethod)) {
+			t.Errorf("%#v: Validate error = %v, want valid = %v", tt.method, err, tt.valid)
+		}
+		if tt.valid && tt.method.String() != tt.str {
+			t.Errorf("%#v: String() = %q, want %q", tt.method, tt.method.String(), tt.str)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code, or a common crypto ticker.
+type Currency string
+
+// Currencies used in examples and tests. Any other code works too.
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	PLN Currency = "PLN"
+	JPY Currency = "JPY"
+	KWD Currency = "KWD"
+	BTC Currency = "BTC"
+)
+
+// exponents lists currencies whose minor unit is not a hundredth.
+var exponents = map[Currency]int{
+	JPY: 0, "KRW": 0, "CLP": 0, "ISK": 0, "VND": 0,
+	KWD: 3, "BHD": 3, "JOD": 3, "OMR": 3, "TND": 3,
+	BTC: 8,
+}
+
+// Exponent returns the number of decimal places of the currency's minor
+// unit: 2 for USD (cents), 0 for JPY, 3 for KWD, 8 for BTC (satoshis).
+// Currencies not listed have 2, like most of ISO 4217.
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+// Errors returned by Money arithmetic and parsing.
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount overflows int64 minor units")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Money is an exact amount of a currency, counted in minor units.
+// The zero value is zero of no currency; it adds to any currency.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New returns minor units of currency: New(1999, USD) is 19.99 USD.
+func New(minor int64, currency Currency) Money {
+	return Money{amount: minor, currency: currency}
+}
+
+// Zero returns zero of currency.
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse parses a decimal amount such as "19.99" or "-5" in currency. It
+// rejects more decimal places than the currency has, rather than round.
+func Parse(s string, currency Currency) (Money, error) {
+	invalid := fmt.Errorf("%w: %q in %s", ErrInvalidAmount, s, currency)
+	neg := strings.HasPrefix(s, "-")
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	exp := currency.Exponent()
+	if whole == "" || len(frac) > exp || strings.HasPrefix(whole, "+") {
+		return Money{}, invalid
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, invalid
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if neg {
+		n = -n
+	}
+	return Money{amount: n, currency: currency}, nil
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants.
+// It panics on error.
+func MustParse(s string, currency Currency) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 { return m.amount }
+
+// Currency returns the currency.
+func (m Money) Currency() Currency { return m.currency }
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.amount == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.amount < 0 }
+
+// IsPositive reports whether the amount is above zero.
+func (m Money) IsPositive() bool { return m.amount > 0 }
+
+// unify returns the common currency of m and o. A zero of no currency
+// takes on the other's currency, so sums can start from Money{}.
+func (m Money) unify(o Money) (Currency, error) {
+	switch {
+	case m.currency == o.currency:
+		return m.currency, nil
+	case m.currency == "" && m.amount == 0:
+		return o.currency, nil
+	case o.currency == "" && o.amount == 0:
+		return m.currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency, o.currency)
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	c, err := m.unify(o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + o.amount
+	if (m.amount > 0 && o.amount > 0 && sum < 0) || (m.amount < 0 && o.amount < 0 && sum >= 0) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: c}, nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(o.Neg())
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+// Mul returns m times n, such as a unit price times a quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.amount == 0 || n == 0 {
+		return Money{currency: m.currency}, nil
+	}
+	p := m.amount * n
+	if p/n != m.amount || (m.amount == -1 && n == math.MinInt64) || (n == -1 && m.amount == math.MinInt64) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: p, currency: m.currency}, nil
+}
+
+// Rounding selects how a fraction of a minor unit is rounded.
+type Rounding int
+
+// Rounding modes.
+const (
+	// HalfEven rounds to the nearest minor unit, ties to even
+	// ("banker's rounding"), so rounding errors do not drift in one
+	// direction over many operations.
+	HalfEven Rounding = iota
+	// HalfUp rounds to the nearest minor unit, ties away from zero, as
+	// most tax authorities require.
+	HalfUp
+	// Down rounds toward zero, never giving away a fraction of a unit.
+	Down
+)
+
+// MulRat returns m times r, rounded to a whole minor unit with mode. It
+// is how percentages are applied: a 10% discount on 19.99 USD is
+// m.MulRat(big.NewRat(10, 100), HalfEven), which is 2.00 USD.
+func (m Money) MulRat(r *big.Rat, mode Rounding) (Money, error) {
+	v := new(big.Rat).SetInt64(m.amount)
+	n := round(v.Mul(v, r), mode)
+	if !n.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: n.Int64(), currency: m.currency}, nil
+}
+
+// Allocate splits m into parts proportional to weights, such as an
+// order discount spread over its lines. Unlike rounding each share on
+// its own, the parts always add up to m exactly: the minor units left
+// after rounding down go one each to the parts with the largest
+// remainders, earlier parts first on ties.
+func (m Money) Allocate(weights ...int64) ([]Money, error) {
+	total := new(big.Int)
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("%w: negative weight %d", ErrInvalidAmount, w)
+		}
+		total.Add(total, big.NewInt(w))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("%w: weights add up to zero", ErrInvalidAmount)
+	}
+	amount := new(big.Int).SetUint64(absUint(m.amount))
+	parts := make([]Money, len(weights))
+	rems := make([]*big.Int, len(weights))
+	left := new(big.Int).Set(amount)
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(w)), total, new(big.Int))
+		parts[i] = Money{amount: q.Int64(), currency: m.currency}
+		rems[i] = r
+		left.Sub(left, q)
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return rems[b].Cmp(rems[a]) })
+	for _, i := range order[:left.Int64()] {
+		parts[i].amount++
+	}
+	if m.amount < 0 {
+		for i := range parts {
+			parts[i].amount = -parts[i].amount
+		}
+	}
+	return parts, nil
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.unify(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Decimal formats the amount without the currency: "19.99", "-0.05",
+// "1500" for JPY.
+func (m Money) Decimal() string {
+	exp := m.currency.Exponent()
+	abs := strconv.FormatUint(absUint(m.amount), 10)
+	sign := ""
+	if m.amount < 0 {
+		sign = "-"
+	}
+	if exp == 0 {
+		return sign + abs
+	}
+	if len(abs) <= exp {
+		abs = strings.Repeat("0", exp-len(abs)+1) + abs
+	}
+	return sign + abs[:len(abs)-exp] + "." + abs[len(abs)-exp:]
+}
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// String formats the amount with its currency: "19.99 USD".
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + string(m.currency)
+}
+
+// moneyJSON is the wire form of Money. The amount is a decimal string
+// so that no JSON reader turns it into a float.
+type moneyJSON struct {
+	Amount   string   `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON encodes m as {"amount":"19.99","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.currency})
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := Parse(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,277 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseAndFormat(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency Currency
+		minor    int64
+		out      string
+	}{
+		{"19.99", USD, 1999, "19.99 USD"},
+		{"19.9", USD, 1990, "19.90 USD"},
+		{"-0.05", EUR, -5, "-0.05 EUR"},
+		{"7", GBP, 700, "7.00 GBP"},
+		{"1500", JPY, 1500, "1500 JPY"},
+		{"1.234", KWD, 1234, "1.234 KWD"},
+		{"0.00000001", BTC, 1, "0.00000001 BTC"},
+		{"0", PLN, 0, "0.00 PLN"},
+	}
+	for _, tt := range tests {
+		m, err := Parse(tt.in, tt.currency)
+		if err != nil {
+			t.Fatalf("Parse(%q, %s): %v", tt.in, tt.currency, err)
+		}
+		if m.Minor() != tt.minor || m.Currency() != tt.currency {
+			t.Errorf("Parse(%q, %s) = %d %s, want %d", tt.in, tt.currency, m.Minor(), m.Currency(), tt.minor)
+		}
+		if got := m.String(); got != tt.out {
+			t.Errorf("String() = %q, want %q", got, tt.out)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency Currency
+		want     error
+	}{
+		{"19.999", USD, ErrInvalidAmount}, // would need rounding
+		{"1.5", JPY, ErrInvalidAmount},
+		{"", USD, ErrInvalidAmount},
+		{".5", USD, ErrInvalidAmount},
+		{"+1", USD, ErrInvalidAmount},
+		{"1e3", USD, ErrInvalidAmount},
+		{"1,000.00", USD, ErrInvalidAmount},
+		{"99999999999999999999", USD, ErrOverflow},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.in, tt.currency); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q, %s) error = %v, want %v", tt.in, tt.currency, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a, b := MustParse("10.10", USD), MustParse("0.20", USD)
+	if sum, err := a.Add(b); err != nil || sum != MustParse("10.30", USD) {
+		t.Errorf("Add = %v, %v", sum, err)
+	}
+	if diff, err := b.Sub(a); err != nil || diff != MustParse("-9.90", USD) {
+		t.Errorf("Sub = %v, %v", diff, err)
+	}
+	if prod, err := b.Mul(3); err != nil || prod != MustParse("0.60", USD) {
+		t.Errorf("Mul = %v, %v", prod, err)
+	}
+	if c, err := a.Cmp(b); err != nil || c != 1 {
+		t.Errorf("Cmp = %d, %v", c, err)
+	}
+
+	// The zero value takes on the other operand's currency.
+	if sum, err := (Money{}).Add(a); err != nil || sum != a {
+		t.Errorf("Money{}.Add = %v, %v", sum, err)
+	}
+
+	eur := MustParse("1", EUR)
+	if _, err := a.Add(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + EUR error = %v", err)
+	}
+	if _, err := a.Cmp(eur); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp(USD, EUR) error = %v", err)
+	}
+	// A zero in another currency is still another currency.
+	if _, err := a.Add(Zero(EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("USD + 0 EUR error = %v", err)
+	}
+}
+
+func TestMoneyOverflow(t *testing.T) {
+	maxUSD, minUSD := New(math.MaxInt64, USD), New(math.MinInt64, USD)
+	one := New(1, USD)
+	checks := map[string]func() (Money, error){
+		"max+1":   func() (Money, error) { return maxUSD.Add(one) },
+		"min-1":   func() (Money, error) { return minUSD.Sub(one) },
+		"1-min":   func() (Money, error) { return one.Sub(minUSD) },
+		"max*2":   func() (Money, error) { return maxUSD.Mul(2) },
+		"min*-1":  func() (Money, error) { return minUSD.Mul(-1) },
+		"-1*min":  func() (Money, error) { return New(-1, USD).Mul(math.MinInt64) },
+		"min+min": func() (Money, error) { return minUSD.Add(minUSD) },
+	}
+	for name, f := range checks {
+		if m, err := f(); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s = %v, %v; want ErrOverflow", name, m, err)
+		}
+	}
+	if m, err := maxUSD.Add(New(-1, USD)); err != nil || m.Minor() != math.MaxInt64-1 {
+		t.Errorf("max-1 = %v, %v", m, err)
+	}
+	if got := minUSD.Decimal(); got != "-92233720368547758.08" {
+		t.Errorf("min Decimal() = %q", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	in := MustParse("1234.5", USD)
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"amount":"1234.50","currency":"USD"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+	var out Money
+	if err := json.Unmarshal(data, &out); err != nil || out != in {
+		t.Errorf("Unmarshal = %v, %v", out, err)
+	}
+	if err := json.Unmarshal([]byte(`{"amount":"1.005","currency":"USD"}`), &out); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Unmarshal of sub-cent amount error = %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rates := NewStaticRates()
+	for _, r := range []struct {
+		from, to Currency
+		rate     string
+	}{
+		{EUR, USD, "1.0842"},
+		{USD, JPY, "157.5"},
+		{BTC, USD, "65000"},
+		{USD, PLN, "0.5"}, // not a real rate: makes half-cent ties
+	} {
+		if err := rates.Set(r.from, r.to, r.rate); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conv := NewConverter(rates)
+	tests := []struct {
+		in   Money
+		to   Currency
+		want string
+	}{
+		{MustParse("100", EUR), USD, "108.42 USD"},
+		{MustParse("59.97", USD), EUR, "55.31 EUR"}, // inverse of EUR/USD
+		{MustParse("10.01", USD), JPY, "1577 JPY"},  // 1576.575 rounds up
+		{MustParse("1000", JPY), USD, "6.35 USD"},   // 6.3492...
+		{MustParse("349", USD), BTC, "0.00536923 BTC"},
+		{MustParse("0.00000001", BTC), USD, "0.00 USD"},
+		{MustParse("0.01", USD), PLN, "0.00 PLN"}, // 0.005: ties go to even
+		{MustParse("0.03", USD), PLN, "0.02 PLN"}, // 0.015
+		{MustParse("0.05", USD), PLN, "0.02 PLN"}, // 0.025
+		{MustParse("-0.03", USD), PLN, "-0.02 PLN"},
+		{MustParse("0.5", USD), USD, "0.50 USD"}, // same currency
+		{MustParse("-100", EUR), USD, "-108.42 USD"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		got, err := conv.Convert(ctx, tt.in, tt.to)
+		if err != nil {
+			t.Fatalf("Convert(%s, %s): %v", tt.in, tt.to, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("Convert(%s, %s) = %s, want %s", tt.in, tt.to, got, tt.want)
+		}
+	}
+	if _, err := conv.Convert(ctx, MustParse("1", KWD), USD); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Convert without a rate error = %v", err)
+	}
+	if err := rates.Set(USD, EUR, "-1"); err == nil {
+		t.Error("Set accepted a negative rate")
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, den               int64
+		halfEven, halfUp, down int64
+	}{
+		{5, 2, 2, 3, 2},
+		{7, 2, 4, 4, 3},
+		{-5, 2, -2, -3, -2},
+		{-7, 2, -4, -4, -3},
+		{26, 10, 3, 3, 2},
+		{24, 10, 2, 2, 2},
+		{-26, 10, -3, -3, -2},
+		{3, 1, 3, 3, 3},
+		{0, 1, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		for mode, want := range map[Rounding]int64{HalfEven: tt.halfEven, HalfUp: tt.halfUp, Down: tt.down} {
+			if got := round(big.NewRat(tt.num, tt.den), mode); got.Int64() != want {
+				t.Errorf("round(%d/%d, %d) = %v, want %d", tt.num, tt.den, mode, got, want)
+			}
+		}
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	tests := []struct {
+		in   string
+		rate *big.Rat
+		mode Rounding
+		want string
+	}{
+		{"19.99", big.NewRat(10, 100), HalfEven, "2.00"},
+		{"0.25", big.NewRat(1, 10), HalfEven, "0.02"}, // 2.5 cents
+		{"0.25", big.NewRat(1, 10), HalfUp, "0.03"},
+		{"0.29", big.NewRat(1, 10), Down, "0.02"},
+		{"-0.25", big.NewRat(1, 10), HalfUp, "-0.03"},
+		{"100.00", big.NewRat(8875, 100000), HalfUp, "8.88"},   // 8.875
+		{"100.00", big.NewRat(8865, 100000), HalfEven, "8.86"}, // 8.865
+		{"100.00", big.NewRat(8865, 100000), HalfUp, "8.87"},
+	}
+	for _, tt := range tests {
+		got, err := MustParse(tt.in, USD).MulRat(tt.rate, tt.mode)
+		if err != nil || got.Decimal() != tt.want {
+			t.Errorf("%s x %s (mode %d) = %v, %v; want %s", tt.in, tt.rate, tt.mode, got, err, tt.want)
+		}
+	}
+	if _, err := New(math.MaxInt64, USD).MulRat(big.NewRat(2, 1), HalfEven); !errors.Is(err, ErrOverflow) {
+		t.Errorf("overflow error = %v", err)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		amount  string
+		weights []int64
+		want    []string
+	}{
+		{"100.00", []int64{1, 1, 1}, []string{"33.34", "33.33", "33.33"}},
+		{"0.05", []int64{3, 7}, []string{"0.02", "0.03"}}, // 1.5 and 3.5 cents: the tie goes to the first
+		{"10.00", []int64{1999, 2999, 0}, []string{"4.00", "6.00", "0.00"}},
+		{"-1.00", []int64{1, 2}, []string{"-0.33", "-0.67"}},
+		{"0.00", []int64{5}, []string{"0.00"}},
+	}
+	for _, tt := range tests {
+		parts, err := MustParse(tt.amount, USD).Allocate(tt.weights...)
+		if err != nil {
+			t.Fatalf("Allocate(%s, %v): %v", tt.amount, tt.weights, err)
+		}
+		sum := Zero(USD)
+		got := make([]string, len(parts))
+		for i, p := range parts {
+			got[i] = p.Decimal()
+			sum, _ = sum.Add(p)
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") || sum != MustParse(tt.amount, USD) {
+			t.Errorf("Allocate(%s, %v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+		}
+	}
+	if _, err := MustParse("1", USD).Allocate(0, 0); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("zero weights error = %v", err)
+	}
+	if _, err := MustParse("1", USD).Allocate(1, -1); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("negative weight error = %v", err)
+	}
+}
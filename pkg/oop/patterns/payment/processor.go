@@ -0,0 +1,378 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+// Status is the lifecycle state of a payment.
+//
+//	authorized ──capture──▶ captured ──refund──▶ partially refunded ──refund──▶ refunded
+//	     │                      └───────────────refund (in full)───────────────────▲
+//	     └──void──▶ voided
+type Status int
+
+// Payment states.
+const (
+	StatusAuthorized Status = iota + 1
+	StatusCaptured
+	StatusPartiallyRefunded
+	StatusRefunded
+	StatusVoided
+)
+
+var statusNames = map[Status]string{
+	StatusAuthorized:        "authorized",
+	StatusCaptured:          "captured",
+	StatusPartiallyRefunded: "partially refunded",
+	StatusRefunded:          "refunded",
+	StatusVoided:            "voided",
+}
+
+// String returns the state's name, such as "captured".
+func (s Status) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// MarshalText encodes the state as its name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state's name.
+func (s *Status) UnmarshalText(text []byte) error {
+	for status, name := range statusNames {
+		if name == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown payment status %q", text)
+}
+
+// Payment is a snapshot of one payment.
+type Payment struct {
+	ID         string `json:"id"`
+	Method     string `json:"method"` // Method.String(), safe to display
+	Authorized Money  `json:"authorized"`
+	Captured   Money  `json:"captured"`
+	Refunded   Money  `json:"refunded"`
+	Status     Status `json:"status"`
+}
+
+// Errors returned by Processor.
+var (
+	ErrMissingIdempotencyKey = errors.New("idempotency key required")
+	ErrIdempotencyConflict   = errors.New("idempotency key reused for a different request")
+	ErrUnknownPayment        = errors.New("unknown payment")
+	ErrInvalidTransition     = errors.New("operation not allowed in the payment's state")
+)
+
+// ProcessorOption configures a Processor.
+type ProcessorOption func(*Processor)
+
+// WithClock sets the clock used to check card expiry.
+func WithClock(clock idioms.Clock) ProcessorOption {
+	return func(p *Processor) { p.clock = clock }
+}
+
+// Processor runs payments through their lifecycle on a Gateway. It is
+// safe for concurrent use.
+//
+// Every operation takes an idempotency key, chosen by the caller and
+// unique per intended operation, such as "order-42-capture". Repeating
+// a call with the same key and arguments returns the first call's
+// result instead of moving money again, so a client that timed out can
+// simply retry. Successes and declines are remembered; transient
+// gateway errors are not, so a retry after one reaches the gateway.
+// Keys are remembered for the life of the Processor; a service would
+// keep them in its database with an expiry.
+type Processor struct {
+	gateway Gateway
+	clock   idioms.Clock
+
+	mu       sync.Mutex
+	payments map[string]*paymentRecord
+	keys     map[string]*keyEntry
+}
+
+// paymentRecord serializes operations on one payment, so a capture and
+// a void cannot both pass the state check.
+type paymentRecord struct {
+	mu      sync.Mutex
+	payment Payment
+}
+
+// keyEntry is the outcome of the operation run under a key. done is
+// closed once result and err are set.
+//
+// Why a hash of the request? The Authorize request includes the card
+// number and CVV, which must not be kept after the call; the hash still
+// tells a replay from a different request under the same key.
+type keyEntry struct {
+	fingerprint [sha256.Size]byte
+	done        chan struct{}
+	result      Payment
+	err         error
+}
+
+// NewProcessor returns a processor using gateway.
+func NewProcessor(gateway Gateway, opts ...ProcessorOption) *Processor {
+	p := &Processor{
+		gateway:  gateway,
+		clock:    idioms.SystemClock(),
+		payments: make(map[string]*paymentRecord),
+		keys:     make(map[string]*keyEntry),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Authorize validates method and reserves amount on it.
+func (p *Processor) Authorize(ctx context.Context, key string, method Method, amount Money) (Payment, error) {
+	request := fmt.Sprintf("authorize|%#v|%s", method, amount)
+	return p.idempotent(ctx, key, request, func() (Payment, error) {
+		if err := method.Validate(p.clock.Now()); err != nil {
+			return Payment{}, err
+		}
+		if !amount.IsPositive() || amount.Currency() == "" {
+			return Payment{}, fmt.Errorf("%w: cannot authorize %s", ErrInvalidAmount, amount)
+		}
+		ref, err := p.gateway.Authorize(ctx, AuthorizeRequest{Method: method, Amount: amount, IdempotencyKey: key})
+		if err != nil {
+			return Payment{}, err
+		}
+		zero := Zero(amount.Currency())
+		rec := &paymentRecord{payment: Payment{
+			ID:         ref,
+			Method:     method.String(),
+			Authorized: amount,
+			Captured:   zero,
+			Refunded:   zero,
+			Status:     StatusAuthorized,
+		}}
+		p.mu.Lock()
+		p.payments[ref] = rec
+		p.mu.Unlock()
+		return rec.payment, nil
+	})
+}
+
+// Capture collects amount of an authorized payment. Capturing less than
+// was authorized releases the rest.
+func (p *Processor) Capture(ctx context.Context, key, id string, amount Money) (Payment, error) {
+	request := fmt.Sprintf("capture|%s|%s", id, amount)
+	return p.idempotent(ctx, key, request, func() (Payment, error) {
+		return p.update(id, func(pay *Payment) error {
+			if pay.Status != StatusAuthorized {
+				return fmt.Errorf("%w: capture of a %s payment", ErrInvalidTransition, pay.Status)
+			}
+			if c, err := amount.Cmp(pay.Authorized); err != nil || c > 0 || !amount.IsPositive() {
+				return fmt.Errorf("%w: capture of %s against %s authorized", ErrInvalidAmount, amount, pay.Authorized)
+			}
+			if err := p.gateway.Capture(ctx, id, amount, key); err != nil {
+				return err
+			}
+			pay.Captured, pay.Status = amount, StatusCaptured
+			return nil
+		})
+	})
+}
+
+// Refund returns amount of a captured payment. Refunds may be partial
+// and repeated until the captured amount is used up.
+func (p *Processor) Refund(ctx context.Context, key, id string, amount Money) (Payment, error) {
+	request := fmt.Sprintf("refund|%s|%s", id, amount)
+	return p.idempotent(ctx, key, request, func() (Payment, error) {
+		return p.update(id, func(pay *Payment) error {
+			if pay.Status != StatusCaptured && pay.Status != StatusPartiallyRefunded {
+				return fmt.Errorf("%w: refund of a %s payment", ErrInvalidTransition, pay.Status)
+			}
+			total, err := pay.Refunded.Add(amount)
+			if err != nil {
+				return err
+			}
+			c, _ := total.Cmp(pay.Captured)
+			if c > 0 || !amount.IsPositive() {
+				return fmt.Errorf("%w: refund of %s with %s already refunded of %s captured",
+					ErrInvalidAmount, amount, pay.Refunded, pay.Captured)
+			}
+			if err := p.gateway.Refund(ctx, id, amount, key); err != nil {
+				return err
+			}
+			pay.Refunded, pay.Status = total, StatusPartiallyRefunded
+			if c == 0 {
+				pay.Status = StatusRefunded
+			}
+			return nil
+		})
+	})
+}
+
+// Void cancels an authorized payment before capture.
+func (p *Processor) Void(ctx context.Context, key, id string) (Payment, error) {
+	return p.idempotent(ctx, key, "void|"+id, func() (Payment, error) {
+		return p.update(id, func(pay *Payment) error {
+			if pay.Status != StatusAuthorized {
+				return fmt.Errorf("%w: void of a %s payment", ErrInvalidTransition, pay.Status)
+			}
+			if err := p.gateway.Void(ctx, id, key); err != nil {
+				return err
+			}
+			pay.Status = StatusVoided
+			return nil
+		})
+	})
+}
+
+// Get returns the current state of a payment.
+func (p *Processor) Get(id string) (Payment, error) {
+	rec, err := p.record(id)
+	if err != nil {
+		return Payment{}, err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.payment, nil
+}
+
+func (p *Processor) record(id string) (*paymentRecord, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rec, ok := p.payments[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPayment, id)
+	}
+	return rec, nil
+}
+
+// update applies change to a copy of payment id under the payment's
+// lock, and keeps the copy only if change succeeds.
+func (p *Processor) update(id string, change func(*Payment) error) (Payment, error) {
+	rec, err := p.record(id)
+	if err != nil {
+		return Payment{}, err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	next := rec.payment
+	if err := change(&next); err != nil {
+		return Payment{}, err
+	}
+	rec.payment = next
+	return next, nil
+}
+
+// idempotent runs op once per key. Concurrent calls with the same key
+// wait for the first one and share its outcome. request describes the
+// call's arguments; only its hash is kept.
+func (p *Processor) idempotent(ctx context.Context, key, request string, op func() (Payment, error)) (Payment, error) {
+	if key == "" {
+		return Payment{}, ErrMissingIdempotencyKey
+	}
+	fingerprint := sha256.Sum256([]byte(request))
+	p.mu.Lock()
+	if e, ok := p.keys[key]; ok {
+		p.mu.Unlock()
+		if e.fingerprint != fingerprint {
+			return Payment{}, fmt.Errorf("%w: %q", ErrIdempotencyConflict, key)
+		}
+		select {
+		case <-e.done:
+			return e.result, e.err
+		case <-ctx.Done():
+			return Payment{}, ctx.Err()
+		}
+	}
+	e := &keyEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	p.keys[key] = e
+	p.mu.Unlock()
+
+	e.result, e.err = op()
+	if e.err != nil && !errors.Is(e.err, ErrDeclined) {
+		p.mu.Lock()
+		delete(p.keys, key)
+		p.mu.Unlock()
+	}
+	close(e.done)
+	return e.result, e.err
+}
+
+// ExamplePayment demonstrates money, conversion and the payment
+// lifecycle on the in-memory gateway.
+func ExamplePayment() {
+	fmt.Println("=== Payment Processing ===")
+	ctx := context.Background()
+
+	// Exact money: no float rounding, explicit currencies.
+	price := MustParse("19.99", USD)
+	total, _ := price.Mul(3)
+	fmt.Printf("3 x %s = %s\n", price, total)
+	if _, err := total.Add(MustParse("5", EUR)); err != nil {
+		fmt.Println("Add:", err)
+	}
+
+	rates := NewStaticRates()
+	_ = rates.Set(EUR, USD, "1.0842")
+	inEUR, _ := NewConverter(rates).Convert(ctx, total, EUR)
+	fmt.Printf("%s = %s at 1.0842 USD/EUR\n", total, inEUR)
+
+	// Card checks happen before the gateway sees anything.
+	clock := idioms.NewManualClock(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))
+	gateway := NewFakeGateway()
+	processor := NewProcessor(gateway, WithClock(clock))
+	typo := Card{Number: "4242 4242 4242 4241", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+	if _, err := processor.Authorize(ctx, "order-1-auth", typo, total); err != nil {
+		fmt.Println("Authorize:", err)
+	}
+
+	// Authorize, capture part, refund part.
+	card := Card{Number: "4242 4242 4242 4242", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+	auth, err := processor.Authorize(ctx, "order-2-auth", card, total)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("%s: %s on %s\n", auth.ID, auth.Status, auth.Method)
+
+	// The first capture attempt times out; the retry with the same key
+	// goes through, and a duplicate retry does not capture twice.
+	gateway.FailNext(ErrGatewayUnavailable)
+	if _, err := processor.Capture(ctx, "order-2-capture", auth.ID, MustParse("39.98", USD)); err != nil {
+		fmt.Println("Capture:", err)
+	}
+	for range 2 {
+		captured, err := processor.Capture(ctx, "order-2-capture", auth.ID, MustParse("39.98", USD))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("%s: %s %s\n", captured.ID, captured.Status, captured.Captured)
+	}
+
+	refunded, err := processor.Refund(ctx, "order-2-refund-1", auth.ID, MustParse("10", USD))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("%s: %s, %s of %s returned\n", refunded.ID, refunded.Status, refunded.Refunded, refunded.Captured)
+	if _, err := processor.Void(ctx, "order-2-void", auth.ID); err != nil {
+		fmt.Println("Void:", err)
+	}
+
+	fmt.Println("Gateway calls:")
+	for _, call := range gateway.Calls() {
+		fmt.Println(" ", call)
+	}
+}
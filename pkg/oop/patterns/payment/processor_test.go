@@ -0,0 +1,335 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+)
+
+var testCard = Card{Number: "4242424242424242", ExpMonth: 12, ExpYear: 2030, CVV: "123"}
+
+func newTestProcessor(g Gateway) *Processor {
+	return NewProcessor(g, WithClock(idioms.NewManualClock(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))))
+}
+
+func TestProcessorLifecycle(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProcessor(NewFakeGateway())
+	usd := func(s string) Money { return MustParse(s, USD) }
+
+	pay, err := p.Authorize(ctx, "a", testCard, usd("100"))
+	if err != nil || pay.Status != StatusAuthorized || pay.Method != "Visa ****4242" {
+		t.Fatalf("Authorize = %+v, %v", pay, err)
+	}
+	if pay, err = p.Capture(ctx, "c", pay.ID, usd("80")); err != nil || pay.Status != StatusCaptured || pay.Captured != usd("80") {
+		t.Fatalf("Capture = %+v, %v", pay, err)
+	}
+	if pay, err = p.Refund(ctx, "r1", pay.ID, usd("30")); err != nil || pay.Status != StatusPartiallyRefunded {
+		t.Fatalf("Refund = %+v, %v", pay, err)
+	}
+	if _, err := p.Refund(ctx, "r2", pay.ID, usd("50.01")); !errors.Is(err, ErrInvalidAmount) {
+		t.Fatalf("over-refund error = %v", err)
+	}
+	if pay, err = p.Refund(ctx, "r3", pay.ID, usd("50")); err != nil || pay.Status != StatusRefunded || pay.Refunded != usd("80") {
+		t.Fatalf("final Refund = %+v, %v", pay, err)
+	}
+	if got, err := p.Get(pay.ID); err != nil || got != pay {
+		t.Errorf("Get = %+v, %v; want %+v", got, err, pay)
+	}
+
+	data, err := json.Marshal(pay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"auth_1","method":"Visa ****4242",` +
+		`"authorized":{"amount":"100.00","currency":"USD"},` +
+		`"captured":{"amount":"80.00","currency":"USD"},` +
+		`"refunded":{"amount":"80.00","currency":"USD"},"status":"refunded"}`
+	if string(data) != want {
+		t.Errorf("JSON = %s\nwant   %s", data, want)
+	}
+	var decoded Payment
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded != pay {
+		t.Errorf("decoded = %+v, %v; want %+v", decoded, err, pay)
+	}
+	if err := json.Unmarshal([]byte(`{"status":"pending"}`), &decoded); err == nil {
+		t.Error("decoding an unknown status succeeded")
+	}
+}
+
+func TestProcessorInvalidTransitions(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway()
+	p := newTestProcessor(g)
+	amount := MustParse("10", USD)
+
+	voided, _ := p.Authorize(ctx, "a1", testCard, amount)
+	if _, err := p.Void(ctx, "v1", voided.ID); err != nil {
+		t.Fatal(err)
+	}
+	captured, _ := p.Authorize(ctx, "a2", testCard, amount)
+	if _, err := p.Capture(ctx, "c2", captured.ID, amount); err != nil {
+		t.Fatal(err)
+	}
+	open, _ := p.Authorize(ctx, "a3", testCard, amount)
+	callsBefore := len(g.Calls())
+
+	tests := []struct {
+		name string
+		op   func() (Payment, error)
+		want error
+	}{
+		{"capture voided", func() (Payment, error) { return p.Capture(ctx, "k1", voided.ID, amount) }, ErrInvalidTransition},
+		{"refund voided", func() (Payment, error) { return p.Refund(ctx, "k2", voided.ID, amount) }, ErrInvalidTransition},
+		{"capture twice", func() (Payment, error) { return p.Capture(ctx, "k3", captured.ID, amount) }, ErrInvalidTransition},
+		{"void captured", func() (Payment, error) { return p.Void(ctx, "k4", captured.ID) }, ErrInvalidTransition},
+		{"refund authorized", func() (Payment, error) { return p.Refund(ctx, "k5", open.ID, amount) }, ErrInvalidTransition},
+		{"capture more", func() (Payment, error) { return p.Capture(ctx, "k6", open.ID, MustParse("10.01", USD)) }, ErrInvalidAmount},
+		{"capture zero", func() (Payment, error) { return p.Capture(ctx, "k7", open.ID, Zero(USD)) }, ErrInvalidAmount},
+		{"capture other currency", func() (Payment, error) { return p.Capture(ctx, "k8", open.ID, MustParse("1", EUR)) }, ErrInvalidAmount},
+		{"refund other currency", func() (Payment, error) { return p.Refund(ctx, "k9", captured.ID, MustParse("1", EUR)) }, ErrCurrencyMismatch},
+		{"unknown payment", func() (Payment, error) { return p.Void(ctx, "k10", "auth_99") }, ErrUnknownPayment},
+		{"authorize zero", func() (Payment, error) { return p.Authorize(ctx, "k11", testCard, Zero(USD)) }, ErrInvalidAmount},
+		{"authorize no currency", func() (Payment, error) { return p.Authorize(ctx, "k12", testCard, New(5, "")) }, ErrInvalidAmount},
+		{"authorize bad card", func() (Payment, error) {
+			return p.Authorize(ctx, "k13", Card{Number: "1234567890123456", ExpMonth: 1, ExpYear: 2030, CVV: "123"}, amount)
+		}, ErrInvalidMethod},
+	}
+	for _, tt := range tests {
+		if _, err := tt.op(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	// Every check above happens before the gateway is called.
+	if calls := g.Calls()[callsBefore:]; len(calls) != 0 {
+		t.Errorf("rejected operations reached the gateway: %q", calls)
+	}
+	if got, _ := p.Get(open.ID); got.Status != StatusAuthorized {
+		t.Errorf("rejected operations changed the payment: %+v", got)
+	}
+}
+
+func TestProcessorIdempotency(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway()
+	p := newTestProcessor(g)
+	amount := MustParse("25", EUR)
+
+	first, err := p.Authorize(ctx, "order-1", testCard, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err := p.Authorize(ctx, "order-1", testCard, amount)
+	if err != nil || replay != first {
+		t.Errorf("replay = %+v, %v; want %+v", replay, err, first)
+	}
+	if n := len(g.Calls()); n != 1 {
+		t.Errorf("gateway calls = %d, want 1", n)
+	}
+
+	// The same key for a different request is a client bug.
+	if _, err := p.Authorize(ctx, "order-1", testCard, MustParse("26", EUR)); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("different amount error = %v", err)
+	}
+	if _, err := p.Capture(ctx, "order-1", first.ID, amount); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("different operation error = %v", err)
+	}
+	if _, err := p.Capture(ctx, "", first.ID, amount); !errors.Is(err, ErrMissingIdempotencyKey) {
+		t.Errorf("empty key error = %v", err)
+	}
+
+	// A replayed capture returns the captured snapshot, not an error
+	// about capturing twice.
+	captured, err := p.Capture(ctx, "order-1-capture", first.ID, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := p.Capture(ctx, "order-1-capture", first.ID, amount); err != nil || again != captured {
+		t.Errorf("replayed capture = %+v, %v", again, err)
+	}
+}
+
+func TestProcessorDoesNotKeepCardDetails(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProcessor(NewFakeGateway())
+	amount := MustParse("25", EUR)
+	if _, err := p.Authorize(ctx, "order-1", testCard, amount); err != nil {
+		t.Fatal(err)
+	}
+	for key, e := range p.keys {
+		if s := fmt.Sprintf("%+v", *e); strings.Contains(s, testCard.Number) || strings.Contains(s, "CVV") {
+			t.Errorf("entry for %q keeps card details: %s", key, s)
+		}
+	}
+
+	// The hash still covers them: another CVV is another request.
+	other := testCard
+	other.CVV = "456"
+	if _, err := p.Authorize(ctx, "order-1", other, amount); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("different CVV error = %v, want ErrIdempotencyConflict", err)
+	}
+}
+
+func TestProcessorRetriesTransientErrors(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway()
+	p := newTestProcessor(g)
+	amount := MustParse("5", GBP)
+
+	g.FailNext(ErrGatewayUnavailable)
+	if _, err := p.Authorize(ctx, "k", testCard, amount); !errors.Is(err, ErrGatewayUnavailable) {
+		t.Fatalf("first attempt error = %v", err)
+	}
+	pay, err := p.Authorize(ctx, "k", testCard, amount)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	g.FailNext(context.DeadlineExceeded)
+	if _, err := p.Void(ctx, "v", pay.ID); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("void error = %v", err)
+	}
+	if got, _ := p.Get(pay.ID); got.Status != StatusAuthorized {
+		t.Errorf("failed void changed the status to %s", got.Status)
+	}
+	if pay, err = p.Void(ctx, "v", pay.ID); err != nil || pay.Status != StatusVoided {
+		t.Errorf("void retry = %+v, %v", pay, err)
+	}
+	want := []string{
+		"authorize Visa ****4242 5.00 GBP",
+		"authorize Visa ****4242 5.00 GBP",
+		"void auth_1",
+		"void auth_1",
+	}
+	if got := g.Calls(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestProcessorRemembersDeclines(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway()
+	g.DeclineWhen(func(req AuthorizeRequest) bool {
+		c, _ := req.Amount.Cmp(MustParse("1000", USD))
+		return c > 0
+	})
+	p := newTestProcessor(g)
+	for range 2 {
+		if _, err := p.Authorize(ctx, "big", testCard, MustParse("1000.01", USD)); !errors.Is(err, ErrDeclined) {
+			t.Fatalf("error = %v, want ErrDeclined", err)
+		}
+	}
+	if n := len(g.Calls()); n != 1 {
+		t.Errorf("gateway calls = %d, want 1: a decline is a final answer", n)
+	}
+	if _, err := p.Authorize(ctx, "small", testCard, MustParse("1000", USD)); err != nil {
+		t.Errorf("authorize under the limit: %v", err)
+	}
+}
+
+// slowGateway holds Authorize until release is closed.
+type slowGateway struct {
+	*FakeGateway
+	release chan struct{}
+}
+
+func (g *slowGateway) Authorize(ctx context.Context, req AuthorizeRequest) (string, error) {
+	<-g.release
+	return g.FakeGateway.Authorize(ctx, req)
+}
+
+func TestProcessorConcurrentSameKey(t *testing.T) {
+	ctx := context.Background()
+	g := &slowGateway{FakeGateway: NewFakeGateway(), release: make(chan struct{})}
+	p := newTestProcessor(g)
+	amount := MustParse("42", USD)
+
+	const callers = 8
+	results := make([]Payment, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = p.Authorize(ctx, "same", testCard, amount)
+		}()
+	}
+	close(g.release)
+	wg.Wait()
+	for i := range callers {
+		if errs[i] != nil || results[i] != results[0] {
+			t.Errorf("caller %d = %+v, %v; want %+v", i, results[i], errs[i], results[0])
+		}
+	}
+	if n := len(g.Calls()); n != 1 {
+		t.Errorf("gateway calls = %d, want 1", n)
+	}
+}
+
+func TestProcessorConcurrentCaptureAndVoid(t *testing.T) {
+	ctx := context.Background()
+	p := newTestProcessor(NewFakeGateway())
+	for i := range 50 {
+		amount := MustParse("1", USD)
+		pay, err := p.Authorize(ctx, fmt.Sprint("a", i), testCard, amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		var captureErr, voidErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, captureErr = p.Capture(ctx, fmt.Sprint("c", i), pay.ID, amount)
+		}()
+		go func() {
+			defer wg.Done()
+			_, voidErr = p.Void(ctx, fmt.Sprint("v", i), pay.ID)
+		}()
+		wg.Wait()
+		if (captureErr == nil) == (voidErr == nil) {
+			t.Fatalf("capture error %v, void error %v: exactly one must win", captureErr, voidErr)
+		}
+	}
+}
+
+func TestFakeGatewayLimits(t *testing.T) {
+	ctx := context.Background()
+	g := NewFakeGateway()
+	usd := func(s string) Money { return MustParse(s, USD) }
+	ref, err := g.Authorize(ctx, AuthorizeRequest{Method: testCard, Amount: usd("10"), IdempotencyKey: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"capture over", g.Capture(ctx, ref, usd("10.01"), "")},
+		{"refund before capture", g.Refund(ctx, ref, usd("1"), "")},
+		{"capture", g.Capture(ctx, ref, usd("6"), "")},
+		{"capture again", g.Capture(ctx, ref, usd("1"), "")},
+		{"void captured", g.Void(ctx, ref, "")},
+		{"refund", g.Refund(ctx, ref, usd("5"), "")},
+		{"refund over", g.Refund(ctx, ref, usd("1.01"), "")},
+		{"refund rest", g.Refund(ctx, ref, usd("1"), "")},
+		{"unknown", g.Void(ctx, "auth_9", "")},
+	}
+	wantOK := map[string]bool{"capture": true, "refund": true, "refund rest": true}
+	for _, c := range checks {
+		if wantOK[c.name] {
+			if c.err != nil {
+				t.Errorf("%s: %v", c.name, c.err)
+			}
+		} else if !errors.Is(c.err, ErrDeclined) {
+			t.Errorf("%s: error = %v, want ErrDeclined", c.name, c.err)
+		}
+	}
+}
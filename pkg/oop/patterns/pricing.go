@@ -0,0 +1,462 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+// Pricing rules are strategies too: each PricingRule is one way to
+// discount a cart, and a PricingEngine decides which of them combine.
+//
+// Why a rule engine? Promotions change every week and are configured,
+// not coded: a new sale is a new rule value, while the logic that
+// stacks, caps and picks between discounts is written and tested once.
+
+// Stacking says how a rule's discounts combine with other rules'.
+type Stacking int
+
+const (
+	// Stackable discounts apply together with every other stackable
+	// discount.
+	Stackable Stacking = iota
+	// Exclusive discounts never combine with another rule. The engine
+	// applies either one exclusive rule or all stackable rules together,
+	// whichever saves the customer more.
+	Exclusive
+)
+
+// Discount is money a rule takes off a cart.
+type Discount struct {
+	Rule   string        `json:"rule"`
+	SKU    string        `json:"sku,omitempty"` // the line discounted; empty for the whole order
+	Amount payment.Money `json:"amount"`
+}
+
+// PricingInput is what a rule sees of a cart.
+type PricingInput struct {
+	Lines    []LineItem
+	Subtotal payment.Money
+	Coupons  []string // normalized codes applied to the cart
+	Now      time.Time
+}
+
+// HasCoupon reports whether code was applied to the cart.
+func (in PricingInput) HasCoupon(code string) bool {
+	return slices.Contains(in.Coupons, normalizeCoupon(code))
+}
+
+// PricingRule is one discount strategy.
+type PricingRule interface {
+	// Name labels the rule's discounts on receipts.
+	Name() string
+	// Stacking says how the rule combines with others.
+	Stacking() Stacking
+	// Discounts returns what the rule takes off the cart, or nothing if
+	// it does not apply. Every rule sees undiscounted prices; the
+	// engine caps the combined discounts.
+	Discounts(in PricingInput) ([]Discount, error)
+}
+
+// Errors returned by pricing rules and the PricingEngine.
+var (
+	ErrInvalidRule   = errors.New("invalid pricing rule")
+	ErrUnknownCoupon = errors.New("unknown coupon")
+	ErrCouponExpired = errors.New("coupon expired")
+)
+
+// parsePercent parses a decimal percentage such as "15" or "8.875" into
+// a fraction.
+func parsePercent(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() < 0 || r.Cmp(big.NewRat(100, 1)) > 0 {
+		return nil, fmt.Errorf("%w: percentage %q", ErrInvalidRule, s)
+	}
+	return r.Quo(r, big.NewRat(100, 1)), nil
+}
+
+// PercentOff takes a percentage off every matching line.
+type PercentOff struct {
+	Label    string
+	Percent  string   // decimal percentage, such as "15" or "12.5"
+	SKUs     []string // lines it applies to; with Category empty too, every line
+	Category string   // lines in this category also match
+	Stack    Stacking
+}
+
+// Name returns Label, or a description such as "15% off".
+func (p *PercentOff) Name() string {
+	if p.Label != "" {
+		return p.Label
+	}
+	return p.Percent + "% off"
+}
+
+// Stacking returns p.Stack.
+func (p *PercentOff) Stacking() Stacking { return p.Stack }
+
+func (p *PercentOff) matches(l LineItem) bool {
+	if len(p.SKUs) == 0 && p.Category == "" {
+		return true
+	}
+	return slices.Contains(p.SKUs, l.SKU) || (p.Category != "" && l.Category == p.Category)
+}
+
+// Discounts implements PricingRule.
+func (p *PercentOff) Discounts(in PricingInput) ([]Discount, error) {
+	rate, err := parsePercent(p.Percent)
+	if err != nil {
+		return nil, err
+	}
+	var out []Discount
+	for _, l := range in.Lines {
+		if !p.matches(l) {
+			continue
+		}
+		sub, err := l.Subtotal()
+		if err != nil {
+			return nil, err
+		}
+		off, err := sub.MulRat(rate, payment.HalfEven)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Discount{Rule: p.Name(), SKU: l.SKU, Amount: off})
+	}
+	return out, nil
+}
+
+// BuyXGetY makes Get units of a SKU free for every Buy units bought:
+// Buy 1, Get 1 is "buy one, get one free". Units are counted in groups
+// of Buy+Get, so three units at Buy 1, Get 1 make one free.
+type BuyXGetY struct {
+	Label    string
+	SKU      string
+	Buy, Get int
+	Stack    Stacking
+}
+
+// Name returns Label, or a description such as "Buy 2 get 1 free".
+func (b *BuyXGetY) Name() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	return fmt.Sprintf("Buy %d get %d free", b.Buy, b.Get)
+}
+
+// Stacking returns b.Stack.
+func (b *BuyXGetY) Stacking() Stacking { return b.Stack }
+
+// Discounts implements PricingRule.
+func (b *BuyXGetY) Discounts(in PricingInput) ([]Discount, error) {
+	if b.Buy < 1 || b.Get < 1 {
+		return nil, fmt.Errorf("%w: buy %d get %d", ErrInvalidRule, b.Buy, b.Get)
+	}
+	for _, l := range in.Lines {
+		if l.SKU != b.SKU {
+			continue
+		}
+		free := l.Quantity / (b.Buy + b.Get) * b.Get
+		if free == 0 {
+			return nil, nil
+		}
+		off, err := l.UnitPrice.Mul(int64(free))
+		if err != nil {
+			return nil, err
+		}
+		return []Discount{{Rule: b.Name(), SKU: l.SKU, Amount: off}}, nil
+	}
+	return nil, nil
+}
+
+// Tier is a spend threshold and the percentage it unlocks.
+type Tier struct {
+	Min     payment.Money
+	Percent string
+}
+
+// TieredDiscount takes a percentage off the whole order that grows with
+// the subtotal: the highest tier whose Min the subtotal reaches applies.
+type TieredDiscount struct {
+	Label string
+	Tiers []Tier
+	Stack Stacking
+}
+
+// Name returns Label, or "Spend more, save more".
+func (t *TieredDiscount) Name() string {
+	if t.Label != "" {
+		return t.Label
+	}
+	return "Spend more, save more"
+}
+
+// Stacking returns t.Stack.
+func (t *TieredDiscount) Stacking() Stacking { return t.Stack }
+
+// Discounts implements PricingRule.
+func (t *TieredDiscount) Discounts(in PricingInput) ([]Discount, error) {
+	var best *Tier
+	for i := range t.Tiers {
+		c, err := in.Subtotal.Cmp(t.Tiers[i].Min)
+		if err != nil {
+			return nil, err
+		}
+		if c < 0 {
+			continue
+		}
+		if best == nil {
+			best = &t.Tiers[i]
+		} else if c, _ := t.Tiers[i].Min.Cmp(best.Min); c > 0 {
+			best = &t.Tiers[i]
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	rate, err := parsePercent(best.Percent)
+	if err != nil {
+		return nil, err
+	}
+	off, err := in.Subtotal.MulRat(rate, payment.HalfEven)
+	if err != nil {
+		return nil, err
+	}
+	return []Discount{{Rule: fmt.Sprintf("%s (%s%%)", t.Name(), best.Percent), Amount: off}}, nil
+}
+
+// AmountOff takes a fixed amount off an order of at least MinSubtotal.
+type AmountOff struct {
+	Label       string
+	Amount      payment.Money
+	MinSubtotal payment.Money
+	Stack       Stacking
+}
+
+// Name returns Label, or a description such as "5.00 USD off".
+func (a *AmountOff) Name() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return a.Amount.String() + " off"
+}
+
+// Stacking returns a.Stack.
+func (a *AmountOff) Stacking() Stacking { return a.Stack }
+
+// Discounts implements PricingRule.
+func (a *AmountOff) Discounts(in PricingInput) ([]Discount, error) {
+	c, err := in.Subtotal.Cmp(a.MinSubtotal)
+	if err != nil {
+		return nil, err
+	}
+	if c < 0 {
+		return nil, nil
+	}
+	if _, err := in.Subtotal.Cmp(a.Amount); err != nil {
+		return nil, err
+	}
+	return []Discount{{Rule: a.Name(), Amount: a.Amount}}, nil
+}
+
+// Coupon makes a rule apply only to carts the code was entered on,
+// until it expires.
+type Coupon struct {
+	Code    string
+	Expires time.Time // zero means never
+	Rule    PricingRule
+}
+
+// Name returns the rule's name and the code.
+func (c *Coupon) Name() string {
+	return fmt.Sprintf("%s (%s)", c.Rule.Name(), normalizeCoupon(c.Code))
+}
+
+// Stacking returns the rule's stacking.
+func (c *Coupon) Stacking() Stacking { return c.Rule.Stacking() }
+
+// ExpiredAt reports whether the coupon is no longer valid at now.
+func (c *Coupon) ExpiredAt(now time.Time) bool {
+	return !c.Expires.IsZero() && !now.Before(c.Expires)
+}
+
+// Discounts implements PricingRule.
+func (c *Coupon) Discounts(in PricingInput) ([]Discount, error) {
+	if !in.HasCoupon(c.Code) || c.ExpiredAt(in.Now) {
+		return nil, nil
+	}
+	ds, err := c.Rule.Discounts(in)
+	for i := range ds {
+		ds[i].Rule = c.Name()
+	}
+	return ds, err
+}
+
+// normalizeCoupon makes codes case-insensitive.
+func normalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// PricingEngine applies pricing rules to carts. It is immutable once
+// built, so one engine can price every cart concurrently.
+type PricingEngine struct {
+	rules []PricingRule
+	clock idioms.Clock
+}
+
+// PricingOption configures a PricingEngine.
+type PricingOption func(*PricingEngine)
+
+// WithPricingRules adds rules. Discounts are applied, and capped, in
+// the order the rules are added.
+func WithPricingRules(rules ...PricingRule) PricingOption {
+	return func(e *PricingEngine) { e.rules = append(e.rules, rules...) }
+}
+
+// WithPricingClock sets the clock used to check coupon expiry.
+func WithPricingClock(clock idioms.Clock) PricingOption {
+	return func(e *PricingEngine) { e.clock = clock }
+}
+
+// NewPricingEngine returns an engine with the given options.
+func NewPricingEngine(opts ...PricingOption) *PricingEngine {
+	e := &PricingEngine{clock: idioms.SystemClock()}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// CheckCoupon returns nil if code belongs to a coupon rule that has not
+// expired, and an error wrapping ErrUnknownCoupon or ErrCouponExpired
+// otherwise.
+func (e *PricingEngine) CheckCoupon(code string) error {
+	code = normalizeCoupon(code)
+	now := e.clock.Now()
+	for _, r := range e.rules {
+		c, ok := r.(*Coupon)
+		if !ok || normalizeCoupon(c.Code) != code {
+			continue
+		}
+		if c.ExpiredAt(now) {
+			return fmt.Errorf("%w: %s on %s", ErrCouponExpired, code, c.Expires.Format(time.DateOnly))
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownCoupon, code)
+}
+
+// Price returns the discounts for lines with coupons applied. It fails
+// if a coupon has expired since it was entered, rather than quietly
+// charging more than the customer was shown.
+//
+// Stackable rules are combined; each exclusive rule is tried on its
+// own; the option that saves the most wins, stackable rules on a tie.
+// Discounts are capped so that no line, and no order, goes below zero.
+func (e *PricingEngine) Price(lines []LineItem, coupons []string) ([]Discount, error) {
+	for _, code := range coupons {
+		if err := e.CheckCoupon(code); err != nil {
+			return nil, err
+		}
+	}
+	in := PricingInput{Lines: lines, Coupons: coupons, Now: e.clock.Now()}
+	for _, l := range lines {
+		sub, err := l.Subtotal()
+		if err != nil {
+			return nil, err
+		}
+		if in.Subtotal, err = in.Subtotal.Add(sub); err != nil {
+			return nil, err
+		}
+	}
+
+	var stackable []Discount
+	var exclusive [][]Discount
+	for _, r := range e.rules {
+		ds, err := r.Discounts(in)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name(), err)
+		}
+		for _, d := range ds {
+			if d.Amount.IsNegative() {
+				return nil, fmt.Errorf("%w: %q gave a negative discount %s", ErrInvalidRule, r.Name(), d.Amount)
+			}
+		}
+		switch {
+		case len(ds) == 0:
+		case r.Stacking() == Exclusive:
+			exclusive = append(exclusive, ds)
+		default:
+			stackable = append(stackable, ds...)
+		}
+	}
+
+	best, bestTotal, err := capDiscounts(in, stackable)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range exclusive {
+		capped, total, err := capDiscounts(in, ds)
+		if err != nil {
+			return nil, err
+		}
+		if c, _ := total.Cmp(bestTotal); c > 0 {
+			best, bestTotal = capped, total
+		}
+	}
+	return best, nil
+}
+
+// capDiscounts trims ds, in order, so that no line's discounts exceed
+// its subtotal and the order's discounts do not exceed the order's
+// subtotal. It drops discounts trimmed to zero.
+func capDiscounts(in PricingInput, ds []Discount) ([]Discount, payment.Money, error) {
+	left := make(map[string]payment.Money, len(in.Lines))
+	for _, l := range in.Lines {
+		sub, err := l.Subtotal()
+		if err != nil {
+			return nil, payment.Money{}, err
+		}
+		left[l.SKU] = sub
+	}
+	orderLeft := in.Subtotal
+	total := payment.Zero(in.Subtotal.Currency())
+	var out []Discount
+	for _, d := range ds {
+		amount, err := minMoney(d.Amount, orderLeft)
+		if err != nil {
+			return nil, payment.Money{}, err
+		}
+		if d.SKU != "" {
+			if amount, err = minMoney(amount, left[d.SKU]); err != nil {
+				return nil, payment.Money{}, err
+			}
+			left[d.SKU], _ = left[d.SKU].Sub(amount)
+		}
+		if amount.IsZero() {
+			continue
+		}
+		orderLeft, _ = orderLeft.Sub(amount)
+		total, _ = total.Add(amount)
+		d.Amount = amount
+		out = append(out, d)
+	}
+	return out, total, nil
+}
+
+func minMoney(a, b payment.Money) (payment.Money, error) {
+	c, err := a.Cmp(b)
+	if err != nil {
+		return payment.Money{}, err
+	}
+	if c > 0 {
+		return b, nil
+	}
+	return a, nil
+}
@@ -0,0 +1,229 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+func usd(s string) payment.Money { return payment.MustParse(s, payment.USD) }
+
+var june2025 = time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+func testLines() []LineItem {
+	return []LineItem{
+		{SKU: "A", Name: "Alpha", Category: "toys", UnitPrice: usd("10.00"), Quantity: 5},
+		{SKU: "B", Name: "Beta", Category: "books", UnitPrice: usd("7.99"), Quantity: 1},
+	}
+}
+
+// formatDiscounts renders discounts as "rule sku amount" for comparison.
+func formatDiscounts(ds []Discount) string {
+	parts := make([]string, len(ds))
+	for i, d := range ds {
+		parts[i] = strings.Join(slices.DeleteFunc([]string{d.Rule, d.SKU, d.Amount.Decimal()}, func(s string) bool { return s == "" }), " ")
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestPricingRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    PricingRule
+		coupons []string
+		want    string
+	}{
+		{"percent all", &PercentOff{Percent: "10"}, nil, "10% off A 5.00; 10% off B 0.80"},
+		{"percent sku", &PercentOff{Percent: "12.5", SKUs: []string{"B"}}, nil, "12.5% off B 1.00"},
+		{"percent category", &PercentOff{Label: "Toy sale", Percent: "50", Category: "toys"}, nil, "Toy sale A 25.00"},
+		{"bogo", &BuyXGetY{SKU: "A", Buy: 1, Get: 1}, nil, "Buy 1 get 1 free A 20.00"},
+		{"buy 2 get 1", &BuyXGetY{SKU: "A", Buy: 2, Get: 1}, nil, "Buy 2 get 1 free A 10.00"},
+		{"bogo not enough", &BuyXGetY{SKU: "B", Buy: 1, Get: 1}, nil, ""},
+		{"bogo missing sku", &BuyXGetY{SKU: "Z", Buy: 1, Get: 1}, nil, ""},
+		{"tier below", &TieredDiscount{Tiers: []Tier{{Min: usd("100"), Percent: "5"}}}, nil, ""},
+		{"tier highest reached", &TieredDiscount{Tiers: []Tier{
+			{Min: usd("50"), Percent: "5"}, {Min: usd("10"), Percent: "2"}, {Min: usd("60"), Percent: "10"},
+		}}, nil, "Spend more, save more (5%) 2.90"},
+		{"amount off", &AmountOff{Amount: usd("5"), MinSubtotal: usd("50")}, nil, "5.00 USD off 5.00"},
+		{"amount off below minimum", &AmountOff{Amount: usd("5"), MinSubtotal: usd("60")}, nil, ""},
+		{"coupon not entered", &Coupon{Code: "SAVE", Rule: &AmountOff{Amount: usd("5")}}, nil, ""},
+		{"coupon entered", &Coupon{Code: "save", Rule: &AmountOff{Amount: usd("5")}}, []string{"SAVE"}, "5.00 USD off (SAVE) 5.00"},
+	}
+	for _, tt := range tests {
+		engine := NewPricingEngine(WithPricingClock(idioms.NewManualClock(june2025)), WithPricingRules(tt.rule))
+		ds, err := engine.Price(testLines(), tt.coupons)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := formatDiscounts(ds); got != tt.want {
+			t.Errorf("%s: discounts = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPricingRuleErrors(t *testing.T) {
+	rules := map[string]PricingRule{
+		"percent":  &PercentOff{Percent: "ten"},
+		"over 100": &PercentOff{Percent: "101"},
+		"buy 0":    &BuyXGetY{SKU: "A", Buy: 0, Get: 1},
+		"tier":     &TieredDiscount{Tiers: []Tier{{Min: usd("1"), Percent: "-5"}}},
+		"currency": &AmountOff{Amount: payment.MustParse("5", payment.EUR)},
+	}
+	for name, rule := range rules {
+		if _, err := NewPricingEngine(WithPricingRules(rule)).Price(testLines(), nil); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestPricingStacking(t *testing.T) {
+	stackable := []PricingRule{
+		&PercentOff{Label: "Toys", Percent: "10", Category: "toys"},
+		&AmountOff{Label: "Fiver", Amount: usd("5")},
+	}
+	tests := []struct {
+		name      string
+		exclusive PricingRule
+		want      string
+	}{
+		{"stackable win", &PercentOff{Label: "Half books", Percent: "50", SKUs: []string{"B"}, Stack: Exclusive},
+			"Toys A 5.00; Fiver 5.00"},
+		{"exclusive wins", &PercentOff{Label: "Staff", Percent: "20", Stack: Exclusive},
+			"Staff A 10.00; Staff B 1.60"},
+		// 10.00 exactly: the tie goes to the stackable rules.
+		{"tie", &AmountOff{Label: "Tenner", Amount: usd("10"), Stack: Exclusive}, "Toys A 5.00; Fiver 5.00"},
+	}
+	for _, tt := range tests {
+		engine := NewPricingEngine(WithPricingRules(append(stackable, tt.exclusive)...))
+		ds, err := engine.Price(testLines(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatDiscounts(ds); got != tt.want {
+			t.Errorf("%s: discounts = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPricingCapsDiscounts(t *testing.T) {
+	engine := NewPricingEngine(WithPricingRules(
+		&PercentOff{Label: "Clearance", Percent: "80", SKUs: []string{"B"}},
+		&PercentOff{Label: "Flash", Percent: "50", SKUs: []string{"B"}}, // capped at the 1.60 left
+		&AmountOff{Label: "Gift card", Amount: usd("100")},              // capped at the rest of the order
+		&AmountOff{Label: "Nothing left", Amount: usd("1")},             // dropped
+	))
+	ds, err := engine.Price(testLines(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Clearance B 6.39; Flash B 1.60; Gift card 50.00"
+	if got := formatDiscounts(ds); got != want {
+		t.Errorf("discounts = %q, want %q", got, want)
+	}
+}
+
+func TestCoupons(t *testing.T) {
+	clock := idioms.NewManualClock(june2025)
+	engine := NewPricingEngine(WithPricingClock(clock), WithPricingRules(
+		&Coupon{Code: "SUMMER", Expires: june2025.Add(24 * time.Hour), Rule: &PercentOff{Percent: "10"}},
+		&Coupon{Code: "FOREVER", Rule: &AmountOff{Amount: usd("1")}},
+	))
+	if err := engine.CheckCoupon(" summer "); err != nil {
+		t.Errorf("valid coupon: %v", err)
+	}
+	if err := engine.CheckCoupon("WINTER"); !errors.Is(err, ErrUnknownCoupon) {
+		t.Errorf("unknown coupon error = %v", err)
+	}
+
+	cart := NewShoppingCart(payment.USD)
+	cart.SetPricing(engine)
+	_ = cart.AddItem(testLines()[0])
+	if err := cart.ApplyCoupon("Summer"); err != nil {
+		t.Fatal(err)
+	}
+	_ = cart.ApplyCoupon("SUMMER") // no effect the second time
+	if err := cart.ApplyCoupon("forever"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := cart.Receipt()
+	if err != nil || r.Savings != usd("6.00") || strings.Join(r.Coupons, ",") != "SUMMER,FOREVER" {
+		t.Fatalf("receipt = %+v, %v", r, err)
+	}
+
+	// A coupon that expires after it was entered fails pricing instead
+	// of quietly raising the total.
+	clock.Advance(24 * time.Hour)
+	if _, err := cart.Receipt(); !errors.Is(err, ErrCouponExpired) {
+		t.Fatalf("expired coupon error = %v", err)
+	}
+	if err := cart.ApplyCoupon("SUMMER"); !errors.Is(err, ErrCouponExpired) {
+		t.Errorf("applying an expired coupon error = %v", err)
+	}
+	cart.RemoveCoupon("summer")
+	if r, err = cart.Receipt(); err != nil || r.Savings != usd("1.00") {
+		t.Errorf("after removing the coupon: %+v, %v", r, err)
+	}
+	if err := NewShoppingCart(payment.USD).ApplyCoupon("SUMMER"); !errors.Is(err, ErrUnknownCoupon) {
+		t.Errorf("coupon without a pricing engine error = %v", err)
+	}
+}
+
+func TestTaxStrategies(t *testing.T) {
+	lines := []TaxableLine{
+		{SKU: "A", Category: "toys", Amount: usd("10.00")},
+		{SKU: "B", Category: "books", Amount: usd("5.00")},
+		{SKU: "C", Category: "food", Amount: usd("2.50")},
+	}
+	tests := []struct {
+		strategy TaxStrategy
+		want     string
+		included bool
+	}{
+		{NoTax{}, "", false},
+		{&SalesTax{Label: "Sales", Rate: "8.875"}, "Sales 8.875% 17.50 1.55", false}, // 1.553125
+		{&SalesTax{Label: "Sales", Rate: "10", Exempt: []string{"food", "books"}}, "Sales 10% 10.00 1.00", false},
+		{&SalesTax{Label: "Sales", Rate: "5", Exempt: []string{"toys", "books", "food"}}, "", false},
+		{&SalesTax{Label: "Half", Rate: "5"}, "Half 5% 17.50 0.88", false}, // 0.875 rounds half up
+		{&VAT{Label: "VAT", Standard: "20", Reduced: map[string]string{"books": "0", "food": "5"}},
+			"VAT 20% 8.33 1.67; VAT 5% 2.38 0.12; VAT 0% 5.00 0.00", true},
+	}
+	for _, tt := range tests {
+		tax, err := tt.strategy.Tax(lines)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.strategy.Name(), err)
+		}
+		parts := make([]string, len(tax.Lines))
+		for i, l := range tax.Lines {
+			parts[i] = fmt.Sprintf("%s %s %s", l.Label, l.Base.Decimal(), l.Amount.Decimal())
+		}
+		if got := strings.Join(parts, "; "); got != tt.want || tax.Included != tt.included && len(tax.Lines) > 0 {
+			t.Errorf("%s: tax = %q (included %v), want %q", tt.strategy.Name(), got, tax.Included, tt.want)
+		}
+	}
+	if _, err := (&SalesTax{Label: "Bad", Rate: "x"}).Tax(lines); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("invalid rate error = %v", err)
+	}
+}
+
+func TestTaxRegions(t *testing.T) {
+	ny := &SalesTax{Label: "NY", Rate: "8.875"}
+	us := &SalesTax{Label: "US", Rate: "6"}
+	regions := TaxRegions{"US": us, "US-NY": ny, "GB": NoTax{}}
+	tests := map[string]TaxStrategy{"US-NY": ny, "us-ny": ny, "US-TX": us, "US": us, "GB": NoTax{}}
+	for region, want := range tests {
+		if got, err := regions.For(region); err != nil || got != want {
+			t.Errorf("For(%q) = %v, %v; want %v", region, got, err, want)
+		}
+	}
+	for _, region := range []string{"FR", "FR-75", ""} {
+		if _, err := regions.For(region); !errors.Is(err, ErrUnknownRegion) {
+			t.Errorf("For(%q) error = %v", region, err)
+		}
+	}
+}
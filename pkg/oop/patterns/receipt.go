@@ -0,0 +1,106 @@
+package patterns
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+// Receipt is a priced cart, itemized.
+type Receipt struct {
+	Lines     []ReceiptLine    `json:"lines"`
+	Subtotal  payment.Money    `json:"subtotal"`
+	Discounts []Discount       `json:"discounts,omitempty"`
+	Savings   payment.Money    `json:"savings"`
+	Coupons   []string         `json:"coupons,omitempty"`
+	Tax       Tax              `json:"tax"`
+	TaxTotal  payment.Money    `json:"tax_total"`
+	Total     payment.Money    `json:"total"`
+	Payment   *payment.Payment `json:"payment,omitempty"` // set by Checkout, unless the total is zero
+}
+
+// ReceiptLine is one line of a receipt.
+type ReceiptLine struct {
+	SKU       string        `json:"sku"`
+	Name      string        `json:"name"`
+	Quantity  int           `json:"quantity"`
+	UnitPrice payment.Money `json:"unit_price"`
+	Subtotal  payment.Money `json:"subtotal"`
+}
+
+// ReceiptRenderer writes a receipt in some format: another strategy,
+// so the checkout service can print the same receipt on a till and
+// return it from an API.
+type ReceiptRenderer interface {
+	Render(w io.Writer, r Receipt) error
+}
+
+// TextReceipt renders a receipt as fixed-width text, with line
+// discounts under their line.
+type TextReceipt struct {
+	Width int // characters per line; 40 if zero
+}
+
+// Render implements ReceiptRenderer.
+func (t TextReceipt) Render(w io.Writer, r Receipt) error {
+	width := t.Width
+	if width == 0 {
+		width = 40
+	}
+	var b strings.Builder
+	row := func(left, right string) {
+		pad := max(width-len(left)-len(right), 1)
+		b.WriteString(left + strings.Repeat(" ", pad) + right + "\n")
+	}
+	for _, l := range r.Lines {
+		fmt.Fprintf(&b, "%s (%s)\n", l.Name, l.SKU)
+		row(fmt.Sprintf("  %d x %s", l.Quantity, l.UnitPrice.Decimal()), l.Subtotal.Decimal())
+		for _, d := range r.Discounts {
+			if d.SKU == l.SKU {
+				row("    "+d.Rule, "-"+d.Amount.Decimal())
+			}
+		}
+	}
+	b.WriteString(strings.Repeat("-", width) + "\n")
+	row("Subtotal", r.Subtotal.Decimal())
+	for _, d := range r.Discounts {
+		if d.SKU == "" {
+			row(d.Rule, "-"+d.Amount.Decimal())
+		}
+	}
+	if !r.Tax.Included {
+		for _, l := range r.Tax.Lines {
+			row(l.Label, l.Amount.Decimal())
+		}
+	}
+	row("Total", r.Total.String())
+	if r.Tax.Included {
+		for _, l := range r.Tax.Lines {
+			row("  incl. "+l.Label, l.Amount.Decimal())
+		}
+	}
+	if r.Savings.IsPositive() {
+		row("You saved", r.Savings.String())
+	}
+	if r.Payment != nil {
+		fmt.Fprintf(&b, "Paid with %s (%s)\n", r.Payment.Method, r.Payment.ID)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// JSONReceipt renders a receipt as JSON. Amounts are decimal strings
+// with their currency, as payment.Money marshals them.
+type JSONReceipt struct {
+	Indent string // indents nested values when set, as in json.MarshalIndent
+}
+
+// Render implements ReceiptRenderer.
+func (j JSONReceipt) Render(w io.Writer, r Receipt) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", j.Indent)
+	return enc.Encode(r)
+}
@@ -0,0 +1,431 @@
+package patterns
+
+import (
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+// Sorting strategies: interchangeable algorithms with the contract of
+// slices.SortFunc.
+//
+// Why a cmp function instead of []int? The algorithm is independent of
+// the element type, and one comparison function orders structs by any
+// field, in either direction. Every strategy sorts in place, as
+// slices.SortFunc does; Sorter.Sorted returns a sorted copy for callers
+// that need the input untouched.
+//
+// Why report stability? A stable sort keeps elements that compare equal
+// in their original order, which is what makes sorting by one key and
+// then by another work. Callers that rely on it can check Stable
+// instead of knowing which algorithm they were handed.
+
+// SortStrategy is a sorting algorithm.
+type SortStrategy[T any] interface {
+	// Sort sorts data in place in the order defined by cmp, which
+	// returns a negative number when a < b, zero when a == b and a
+	// positive number when a > b.
+	//
+	// Strategies that do not compare elements, such as RadixSort, order
+	// by their own configuration and ignore cmp. Callers that choose a
+	// strategy at run time must pass a cmp that agrees with it.
+	Sort(data []T, cmp func(a, b T) int)
+	// Name returns the algorithm name.
+	Name() string
+	// Stable reports whether elements that compare equal keep their
+	// relative order.
+	Stable() bool
+}
+
+// BubbleSort swaps adjacent out-of-order elements until none are left.
+// It is O(n²) and only worth using to show why the others exist.
+type BubbleSort[T any] struct{}
+
+// Sort performs bubble sort.
+func (BubbleSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	for i := len(data) - 1; i > 0; i-- {
+		swapped := false
+		for j := range i {
+			if cmp(data[j], data[j+1]) > 0 {
+				data[j], data[j+1] = data[j+1], data[j]
+				swapped = true
+			}
+		}
+		if !swapped {
+			return
+		}
+	}
+}
+
+// Name returns the algorithm name.
+func (BubbleSort[T]) Name() string { return "Bubble Sort" }
+
+// Stable returns true: only strictly greater neighbours are swapped.
+func (BubbleSort[T]) Stable() bool { return true }
+
+// QuickSort partitions around the last element and recurses. It is
+// O(n log n) on random input but O(n²) on sorted input; IntroSort fixes
+// that.
+type QuickSort[T any] struct{}
+
+// Sort performs quick sort.
+func (QuickSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	for len(data) > 1 {
+		p := lomutoPartition(data, cmp)
+		// Recurse into the smaller side and loop on the larger one, so
+		// the stack stays O(log n) even when the time does not.
+		if p < len(data)-1-p {
+			QuickSort[T]{}.Sort(data[:p], cmp)
+			data = data[p+1:]
+		} else {
+			QuickSort[T]{}.Sort(data[p+1:], cmp)
+			data = data[:p]
+		}
+	}
+}
+
+// lomutoPartition moves the last element to its final position p, with
+// smaller elements before it and the rest after it, and returns p.
+func lomutoPartition[T any](data []T, cmp func(a, b T) int) int {
+	high := len(data) - 1
+	pivot := data[high]
+	i := 0
+	for j := range high {
+		if cmp(data[j], pivot) < 0 {
+			data[i], data[j] = data[j], data[i]
+			i++
+		}
+	}
+	data[i], data[high] = data[high], data[i]
+	return i
+}
+
+// Name returns the algorithm name.
+func (QuickSort[T]) Name() string { return "Quick Sort" }
+
+// Stable returns false: partitioning swaps elements across equal ones.
+func (QuickSort[T]) Stable() bool { return false }
+
+// MergeSort splits the input in half, sorts each half and merges them.
+// It is O(n log n) in every case and stable, at the price of an O(n)
+// buffer.
+type MergeSort[T any] struct{}
+
+// Sort performs merge sort.
+func (MergeSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	mergeSort(data, make([]T, len(data)), cmp)
+}
+
+// mergeSort sorts data using buf, which is at least as long, as scratch.
+func mergeSort[T any](data, buf []T, cmp func(a, b T) int) {
+	if len(data) <= insertionThreshold {
+		insertionSort(data, cmp)
+		return
+	}
+	mid := len(data) / 2
+	mergeSort(data[:mid], buf[:mid], cmp)
+	mergeSort(data[mid:], buf[mid:], cmp)
+	merge(data, mid, buf, cmp)
+}
+
+// merge merges the sorted runs data[:mid] and data[mid:] using buf as
+// scratch. Ties take the left element first, which keeps it stable.
+func merge[T any](data []T, mid int, buf []T, cmp func(a, b T) int) {
+	if cmp(data[mid-1], data[mid]) <= 0 {
+		return // already in order
+	}
+	left := buf[:copy(buf, data[:mid])]
+	i, j, k := 0, mid, 0
+	for i < len(left) && j < len(data) {
+		if cmp(data[j], left[i]) < 0 {
+			data[k] = data[j]
+			j++
+		} else {
+			data[k] = left[i]
+			i++
+		}
+		k++
+	}
+	copy(data[k:], left[i:])
+}
+
+// insertionThreshold is the length below which the divide-and-conquer
+// sorts switch to insertion sort, which is faster on short inputs.
+const insertionThreshold = 12
+
+// insertionSort is a stable O(n²) sort for short inputs.
+func insertionSort[T any](data []T, cmp func(a, b T) int) {
+	for i := 1; i < len(data); i++ {
+		for j := i; j > 0 && cmp(data[j], data[j-1]) < 0; j-- {
+			data[j], data[j-1] = data[j-1], data[j]
+		}
+	}
+}
+
+// Name returns the algorithm name.
+func (MergeSort[T]) Name() string { return "Merge Sort" }
+
+// Stable returns true.
+func (MergeSort[T]) Stable() bool { return true }
+
+// HeapSort builds a max-heap in place and repeatedly moves its top to
+// the end. It is O(n log n) in every case with no extra memory.
+type HeapSort[T any] struct{}
+
+// Sort performs heap sort.
+func (HeapSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	heapSort(data, cmp)
+}
+
+func heapSort[T any](data []T, cmp func(a, b T) int) {
+	for i := len(data)/2 - 1; i >= 0; i-- {
+		siftDown(data, i, cmp)
+	}
+	for end := len(data) - 1; end > 0; end-- {
+		data[0], data[end] = data[end], data[0]
+		siftDown(data[:end], 0, cmp)
+	}
+}
+
+// siftDown moves data[root] down until neither child is larger.
+func siftDown[T any](data []T, root int, cmp func(a, b T) int) {
+	for {
+		child := 2*root + 1
+		if child >= len(data) {
+			return
+		}
+		if child+1 < len(data) && cmp(data[child], data[child+1]) < 0 {
+			child++
+		}
+		if cmp(data[root], data[child]) >= 0 {
+			return
+		}
+		data[root], data[child] = data[child], data[root]
+		root = child
+	}
+}
+
+// Name returns the algorithm name.
+func (HeapSort[T]) Name() string { return "Heap Sort" }
+
+// Stable returns false.
+func (HeapSort[T]) Stable() bool { return false }
+
+// IntroSort is quicksort with a median-of-three pivot that switches to
+// heap sort when the recursion gets too deep and to insertion sort for
+// short ranges. It keeps quicksort's speed on typical input and heap
+// sort's O(n log n) worst case; C++'s std::sort uses the same scheme.
+type IntroSort[T any] struct{}
+
+// Sort performs introsort.
+func (IntroSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	introSort(data, 2*bits.Len(uint(len(data))), cmp)
+}
+
+func introSort[T any](data []T, depth int, cmp func(a, b T) int) {
+	for len(data) > insertionThreshold {
+		if depth == 0 {
+			heapSort(data, cmp)
+			return
+		}
+		depth--
+		lt, gt := partition3(data, cmp)
+		if lt < len(data)-gt {
+			introSort(data[:lt], depth, cmp)
+			data = data[gt:]
+		} else {
+			introSort(data[gt:], depth, cmp)
+			data = data[:lt]
+		}
+	}
+	insertionSort(data, cmp)
+}
+
+// partition3 partitions data around a median-of-three pivot into
+// data[:lt] < pivot, data[lt:gt] == pivot and data[gt:] > pivot. The
+// middle band is final, so inputs with many equal elements shrink fast
+// instead of degrading to O(n²).
+func partition3[T any](data []T, cmp func(a, b T) int) (lt, gt int) {
+	a, b, c := 0, len(data)/2, len(data)-1
+	if cmp(data[b], data[a]) < 0 {
+		a, b = b, a
+	}
+	if cmp(data[c], data[b]) < 0 {
+		b = c
+		if cmp(data[b], data[a]) < 0 {
+			b = a
+		}
+	}
+	pivot := data[b]
+
+	lt, i, gt := 0, 0, len(data)
+	for i < gt {
+		switch c := cmp(data[i], pivot); {
+		case c < 0:
+			data[lt], data[i] = data[i], data[lt]
+			lt++
+			i++
+		case c > 0:
+			gt--
+			data[i], data[gt] = data[gt], data[i]
+		default:
+			i++
+		}
+	}
+	return lt, gt
+}
+
+// Name returns the algorithm name.
+func (IntroSort[T]) Name() string { return "Introsort" }
+
+// Stable returns false.
+func (IntroSort[T]) Stable() bool { return false }
+
+// RadixSort orders elements by an integer key, one byte at a time from
+// the least significant, without comparing elements at all. It is O(n)
+// for fixed-width keys and stable.
+//
+// RadixSort ignores the cmp argument of Sort: the order is Key,
+// ascending unless Descending is set. Pass a cmp consistent with both,
+// such as func(a, b T) int { return cmp.Compare(key(a), key(b)) }, so
+// that swapping in a comparison sort gives the same result.
+type RadixSort[T any] struct {
+	Key        func(T) int64
+	Descending bool // order by decreasing Key, still keeping equal keys in input order
+}
+
+// NewRadixSort creates a radix sort ordering by key.
+func NewRadixSort[T any](key func(T) int64) *RadixSort[T] {
+	return &RadixSort[T]{Key: key}
+}
+
+// Sort performs a least-significant-digit radix sort on Key.
+func (r *RadixSort[T]) Sort(data []T, _ func(a, b T) int) {
+	if len(data) < 2 {
+		return
+	}
+	// Flipping the sign bit maps int64 order onto uint64 order;
+	// flipping every bit reverses it.
+	flip := uint64(1 << 63)
+	if r.Descending {
+		flip = 1<<63 - 1
+	}
+	keys := make([]uint64, len(data))
+	var differ uint64
+	for i, v := range data {
+		keys[i] = uint64(r.Key(v)) ^ flip
+		differ |= keys[i] ^ keys[0]
+	}
+
+	src, dst := data, make([]T, len(data))
+	srcKeys, dstKeys := keys, make([]uint64, len(data))
+	for shift := uint(0); shift < 64; shift += 8 {
+		// Skip bytes that are the same in every key: for small
+		// non-negative keys that is most of them.
+		if differ>>shift&0xff == 0 {
+			continue
+		}
+		var offsets [256]int
+		for _, k := range srcKeys {
+			offsets[k>>shift&0xff]++
+		}
+		pos := 0
+		for b, n := range offsets {
+			offsets[b] = pos
+			pos += n
+		}
+		for i, k := range srcKeys {
+			b := k >> shift & 0xff
+			dst[offsets[b]] = src[i]
+			dstKeys[offsets[b]] = k
+			offsets[b]++
+		}
+		src, dst = dst, src
+		srcKeys, dstKeys = dstKeys, srcKeys
+	}
+	if &src[0] != &data[0] {
+		copy(data, src)
+	}
+}
+
+// Name returns the algorithm name.
+func (r *RadixSort[T]) Name() string { return "Radix Sort" }
+
+// Stable returns true: each pass keeps the order of the previous one
+// among equal bytes.
+func (r *RadixSort[T]) Stable() bool { return true }
+
+// ParallelMergeSort is MergeSort with the two halves of every large
+// range sorted in separate goroutines. It pays off from a few tens of
+// thousands of elements, when cmp is cheap, on a machine with idle
+// cores.
+type ParallelMergeSort[T any] struct {
+	// Threshold is the range length below which halves are sorted on
+	// the current goroutine. The zero value selects 4096.
+	Threshold int
+}
+
+// Sort performs a parallel merge sort.
+func (p ParallelMergeSort[T]) Sort(data []T, cmp func(a, b T) int) {
+	threshold := p.Threshold
+	if threshold <= 0 {
+		threshold = 4096
+	}
+	parallelMergeSort(data, make([]T, len(data)), threshold, cmp)
+}
+
+func parallelMergeSort[T any](data, buf []T, threshold int, cmp func(a, b T) int) {
+	if len(data) <= threshold {
+		mergeSort(data, buf, cmp)
+		return
+	}
+	mid := len(data) / 2
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		parallelMergeSort(data[:mid], buf[:mid], threshold, cmp)
+	}()
+	parallelMergeSort(data[mid:], buf[mid:], threshold, cmp)
+	wg.Wait()
+	merge(data, mid, buf, cmp)
+}
+
+// Name returns the algorithm name.
+func (ParallelMergeSort[T]) Name() string { return "Parallel Merge Sort" }
+
+// Stable returns true: halves are merged exactly as in MergeSort.
+func (ParallelMergeSort[T]) Stable() bool { return true }
+
+// Sorter sorts with a swappable strategy and a fixed order.
+type Sorter[T any] struct {
+	strategy SortStrategy[T]
+	cmp      func(a, b T) int
+}
+
+// NewSorter creates a sorter.
+func NewSorter[T any](strategy SortStrategy[T], cmp func(a, b T) int) *Sorter[T] {
+	return &Sorter[T]{strategy: strategy, cmp: cmp}
+}
+
+// SetStrategy changes the sorting strategy.
+func (s *Sorter[T]) SetStrategy(strategy SortStrategy[T]) {
+	s.strategy = strategy
+}
+
+// Strategy returns the current strategy.
+func (s *Sorter[T]) Strategy() SortStrategy[T] {
+	return s.strategy
+}
+
+// Sort sorts data in place.
+func (s *Sorter[T]) Sort(data []T) {
+	s.strategy.Sort(data, s.cmp)
+}
+
+// Sorted returns a sorted copy of data and leaves data unchanged.
+func (s *Sorter[T]) Sorted(data []T) []T {
+	out := slices.Clone(data)
+	s.strategy.Sort(out, s.cmp)
+	return out
+}
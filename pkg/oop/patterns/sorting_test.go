@@ -0,0 +1,206 @@
+package patterns
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// record has a key to sort by and its position in the input, so that a
+// stable sort's output can be checked exactly.
+type record struct {
+	key int
+	pos int
+}
+
+func byKey(a, b record) int { return cmp.Compare(a.key, b.key) }
+
+func recordStrategies() []SortStrategy[record] {
+	return []SortStrategy[record]{
+		BubbleSort[record]{},
+		QuickSort[record]{},
+		MergeSort[record]{},
+		HeapSort[record]{},
+		IntroSort[record]{},
+		NewRadixSort(func(r record) int64 { return int64(r.key) }),
+		ParallelMergeSort[record]{},
+		// A low threshold puts the goroutine split to work on small inputs.
+		ParallelMergeSort[record]{Threshold: 16},
+	}
+}
+
+// shapes generates inputs that break naive implementations: sorted and
+// reversed runs, many duplicates, and keys at the extremes of int.
+var shapes = map[string]func(rng *rand.Rand, n int) []int{
+	"random": func(rng *rand.Rand, n int) []int {
+		return fill(n, func(int) int { return rng.IntN(1_000_000) - 500_000 })
+	},
+	"sorted":   func(_ *rand.Rand, n int) []int { return fill(n, func(i int) int { return i }) },
+	"reversed": func(_ *rand.Rand, n int) []int { return fill(n, func(i int) int { return n - i }) },
+	"all equal": func(_ *rand.Rand, n int) []int {
+		return fill(n, func(int) int { return 7 })
+	},
+	"few distinct": func(rng *rand.Rand, n int) []int {
+		return fill(n, func(int) int { return rng.IntN(4) })
+	},
+	"organ pipe": func(_ *rand.Rand, n int) []int {
+		return fill(n, func(i int) int { return min(i, n-i) })
+	},
+	"extremes": func(rng *rand.Rand, n int) []int {
+		vals := []int{math.MinInt64, math.MinInt64 + 1, -1, 0, 1, math.MaxInt64 - 1, math.MaxInt64}
+		return fill(n, func(int) int { return vals[rng.IntN(len(vals))] })
+	},
+}
+
+func fill(n int, f func(i int) int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = f(i)
+	}
+	return out
+}
+
+func TestSortStrategiesMatchSlicesSortFunc(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	sizes := []int{0, 1, 2, 3, 11, 12, 13, 100, 1000, 5000}
+
+	for _, s := range recordStrategies() {
+		for shape, gen := range shapes {
+			for _, n := range sizes {
+				if _, ok := s.(BubbleSort[record]); ok && n > 1000 {
+					continue
+				}
+				keys := gen(rng, n)
+				in := make([]record, n)
+				for i, k := range keys {
+					in[i] = record{key: k, pos: i}
+				}
+
+				want := slices.Clone(in)
+				if s.Stable() {
+					slices.SortStableFunc(want, byKey)
+				} else {
+					slices.SortFunc(want, byKey)
+				}
+				got := slices.Clone(in)
+				s.Sort(got, byKey)
+
+				name := fmt.Sprintf("%s/%s/%d", s.Name(), shape, n)
+				if s.Stable() {
+					// A stable sort has exactly one correct output.
+					if !slices.Equal(got, want) {
+						t.Fatalf("%s: output differs from slices.SortStableFunc", name)
+					}
+					continue
+				}
+				// An unstable sort may order equal keys any way, but must
+				// return the same keys, sorted, and the same elements.
+				if !slices.EqualFunc(got, want, func(a, b record) bool { return a.key == b.key }) {
+					t.Fatalf("%s: keys differ from slices.SortFunc", name)
+				}
+				slices.SortFunc(got, func(a, b record) int { return cmp.Compare(a.pos, b.pos) })
+				if !slices.Equal(got, in) {
+					t.Fatalf("%s: output is not a permutation of the input", name)
+				}
+			}
+		}
+	}
+}
+
+func TestSortStrategiesReportStability(t *testing.T) {
+	want := map[string]bool{
+		"Bubble Sort":         true,
+		"Quick Sort":          false,
+		"Merge Sort":          true,
+		"Heap Sort":           false,
+		"Introsort":           false,
+		"Radix Sort":          true,
+		"Parallel Merge Sort": true,
+	}
+	for _, s := range recordStrategies() {
+		if s.Stable() != want[s.Name()] {
+			t.Errorf("%s: Stable() = %t, want %t", s.Name(), s.Stable(), want[s.Name()])
+		}
+	}
+}
+
+func TestSortStrategiesHonorCmp(t *testing.T) {
+	// A descending order and a non-integer element type.
+	desc := func(a, b string) int { return cmp.Compare(b, a) }
+	in := []string{"pear", "apple", "fig", "kiwi", "banana", "cherry", "date", "apple"}
+	want := slices.Clone(in)
+	slices.SortFunc(want, desc)
+	for _, s := range []SortStrategy[string]{
+		BubbleSort[string]{},
+		QuickSort[string]{},
+		MergeSort[string]{},
+		HeapSort[string]{},
+		IntroSort[string]{},
+		ParallelMergeSort[string]{Threshold: 2},
+	} {
+		got := slices.Clone(in)
+		s.Sort(got, desc)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", s.Name(), got, want)
+		}
+	}
+}
+
+func TestRadixSortDescending(t *testing.T) {
+	rng := rand.New(rand.NewPCG(9, 10))
+	s := &RadixSort[record]{Key: func(r record) int64 { return int64(r.key) }, Descending: true}
+	desc := func(a, b record) int { return byKey(b, a) }
+	for shape, gen := range shapes {
+		keys := gen(rng, 1000)
+		in := make([]record, len(keys))
+		for i, k := range keys {
+			in[i] = record{key: k, pos: i}
+		}
+		want := slices.Clone(in)
+		slices.SortStableFunc(want, desc)
+		got := slices.Clone(in)
+		s.Sort(got, desc)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: output differs from slices.SortStableFunc", shape)
+		}
+	}
+}
+
+func TestSorter(t *testing.T) {
+	data := []int{3, 1, 2}
+	s := NewSorter[int](QuickSort[int]{}, cmp.Compare[int])
+
+	if got := s.Sorted(data); !slices.Equal(got, []int{1, 2, 3}) || !slices.Equal(data, []int{3, 1, 2}) {
+		t.Errorf("Sorted = %v with input now %v; want a sorted copy and the input unchanged", got, data)
+	}
+	s.SetStrategy(HeapSort[int]{})
+	s.Sort(data)
+	if !slices.Equal(data, []int{1, 2, 3}) {
+		t.Errorf("Sort left %v", data)
+	}
+	if s.Strategy().Name() != "Heap Sort" {
+		t.Errorf("Strategy = %s after SetStrategy", s.Strategy().Name())
+	}
+}
+
+func BenchmarkSortStrategies(b *testing.B) {
+	data := shapes["random"](rand.New(rand.NewPCG(7, 8)), 100_000)
+	for _, s := range []SortStrategy[int]{
+		MergeSort[int]{},
+		HeapSort[int]{},
+		IntroSort[int]{},
+		NewRadixSort(func(n int) int64 { return int64(n) }),
+		ParallelMergeSort[int]{},
+	} {
+		b.Run(s.Name(), func(b *testing.B) {
+			buf := make([]int, len(data))
+			for b.Loop() {
+				copy(buf, data)
+				s.Sort(buf, cmp.Compare[int])
+			}
+		})
+	}
+}
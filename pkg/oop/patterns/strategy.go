@@ -1,8 +1,17 @@
 package patterns
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
+
+	"github.com/KrystianMarek/golang-202/pkg/idioms"
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
 )
 
 // Strategy pattern demonstrates selecting algorithms at runtime.
@@ -10,240 +19,243 @@ import (
 // Why? Strategy pattern allows changing behavior at runtime by
 // encapsulating algorithms in interchangeable objects.
 
-// PaymentStrategy defines the interface for payment algorithms.
+// PaymentStrategy charges an amount with one payment method. Each
+// strategy validates its method, runs the payment lifecycle on a
+// payment.Processor and returns the captured payment.
+//
+// key is the caller's idempotency key for the whole charge: calling Pay
+// again with the same key after an error resumes the charge instead of
+// starting a second one.
 type PaymentStrategy interface {
-	Pay(amount float64) string
+	Pay(ctx context.Context, key string, amount payment.Money) (payment.Payment, error)
+}
+
+// authorizeAndCapture charges method in two idempotent steps derived
+// from key.
+func authorizeAndCapture(ctx context.Context, p *payment.Processor, key string, method payment.Method, amount payment.Money) (payment.Payment, error) {
+	auth, err := p.Authorize(ctx, key+"/authorize", method, amount)
+	if err != nil {
+		return payment.Payment{}, err
+	}
+	return p.Capture(ctx, key+"/capture", auth.ID, auth.Authorized)
 }
 
-// CreditCardStrategy implements credit card payment.
+// CreditCardStrategy charges a payment card.
 type CreditCardStrategy struct {
-	CardNumber string
-	CVV        string
+	Card      payment.Card
+	Processor *payment.Processor
 }
 
-// Pay processes credit card payment.
-func (c *CreditCardStrategy) Pay(amount float64) string {
-	masked := "****-****-****-" + c.CardNumber[len(c.CardNumber)-4:]
-	return fmt.Sprintf("Paid $%.2f using credit card %s", amount, masked)
+// Pay authorizes and captures amount on the card.
+func (c *CreditCardStrategy) Pay(ctx context.Context, key string, amount payment.Money) (payment.Payment, error) {
+	return authorizeAndCapture(ctx, c.Processor, key, c.Card, amount)
 }
 
-// PayPalStrategy implements PayPal payment.
+// PayPalStrategy charges a PayPal account.
 type PayPalStrategy struct {
-	Email string
+	Email     string
+	Processor *payment.Processor
 }
 
-// Pay processes PayPal payment.
-func (p *PayPalStrategy) Pay(amount float64) string {
-	return fmt.Sprintf("Paid $%.2f using PayPal account %s", amount, p.Email)
+// Pay authorizes and captures amount on the account.
+func (p *PayPalStrategy) Pay(ctx context.Context, key string, amount payment.Money) (payment.Payment, error) {
+	return authorizeAndCapture(ctx, p.Processor, key, payment.PayPalAccount{Email: p.Email}, amount)
 }
 
-// CryptoStrategy implements cryptocurrency payment.
+// CryptoStrategy charges a crypto wallet in its own currency, converting
+// the amount first.
 type CryptoStrategy struct {
 	WalletAddress string
+	Currency      payment.Currency
+	Converter     *payment.Converter
+	Processor     *payment.Processor
 }
 
-// Pay processes crypto payment.
-func (c *CryptoStrategy) Pay(amount float64) string {
-	return fmt.Sprintf("Paid $%.2f using crypto wallet %s",
-		amount, c.WalletAddress[:10]+"...")
-}
-
-// ShoppingCart uses a payment strategy.
-type ShoppingCart struct {
-	items           []string
-	total           float64
-	paymentStrategy PaymentStrategy
-}
-
-// NewShoppingCart creates a shopping cart.
-func NewShoppingCart() *ShoppingCart {
-	return &ShoppingCart{
-		items: make([]string, 0),
-		total: 0,
+// Pay converts amount to the wallet's currency, then authorizes and
+// captures it.
+func (c *CryptoStrategy) Pay(ctx context.Context, key string, amount payment.Money) (payment.Payment, error) {
+	converted, err := c.Converter.Convert(ctx, amount, c.Currency)
+	if err != nil {
+		return payment.Payment{}, err
 	}
+	return authorizeAndCapture(ctx, c.Processor, key, payment.CryptoWallet{Address: c.WalletAddress}, converted)
 }
 
-// AddItem adds an item to the cart.
-func (s *ShoppingCart) AddItem(item string, price float64) {
-	s.items = append(s.items, item)
-	s.total += price
-}
-
-// SetPaymentStrategy sets the payment strategy.
-func (s *ShoppingCart) SetPaymentStrategy(strategy PaymentStrategy) {
-	s.paymentStrategy = strategy
-}
+// ExampleStrategy demonstrates the Strategy pattern.
+func ExampleStrategy() {
+	fmt.Println("=== Strategy Pattern ===")
 
-// Checkout processes the payment.
-func (s *ShoppingCart) Checkout() string {
-	if s.paymentStrategy == nil {
-		return "No payment method selected"
+	// Checkout: discount rules, a tax strategy for the region and a
+	// payment strategy, all swappable. Every cart charges through one
+	// processor.
+	ctx := context.Background()
+	clock := idioms.NewManualClock(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC))
+	processor := payment.NewProcessor(payment.NewFakeGateway(), payment.WithClock(clock))
+	usd := func(s string) payment.Money { return payment.MustParse(s, payment.USD) }
+	pricing := NewPricingEngine(WithPricingClock(clock), WithPricingRules(
+		&BuyXGetY{SKU: "MOUSE-1", Buy: 1, Get: 1},
+		&PercentOff{Label: "Book week", Percent: "20", Category: "books"},
+		&TieredDiscount{Tiers: []Tier{{Min: usd("500"), Percent: "5"}, {Min: usd("1000"), Percent: "10"}}},
+		&Coupon{Code: "WELCOME10", Expires: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC),
+			Rule: &AmountOff{Amount: usd("10"), MinSubtotal: usd("50")}},
+		&Coupon{Code: "SPRING", Expires: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Rule: &PercentOff{Percent: "15"}},
+		&Coupon{Code: "STAFF30", Rule: &PercentOff{Label: "Staff discount", Percent: "30", Stack: Exclusive}},
+	))
+	regions := TaxRegions{
+		"US":    &SalesTax{Label: "US sales tax", Rate: "6"},
+		"US-NY": &SalesTax{Label: "NY sales tax", Rate: "8.875", Exempt: []string{"books"}},
+		"US-OR": NoTax{},
+		"DE":    &VAT{Label: "DE VAT", Standard: "19", Reduced: map[string]string{"books": "7"}},
 	}
-	result := s.paymentStrategy.Pay(s.total)
-	fmt.Printf("Items: %v\n", s.items)
-	return result
-}
-
-// CompressionStrategy defines compression algorithms.
-type CompressionStrategy interface {
-	Compress(data string) string
-}
-
-// ZipCompression implements ZIP compression.
-type ZipCompression struct{}
-
-// Compress simulates ZIP compression.
-func (z *ZipCompression) Compress(data string) string {
-	return fmt.Sprintf("[ZIP:%s]", strings.ToUpper(data))
-}
 
-// RarCompression implements RAR compression.
-type RarCompression struct{}
-
-// Compress simulates RAR compression.
-func (r *RarCompression) Compress(data string) string {
-	return fmt.Sprintf("[RAR:%s]", strings.ToLower(data))
-}
-
-// FileCompressor uses compression strategies.
-type FileCompressor struct {
-	strategy CompressionStrategy
-}
-
-// NewFileCompressor creates a compressor.
-func NewFileCompressor(strategy CompressionStrategy) *FileCompressor {
-	return &FileCompressor{strategy: strategy}
-}
-
-// SetStrategy changes the compression strategy.
-func (f *FileCompressor) SetStrategy(strategy CompressionStrategy) {
-	f.strategy = strategy
-}
-
-// Compress compresses data using the current strategy.
-func (f *FileCompressor) Compress(filename, data string) string {
-	compressed := f.strategy.Compress(data)
-	return fmt.Sprintf("File %s: %s", filename, compressed)
-}
-
-// SortStrategy defines sorting algorithms.
-type SortStrategy interface {
-	Sort(data []int) []int
-	Name() string
-}
-
-// BubbleSort implements bubble sort.
-type BubbleSort struct{}
-
-// Sort performs bubble sort.
-func (b *BubbleSort) Sort(data []int) []int {
-	result := make([]int, len(data))
-	copy(result, data)
-
-	for i := 0; i < len(result); i++ {
-		for j := 0; j < len(result)-1-i; j++ {
-			if result[j] > result[j+1] {
-				result[j], result[j+1] = result[j+1], result[j]
-			}
+	cart := NewShoppingCart(payment.USD)
+	cart.SetPricing(pricing)
+	for _, item := range []LineItem{
+		{SKU: "LAPTOP-15", Name: "Laptop", Category: "electronics", UnitPrice: usd("999.99"), Quantity: 1},
+		{SKU: "MOUSE-1", Name: "Mouse", Category: "electronics", UnitPrice: usd("29.99"), Quantity: 2},
+		{SKU: "BOOK-GO", Name: "The Go Book", Category: "books", UnitPrice: usd("39.99"), Quantity: 1},
+		{SKU: "MOUSE-1", Name: "Mouse", Category: "electronics", UnitPrice: usd("29.99"), Quantity: 1},
+	} {
+		if err := cart.AddItem(item); err != nil {
+			fmt.Println("Error:", err)
+			return
 		}
 	}
-	return result
-}
-
-// Name returns the algorithm name.
-func (b *BubbleSort) Name() string {
-	return "Bubble Sort"
-}
-
-// QuickSort implements quick sort.
-type QuickSort struct{}
-
-// Sort performs quick sort.
-func (q *QuickSort) Sort(data []int) []int {
-	result := make([]int, len(data))
-	copy(result, data)
-	q.quickSort(result, 0, len(result)-1)
-	return result
-}
-
-func (q *QuickSort) quickSort(arr []int, low, high int) {
-	if low < high {
-		pi := q.partition(arr, low, high)
-		q.quickSort(arr, low, pi-1)
-		q.quickSort(arr, pi+1, high)
-	}
-}
-
-func (q *QuickSort) partition(arr []int, low, high int) int {
-	pivot := arr[high]
-	i := low - 1
-
-	for j := low; j < high; j++ {
-		if arr[j] < pivot {
-			i++
-			arr[i], arr[j] = arr[j], arr[i]
+	for _, code := range []string{"welcome10", "SPRING", "NOPE"} {
+		if err := cart.ApplyCoupon(code); err != nil {
+			fmt.Println("Coupon:", err)
 		}
 	}
-	arr[i+1], arr[high] = arr[high], arr[i+1]
-	return i + 1
-}
-
-// Name returns the algorithm name.
-func (q *QuickSort) Name() string {
-	return "Quick Sort"
-}
-
-// Sorter uses sorting strategies.
-type Sorter struct {
-	strategy SortStrategy
-}
-
-// NewSorter creates a sorter.
-func NewSorter(strategy SortStrategy) *Sorter {
-	return &Sorter{strategy: strategy}
-}
-
-// Sort sorts data using the current strategy.
-func (s *Sorter) Sort(data []int) {
-	fmt.Printf("Using %s\n", s.strategy.Name())
-	sorted := s.strategy.Sort(data)
-	fmt.Printf("Input: %v\n", data)
-	fmt.Printf("Sorted: %v\n", sorted)
-}
-
-// ExampleStrategy demonstrates the Strategy pattern.
-func ExampleStrategy() {
-	fmt.Println("=== Strategy Pattern ===")
-
-	// Payment strategies
-	cart := NewShoppingCart()
-	cart.AddItem("Laptop", 999.99)
-	cart.AddItem("Mouse", 29.99)
-
+	tax, err := regions.For("US-NY")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	cart.SetTaxStrategy(tax)
 	cart.SetPaymentStrategy(&CreditCardStrategy{
-		CardNumber: "1234567890123456",
-		CVV:        "123",
+		Card:      payment.Card{Number: "4111 1111 1111 1111", ExpMonth: 8, ExpYear: 2027, CVV: "123"},
+		Processor: processor,
 	})
-	fmt.Println(cart.Checkout())
-
-	cart2 := NewShoppingCart()
-	cart2.AddItem("Book", 19.99)
-	cart2.SetPaymentStrategy(&PayPalStrategy{Email: "user@example.com"})
-	fmt.Println(cart2.Checkout())
+	receipt, err := cart.Checkout(ctx, "order-1")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	_ = TextReceipt{}.Render(os.Stdout, receipt)
+
+	// The same cart elsewhere, and with an exclusive coupon that
+	// replaces the stackable discounts only when it saves more.
+	totals := func(label string) {
+		r, err := cart.Receipt()
+		if err != nil {
+			fmt.Printf("%s: %v\n", label, err)
+			return
+		}
+		fmt.Printf("%-24s saved %s, tax %s, total %s\n", label+":", r.Savings, r.TaxTotal, r.Total)
+	}
+	for _, region := range []string{"US-OR", "US-TX"} {
+		if tax, err = regions.For(region); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		cart.SetTaxStrategy(tax)
+		totals(region)
+	}
+	_ = cart.ApplyCoupon("STAFF30")
+	totals("US-TX with STAFF30")
+	_ = cart.RemoveItem("LAPTOP-15")
+	totals("... without the laptop")
+	if _, err := regions.For("FR"); err != nil {
+		fmt.Println("Error:", err)
+	}
 
-	// Compression strategies
-	compressor := NewFileCompressor(&ZipCompression{})
-	fmt.Println(compressor.Compress("data.txt", "Hello World"))
+	// A German cart: VAT is already in the prices.
+	eur := func(s string) payment.Money { return payment.MustParse(s, payment.EUR) }
+	deCart := NewShoppingCart(payment.EUR)
+	_ = deCart.AddItem(LineItem{SKU: "BOOK-GO", Name: "Das Go-Buch", Category: "books", UnitPrice: eur("42.00"), Quantity: 1})
+	_ = deCart.AddItem(LineItem{SKU: "CABLE", Name: "USB-C cable", UnitPrice: eur("11.90"), Quantity: 2})
+	deCart.SetTaxStrategy(regions["DE"])
+	deCart.SetPaymentStrategy(&PayPalStrategy{Email: "user@example.com", Processor: processor})
+	if receipt, err = deCart.Checkout(ctx, "order-2"); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	_ = TextReceipt{}.Render(os.Stdout, receipt)
+
+	// Crypto converts the total before charging it, and the receipt
+	// goes to the API as JSON.
+	rates := payment.NewStaticRates()
+	_ = rates.Set(payment.BTC, payment.USD, "65000")
+	btcCart := NewShoppingCart(payment.USD)
+	_ = btcCart.AddItem(LineItem{SKU: "MONITOR-27", Name: "Monitor", UnitPrice: usd("349.00"), Quantity: 1})
+	btcCart.SetPaymentStrategy(&CryptoStrategy{
+		WalletAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		Currency:      payment.BTC,
+		Converter:     payment.NewConverter(rates),
+		Processor:     processor,
+	})
+	if receipt, err = btcCart.Checkout(ctx, "order-3"); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	_ = JSONReceipt{}.Render(os.Stdout, receipt)
+
+	// Compression strategies: the same input through every registered
+	// algorithm, then an automatic pick by ratio.
+	registry := NewCompressionRegistry()
+	if err := registry.Register(NewCodecStrategy(ZstdCodec)); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	text := strings.Repeat("Hello World, hello strategies! ", 200)
+	for _, strategy := range registry.Strategies() {
+		var compressed, restored bytes.Buffer
+		compressor := NewFileCompressor(strategy)
+		if _, err := compressor.Compress(strings.NewReader(text), &compressed); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		size := compressed.Len()
+		if err := compressor.Decompress(&compressed, &restored); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("%-5s %d -> %d bytes, round trip ok: %t\n",
+			strategy.Name(), len(text), size, restored.String() == text)
+	}
 
-	compressor.SetStrategy(&RarCompression{})
-	fmt.Println(compressor.Compress("archive.txt", "Hello World"))
+	auto := NewAutoFileCompressor(registry, CompressionTarget{MaxRatio: 0.1})
+	chosen, err := auto.Compress(strings.NewReader(text), io.Discard)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Smallest output within a 0.1 ratio: %s\n", chosen.Name())
 
-	// Sorting strategies
+	// Sorting strategies: one order, several algorithms.
 	data := []int{64, 34, 25, 12, 22, 11, 90}
-
-	sorter := NewSorter(&BubbleSort{})
-	sorter.Sort(data)
-
-	sorter = NewSorter(&QuickSort{})
-	sorter.Sort(data)
+	sorter := NewSorter[int](BubbleSort[int]{}, cmp.Compare[int])
+	fmt.Printf("Input: %v\n", data)
+	fmt.Printf("%s: %v\n", sorter.Strategy().Name(), sorter.Sorted(data))
+	sorter.SetStrategy(IntroSort[int]{})
+	fmt.Printf("%s: %v\n", sorter.Strategy().Name(), sorter.Sorted(data))
+
+	// Stability matters when sorting records by one field: a stable
+	// strategy keeps the earlier order among equal keys.
+	type employee struct {
+		name string
+		dept int
+	}
+	staff := []employee{{"Ann", 2}, {"Bob", 1}, {"Cid", 2}, {"Dee", 1}, {"Eve", 2}}
+	byDept := NewSorter[employee](NewRadixSort(func(e employee) int64 { return int64(e.dept) }),
+		func(a, b employee) int { return cmp.Compare(a.dept, b.dept) })
+	for _, strategy := range []SortStrategy[employee]{
+		byDept.Strategy(),
+		MergeSort[employee]{},
+		ParallelMergeSort[employee]{},
+	} {
+		byDept.SetStrategy(strategy)
+		fmt.Printf("%s (stable: %t): %v\n", strategy.Name(), strategy.Stable(), byDept.Sorted(staff))
+	}
 }
@@ -0,0 +1,181 @@
+package patterns
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/KrystianMarek/golang-202/pkg/oop/patterns/payment"
+)
+
+// TaxStrategy computes the tax on a cart for one region.
+//
+// Why a strategy? Regions disagree on nearly everything: whether tax is
+// added at the till or already in the shelf price, which goods are
+// exempt or reduced, and where rounding happens. The cart only needs
+// the result.
+type TaxStrategy interface {
+	// Name describes the strategy, such as "NY sales tax".
+	Name() string
+	// Tax returns the tax on lines, whose amounts are after discounts.
+	Tax(lines []TaxableLine) (Tax, error)
+}
+
+// TaxableLine is a cart line as a tax strategy sees it.
+type TaxableLine struct {
+	SKU      string
+	Category string
+	Amount   payment.Money // after discounts
+}
+
+// Tax is the tax on a cart, with one line per rate.
+type Tax struct {
+	Lines    []TaxLine `json:"lines,omitempty"`
+	Included bool      `json:"included"` // already part of the prices, not added to the total
+}
+
+// TaxLine is the tax at one rate.
+type TaxLine struct {
+	Label  string        `json:"label"`
+	Base   payment.Money `json:"base"` // the amount taxed, net of the tax itself
+	Amount payment.Money `json:"amount"`
+}
+
+// Total returns the sum of the tax lines in currency.
+func (t Tax) Total(currency payment.Currency) (payment.Money, error) {
+	total := payment.Zero(currency)
+	for _, l := range t.Lines {
+		var err error
+		if total, err = total.Add(l.Amount); err != nil {
+			return payment.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// NoTax is the strategy for regions, or customers, that pay no tax.
+type NoTax struct{}
+
+// Name returns "No tax".
+func (NoTax) Name() string { return "No tax" }
+
+// Tax returns no tax.
+func (NoTax) Tax([]TaxableLine) (Tax, error) { return Tax{}, nil }
+
+// SalesTax is a US-style sales tax: one rate added on top of the
+// prices of every non-exempt line. The tax is computed on the taxable
+// total and rounded once, half up.
+type SalesTax struct {
+	Label  string   // such as "NY sales tax"
+	Rate   string   // decimal percentage, such as "8.875"
+	Exempt []string // categories not taxed, such as "groceries"
+}
+
+// Name returns Label.
+func (s *SalesTax) Name() string { return s.Label }
+
+// Tax implements TaxStrategy.
+func (s *SalesTax) Tax(lines []TaxableLine) (Tax, error) {
+	rate, err := parsePercent(s.Rate)
+	if err != nil {
+		return Tax{}, err
+	}
+	var base payment.Money
+	for _, l := range lines {
+		if slices.Contains(s.Exempt, l.Category) {
+			continue
+		}
+		if base, err = base.Add(l.Amount); err != nil {
+			return Tax{}, err
+		}
+	}
+	if base.IsZero() {
+		return Tax{}, nil
+	}
+	amount, err := base.MulRat(rate, payment.HalfUp)
+	if err != nil {
+		return Tax{}, err
+	}
+	return Tax{Lines: []TaxLine{{Label: fmt.Sprintf("%s %s%%", s.Label, s.Rate), Base: base, Amount: amount}}}, nil
+}
+
+// VAT is a European-style value-added tax: a standard rate, reduced
+// rates for some categories, and prices that already include the tax.
+// The tax in each rate's gross total is extracted and rounded half up.
+type VAT struct {
+	Label    string            // such as "DE VAT"
+	Standard string            // decimal percentage, such as "19"
+	Reduced  map[string]string // category to rate, such as "books": "7"
+}
+
+// Name returns Label.
+func (v *VAT) Name() string { return v.Label }
+
+// Tax implements TaxStrategy.
+func (v *VAT) Tax(lines []TaxableLine) (Tax, error) {
+	gross := make(map[string]payment.Money)
+	for _, l := range lines {
+		rate := v.Standard
+		if r, ok := v.Reduced[l.Category]; ok {
+			rate = r
+		}
+		sum, err := gross[rate].Add(l.Amount)
+		if err != nil {
+			return Tax{}, err
+		}
+		gross[rate] = sum
+	}
+	rates := make(map[string]*big.Rat, len(gross))
+	for rate := range gross {
+		r, err := parsePercent(rate)
+		if err != nil {
+			return Tax{}, err
+		}
+		rates[rate] = r
+	}
+	// Highest rate first, as receipts usually list them.
+	order := slices.SortedFunc(maps.Keys(gross), func(a, b string) int { return rates[b].Cmp(rates[a]) })
+	tax := Tax{Included: true}
+	for _, rate := range order {
+		if gross[rate].IsZero() {
+			continue
+		}
+		// The tax in a gross price g at rate r is g * r / (1 + r).
+		r := rates[rate]
+		share := new(big.Rat).Quo(r, new(big.Rat).Add(r, big.NewRat(1, 1)))
+		amount, err := gross[rate].MulRat(share, payment.HalfUp)
+		if err != nil {
+			return Tax{}, err
+		}
+		base, _ := gross[rate].Sub(amount)
+		tax.Lines = append(tax.Lines, TaxLine{Label: fmt.Sprintf("%s %s%%", v.Label, rate), Base: base, Amount: amount})
+	}
+	return tax, nil
+}
+
+// ErrUnknownRegion is returned by TaxRegions.For for regions without a
+// strategy.
+var ErrUnknownRegion = errors.New("no tax strategy for region")
+
+// TaxRegions maps region codes, such as "US-NY" or "DE", to the tax
+// strategy used there.
+type TaxRegions map[string]TaxStrategy
+
+// For returns the strategy for region. A subdivision without its own
+// entry falls back to its country: "US-OR" uses "US" if there is no
+// "US-OR".
+func (t TaxRegions) For(region string) (TaxStrategy, error) {
+	region = strings.ToUpper(region)
+	if s, ok := t[region]; ok {
+		return s, nil
+	}
+	if country, _, ok := strings.Cut(region, "-"); ok {
+		if s, ok := t[country]; ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownRegion, region)
+}